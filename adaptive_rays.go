@@ -0,0 +1,125 @@
+package main
+
+import (
+	"math"
+	"syscall/js"
+)
+
+// --- Adaptive Ray Count ---
+//
+// With adaptive rays on, a source's numRays directions are traced in ADAPTIVE_RAY_BATCHES
+// interleaved batches (ray i belongs to batch i mod the batch count, so each batch is itself a
+// spiral over the whole sphere). After ADAPTIVE_MIN_BATCHES, tracing stops as soon as the score
+// estimate's relative standard error is within adaptiveRayTolerance; the estimate is the mean
+// per-ray score times numRays, so scores stay comparable with a full pass. Simple rooms converge
+// after a few batches while cluttered ones keep tracing up to numRays.
+
+const (
+	ADAPTIVE_RAY_BATCHES        = 16
+	ADAPTIVE_MIN_BATCHES        = 4
+	DEFAULT_ADAPTIVE_TOLERANCE  = 0.05
+	MIN_ADAPTIVE_TOLERANCE      = 0.005
+	MAX_ADAPTIVE_TOLERANCE      = 0.5
+	CONFIDENCE_95_Z             = 1.96
+	ADAPTIVE_MIN_RAYS_PER_BATCH = 8 // Fewer rays than this per batch are traced in one go
+)
+
+var (
+	adaptiveRays         = false                      // Toggle "adaptiveRays"
+	adaptiveRayTolerance = DEFAULT_ADAPTIVE_TOLERANCE // Slider "rayTolerance": relative standard error to stop at
+	lastRayConvergence   RayConvergence               // Of the last visualization pass, summed over sources
+)
+
+// RayConvergence describes how far a score estimate got: rays traced out of those planned, and
+// the half-width of its 95% confidence interval.
+type RayConvergence struct {
+	Rays        int
+	Planned     int
+	Score       float64
+	HalfWidth95 float64
+	Converged   bool
+}
+
+// traceAdaptively traces rays [0, n) through trace, which returns each ray's score, and returns
+// the estimated total. Unless adapt is set it is a plain sum in index order. With parallel set,
+// the rays of a batch are traced on the evaluation pool (see parallel_tracing.go).
+func traceAdaptively(n int, adapt, parallel bool, trace func(i int) float64) RayConvergence {
+	batches := 1
+	if adapt && n >= ADAPTIVE_RAY_BATCHES*ADAPTIVE_MIN_RAYS_PER_BATCH {
+		batches = ADAPTIVE_RAY_BATCHES
+	}
+	count, sum, sumSq := 0, 0.0, 0.0
+	scores := make([]float64, (n+batches-1)/batches)
+	for b := 0; b < batches; b++ {
+		size := (n - b + batches - 1) / batches
+		forEachChunked(size, parallel, func(k int) { scores[k] = trace(b + k*batches) })
+		for _, x := range scores[:size] {
+			count++
+			sum += x
+			sumSq += x * x
+		}
+		if b+1 >= ADAPTIVE_MIN_BATCHES && b+1 < batches && withinTolerance(count, n, sum, sumSq) {
+			break
+		}
+	}
+	if count == 0 {
+		return RayConvergence{Planned: n, Converged: true}
+	}
+	mean := sum / float64(count)
+	return RayConvergence{
+		Rays:        count,
+		Planned:     n,
+		Score:       mean * float64(n),
+		HalfWidth95: CONFIDENCE_95_Z * float64(n) * sampleStdErr(count, n, sum, sumSq),
+		Converged:   count == n || withinTolerance(count, n, sum, sumSq),
+	}
+}
+
+// sampleStdErr is the standard error of the mean of count per-ray scores drawn from n, with the
+// finite population correction (a full pass has no sampling error).
+func sampleStdErr(count, n int, sum, sumSq float64) float64 {
+	if count < 2 {
+		return 0
+	}
+	k := float64(count)
+	mean := sum / k
+	variance := math.Max(0, (sumSq-k*mean*mean)/(k-1))
+	return math.Sqrt(variance / k * (1 - k/float64(n)))
+}
+
+// withinTolerance reports whether the mean's relative standard error is at most adaptiveRayTolerance.
+func withinTolerance(count, n int, sum, sumSq float64) bool {
+	return sampleStdErr(count, n, sum, sumSq) <= adaptiveRayTolerance*math.Abs(sum/float64(count))
+}
+
+// addConvergence accumulates a source's convergence, weighted by its gain, into c. Half-widths
+// add in quadrature since sources are traced independently.
+func (c *RayConvergence) addConvergence(other RayConvergence, gain float64) {
+	first := c.Planned == 0
+	c.Rays += other.Rays
+	c.Planned += other.Planned
+	c.Score += gain * other.Score
+	c.HalfWidth95 = math.Hypot(c.HalfWidth95, gain*other.HalfWidth95)
+	c.Converged = (first || c.Converged) && other.Converged
+}
+
+// goGetRayConvergence() returns {rays, planned, score, halfWidth95, relativeError, converged} for
+// the last visualization pass: the rays traced, the 95% confidence half-width of the traced part
+// of the listener score, and that half-width relative to it.
+func goGetRayConvergence(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetRayConvergence")
+	c := lastRayConvergence
+	relative := 0.0
+	if c.Score != 0 {
+		relative = c.HalfWidth95 / math.Abs(c.Score)
+	}
+	return js.ValueOf(map[string]interface{}{
+		"rays":          c.Rays,
+		"planned":       c.Planned,
+		"score":         c.Score,
+		"halfWidth95":   c.HalfWidth95,
+		"relativeError": relative,
+		"converged":     c.Converged,
+		"adaptive":      adaptiveRays,
+	})
+}
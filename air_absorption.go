@@ -0,0 +1,84 @@
+package main
+
+import "math"
+
+// --- Air Absorption (ISO 9613-1) ---
+
+const (
+	DEFAULT_AIR_TEMPERATURE float64 = 20.0 // °C
+	DEFAULT_AIR_HUMIDITY    float64 = 50.0 // % relative humidity
+	MIN_AIR_TEMPERATURE     float64 = -20.0
+	MAX_AIR_TEMPERATURE     float64 = 50.0
+	MIN_AIR_HUMIDITY        float64 = 0.0
+	MAX_AIR_HUMIDITY        float64 = 100.0
+)
+
+var (
+	// Attenuation in dB per meter, per octave band and averaged for the broadband tracers.
+	// Recomputed by setAirConditions.
+	airBandAttenuation      = airAttenuationPerBand(DEFAULT_AIR_TEMPERATURE, DEFAULT_AIR_HUMIDITY)
+	airBroadbandAttenuation = meanOf(airBandAttenuation)
+)
+
+// airAttenuationDB is the ISO 9613-1 atmospheric absorption coefficient in dB per meter at
+// frequency hz, for air at tempC °C and relHumidity % at standard pressure.
+func airAttenuationDB(hz, tempC, relHumidity float64) float64 {
+	const (
+		t0  = 293.15 // Reference temperature, K
+		t01 = 273.16 // Triple point of water, K
+	)
+	t := tempC + 273.15
+	psatRatio := math.Pow(10, -6.8346*math.Pow(t01/t, 1.261)+4.6151)
+	h := relHumidity * psatRatio // Molar concentration of water vapour, %
+	frO := 24 + 4.04e4*h*(0.02+h)/(0.391+h)
+	frN := math.Pow(t/t0, -0.5) * (9 + 280*h*math.Exp(-4.170*(math.Pow(t/t0, -1.0/3)-1)))
+	f2 := hz * hz
+	return 8.686 * f2 * (1.84e-11*math.Sqrt(t/t0) +
+		math.Pow(t/t0, -2.5)*(0.01275*math.Exp(-2239.1/t)/(frO+f2/frO)+
+			0.1068*math.Exp(-3352.0/t)/(frN+f2/frN)))
+}
+
+// airAttenuationPerBand evaluates airAttenuationDB at every OCTAVE_BANDS_HZ center frequency.
+func airAttenuationPerBand(tempC, relHumidity float64) []float64 {
+	bands := make([]float64, len(OCTAVE_BANDS_HZ))
+	for b, hz := range OCTAVE_BANDS_HZ {
+		bands[b] = airAttenuationDB(hz, tempC, relHumidity)
+	}
+	return bands
+}
+
+func meanOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// setAirConditions updates temperature and humidity (clamped to their bounds), the derived
+// attenuation coefficients, and, when the temperature changes, the speed of sound and air density.
+func setAirConditions(tempC, relHumidity float64) {
+	tempC = boundedParam("airTemperature", tempC, MIN_AIR_TEMPERATURE, MAX_AIR_TEMPERATURE)
+	if tempC != environment.TemperatureC {
+		environment.SpeedOfSound = speedOfSoundAt(tempC)
+		environment.AirDensity = airDensityAt(tempC)
+	}
+	environment.TemperatureC = tempC
+	environment.HumidityPct = boundedParam("airHumidity", relHumidity, MIN_AIR_HUMIDITY, MAX_AIR_HUMIDITY)
+	airBandAttenuation = airAttenuationPerBand(environment.TemperatureC, environment.HumidityPct)
+	airBroadbandAttenuation = meanOf(airBandAttenuation)
+}
+
+// airTransmission is the energy fraction left after distance meters of air, for the broadband
+// tracers. Like energyAfterReflection, it uses the mean over the octave bands.
+func airTransmission(distance float64) float64 {
+	return math.Pow(10, -airBroadbandAttenuation*distance/10)
+}
+
+// airBandTransmission is airTransmission for octave band b.
+func airBandTransmission(b int, distance float64) float64 {
+	return math.Pow(10, -airBandAttenuation[b]*distance/10)
+}
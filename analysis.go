@@ -0,0 +1,86 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Listener Placement Analysis ---
+
+// HeightProfileSample is the listener score at one ear height.
+type HeightProfileSample struct {
+	Height float64
+	Score  int
+	Valid  bool // False if the listener could not actually be placed there (obstacle, zone, ...)
+}
+
+// computeHeightProfile scores the listener at several heights above its current XZ position,
+// keeping the sound source where it is. Heights are clamped to the room.
+func computeHeightProfile(minHeight, maxHeight, step float64) []HeightProfileSample {
+	if soundSource == nil || listener == nil || step <= 0 || maxHeight < minHeight {
+		return nil
+	}
+	listenerRadius := math.Max(listener.Scale.X, math.Max(listener.Scale.Y, listener.Scale.Z)) / 2.0
+	minHeight = math.Max(minHeight, listenerRadius)
+	maxHeight = math.Min(maxHeight, roomHeight-listenerRadius)
+
+	samples := []HeightProfileSample{}
+	for h := minHeight; h <= maxHeight+EPSILON; h += step {
+		testPos := Vector3{X: listener.Position.X, Y: h, Z: listener.Position.Z}
+		valid := true
+		if occupancyCloud != nil {
+			valid = occupancyCloud.IsPositionAttemptValid(testPos, listener.Scale, StateListener, soundSource.Position, soundSource.Scale)
+		}
+		samples = append(samples, HeightProfileSample{
+			Height: h,
+			Score:  calculateListenerScore(soundSource.Position, testPos),
+			Valid:  valid,
+		})
+	}
+	return samples
+}
+
+// goComputeHeightProfile([minHeight, maxHeight, step]) returns how the listener score varies with ear height.
+// Defaults to 0.6 m - 2.0 m in 0.2 m steps.
+func goComputeHeightProfile(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goComputeHeightProfile")
+	minHeight, maxHeight, step := 0.6, 2.0, 0.2
+	if len(args) == 3 {
+		minHeight, maxHeight, step = args[0].Float(), args[1].Float(), args[2].Float()
+	} else if len(args) != 0 {
+		log.Println("Error: goComputeHeightProfile expects 0 or 3 arguments (minHeight, maxHeight, step)")
+		return nil
+	}
+
+	samples := computeHeightProfile(minHeight, maxHeight, step)
+	if len(samples) == 0 {
+		return nil
+	}
+	jsSamples := make([]interface{}, len(samples))
+	minScore, maxScore := math.MaxInt32, math.MinInt32
+	bestHeight := samples[0].Height
+	for i, sample := range samples {
+		jsSamples[i] = map[string]interface{}{
+			"height": sample.Height,
+			"score":  sample.Score,
+			"valid":  sample.Valid,
+		}
+		if sample.Score < minScore {
+			minScore = sample.Score
+		}
+		if sample.Score > maxScore {
+			maxScore = sample.Score
+			bestHeight = sample.Height
+		}
+	}
+	return js.ValueOf(map[string]interface{}{
+		"x":          listener.Position.X,
+		"z":          listener.Position.Z,
+		"samples":    jsSamples,
+		"minScore":   minScore,
+		"maxScore":   maxScore,
+		"spread":     maxScore - minScore, // How much ear height matters here
+		"bestHeight": bestHeight,
+	})
+}
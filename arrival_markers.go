@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"syscall/js"
+)
+
+// --- Listener Arrival Markers (where sound reaches the listener) ---
+
+// ArrivalPoint is one path reaching the listener during the last visualization pass.
+type ArrivalPoint struct {
+	Source     *SceneObject
+	Point      Vector3 // Where the ray enters the listener sphere
+	Normal     Vector3 // Outward sphere normal at Point
+	Direction  Vector3 // Direction of travel on arrival
+	Incidence  float64 // Angle (degrees) between the incoming ray and the normal; 0 is head-on
+	Bounces    int
+	Energy     float64 // Energy left after absorption, weighted by the source gain
+	PathLength float64 // Meters travelled from the source to Point
+}
+
+var arrivalPoints []ArrivalPoint // One per captured path (see setCaptureArrival), reset every pass
+
+// listenerEntryPoint returns where a ray from origin along direction enters the sphere of radius
+// around center. The tracer counts hits by closest approach, so the ray may only graze the sphere;
+// if it does not actually cross the surface, the surface point nearest the closest approach is used.
+func listenerEntryPoint(origin, direction, center Vector3, radius float64) Vector3 {
+	t := center.Sub(origin).Dot(direction)
+	closest := origin.Add(direction.Scale(t))
+	offset := closest.Sub(center)
+	d2 := offset.Dot(offset)
+	if d2 < radius*radius {
+		return origin.Add(direction.Scale(t - math.Sqrt(radius*radius-d2)))
+	}
+	if d2 == 0 {
+		return center.Sub(direction.Scale(radius))
+	}
+	return center.Add(offset.Normalize().Scale(radius))
+}
+
+// newArrival describes a listener hit of the visualization pass.
+// pathLength is the distance travelled to origin.
+func newArrival(source *SceneObject, origin, direction, listenerPos Vector3, listenerRadius float64, bounces int, energy, pathLength float64) ArrivalPoint {
+	point := listenerEntryPoint(origin, direction, listenerPos, listenerRadius)
+	normal := point.Sub(listenerPos).Normalize()
+	cosIncidence := math.Max(-1, math.Min(1, -direction.Dot(normal)))
+	return ArrivalPoint{
+		Source:     source,
+		Point:      point,
+		Normal:     normal,
+		Direction:  direction,
+		Incidence:  math.Acos(cosIncidence) * 180 / math.Pi,
+		Bounces:    bounces,
+		Energy:     energy * sourceEnergyGain(source),
+		PathLength: pathLength + point.Sub(origin).Length(),
+	}
+}
+
+// goGetArrivalMarkers returns every listener-reaching path of the last pass as
+// [{source, position: {x,y,z}, normal: {x,y,z}, direction: {x,y,z}, incidence, bounces, energy}],
+// with positions on the listener sphere and incidence in degrees.
+func goGetArrivalMarkers(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetArrivalMarkers")
+	markers := make([]interface{}, len(arrivalPoints))
+	for i, a := range arrivalPoints {
+		markers[i] = map[string]interface{}{
+			"source":    sourceID(a.Source),
+			"position":  map[string]interface{}{"x": a.Point.X, "y": a.Point.Y, "z": a.Point.Z},
+			"normal":    map[string]interface{}{"x": a.Normal.X, "y": a.Normal.Y, "z": a.Normal.Z},
+			"direction": map[string]interface{}{"x": a.Direction.X, "y": a.Direction.Y, "z": a.Direction.Z},
+			"incidence": a.Incidence,
+			"bounces":   a.Bounces,
+			"energy":    a.Energy,
+		}
+	}
+	return js.ValueOf(markers)
+}
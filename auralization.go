@@ -0,0 +1,257 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"syscall/js"
+)
+
+// --- Auralization (convolving dry audio with the simulated impulse response) ---
+
+const (
+	AURALIZATION_IR_RAYS     = 5000  // Rays traced for the energy histogram behind the impulse response
+	AURALIZATION_MAX_SECONDS = 10.0  // Longest dry clip accepted
+	AURALIZATION_NOISE_SEED  = 20240 // Fixed so the same room always sounds the same
+	AURALIZATION_MIN_RATE    = 8000.0
+	AURALIZATION_MAX_RATE    = 192000.0
+	IMPULSE_RESPONSE_RATE    = 48000.0 // Default sample rate of goComputeImpulseResponse
+)
+
+// fft computes the discrete Fourier transform of a (length a power of two) in place.
+// With inverse set it computes the unscaled inverse transform.
+func fft(a []complex128, inverse bool) {
+	n := len(a)
+	for i, j := 1, 0; i < n; i++ { // Bit-reversal permutation
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u, v := a[start+k], a[start+k+size/2]*w
+				a[start+k], a[start+k+size/2] = u+v, u-v
+				w *= step
+			}
+		}
+	}
+}
+
+// convolveFFT returns the full linear convolution of x and h.
+func convolveFFT(x, h []float32) []float32 {
+	if len(x) == 0 || len(h) == 0 {
+		return nil
+	}
+	outLen := len(x) + len(h) - 1
+	n := 1
+	for n < outLen {
+		n <<= 1
+	}
+	fx := make([]complex128, n)
+	fh := make([]complex128, n)
+	for i, v := range x {
+		fx[i] = complex(float64(v), 0)
+	}
+	for i, v := range h {
+		fh[i] = complex(float64(v), 0)
+	}
+	fft(fx, false)
+	fft(fh, false)
+	for i := range fx {
+		fx[i] *= fh[i]
+	}
+	fft(fx, true)
+	out := make([]float32, outLen)
+	for i := range out {
+		out[i] = float32(real(fx[i]) / float64(n))
+	}
+	return out
+}
+
+// bandpassNoise returns unit-RMS noise filtered to the octave band around centerHz
+// (RBJ band-pass biquad, one octave wide).
+func bandpassNoise(rng *rand.Rand, samples int, centerHz, sampleRate float64) []float64 {
+	w0 := 2 * math.Pi * centerHz / sampleRate
+	alpha := math.Sin(w0) * math.Sinh(math.Ln2/2*w0/math.Sin(w0))
+	a0 := 1 + alpha
+	b0, b2 := alpha/a0, -alpha/a0
+	a1, a2 := -2*math.Cos(w0)/a0, (1-alpha)/a0
+
+	out := make([]float64, samples)
+	var x1, x2, y1, y2, power float64
+	for i := range out {
+		x := rng.NormFloat64()
+		y := b0*x + b2*x2 - a1*y1 - a2*y2
+		x2, x1 = x1, x
+		y2, y1 = y1, y
+		out[i] = y
+		power += y * y
+	}
+	if power > 0 {
+		rms := math.Sqrt(power / float64(samples))
+		for i := range out {
+			out[i] /= rms
+		}
+	}
+	return out
+}
+
+// synthesizeImpulseResponse turns a band echogram into a pressure impulse response at sampleRate:
+// per octave band, band-limited noise is shaped so each histogram bin carries that bin's energy.
+// Bands at or above Nyquist are skipped.
+func synthesizeImpulseResponse(eg *BandEchogram, sampleRate float64) []float32 {
+	samplesPerBin := eg.BinSec * sampleRate
+	samples := int(math.Ceil(float64(len(eg.Energy)) * samplesPerBin))
+	ir := make([]float64, samples)
+	rng := rand.New(rand.NewSource(AURALIZATION_NOISE_SEED))
+	for b, centerHz := range eg.BandsHz {
+		if centerHz*math.Sqrt2 >= sampleRate/2 {
+			continue
+		}
+		noise := bandpassNoise(rng, samples, centerHz, sampleRate)
+		for i := range ir {
+			bin := int(float64(i) / samplesPerBin)
+			if bin >= len(eg.Energy) {
+				break
+			}
+			ir[i] += noise[i] * math.Sqrt(eg.Energy[bin][b]/samplesPerBin)
+		}
+	}
+	out := make([]float32, samples)
+	for i, v := range ir {
+		out[i] = float32(v)
+	}
+	return out
+}
+
+// roomImpulseResponse synthesizes the impulse response at the listener for all unmuted sources,
+// each weighted by its gain.
+func roomImpulseResponse(sampleRate, maxTimeSec float64, rays int) []float32 {
+	combined := roomBandEchogram(rays, ENERGY_TRACE_DEFAULT_BIN, maxTimeSec)
+	if combined == nil {
+		return nil
+	}
+	return synthesizeImpulseResponse(combined, sampleRate)
+}
+
+// roomBandEchogram sums the band echograms of all unmuted sources at the listener, each weighted
+// by its gain. Returns nil if every source is muted.
+func roomBandEchogram(rays int, binSec, maxTimeSec float64) *BandEchogram {
+	var combined *BandEchogram
+	sources := soundSources
+	if len(sources) == 0 {
+		sources = []*SceneObject{soundSource}
+	}
+	for _, src := range sources {
+		gain := sourceEnergyGain(src)
+		if gain == 0 {
+			continue
+		}
+		eg := traceBandEchogram(src, listener, rays, binSec, maxTimeSec)
+		if combined == nil {
+			combined = &BandEchogram{BinSec: eg.BinSec, BandsHz: eg.BandsHz, Energy: make([][]float64, len(eg.Energy))}
+			for i := range combined.Energy {
+				combined.Energy[i] = make([]float64, len(eg.BandsHz))
+			}
+		}
+		for i, row := range eg.Energy {
+			for b, e := range row {
+				combined.Energy[i][b] += gain * e
+			}
+		}
+	}
+	return combined
+}
+
+// goComputeImpulseResponse([sampleRate, maxTime]) traces the room and returns the impulse response
+// at the listener as {sampleRate, time, amplitude}: two Float32Arrays of equal length with sample
+// times in seconds and pressure amplitudes. Returns null on invalid arguments.
+func goComputeImpulseResponse(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goComputeImpulseResponse")
+	sampleRate, maxTime := IMPULSE_RESPONSE_RATE, ENERGY_TRACE_MAX_TIME
+	if len(args) >= 1 {
+		sampleRate = args[0].Float()
+	}
+	if len(args) >= 2 {
+		maxTime = args[1].Float()
+	}
+	if sampleRate < AURALIZATION_MIN_RATE || sampleRate > AURALIZATION_MAX_RATE {
+		log.Printf("Error: goComputeImpulseResponse sample rate %.0f outside [%.0f, %.0f]", sampleRate, AURALIZATION_MIN_RATE, AURALIZATION_MAX_RATE)
+		return nil
+	}
+	if maxTime <= 0 || maxTime > AURALIZATION_MAX_SECONDS {
+		log.Printf("Error: goComputeImpulseResponse maxTime must be in (0, %.0f] s, got %.3f", AURALIZATION_MAX_SECONDS, maxTime)
+		return nil
+	}
+	if listener == nil || soundSource == nil {
+		return nil
+	}
+	ir := roomImpulseResponse(sampleRate, maxTime, AURALIZATION_IR_RAYS)
+	times := make([]float32, len(ir))
+	for i := range times {
+		times[i] = float32(float64(i) / sampleRate)
+	}
+	return js.ValueOf(map[string]interface{}{
+		"sampleRate": sampleRate,
+		"time":       float32ArrayFromGo(times),
+		"amplitude":  float32ArrayFromGo(ir),
+	})
+}
+
+// auralize convolves dry with the room's impulse response and rescales the result to the dry
+// clip's peak level, so playback volume is comparable.
+func auralize(dry []float32, sampleRate float64) []float32 {
+	ir := roomImpulseResponse(sampleRate, ENERGY_TRACE_MAX_TIME, AURALIZATION_IR_RAYS)
+	wet := convolveFFT(dry, ir)
+	dryPeak, wetPeak := 0.0, 0.0
+	for _, v := range dry {
+		dryPeak = math.Max(dryPeak, math.Abs(float64(v)))
+	}
+	for _, v := range wet {
+		wetPeak = math.Max(wetPeak, math.Abs(float64(v)))
+	}
+	if wetPeak > 0 {
+		scale := float32(dryPeak / wetPeak)
+		for i := range wet {
+			wet[i] *= scale
+		}
+	}
+	return wet
+}
+
+// goAuralize(pcm, sampleRate) convolves a mono dry clip (Float32Array) with the simulated room
+// impulse response at the listener and returns the wet signal as a Float32Array (null on error).
+func goAuralize(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goAuralize")
+	if len(args) != 2 {
+		log.Println("Error: goAuralize expects 2 arguments (pcm Float32Array, sampleRate)")
+		return nil
+	}
+	sampleRate := args[1].Float()
+	if sampleRate < AURALIZATION_MIN_RATE || sampleRate > AURALIZATION_MAX_RATE {
+		log.Printf("Error: goAuralize sample rate %.0f outside [%.0f, %.0f]", sampleRate, AURALIZATION_MIN_RATE, AURALIZATION_MAX_RATE)
+		return nil
+	}
+	if listener == nil || soundSource == nil {
+		return nil
+	}
+	dry := float32ArrayToGo(args[0])
+	if float64(len(dry)) > AURALIZATION_MAX_SECONDS*sampleRate {
+		log.Printf("Error: goAuralize clip longer than %.0f s", AURALIZATION_MAX_SECONDS)
+		return nil
+	}
+	return float32ArrayFromGo(auralize(dry, sampleRate))
+}
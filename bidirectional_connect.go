@@ -0,0 +1,128 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// --- Bidirectional Path Connections ---
+//
+// In a cluttered room few specular paths from the source find the listener. With connections on,
+// rays are also traced outward from the listener, and every reflection point of a source ray is
+// joined to reflection points of listener rays that it can see: the source ray's energy scatters
+// diffusely (its diffuseShare) towards the listener-side point, which scatters it on along the
+// listener ray's path. For listener rays emitted uniformly, the density of their reflection points
+// cancels against the listener's cross-section, and one connection of listener ray vertex y to
+// source vertex x is worth 4r²·sₓ·s_y·cos θₓ·cos θ_y / (M·d²) of the source ray's energy, for M
+// listener rays of radius r and a connection of length d. Each source vertex is joined to
+// BIDIR_CONNECTIONS_PER_VERTEX listener vertices picked at random, weighted up to stand for all
+// of them. The score adds to the forward score; like next-event estimation, forward and listener
+// rays then continue without their scattered share.
+
+const BIDIR_CONNECTIONS_PER_VERTEX = 2
+
+var bidirectionalConnect bool // Toggle "bidirectionalConnect": join source and listener rays at mutually visible points
+
+// PathVertex is a reflection point of a traced ray.
+type PathVertex struct {
+	Point      Vector3
+	Direction  Vector3 // Travel direction of the arriving ray
+	Normal     Vector3 // Facing the side the ray arrived on
+	Object     *SceneObject
+	Energy     float64 // Energy arriving at the point, before its reflection
+	Bounces    int     // Reflections up to and including this one
+	PathLength float64 // Meters travelled from the ray's origin to Point
+}
+
+// diffuseShare is the share of a reflection off obj that leaves diffusely for next-event
+// estimation and path connections: its Scattering, or neeDefaultScattering if it has none.
+func diffuseShare(obj *SceneObject) float64 {
+	if obj.Material.Scattering > 0 {
+		return math.Min(1, obj.Material.Scattering)
+	}
+	return neeDefaultScattering
+}
+
+// traceVertices follows a ray from origin specularly and returns its reflection points, up to
+// maxReflections of them and no further along than maxPathLength. The ray loses each reflection's
+// absorption and diffuse share.
+func traceVertices(origin, direction Vector3, energy float64, collidables []*SceneObject, sets *CollidableSets, vertices []PathVertex) []PathVertex {
+	pathLength := 0.0
+	for bounces := 1; bounces <= maxReflections; bounces++ {
+		hit := performRaycast(origin, direction, segmentReach(pathLength), collidables, nil)
+		if !hit.Hit || !surfaceContinuesPath(hit.Object, bounces) {
+			break
+		}
+		energy *= airTransmission(hit.Distance)
+		pathLength += hit.Distance
+		normal := hit.Normal
+		if normal.Dot(direction) > 0 {
+			normal = normal.Scale(-1)
+		}
+		vertices = append(vertices, PathVertex{Point: hit.Point, Direction: direction, Normal: normal, Object: hit.Object, Energy: energy, Bounces: bounces, PathLength: pathLength})
+		energy = energyAfterReflection(energy, hit.Object, direction, hit.Normal) * (1 - diffuseShare(hit.Object))
+		if energy <= 0 {
+			break
+		}
+		direction = direction.Reflect(hit.Normal)
+		origin = reflectionOrigin(hit, direction)
+		collidables = sets.Reflected
+	}
+	return vertices
+}
+
+// connectionScore traces len(directions) rays each way and returns the score of the connected
+// paths between source and listener, on the scale of the forward score of as many rays.
+func connectionScore(source *SceneObject, sourcePos, listenerPos Vector3, listenerRadius float64, directions []Vector3) float64 {
+	sets := collidableSetsFor(source)
+	directCollidables := sets.Reflected
+	if source != nil && source.Position == sourcePos {
+		directCollidables = sets.Direct
+	}
+	emitters := emitterPoints(source, sourcePos)
+	var sourceVertices, listenerVertices []PathVertex
+	for i, direction := range directions {
+		if emitted := emissionGain(source, direction); emitted > 0 {
+			sourceVertices = traceVertices(emitters[i%len(emitters)], direction, emitted, directCollidables, sets, sourceVertices)
+		}
+		listenerVertices = traceVertices(listenerPos, direction, receiverGain(direction.Scale(-1)), sets.Reflected, sets, listenerVertices)
+	}
+	if len(sourceVertices) == 0 || len(listenerVertices) == 0 {
+		return 0
+	}
+
+	samples := min(BIDIR_CONNECTIONS_PER_VERTEX, len(listenerVertices))
+	weight := 4 * listenerRadius * listenerRadius / float64(len(directions)) * float64(len(listenerVertices)) / float64(samples)
+	score := 0.0
+	for _, x := range sourceVertices {
+		sx := diffuseShare(x.Object) * energyAfterReflection(1, x.Object, x.Direction, x.Normal)
+		for k := 0; k < samples; k++ {
+			y := listenerVertices[rand.Intn(len(listenerVertices))]
+			bounces := x.Bounces + y.Bounces
+			if bounces > maxReflections || !stochasticOrderCounts(bounces) {
+				continue
+			}
+			leg := y.Point.Sub(x.Point)
+			d := leg.Length()
+			if d < listenerRadius { // Too close to resolve; the estimator's 1/d² would blow up
+				continue
+			}
+			if x.PathLength+d+y.PathLength > maxPathLength() { // The joined path ends past the decay time
+				continue
+			}
+			dir := leg.Scale(1 / d)
+			cosX, cosY := dir.Dot(x.Normal), -dir.Dot(y.Normal)
+			if cosX <= 0 || cosY <= 0 {
+				continue
+			}
+			start := x.Point.Add(x.Normal.Scale(math.Max(reflectionOffset, sceneEpsilon(x.Point))))
+			if blocker := performRaycast(start, dir, d-2*reflectionOffset, sets.Reflected, nil); blocker.Hit {
+				continue
+			}
+			sy := diffuseShare(y.Object) * energyAfterReflection(1, y.Object, dir, y.Normal)
+			energy := x.Energy * sx * y.Energy * sy * airTransmission(d) * cosX * cosY / (d * d)
+			score += arrivalScore(bounces, weight*energy)
+		}
+	}
+	return score
+}
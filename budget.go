@@ -0,0 +1,160 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+	"time"
+)
+
+// --- Simulation Budget Guardrails ---
+//
+// A visualization pass traces at most sources × numRays × (maxReflections+1) segments, twice
+// that with bidirectional tracing, plus the vertex and shadow rays of path connections. Slider
+// changes that would push this past the budget are rejected instead of freezing the page;
+// goEstimateSimulationCost lets the UI show the cost first.
+// With a decay time set, paths end at maxPathLength whatever their bounce count, so the depth
+// counted is at most the reflections a path makes on average within it.
+
+const (
+	DEFAULT_MAX_SEGMENTS_PER_PASS       = 2000000
+	DEFAULT_SEGMENT_COST          int64 = 500 // Nanoseconds per segment until a pass has been timed
+)
+
+var (
+	maxSegmentsPerPass  = DEFAULT_MAX_SEGMENTS_PER_PASS
+	measuredSegmentCost = DEFAULT_SEGMENT_COST // Nanoseconds per worst-case segment of the last pass
+)
+
+// SimulationCost is the worst-case work of one visualization pass.
+type SimulationCost struct {
+	Rays        int // Rays emitted across all active sources
+	Segments    int // Upper bound on traced segments
+	EstimatedMs float64
+}
+
+// activeSourceCount counts sources that emit rays (muted sources are skipped by the tracer).
+func activeSourceCount() int {
+	count := 0
+	for _, src := range soundSources {
+		if sourceEnergyGain(src) > 0 {
+			count++
+		}
+	}
+	if count == 0 && soundSource != nil {
+		count = 1
+	}
+	return count
+}
+
+// estimateSimulationCost bounds the cost of a pass with the given ray count and reflection depth.
+func estimateSimulationCost(rays, reflections int) SimulationCost {
+	reflections = pathLimitedReflections(reflections)
+	totalRays := rays * activeSourceCount()
+	segments := totalRays * (reflections + 1)
+	if bidirectionalTracing {
+		segments *= 2
+	}
+	if bidirectionalConnect { // Both ray sets, then shadow rays between their reflection points
+		segments += totalRays * (reflections + 1) * (2 + BIDIR_CONNECTIONS_PER_VERTEX)
+	}
+	return SimulationCost{
+		Rays:        totalRays,
+		Segments:    segments,
+		EstimatedMs: float64(int64(segments)*measuredSegmentCost) / float64(time.Millisecond),
+	}
+}
+
+// pathLimitedReflections caps a reflection depth at the reflections a path makes on average before
+// reaching maxPathLength, one per mean free path of the room.
+func pathLimitedReflections(reflections int) int {
+	limit := maxPathLength()
+	if math.IsInf(limit, 1) {
+		return reflections
+	}
+	meanFreePath := computeSceneStatistics().MeanFreePath
+	if meanFreePath <= 0 {
+		return reflections
+	}
+	if bounces := int(math.Ceil(limit / meanFreePath)); bounces < reflections {
+		return bounces
+	}
+	return reflections
+}
+
+// withinSimulationBudget reports whether a pass with these settings fits the budget, logging the
+// estimate when it does not.
+func withinSimulationBudget(rays, reflections int) bool {
+	if rays < 0 || reflections < 0 {
+		log.Printf("Rejected: numRays=%d and maxBounces=%d must be non-negative", rays, reflections)
+		return false
+	}
+	cost := estimateSimulationCost(rays, reflections)
+	if cost.Segments <= maxSegmentsPerPass {
+		return true
+	}
+	log.Printf("Rejected: numRays=%d with maxBounces=%d would trace up to %d segments (~%.0f ms), over the budget of %d",
+		rays, reflections, cost.Segments, cost.EstimatedMs, maxSegmentsPerPass)
+	return false
+}
+
+// resyncUISliders pushes the current parameters back to the sliders, e.g. after a rejected change.
+func resyncUISliders() {
+	if soundSource == nil || listener == nil {
+		return
+	}
+	callOptionalJS("updateAllUISliders",
+		numRays, initialRayOpacity, maxReflections, volumeAttenuationFactor, explorationFactor,
+		soundSource.Position.X, soundSource.Position.Y, soundSource.Position.Z,
+		listener.Position.X, listener.Position.Y, listener.Position.Z,
+		showOnlyListenerRays,
+	)
+}
+
+// recordPassCost updates the per-segment cost from a timed pass.
+func recordPassCost(rays int, elapsed time.Duration) {
+	segments := int64(rays) * int64(maxReflections+1)
+	if segments > 0 && elapsed > 0 {
+		measuredSegmentCost = max(1, elapsed.Nanoseconds()/segments)
+	}
+}
+
+// goEstimateSimulationCost([numRays, maxBounces]) returns {rays, segments, estimatedMs, limit,
+// withinBudget} for the given settings, defaulting to the current ones.
+func goEstimateSimulationCost(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goEstimateSimulationCost")
+	rays, reflections := numRays, maxReflections
+	if len(args) >= 1 {
+		rays = args[0].Int()
+	}
+	if len(args) >= 2 {
+		reflections = args[1].Int()
+	}
+	cost := estimateSimulationCost(rays, reflections)
+	return js.ValueOf(map[string]interface{}{
+		"rays":         cost.Rays,
+		"segments":     cost.Segments,
+		"estimatedMs":  cost.EstimatedMs,
+		"limit":        maxSegmentsPerPass,
+		"withinBudget": cost.Segments <= maxSegmentsPerPass,
+	})
+}
+
+// goSetSimulationBudget(maxSegments) sets the per-pass segment budget (0 restores the default).
+func goSetSimulationBudget(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetSimulationBudget")
+	if len(args) != 1 {
+		log.Println("Error: goSetSimulationBudget expects 1 argument (maxSegments)")
+		return nil
+	}
+	limit := args[0].Int()
+	if limit < 0 {
+		log.Printf("Error: simulation budget must be non-negative, got %d", limit)
+		return nil
+	}
+	if limit == 0 {
+		limit = DEFAULT_MAX_SEGMENTS_PER_PASS
+	}
+	maxSegmentsPerPass = limit
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Camera Tour (keyframed fly-through for presentations) ---
+
+const (
+	CAMERA_TOUR_ORBIT_STEPS    = 8   // Keyframes on the orbit around the room
+	CAMERA_TOUR_ORBIT_SECONDS  = 2.0 // Travel time between orbit keyframes
+	CAMERA_TOUR_POI_SECONDS    = 3.0 // Travel time to a point of interest
+	CAMERA_TOUR_HOLD_SECONDS   = 2.0 // Pause at each point of interest
+	CAMERA_TOUR_POI_DISTANCE   = 4.0 // Meters between the camera and a point of interest
+	CAMERA_TOUR_CEILING_MARGIN = 0.5 // Meters the camera stays below the ceiling
+)
+
+// CameraKeyframe is one stop of a camera tour. Duration is the time (seconds) to move there from
+// the previous keyframe; a keyframe equal to its predecessor is a hold.
+type CameraKeyframe struct {
+	Position Vector3
+	Target   Vector3
+	Duration float64
+	Label    string
+}
+
+// cameraPointOfInterest is a spot the tour visits.
+type cameraPointOfInterest struct {
+	label string
+	point Vector3
+}
+
+// tourPointsOfInterest lists the source, the listener and, after a coverage heatmap run, the sweet spot.
+func tourPointsOfInterest() []cameraPointOfInterest {
+	var pois []cameraPointOfInterest
+	if soundSource != nil {
+		pois = append(pois, cameraPointOfInterest{"source", soundSource.Position})
+	}
+	if listener != nil {
+		pois = append(pois, cameraPointOfInterest{"listener", listener.Position})
+	}
+	if sweetSpot != nil {
+		pois = append(pois, cameraPointOfInterest{"sweetSpot", sweetSpot.Centroid})
+	}
+	return pois
+}
+
+// clampToRoom keeps a camera position inside the room, below the ceiling.
+func clampToRoom(p Vector3) Vector3 {
+	margin := CAMERA_TOUR_CEILING_MARGIN
+	return Vector3{
+		X: math.Max(-roomWidth/2+margin, math.Min(roomWidth/2-margin, p.X)),
+		Y: math.Max(margin, math.Min(roomHeight-margin, p.Y)),
+		Z: math.Max(-roomDepth/2+margin, math.Min(roomDepth/2-margin, p.Z)),
+	}
+}
+
+// buildCameraTour plans a fly-through: from the current camera to an overview, one orbit of the
+// room looking at its center, a close-up with a hold at each point of interest (viewed from the
+// room-center side, above it), and back to the overview.
+func buildCameraTour() []CameraKeyframe {
+	center := Vector3{0, roomHeight / 3, 0}
+	orbitRadius := 0.4 * math.Min(roomWidth, roomDepth)
+	orbitHeight := 0.8 * roomHeight
+	overview := CameraKeyframe{
+		Position: clampToRoom(Vector3{orbitRadius, orbitHeight, orbitRadius}),
+		Target:   center,
+		Duration: CAMERA_TOUR_POI_SECONDS,
+		Label:    "overview",
+	}
+
+	tour := []CameraKeyframe{{Position: mainCamera.Position, Target: mainCamera.Target, Label: "start"}, overview}
+	startAngle := math.Atan2(overview.Position.Z, overview.Position.X)
+	for i := 1; i <= CAMERA_TOUR_ORBIT_STEPS; i++ {
+		angle := startAngle + 2*math.Pi*float64(i)/CAMERA_TOUR_ORBIT_STEPS
+		tour = append(tour, CameraKeyframe{
+			Position: clampToRoom(Vector3{orbitRadius * math.Cos(angle), orbitHeight, orbitRadius * math.Sin(angle)}),
+			Target:   center,
+			Duration: CAMERA_TOUR_ORBIT_SECONDS,
+			Label:    "orbit",
+		})
+	}
+
+	for _, poi := range tourPointsOfInterest() {
+		away := Vector3{poi.point.X, 0, poi.point.Z}.Scale(-1) // Towards the room center
+		if away.Length() < EPSILON {
+			away = Vector3{1, 0, 0}
+		}
+		offset := away.Normalize().Add(Vector3{0, 0.6, 0}).Normalize().Scale(CAMERA_TOUR_POI_DISTANCE)
+		stop := CameraKeyframe{Position: clampToRoom(poi.point.Add(offset)), Target: poi.point, Duration: CAMERA_TOUR_POI_SECONDS, Label: poi.label}
+		hold := stop
+		hold.Duration = CAMERA_TOUR_HOLD_SECONDS
+		tour = append(tour, stop, hold)
+	}
+	return append(tour, overview)
+}
+
+// goGetCameraTour returns a keyframed camera path as
+// [{position: {x,y,z}, target: {x,y,z}, duration, label}] for JS to interpolate and play back.
+// The first keyframe is the current camera (duration 0).
+func goGetCameraTour(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetCameraTour")
+	tour := buildCameraTour()
+	keyframes := make([]interface{}, len(tour))
+	totalSeconds := 0.0
+	for i, k := range tour {
+		keyframes[i] = map[string]interface{}{
+			"position": map[string]interface{}{"x": k.Position.X, "y": k.Position.Y, "z": k.Position.Z},
+			"target":   map[string]interface{}{"x": k.Target.X, "y": k.Target.Y, "z": k.Target.Z},
+			"duration": k.Duration,
+			"label":    k.Label,
+		}
+		totalSeconds += k.Duration
+	}
+	log.Printf("Camera tour planned: %d keyframes, %.1f s", len(tour), totalSeconds)
+	return js.ValueOf(keyframes)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"syscall/js"
+)
+
+// --- Clarity Metrics (C50, C80, D50) ---
+//
+// Early-to-late energy ratios of the arrivals at the listener. The early window starts at the
+// first arrival (the direct sound, or the earliest reflection if it is blocked).
+
+const (
+	CLARITY_LIMIT_DB        = 40.0  // Clarity is clipped to ±this when one side of the ratio is empty
+	CLARITY_EVAL_RAYS       = 400   // Rays per C80 objective evaluation
+	CLARITY_SCORE_OFFSET_DB = 30.0  // C80 is shifted by this before scaling, so typical scores are positive
+	CLARITY_SCORE_SCALE     = 100.0 // Objective score units per dB
+)
+
+// LearningObjectiveC80 makes learning maximize C80 instead of the Fibonacci score.
+const LearningObjectiveC80 = "c80"
+
+// ClarityMetrics are early-to-late ratios in dB (C50, C80) and the early energy fraction (D50).
+type ClarityMetrics struct {
+	C50, C80, D50 float64
+	Arrivals      int // Arrivals the metrics were computed from; 0 means no sound reached the listener
+}
+
+var lastClarity ClarityMetrics // Metrics of the last visualization pass
+
+// clarityRatioDB is 10·log10(early/late), clipped to ±CLARITY_LIMIT_DB.
+func clarityRatioDB(early, late float64) float64 {
+	if late <= 0 {
+		return CLARITY_LIMIT_DB
+	}
+	if early <= 0 {
+		return -CLARITY_LIMIT_DB
+	}
+	return math.Max(-CLARITY_LIMIT_DB, math.Min(CLARITY_LIMIT_DB, 10*math.Log10(early/late)))
+}
+
+// computeClarity derives the metrics from arrival times (seconds) and energies.
+func computeClarity(times, energies []float64) ClarityMetrics {
+	first := math.Inf(1)
+	arrivals := 0
+	for i, t := range times {
+		if energies[i] > 0 {
+			first = math.Min(first, t)
+			arrivals++
+		}
+	}
+	if arrivals == 0 {
+		return ClarityMetrics{}
+	}
+	var total, early50, early80 float64
+	for i, t := range times {
+		e := energies[i]
+		total += e
+		if t-first < 0.050 {
+			early50 += e
+		}
+		if t-first < 0.080 {
+			early80 += e
+		}
+	}
+	return ClarityMetrics{
+		C50:      clarityRatioDB(early50, total-early50),
+		C80:      clarityRatioDB(early80, total-early80),
+		D50:      early50 / total,
+		Arrivals: arrivals,
+	}
+}
+
+// clarityFromArrivals computes the metrics of the visualization pass's listener arrivals.
+func clarityFromArrivals(arrivals []ArrivalPoint) ClarityMetrics {
+	times := make([]float64, len(arrivals))
+	energies := make([]float64, len(arrivals))
+	for i, a := range arrivals {
+		times[i] = a.PathLength / environment.SpeedOfSound
+		energies[i] = a.Energy
+	}
+	return computeClarity(times, energies)
+}
+
+// clarityFromEchogram computes the metrics of a band echogram's broadband histogram, taking each
+// bin's center as its arrival time.
+func clarityFromEchogram(eg *BandEchogram) ClarityMetrics {
+	broadband, _ := bandColumns(eg)
+	times := make([]float64, len(broadband))
+	for i := range times {
+		times[i] = (float64(i) + 0.5) * eg.BinSec
+	}
+	return computeClarity(times, broadband)
+}
+
+// c80Score is the C80 learning objective for a placement of the primary source and the listener,
+// as a non-negative integer score (see CLARITY_SCORE_OFFSET_DB and CLARITY_SCORE_SCALE).
+// Placements where no sound arrives score 0.
+func c80Score(sourcePos, listenerPos Vector3) int {
+	eg := traceBandEchogramAt(soundSource, listener, sourcePos, listenerPos, CLARITY_EVAL_RAYS, ENERGY_TRACE_DEFAULT_BIN, ENERGY_TRACE_MAX_TIME)
+	clarity := clarityFromEchogram(eg)
+	if clarity.Arrivals == 0 {
+		return 0
+	}
+	return int(math.Round(math.Max(0, clarity.C80+CLARITY_SCORE_OFFSET_DB) * CLARITY_SCORE_SCALE))
+}
+
+// goGetClarityMetrics returns {c50, c80, d50, arrivals} of the last visualization pass.
+func goGetClarityMetrics(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetClarityMetrics")
+	return js.ValueOf(map[string]interface{}{
+		"c50":      lastClarity.C50,
+		"c80":      lastClarity.C80,
+		"d50":      lastClarity.D50,
+		"arrivals": lastClarity.Arrivals,
+	})
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Clearance Field (distance transform over the occupancy cloud) ---
+
+const edtInfinity = 1e20 // "No blocking cell seen yet" for the squared distance transform
+
+// distanceTransform1D computes the squared distance transform of f along one line of cells
+// spaced `spacing` apart (Felzenszwalb & Huttenlocher's lower envelope of parabolas).
+// Results are written to out; v and z are scratch buffers of len(f) and len(f)+1.
+func distanceTransform1D(f, out []float64, spacing float64, v []int, z []float64) {
+	n := len(f)
+	w2 := spacing * spacing
+	k := 0
+	v[0] = 0
+	z[0] = math.Inf(-1)
+	z[1] = math.Inf(1)
+	for q := 1; q < n; q++ {
+		s := intersection(f, q, v[k], w2)
+		for s <= z[k] {
+			k--
+			s = intersection(f, q, v[k], w2)
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.Inf(1)
+	}
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		p := v[k]
+		out[q] = w2*float64((q-p)*(q-p)) + f[p]
+	}
+}
+
+// intersection returns the position where the parabolas rooted at cells q and p meet.
+func intersection(f []float64, q, p int, w2 float64) float64 {
+	return ((f[q] + w2*float64(q*q)) - (f[p] + w2*float64(p*p))) / (2 * w2 * float64(q-p))
+}
+
+// ComputeClearanceField returns, for every cell, the distance in world units from the cell center
+// to the nearest cell whose state blocks placement, also limited by the distance to the cloud
+// boundary. Blocking cells get 0. Computed with a separable exact Euclidean distance transform.
+func (oc *OccupancyCloud) ComputeClearanceField() [][][]float64 {
+	nx, ny, nz := oc.CellsX, oc.CellsY, oc.CellsZ
+	field := make([][][]float64, nx)
+	for ix := range field {
+		field[ix] = make([][]float64, ny)
+		for iy := range field[ix] {
+			field[ix][iy] = make([]float64, nz)
+			for iz := range field[ix][iy] {
+				if oc.Grid[ix][iy][iz].State.BlocksPlacement() {
+					field[ix][iy][iz] = 0
+				} else {
+					field[ix][iy][iz] = edtInfinity
+				}
+			}
+		}
+	}
+
+	maxN := nx
+	if ny > maxN {
+		maxN = ny
+	}
+	if nz > maxN {
+		maxN = nz
+	}
+	f := make([]float64, maxN)
+	out := make([]float64, maxN)
+	v := make([]int, maxN)
+	z := make([]float64, maxN+1)
+
+	// Pass along Z, then Y, then X
+	for ix := 0; ix < nx; ix++ {
+		for iy := 0; iy < ny; iy++ {
+			copy(f[:nz], field[ix][iy])
+			distanceTransform1D(f[:nz], out[:nz], oc.CellSize.Z, v, z)
+			copy(field[ix][iy], out[:nz])
+		}
+	}
+	for ix := 0; ix < nx; ix++ {
+		for iz := 0; iz < nz; iz++ {
+			for iy := 0; iy < ny; iy++ {
+				f[iy] = field[ix][iy][iz]
+			}
+			distanceTransform1D(f[:ny], out[:ny], oc.CellSize.Y, v, z)
+			for iy := 0; iy < ny; iy++ {
+				field[ix][iy][iz] = out[iy]
+			}
+		}
+	}
+	for iy := 0; iy < ny; iy++ {
+		for iz := 0; iz < nz; iz++ {
+			for ix := 0; ix < nx; ix++ {
+				f[ix] = field[ix][iy][iz]
+			}
+			distanceTransform1D(f[:nx], out[:nx], oc.CellSize.X, v, z)
+			for ix := 0; ix < nx; ix++ {
+				field[ix][iy][iz] = out[ix]
+			}
+		}
+	}
+
+	for ix := 0; ix < nx; ix++ {
+		for iy := 0; iy < ny; iy++ {
+			for iz := 0; iz < nz; iz++ {
+				// Distance from the cell center to the cloud boundary along each axis
+				toBoundary := math.Min(
+					math.Min((float64(ix)+0.5)*oc.CellSize.X, (float64(nx-ix)-0.5)*oc.CellSize.X),
+					math.Min(
+						math.Min((float64(iy)+0.5)*oc.CellSize.Y, (float64(ny-iy)-0.5)*oc.CellSize.Y),
+						math.Min((float64(iz)+0.5)*oc.CellSize.Z, (float64(nz-iz)-0.5)*oc.CellSize.Z)))
+				field[ix][iy][iz] = math.Min(math.Sqrt(field[ix][iy][iz]), toBoundary)
+			}
+		}
+	}
+	return field
+}
+
+// PlacementField is a downsampled XZ map of where a dynamic object could be placed at one height.
+// Allowed holds the fraction (0..1) of fine cells in each coarse cell that are valid placements.
+type PlacementField struct {
+	ResX, ResZ int
+	MinX, MinZ float64
+	CellW      float64
+	CellD      float64
+	Height     float64
+	Allowed    [][]float64 // [ix][iz]
+}
+
+// computePlacementField evaluates where an object with the given radius (plus its clearance margin)
+// fits at the given height, using the clearance field instead of per-cell sphere tests.
+// The other dynamic object is treated as a sphere obstacle. downsample groups fine cells per side.
+func computePlacementField(radius float64, state PointState, other *SceneObject, height float64, downsample int) *PlacementField {
+	oc := occupancyCloud
+	if oc == nil || downsample < 1 {
+		return nil
+	}
+	_, iy, _, inBounds := oc.worldToGridCoords(Vector3{oc.RoomMin.X, height, oc.RoomMin.Z})
+	if !inBounds {
+		return nil
+	}
+	clearance := oc.ComputeClearanceField()
+	required := radius + oc.ClearanceMargin(state)
+
+	resX := (oc.CellsX + downsample - 1) / downsample
+	resZ := (oc.CellsZ + downsample - 1) / downsample
+	pf := &PlacementField{
+		ResX: resX, ResZ: resZ,
+		MinX: oc.RoomMin.X, MinZ: oc.RoomMin.Z,
+		CellW: oc.CellSize.X * float64(downsample), CellD: oc.CellSize.Z * float64(downsample),
+		Height:  height,
+		Allowed: make([][]float64, resX),
+	}
+	for cx := 0; cx < resX; cx++ {
+		pf.Allowed[cx] = make([]float64, resZ)
+		for cz := 0; cz < resZ; cz++ {
+			allowed, total := 0, 0
+			for ix := cx * downsample; ix < (cx+1)*downsample && ix < oc.CellsX; ix++ {
+				for iz := cz * downsample; iz < (cz+1)*downsample && iz < oc.CellsZ; iz++ {
+					total++
+					if clearance[ix][iy][iz] < required {
+						continue
+					}
+					if other != nil {
+						center := Vector3{
+							X: oc.RoomMin.X + (float64(ix)+0.5)*oc.CellSize.X,
+							Y: height,
+							Z: oc.RoomMin.Z + (float64(iz)+0.5)*oc.CellSize.Z,
+						}
+						if spheresIntersect(center, radius, other.Position, math.Max(other.Scale.X, other.Scale.Z)/2.0) {
+							continue
+						}
+					}
+					allowed++
+				}
+			}
+			if total > 0 {
+				pf.Allowed[cx][cz] = float64(allowed) / float64(total)
+			}
+		}
+	}
+	return pf
+}
+
+// goGetListenerPlacementField([downsample, height]) returns where the listener may be placed as
+// {resX, resZ, minX, minZ, cellW, cellD, height, allowed: [resX*resZ fractions, row-major by X]}.
+func goGetListenerPlacementField(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetListenerPlacementField")
+	if listener == nil {
+		return nil
+	}
+	downsample := 2
+	height := listener.Position.Y
+	if len(args) >= 1 {
+		downsample = args[0].Int()
+	}
+	if len(args) >= 2 {
+		height = args[1].Float()
+	}
+	if downsample < 1 {
+		log.Printf("Error: placement field downsample factor must be >= 1, got %d", downsample)
+		return nil
+	}
+	radius := math.Max(listener.Scale.X, math.Max(listener.Scale.Y, listener.Scale.Z)) / 2.0
+	pf := computePlacementField(radius, StateListener, soundSource, height, downsample)
+	if pf == nil {
+		return nil
+	}
+	flat := make([]interface{}, 0, pf.ResX*pf.ResZ)
+	for cx := 0; cx < pf.ResX; cx++ {
+		for cz := 0; cz < pf.ResZ; cz++ {
+			flat = append(flat, pf.Allowed[cx][cz])
+		}
+	}
+	return js.ValueOf(map[string]interface{}{
+		"resX":    pf.ResX,
+		"resZ":    pf.ResZ,
+		"minX":    pf.MinX,
+		"minZ":    pf.MinZ,
+		"cellW":   pf.CellW,
+		"cellD":   pf.CellD,
+		"height":  pf.Height,
+		"allowed": flat,
+	})
+}
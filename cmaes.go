@@ -0,0 +1,338 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// --- CMA-ES Learning Strategy ---
+// Covariance Matrix Adaptation Evolution Strategy over the continuous coordinates of the
+// movable entities: (sourceX, sourceY, sourceZ, listenerX, listenerY, listenerZ).
+// Unlike the coordinate-descent lattice it is not limited to optimizationStepSize increments.
+// The occupancy cloud acts as a feasibility filter: invalid samples are resampled.
+
+const (
+	CMAES_DIMENSIONS       = 6
+	CMAES_MAX_RESAMPLES    = 20  // Attempts to draw a feasible candidate before giving up on it
+	CMAES_INITIAL_SIGMA    = 2.0 // Initial step size in world units (scaled by explorationFactor)
+	CMAES_MIN_SIGMA        = 0.01
+	CMAES_INFEASIBLE_SCORE = -1 // Score assigned to candidates that stayed infeasible
+)
+
+type CMAESOptimizer struct {
+	n       int
+	lambda  int       // Population size
+	mu      int       // Number of parents
+	weights []float64 // Recombination weights (sum to 1)
+	muEff   float64
+
+	cSigma, dSigma, cc, c1, cMu, chiN float64
+
+	mean   []float64
+	sigma  float64
+	pSigma []float64
+	pc     []float64
+	C      [][]float64 // Covariance matrix
+	B      [][]float64 // Eigenvectors of C (columns)
+	D      []float64   // Square roots of the eigenvalues of C
+
+	generation int
+	lower      []float64 // Per-coordinate bounds
+	upper      []float64
+}
+
+type cmaesCandidate struct {
+	x     []float64
+	score int
+}
+
+// NewCMAESOptimizer creates an optimizer starting at mean with the given bounds and step size.
+func NewCMAESOptimizer(mean, lower, upper []float64, sigma float64) *CMAESOptimizer {
+	n := len(mean)
+	lambda := 4 + int(3*math.Log(float64(n)))
+	mu := lambda / 2
+
+	weights := make([]float64, mu)
+	sumW := 0.0
+	for i := range weights {
+		weights[i] = math.Log(float64(mu)+0.5) - math.Log(float64(i+1))
+		sumW += weights[i]
+	}
+	sumW2 := 0.0
+	for i := range weights {
+		weights[i] /= sumW
+		sumW2 += weights[i] * weights[i]
+	}
+	muEff := 1 / sumW2
+	nf := float64(n)
+
+	opt := &CMAESOptimizer{
+		n:       n,
+		lambda:  lambda,
+		mu:      mu,
+		weights: weights,
+		muEff:   muEff,
+		cSigma:  (muEff + 2) / (nf + muEff + 5),
+		cc:      (4 + muEff/nf) / (nf + 4 + 2*muEff/nf),
+		c1:      2 / ((nf+1.3)*(nf+1.3) + muEff),
+		chiN:    math.Sqrt(nf) * (1 - 1/(4*nf) + 1/(21*nf*nf)),
+		mean:    append([]float64(nil), mean...),
+		sigma:   sigma,
+		pSigma:  make([]float64, n),
+		pc:      make([]float64, n),
+		D:       make([]float64, n),
+		lower:   lower,
+		upper:   upper,
+	}
+	opt.dSigma = 1 + 2*math.Max(0, math.Sqrt((muEff-1)/(nf+1))-1) + opt.cSigma
+	opt.cMu = math.Min(1-opt.c1, 2*(muEff-2+1/muEff)/((nf+2)*(nf+2)+muEff))
+
+	opt.C = identityMatrix(n)
+	opt.B = identityMatrix(n)
+	for i := range opt.D {
+		opt.D[i] = 1
+	}
+	return opt
+}
+
+func identityMatrix(n int) [][]float64 {
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+		m[i][i] = 1
+	}
+	return m
+}
+
+// sample draws x = mean + sigma * B * D * z and clamps it to the bounds.
+func (opt *CMAESOptimizer) sample() []float64 {
+	z := make([]float64, opt.n)
+	for i := range z {
+		z[i] = rand.NormFloat64() * opt.D[i]
+	}
+	x := make([]float64, opt.n)
+	for i := 0; i < opt.n; i++ {
+		sum := 0.0
+		for j := 0; j < opt.n; j++ {
+			sum += opt.B[i][j] * z[j]
+		}
+		x[i] = math.Max(opt.lower[i], math.Min(opt.upper[i], opt.mean[i]+opt.sigma*sum))
+	}
+	return x
+}
+
+// Step runs one generation: sample lambda feasible candidates, evaluate them and update the
+// distribution. Returns the best candidate of the generation.
+func (opt *CMAESOptimizer) Step(isFeasible func(x []float64) bool, evaluate func(x []float64) int) cmaesCandidate {
+	population := make([]cmaesCandidate, opt.lambda)
+	for k := range population {
+		x := opt.sample()
+		feasible := isFeasible(x)
+		for attempt := 1; !feasible && attempt < CMAES_MAX_RESAMPLES; attempt++ {
+			x = opt.sample()
+			feasible = isFeasible(x)
+		}
+		score := CMAES_INFEASIBLE_SCORE
+		if feasible {
+			score = evaluate(x)
+		}
+		population[k] = cmaesCandidate{x: x, score: score}
+	}
+	// Maximizing the score
+	sort.SliceStable(population, func(i, j int) bool { return population[i].score > population[j].score })
+
+	n := opt.n
+	oldMean := opt.mean
+	opt.mean = make([]float64, n)
+	for i := 0; i < opt.mu; i++ {
+		for d := 0; d < n; d++ {
+			opt.mean[d] += opt.weights[i] * population[i].x[d]
+		}
+	}
+	yW := make([]float64, n)
+	for d := range yW {
+		yW[d] = (opt.mean[d] - oldMean[d]) / opt.sigma
+	}
+
+	// pSigma update uses C^(-1/2) * yW = B * D^-1 * B^T * yW
+	invSqrtCyW := make([]float64, n)
+	tmp := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			tmp[j] += opt.B[i][j] * yW[i]
+		}
+		tmp[j] /= opt.D[j]
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			invSqrtCyW[i] += opt.B[i][j] * tmp[j]
+		}
+	}
+	csFactor := math.Sqrt(opt.cSigma * (2 - opt.cSigma) * opt.muEff)
+	pSigmaNorm := 0.0
+	for d := 0; d < n; d++ {
+		opt.pSigma[d] = (1-opt.cSigma)*opt.pSigma[d] + csFactor*invSqrtCyW[d]
+		pSigmaNorm += opt.pSigma[d] * opt.pSigma[d]
+	}
+	pSigmaNorm = math.Sqrt(pSigmaNorm)
+
+	opt.generation++
+	hSigma := 0.0
+	if pSigmaNorm/math.Sqrt(1-math.Pow(1-opt.cSigma, float64(2*opt.generation))) < (1.4+2/float64(n+1))*opt.chiN {
+		hSigma = 1
+	}
+	ccFactor := math.Sqrt(opt.cc * (2 - opt.cc) * opt.muEff)
+	for d := 0; d < n; d++ {
+		opt.pc[d] = (1-opt.cc)*opt.pc[d] + hSigma*ccFactor*yW[d]
+	}
+
+	// Covariance update: rank-one (pc) plus rank-mu (selected steps)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			rankMu := 0.0
+			for k := 0; k < opt.mu; k++ {
+				yi := (population[k].x[i] - oldMean[i]) / opt.sigma
+				yj := (population[k].x[j] - oldMean[j]) / opt.sigma
+				rankMu += opt.weights[k] * yi * yj
+			}
+			value := (1-opt.c1-opt.cMu)*opt.C[i][j] +
+				opt.c1*(opt.pc[i]*opt.pc[j]+(1-hSigma)*opt.cc*(2-opt.cc)*opt.C[i][j]) +
+				opt.cMu*rankMu
+			opt.C[i][j] = value
+			opt.C[j][i] = value
+		}
+	}
+
+	opt.sigma *= math.Exp((opt.cSigma / opt.dSigma) * (pSigmaNorm/opt.chiN - 1))
+	opt.sigma = math.Max(opt.sigma, CMAES_MIN_SIGMA)
+
+	eigenvalues, eigenvectors := symmetricEigen(opt.C)
+	for i, ev := range eigenvalues {
+		opt.D[i] = math.Sqrt(math.Max(ev, 1e-12))
+	}
+	opt.B = eigenvectors
+
+	return population[0]
+}
+
+// symmetricEigen returns the eigenvalues and eigenvectors (as columns) of a symmetric matrix
+// using cyclic Jacobi rotations. Fine for the tiny matrices used here.
+func symmetricEigen(matrix [][]float64) ([]float64, [][]float64) {
+	n := len(matrix)
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = append([]float64(nil), matrix[i]...)
+	}
+	v := identityMatrix(n)
+
+	for sweep := 0; sweep < 50; sweep++ {
+		offDiagonal := 0.0
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				offDiagonal += a[i][j] * a[i][j]
+			}
+		}
+		if offDiagonal < 1e-20 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(a[p][q]) < 1e-15 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ { // Rotate columns p and q
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < n; k++ { // Rotate rows p and q
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+
+	eigenvalues := make([]float64, n)
+	for i := range eigenvalues {
+		eigenvalues[i] = a[i][i]
+	}
+	return eigenvalues, v
+}
+
+var (
+	cmaesOptimizer    *CMAESOptimizer       // Active CMA-ES state while learning with the "cmaes" strategy
+	cmaesInitialSigma = CMAES_INITIAL_SIGMA // Set by optimizer profiles
+)
+
+// placementBounds returns the coordinate bounds for an object of the given scale inside the cloud.
+func placementBounds(scale Vector3) (lower, upper Vector3) {
+	roomMin, roomMax := Vector3{-roomWidth / 2, 0, -roomDepth / 2}, Vector3{roomWidth / 2, roomHeight, roomDepth / 2}
+	if occupancyCloud != nil {
+		roomMin, roomMax = occupancyCloud.RoomMin, occupancyCloud.RoomMax
+	}
+	return roomMin.Add(scale.Scale(0.5)), roomMax.Sub(scale.Scale(0.5))
+}
+
+// placementFeasible reports whether the source and listener can both stand at the given positions.
+func placementFeasible(sourcePos, listenerPos Vector3) bool {
+	if occupancyCloud == nil {
+		return !spheresIntersect(sourcePos, soundSource.Scale.X/2.0, listenerPos, listener.Scale.X/2.0)
+	}
+	return occupancyCloud.IsPositionAttemptValid(sourcePos, soundSource.Scale, StateSoundSource, listenerPos, listener.Scale) &&
+		occupancyCloud.IsPositionAttemptValid(listenerPos, listener.Scale, StateListener, sourcePos, soundSource.Scale)
+}
+
+// startCMAESLearning initializes CMA-ES around the current source/listener positions.
+func startCMAESLearning() {
+	sLower, sUpper := placementBounds(soundSource.Scale)
+	lLower, lUpper := placementBounds(listener.Scale)
+	mean := []float64{
+		soundSource.Position.X, soundSource.Position.Y, soundSource.Position.Z,
+		listener.Position.X, listener.Position.Y, listener.Position.Z,
+	}
+	lower := []float64{sLower.X, sLower.Y, sLower.Z, lLower.X, lLower.Y, lLower.Z}
+	upper := []float64{sUpper.X, sUpper.Y, sUpper.Z, lUpper.X, lUpper.Y, lUpper.Z}
+	cmaesOptimizer = NewCMAESOptimizer(mean, lower, upper, cmaesInitialSigma*math.Max(explorationFactor, 0.1))
+	log.Printf("CMA-ES initialized: lambda=%d, mu=%d, sigma=%.2f", cmaesOptimizer.lambda, cmaesOptimizer.mu, cmaesOptimizer.sigma)
+}
+
+// runCMAESLearningStep runs one CMA-ES generation and moves the source and listener to the
+// generation's best feasible candidate.
+func runCMAESLearningStep() {
+	if cmaesOptimizer == nil {
+		startCMAESLearning()
+	}
+	split := func(x []float64) (Vector3, Vector3) { // Locked objects stay where they are
+		return lockedPlacement(Vector3{x[0], x[1], x[2]}, Vector3{x[3], x[4], x[5]})
+	}
+	isFeasible := func(x []float64) bool {
+		return placementFeasible(split(x))
+	}
+	evaluate := func(x []float64) int {
+		sourcePos, listenerPos := split(x)
+		return objectiveScore(sourcePos, listenerPos)
+	}
+
+	best := cmaesOptimizer.Step(isFeasible, evaluate)
+	if best.score == CMAES_INFEASIBLE_SCORE {
+		return // Nothing feasible this generation; keep the current placement
+	}
+	soundSource.Position, listener.Position = split(best.x)
+	resyncDynamicObjectsInCloud()
+}
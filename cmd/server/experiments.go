@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Experiment Database ---
+//
+// The WASM module can snap its scene, parameters, records and metrics into an experiment artifact
+// (goSyncExperiments) and post it here, so a room-design project keeps its history across browser
+// sessions. Every project is one JSON file in experimentsDir holding its experiments in the order
+// they were saved. The artifact itself is stored as sent; the server only reads the fields it
+// lists and searches by.
+//
+//	GET  /api/projects                              projects with experiment counts
+//	GET  /api/projects/{project}/experiments?q=...  summaries, newest first, optionally filtered
+//	POST /api/projects/{project}/experiments        store an artifact, returns its summary
+//	GET  /api/projects/{project}/experiments/{id}   the full experiment
+//	GET  /api/experiments/search?q=...              summaries across all projects
+
+const (
+	experimentsDir     = "experiments"
+	maxExperimentBytes = 32 << 20 // Largest artifact accepted; meshes make scenes big
+	defaultSearchLimit = 100
+)
+
+var projectNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`) // Also keeps names safe as file names
+
+// ProjectInfo is what the project listing returns for a project.
+type ProjectInfo struct {
+	Project     string `json:"project"`
+	Experiments int    `json:"experiments"`
+}
+
+// ExperimentSummary is what listings and searches return for an experiment.
+type ExperimentSummary struct {
+	ID        int     `json:"id"`
+	Project   string  `json:"project"`
+	Name      string  `json:"name"`
+	SavedAt   string  `json:"savedAt"`
+	SceneHash string  `json:"sceneHash"`
+	Score     float64 `json:"score"` // Listener score when the experiment was taken
+	BestScore int     `json:"bestScore"`
+	Records   int     `json:"records"`
+}
+
+// StoredExperiment is one saved artifact and its summary.
+type StoredExperiment struct {
+	ExperimentSummary
+	Artifact json.RawMessage `json:"artifact"`
+}
+
+// artifactFields are the parts of an artifact the server summarizes.
+type artifactFields struct {
+	Name      string `json:"name"`
+	SceneHash string `json:"sceneHash"`
+	Records   []struct {
+		Score int
+	} `json:"records"`
+	Metrics struct {
+		ListenerScore float64 `json:"listenerScore"`
+	} `json:"metrics"`
+}
+
+// ExperimentStore reads and writes the project files. The mutex covers every file access.
+type ExperimentStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func (s *ExperimentStore) projectPath(project string) string {
+	return filepath.Join(s.dir, project+".json")
+}
+
+// load returns the experiments of a project, none if it has not been saved to yet.
+func (s *ExperimentStore) load(project string) ([]StoredExperiment, error) {
+	data, err := os.ReadFile(s.projectPath(project))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var experiments []StoredExperiment
+	if err := json.Unmarshal(data, &experiments); err != nil {
+		return nil, fmt.Errorf("project %s: %v", project, err)
+	}
+	return experiments, nil
+}
+
+// save replaces a project's file through a temporary file, so a crash never leaves it half written.
+func (s *ExperimentStore) save(project string, experiments []StoredExperiment) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(experiments)
+	if err != nil {
+		return err
+	}
+	tmp := s.projectPath(project) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.projectPath(project))
+}
+
+// projectNames lists the stored projects in alphabetical order.
+func (s *ExperimentStore) projectNames() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".json")
+		if !e.IsDir() && name != e.Name() && projectNamePattern.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// projects lists every stored project with its number of experiments.
+func (s *ExperimentStore) projects() ([]ProjectInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names, err := s.projectNames()
+	if err != nil {
+		return nil, err
+	}
+	infos := []ProjectInfo{}
+	for _, name := range names {
+		experiments, err := s.load(name)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, ProjectInfo{name, len(experiments)})
+	}
+	return infos, nil
+}
+
+// get returns one experiment of a project.
+func (s *ExperimentStore) get(project string, id int) (StoredExperiment, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	experiments, err := s.load(project)
+	for _, e := range experiments {
+		if e.ID == id {
+			return e, true, nil
+		}
+	}
+	return StoredExperiment{}, false, err
+}
+
+// add stores an artifact as the project's next experiment.
+func (s *ExperimentStore) add(project string, artifact json.RawMessage) (ExperimentSummary, error) {
+	var fields artifactFields
+	if err := json.Unmarshal(artifact, &fields); err != nil {
+		return ExperimentSummary{}, fmt.Errorf("artifact is not a JSON object: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	experiments, err := s.load(project)
+	if err != nil {
+		return ExperimentSummary{}, err
+	}
+	id := 1
+	if len(experiments) > 0 {
+		id = experiments[len(experiments)-1].ID + 1
+	}
+	summary := ExperimentSummary{
+		ID:        id,
+		Project:   project,
+		Name:      fields.Name,
+		SavedAt:   time.Now().UTC().Format(time.RFC3339),
+		SceneHash: fields.SceneHash,
+		Score:     fields.Metrics.ListenerScore,
+		Records:   len(fields.Records),
+	}
+	for _, r := range fields.Records {
+		if r.Score > summary.BestScore {
+			summary.BestScore = r.Score
+		}
+	}
+	experiments = append(experiments, StoredExperiment{ExperimentSummary: summary, Artifact: artifact})
+	return summary, s.save(project, experiments)
+}
+
+// search returns the summaries of experiments matching query, newest first, from one project or
+// from all of them if project is empty. query matches names and scene hashes case-insensitively;
+// an empty query matches everything.
+func (s *ExperimentStore) search(project, query string, limit int) ([]ExperimentSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	projects := []string{project}
+	if project == "" {
+		var err error
+		if projects, err = s.projectNames(); err != nil {
+			return nil, err
+		}
+	}
+	query = strings.ToLower(query)
+	matches := []ExperimentSummary{}
+	for _, project := range projects {
+		experiments, err := s.load(project)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(experiments) - 1; i >= 0; i-- { // Newest first within a project
+			e := experiments[i]
+			if strings.Contains(strings.ToLower(e.Name), query) || strings.Contains(strings.ToLower(e.SceneHash), query) {
+				matches = append(matches, e.ExperimentSummary)
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].SavedAt > matches[j].SavedAt })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// registerExperimentRoutes adds the experiment endpoints to mux.
+func registerExperimentRoutes(mux *http.ServeMux, store *ExperimentStore) {
+	mux.HandleFunc("GET /api/projects", func(w http.ResponseWriter, r *http.Request) {
+		infos, err := store.projects()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, infos)
+	})
+	mux.HandleFunc("GET /api/projects/{project}/experiments", func(w http.ResponseWriter, r *http.Request) {
+		if project, ok := projectParam(w, r); ok {
+			writeSearch(w, r, store, project)
+		}
+	})
+	mux.HandleFunc("POST /api/projects/{project}/experiments", func(w http.ResponseWriter, r *http.Request) {
+		project, ok := projectParam(w, r)
+		if !ok {
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxExperimentBytes))
+		if err != nil {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		summary, err := store.add(project, body)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, summary)
+	})
+	mux.HandleFunc("GET /api/projects/{project}/experiments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		project, ok := projectParam(w, r)
+		if !ok {
+			return
+		}
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid experiment id %q", r.PathValue("id")))
+			return
+		}
+		experiment, found, err := store.get(project, id)
+		switch {
+		case err != nil:
+			writeJSONError(w, http.StatusInternalServerError, err)
+		case !found:
+			writeJSONError(w, http.StatusNotFound, fmt.Errorf("project %s has no experiment %d", project, id))
+		default:
+			writeJSON(w, http.StatusOK, experiment)
+		}
+	})
+	mux.HandleFunc("GET /api/experiments/search", func(w http.ResponseWriter, r *http.Request) {
+		writeSearch(w, r, store, "")
+	})
+}
+
+// writeSearch answers with the summaries matching the "q" and "limit" query parameters.
+func writeSearch(w http.ResponseWriter, r *http.Request, store *ExperimentStore, project string) {
+	limit := defaultSearchLimit
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	matches, err := store.search(project, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, matches)
+}
+
+// projectParam returns the request's project name, answering 400 if it is not a valid one.
+func projectParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	project := r.PathValue("project")
+	if !projectNamePattern.MatchString(project) {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("project names are 1-64 letters, digits, '-' or '_', got %q", project))
+		return "", false
+	}
+	return project, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError answers {"error": message}.
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
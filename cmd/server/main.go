@@ -73,6 +73,8 @@ func main() {
 		http.ServeFile(w, r, filepath.Join(".", strings.TrimPrefix(filePath, "/")))
 	})
 
+	registerExperimentRoutes(http.DefaultServeMux, &ExperimentStore{dir: experimentsDir})
+
 	// Start the server
 	err := http.ListenAndServe(":"+port, nil)
 	if err != nil {
@@ -0,0 +1,77 @@
+package main
+
+// --- Collidable Sets ---
+// Occluder membership for traced rays, precomputed per emitting source:
+//   - the listener is the receiver, never an occluder. Each segment tests its sphere explicitly
+//     and a ray that reaches it ends there, so it can neither pass through nor reflect beyond it;
+//   - direct segments (depth 0) ignore the emitting source, which they start inside;
+//   - reflected segments (depth >= 1) can be blocked by the emitting source.
+// The sets only change with the scene, so they are reused across evaluations and visual passes.
+
+// CollidableSets holds the occluder lists for rays emitted by one source.
+type CollidableSets struct {
+	Source    *SceneObject
+	Direct    []*SceneObject // Occluders for first segments cast from the source's own position
+	Reflected []*SceneObject // Occluders for every other segment, the source itself included
+	Reverse   bool           // Rays start at the listener and end on Source (see reverse_tracing.go)
+}
+
+var (
+	collidableSetsCache  = map[*SceneObject]*CollidableSets{} // Keyed by emitting source
+	evaluationDirections []Vector3                            // Golden-angle spiral directions for len(evaluationDirections) rays
+)
+
+// invalidateCollidableSets drops cached occluder lists. Call whenever objects are added to or
+// removed from allSceneObjects, or the listener changes.
+func invalidateCollidableSets() {
+	for source := range collidableSetsCache {
+		delete(collidableSetsCache, source)
+	}
+	for key := range rayGrids {
+		delete(rayGrids, key)
+	}
+}
+
+// collidableSetsFor returns the cached sets for source, building them on first use.
+func collidableSetsFor(source *SceneObject) *CollidableSets {
+	if sets, ok := collidableSetsCache[source]; ok {
+		return sets
+	}
+	sets := &CollidableSets{Source: source}
+	for _, obj := range allSceneObjects {
+		if obj == listener {
+			continue
+		}
+		sets.Reflected = append(sets.Reflected, obj)
+		if obj != source {
+			sets.Direct = append(sets.Direct, obj)
+		}
+	}
+	if source != nil && !containsObject(sets.Reflected, source) { // Source not registered in the scene
+		sets.Reflected = append(sets.Reflected, source)
+	}
+	registerRayGrid(sets.Direct)
+	registerRayGrid(sets.Reflected)
+	collidableSetsCache[source] = sets
+	return sets
+}
+
+// evaluationDirectionsFor returns n evenly distributed unit directions (see ray_sampling.go),
+// reusing the previous set when n is unchanged.
+func evaluationDirectionsFor(n int) []Vector3 {
+	if len(evaluationDirections) == n {
+		return evaluationDirections
+	}
+	evaluationDirections = sphereDirections(n, stratifiedSampling)
+	return evaluationDirections
+}
+
+// containsObject reports whether obj is in objects.
+func containsObject(objects []*SceneObject, obj *SceneObject) bool {
+	for _, o := range objects {
+		if o == obj {
+			return true
+		}
+	}
+	return false
+}
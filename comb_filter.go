@@ -0,0 +1,125 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+	"syscall/js"
+)
+
+// --- Comb Filtering at the Listener ---
+
+const (
+	COMB_MAX_NOTCH_HZ     float64 = 20000 // Upper end of the audible band; notches above are not reported
+	COMB_REPORTED_NOTCHES int     = 5     // Lowest notch frequencies listed per reflection
+	COMB_DEFAULT_RESULTS  int     = 5     // Worst offenders returned to JS by default
+)
+
+// CombFilterEstimate describes the interference between the direct sound and one early reflection.
+// A reflection arriving delaySec late with relative pressure g cancels the direct sound at
+// f = (2k+1) / (2 * delaySec), producing notches of depth 20*log10(1-g).
+type CombFilterEstimate struct {
+	Reflection        EarlyReflection
+	DelayMs           float64
+	RelativeAmplitude float64 // Reflection pressure relative to the direct sound (0..1)
+	NotchesHz         []float64
+	NotchSpacingHz    float64
+	NotchDepthDB      float64
+	PeakGainDB        float64
+}
+
+// estimateCombFilters evaluates every first-order reflection from source at the listener and returns
+// them ordered from deepest notches to shallowest. Amplitudes use spherical spreading and the
+// reflecting material's absorption at the reflection's angle of incidence.
+func estimateCombFilters(source *SceneObject) []CombFilterEstimate {
+	if source == nil || listener == nil {
+		return nil
+	}
+	direct := source.Position.DistanceTo(listener.Position)
+	if direct < EPSILON {
+		return nil
+	}
+	var estimates []CombFilterEstimate
+	for _, r := range firstOrderReflections(source, listener) {
+		extra := r.PathLength - direct
+		if extra <= EPSILON {
+			continue
+		}
+		delaySec := extra / environment.SpeedOfSound
+		cosIncidence := incidenceCos(r.Point.Sub(source.Position), r.FaceNormal)
+		g := math.Sqrt(math.Max(0, 1-surfaceAbsorption(r.Surface.Material, cosIncidence))) * direct / r.PathLength
+		e := CombFilterEstimate{
+			Reflection:        r,
+			DelayMs:           delaySec * 1000,
+			RelativeAmplitude: g,
+			NotchSpacingHz:    1 / delaySec,
+			NotchDepthDB:      20 * math.Log10(math.Max(1-g, EPSILON)),
+			PeakGainDB:        20 * math.Log10(1+g),
+		}
+		for k := 0; k < COMB_REPORTED_NOTCHES; k++ {
+			f := float64(2*k+1) / (2 * delaySec)
+			if f > COMB_MAX_NOTCH_HZ {
+				break
+			}
+			e.NotchesHz = append(e.NotchesHz, f)
+		}
+		estimates = append(estimates, e)
+	}
+	sort.Slice(estimates, func(i, j int) bool {
+		return estimates[i].RelativeAmplitude > estimates[j].RelativeAmplitude
+	})
+	return estimates
+}
+
+// goEstimateCombFilter([sourceName, maxResults]) returns the worst comb-filter offenders at the
+// listener for one source (the primary by default):
+// {directDistance, directOccluded, reflections: [{surface, material, point, pathLength, delayMs,
+// relativeAmplitude, notchDepthDb, peakGainDb, notchSpacingHz, notchesHz}]}.
+func goEstimateCombFilter(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goEstimateCombFilter")
+	source := soundSource
+	if len(args) >= 1 && args[0].Type() == js.TypeString && args[0].String() != "" {
+		source = findSoundSource(args[0].String())
+		if source == nil {
+			log.Printf("Error: unknown sound source %q", args[0].String())
+			return nil
+		}
+	}
+	maxResults := COMB_DEFAULT_RESULTS
+	if len(args) >= 2 {
+		maxResults = args[1].Int()
+	}
+	if source == nil || listener == nil {
+		return nil
+	}
+
+	estimates := estimateCombFilters(source)
+	if maxResults >= 0 && len(estimates) > maxResults {
+		estimates = estimates[:maxResults]
+	}
+	reflections := make([]interface{}, len(estimates))
+	for i, e := range estimates {
+		notches := make([]interface{}, len(e.NotchesHz))
+		for j, f := range e.NotchesHz {
+			notches[j] = f
+		}
+		p := e.Reflection.Point
+		reflections[i] = map[string]interface{}{
+			"surface":           e.Reflection.Surface.Name,
+			"material":          e.Reflection.Surface.Material.Name,
+			"point":             []interface{}{p.X, p.Y, p.Z},
+			"pathLength":        e.Reflection.PathLength,
+			"delayMs":           e.DelayMs,
+			"relativeAmplitude": e.RelativeAmplitude,
+			"notchDepthDb":      e.NotchDepthDB,
+			"peakGainDb":        e.PeakGainDB,
+			"notchSpacingHz":    e.NotchSpacingHz,
+			"notchesHz":         notches,
+		}
+	}
+	return js.ValueOf(map[string]interface{}{
+		"directDistance": source.Position.DistanceTo(listener.Position),
+		"directOccluded": isDirectPathOccluded(source, listener),
+		"reflections":    reflections,
+	})
+}
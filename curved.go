@@ -0,0 +1,165 @@
+package main
+
+import "math"
+
+// --- Curved Reflectors ---
+//
+// Two quadric shells with analytic intersection, for focusing and whispering-gallery experiments:
+//
+//   - "ellipsoid": semi-axes Scale.X, Scale.Y, Scale.Z around Position.
+//   - "paraboloid": a dish opening along local +Y with its vertex at Position, rim radius
+//     Scale.X (Scale.Z across) and depth Scale.Y; a circular dish focuses at Scale.X²/(4·Scale.Y)
+//     above the vertex.
+//
+// Both are intersected in the unit frame, where they are x²+y²+z² = 1 and y = x²+z² (x²+z² ≤ 1),
+// and are thin two-sided shells: rays reflect off whichever side they reach, so a source placed
+// inside an ellipsoid sees its inner wall.
+
+// isCurvedShape reports whether shapeType is one of the quadric reflectors.
+func isCurvedShape(shapeType string) bool {
+	return shapeType == "ellipsoid" || shapeType == "paraboloid"
+}
+
+// unitFrameRay takes a world ray into obj's unit frame (local frame divided by Scale). The ray
+// parameter is the same in both frames.
+func unitFrameRay(obj *SceneObject, origin, direction Vector3) (Vector3, Vector3) {
+	localOrigin, localDir := boxLocalPoint(obj, origin), direction
+	if rot, rotated := boxRotation(obj); rotated {
+		localDir = rot.ApplyTranspose(direction)
+	}
+	return Vector3{localOrigin.X / obj.Scale.X, localOrigin.Y / obj.Scale.Y, localOrigin.Z / obj.Scale.Z},
+		Vector3{localDir.X / obj.Scale.X, localDir.Y / obj.Scale.Y, localDir.Z / obj.Scale.Z}
+}
+
+// unitFrameNormalToWorld brings a unit-frame surface normal (or gradient) of obj to a world unit
+// normal, using the inverse transpose of the scale.
+func unitFrameNormalToWorld(obj *SceneObject, n Vector3) Vector3 {
+	return boxWorldDirection(obj, Vector3{n.X / obj.Scale.X, n.Y / obj.Scale.Y, n.Z / obj.Scale.Z}).Normalize()
+}
+
+// unitFrameBoundsToWorld is the world-axis box enclosing the unit-frame box [lo, hi] of obj.
+func unitFrameBoundsToWorld(obj *SceneObject, lo, hi Vector3) (Vector3, Vector3) {
+	var worldMin, worldMax Vector3
+	for i, corner := range []Vector3{
+		{lo.X, lo.Y, lo.Z}, {hi.X, lo.Y, lo.Z}, {lo.X, hi.Y, lo.Z}, {hi.X, hi.Y, lo.Z},
+		{lo.X, lo.Y, hi.Z}, {hi.X, lo.Y, hi.Z}, {lo.X, hi.Y, hi.Z}, {hi.X, hi.Y, hi.Z},
+	} {
+		p := boxWorldPoint(obj, Vector3{corner.X * obj.Scale.X, corner.Y * obj.Scale.Y, corner.Z * obj.Scale.Z})
+		if i == 0 {
+			worldMin, worldMax = p, p
+			continue
+		}
+		worldMin = Vector3{math.Min(worldMin.X, p.X), math.Min(worldMin.Y, p.Y), math.Min(worldMin.Z, p.Z)}
+		worldMax = Vector3{math.Max(worldMax.X, p.X), math.Max(worldMax.Y, p.Y), math.Max(worldMax.Z, p.Z)}
+	}
+	return worldMin, worldMax
+}
+
+// curvedUnitBounds is the unit-frame box of a curved shape.
+func curvedUnitBounds(shapeType string) (Vector3, Vector3) {
+	if shapeType == "paraboloid" {
+		return Vector3{-1, 0, -1}, Vector3{1, 1, 1}
+	}
+	return Vector3{-1, -1, -1}, Vector3{1, 1, 1}
+}
+
+// curvedUnitGradient is the gradient of the implicit surface at a unit-frame point, which points
+// outward for the ellipsoid and to the convex (outer) side for the paraboloid.
+func curvedUnitGradient(shapeType string, q Vector3) Vector3 {
+	if shapeType == "paraboloid" {
+		return Vector3{2 * q.X, -1, 2 * q.Z}
+	}
+	return q
+}
+
+// quadraticRoots returns the real roots of a·t² + b·t + c in ascending order; a may be zero.
+func quadraticRoots(a, b, c float64) []float64 {
+	if math.Abs(a) < EPSILON*EPSILON {
+		if math.Abs(b) < EPSILON*EPSILON {
+			return nil
+		}
+		return []float64{-c / b}
+	}
+	discriminant := b*b - 4*a*c
+	if discriminant < 0 {
+		return nil
+	}
+	sq := math.Sqrt(discriminant)
+	t0, t1 := (-b-sq)/(2*a), (-b+sq)/(2*a)
+	if t0 > t1 {
+		t0, t1 = t1, t0
+	}
+	return []float64{t0, t1}
+}
+
+// raycastCurved intersects a ray with a curved shell, returning the nearest hit beyond EPSILON
+// and below maxDist with a normal facing the incoming ray.
+func raycastCurved(obj *SceneObject, origin, direction Vector3, maxDist float64) (float64, Vector3, bool) {
+	o, d := unitFrameRay(obj, origin, direction)
+	var roots []float64
+	if obj.ShapeType == "paraboloid" {
+		roots = quadraticRoots(d.X*d.X+d.Z*d.Z, 2*(o.X*d.X+o.Z*d.Z)-d.Y, o.X*o.X+o.Z*o.Z-o.Y)
+	} else {
+		roots = quadraticRoots(d.Dot(d), 2*o.Dot(d), o.Dot(o)-1)
+	}
+	for _, t := range roots {
+		if t <= EPSILON || t >= maxDist {
+			continue
+		}
+		q := o.Add(d.Scale(t))
+		if obj.ShapeType == "paraboloid" && q.X*q.X+q.Z*q.Z > 1 { // Beyond the rim
+			continue
+		}
+		normal := unitFrameNormalToWorld(obj, curvedUnitGradient(obj.ShapeType, q))
+		if normal.Dot(direction) > 0 {
+			normal = normal.Scale(-1)
+		}
+		return t, normal, true
+	}
+	return 0, Vector3{}, false
+}
+
+// curvedNormalAt is the outward normal of a curved shell at a world point on its surface.
+func curvedNormalAt(obj *SceneObject, p Vector3) Vector3 {
+	local := boxLocalPoint(obj, p)
+	q := Vector3{local.X / obj.Scale.X, local.Y / obj.Scale.Y, local.Z / obj.Scale.Z}
+	return unitFrameNormalToWorld(obj, curvedUnitGradient(obj.ShapeType, q))
+}
+
+// curvedDistance estimates the unsigned distance from p to a curved shell: the implicit value
+// over its world gradient length near the surface, and the distance to the rim beyond a dish's edge.
+func curvedDistance(obj *SceneObject, p Vector3) float64 {
+	local := boxLocalPoint(obj, p)
+	q := Vector3{local.X / obj.Scale.X, local.Y / obj.Scale.Y, local.Z / obj.Scale.Z}
+	var value float64
+	if obj.ShapeType == "paraboloid" {
+		if r := math.Hypot(q.X, q.Z); r > 1 {
+			rim := Vector3{q.X / r * obj.Scale.X, obj.Scale.Y, q.Z / r * obj.Scale.Z}
+			return local.DistanceTo(rim)
+		}
+		value = q.X*q.X + q.Z*q.Z - q.Y
+	} else {
+		value = q.Dot(q) - 1
+	}
+	g := curvedUnitGradient(obj.ShapeType, q)
+	gradient := Vector3{g.X / obj.Scale.X, g.Y / obj.Scale.Y, g.Z / obj.Scale.Z}
+	if obj.ShapeType == "ellipsoid" {
+		gradient = gradient.Scale(2)
+	}
+	if length := gradient.Length(); length > EPSILON {
+		return math.Abs(value) / length
+	}
+	return math.Abs(value) * math.Min(obj.Scale.X, math.Min(obj.Scale.Y, obj.Scale.Z))
+}
+
+// curvedSurfaceArea is the area of a curved shell: Thomsen's approximation for the ellipsoid and
+// the exact paraboloid formula for the dish, using the mean rim radius for elliptical dishes.
+func curvedSurfaceArea(obj *SceneObject) float64 {
+	a, b, c := obj.Scale.X, obj.Scale.Y, obj.Scale.Z
+	if obj.ShapeType == "paraboloid" {
+		r, h := math.Sqrt(a*c), b
+		return math.Pi * r / (6 * h * h) * (math.Pow(r*r+4*h*h, 1.5) - r*r*r)
+	}
+	const p = 1.6075
+	return 4 * math.Pi * math.Pow((math.Pow(a*b, p)+math.Pow(a*c, p)+math.Pow(b*c, p))/3, 1/p)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Sound Source Directivity ---
+//
+// The primary SoundSource may radiate with a pattern aimed by yaw and pitch. Each emitted ray's
+// starting energy is the pattern's gain in its direction, and cone sources cull rays outside the
+// aperture. Additional sources stay omnidirectional. Reverse (listener-first) rays apply the gain
+// where they reach the source (see arrivalGain).
+
+const (
+	DirectivityOmni     = "omni"
+	DirectivityCardioid = "cardioid"
+	DirectivityCone     = "cone"
+
+	DEFAULT_SOURCE_APERTURE_DEG = 90.0 // Full opening angle of a cone source
+	SOURCE_AIM_STEP_DEG         = 10.0 // Yaw/pitch increment tried by the learning aim step
+	MAX_SOURCE_PITCH_DEG        = 90.0
+)
+
+// SourceDirectivity is the radiation pattern of the primary source and where it points.
+type SourceDirectivity struct {
+	Pattern     string
+	ApertureDeg float64 // Cone only: full opening angle
+	YawDeg      float64 // About +Y; 0 faces +Z
+	PitchDeg    float64 // Above the horizontal
+}
+
+var sourceDirectivity = SourceDirectivity{Pattern: DirectivityOmni, ApertureDeg: DEFAULT_SOURCE_APERTURE_DEG}
+
+// sourceAim is the unit vector the primary source points along.
+func sourceAim() Vector3 {
+	yaw := sourceDirectivity.YawDeg * math.Pi / 180
+	pitch := sourceDirectivity.PitchDeg * math.Pi / 180
+	return Vector3{math.Sin(yaw) * math.Cos(pitch), math.Sin(pitch), math.Cos(yaw) * math.Cos(pitch)}
+}
+
+// emissionGain is the energy factor for a ray leaving source along direction: 1 everywhere for
+// omnidirectional sources, (1+cosθ)/2 for a cardioid, and 1 inside / 0 outside a cone.
+func emissionGain(source *SceneObject, direction Vector3) float64 {
+	if source != soundSource || sourceDirectivity.Pattern == DirectivityOmni {
+		return 1
+	}
+	cosAngle := direction.Normalize().Dot(sourceAim())
+	switch sourceDirectivity.Pattern {
+	case DirectivityCardioid:
+		return (1 + cosAngle) / 2
+	case DirectivityCone:
+		if cosAngle >= math.Cos(sourceDirectivity.ApertureDeg/2*math.Pi/180) {
+			return 1
+		}
+		return 0
+	}
+	return 1
+}
+
+// setSourceAim sets the yaw (wrapped to [-180, 180)) and pitch (clamped) of the primary source.
+func setSourceAim(yawDeg, pitchDeg float64) {
+	sourceDirectivity.YawDeg = math.Mod(math.Mod(yawDeg+180, 360)+360, 360) - 180
+	sourceDirectivity.PitchDeg = math.Max(-MAX_SOURCE_PITCH_DEG, math.Min(MAX_SOURCE_PITCH_DEG, pitchDeg))
+}
+
+// optimizeSourceAimStep tries one aim increment in each direction of yaw and pitch, keeping the
+// best by the learning objective. Omnidirectional sources have nothing to aim.
+func optimizeSourceAimStep() bool {
+	if sourceDirectivity.Pattern == DirectivityOmni || soundSource == nil || listener == nil {
+		return false
+	}
+	yaw, pitch := sourceDirectivity.YawDeg, sourceDirectivity.PitchDeg
+	bestYaw, bestPitch := yaw, pitch
+	bestScore := objectiveScore(soundSource.Position, listener.Position)
+	for _, step := range [][2]float64{{SOURCE_AIM_STEP_DEG, 0}, {-SOURCE_AIM_STEP_DEG, 0}, {0, SOURCE_AIM_STEP_DEG}, {0, -SOURCE_AIM_STEP_DEG}} {
+		setSourceAim(yaw+step[0], pitch+step[1])
+		if score := objectiveScore(soundSource.Position, listener.Position); score > bestScore {
+			bestYaw, bestPitch, bestScore = sourceDirectivity.YawDeg, sourceDirectivity.PitchDeg, score
+		}
+	}
+	setSourceAim(bestYaw, bestPitch)
+	if bestYaw == yaw && bestPitch == pitch {
+		return false
+	}
+	callOptionalJS("updateSourceAim", sourceDirectivity.YawDeg, sourceDirectivity.PitchDeg)
+	return true
+}
+
+// goSetSourceDirectivity([pattern, apertureDeg]) selects "omni", "cardioid" or "cone" for the
+// primary source. Returns false for an unknown pattern or an aperture outside (0, 360].
+func goSetSourceDirectivity(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetSourceDirectivity")
+	if len(args) < 1 {
+		log.Println("Error: goSetSourceDirectivity expects 1 or 2 arguments (pattern, apertureDeg)")
+		return false
+	}
+	pattern := args[0].String()
+	switch pattern {
+	case DirectivityOmni, DirectivityCardioid, DirectivityCone:
+	default:
+		log.Printf("Error: unknown directivity pattern %q", pattern)
+		return false
+	}
+	if len(args) >= 2 {
+		aperture := args[1].Float()
+		if math.IsNaN(aperture) || aperture <= 0 || aperture > 360 {
+			log.Printf("Error: cone aperture must be in (0, 360] degrees, got %v", aperture)
+			return false
+		}
+		sourceDirectivity.ApertureDeg = aperture
+	}
+	sourceDirectivity.Pattern = pattern
+	logEvent(LogInterop, LogNotice, "Source directivity: %s (aperture %.0f°)", pattern, sourceDirectivity.ApertureDeg)
+	debouncedVisualizeFunc()
+	return true
+}
@@ -0,0 +1,100 @@
+package main
+
+import "math"
+
+// --- First-Order Early Reflections (image sources) ---
+
+// EarlyReflection is a specular single-bounce path from a source to a receiver via one box face.
+type EarlyReflection struct {
+	Surface    *SceneObject // Object owning the reflecting face
+	FaceNormal Vector3      // Outward normal of the face
+	Point      Vector3      // Reflection point on the face
+	PathLength float64      // Source -> Point -> receiver, in meters
+}
+
+// axisComponent returns v's X, Y or Z component for axis 0, 1 or 2.
+func axisComponent(v Vector3, axis int) float64 {
+	switch axis {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	default:
+		return v.Z
+	}
+}
+
+// withAxisComponent returns v with the component for axis replaced by value.
+func withAxisComponent(v Vector3, axis int, value float64) Vector3 {
+	switch axis {
+	case 0:
+		v.X = value
+	case 1:
+		v.Y = value
+	default:
+		v.Z = value
+	}
+	return v
+}
+
+// firstOrderReflections mirrors the source across every face of every visible static box and keeps
+// the image sources whose reflection point lies on the face and whose two legs are unobstructed.
+// Spheres are skipped: curved surfaces have no single image source.
+func firstOrderReflections(source, receiver *SceneObject) []EarlyReflection {
+	return firstOrderReflectionsAt(source, receiver, source.Position, receiver.Position)
+}
+
+// firstOrderReflectionsAt is firstOrderReflections with the source and receiver at the given
+// positions instead of their current ones.
+func firstOrderReflectionsAt(source, receiver *SceneObject, sourcePos, receiverPos Vector3) []EarlyReflection {
+	var reflections []EarlyReflection
+	for _, obj := range allSceneObjects {
+		if !obj.IsStatic || !obj.Visible || obj.ShapeType != "box" {
+			continue
+		}
+		// Mirror in the box's local frame, where its faces are axis-aligned (see obb.go)
+		half := obj.Scale.Scale(0.5)
+		localSource, localReceiver := boxLocalPoint(obj, sourcePos), boxLocalPoint(obj, receiverPos)
+		for axis := 0; axis < 3; axis++ {
+			for _, side := range []float64{-1, 1} {
+				plane := side * axisComponent(half, axis)
+				sourceDist := (axisComponent(localSource, axis) - plane) * side
+				receiverDist := (axisComponent(localReceiver, axis) - plane) * side
+				if sourceDist <= EPSILON || receiverDist <= EPSILON { // Both must be in front of the face
+					continue
+				}
+				image := withAxisComponent(localSource, axis, plane-side*sourceDist)
+				point := boxWorldPoint(obj, image.Add(localReceiver.Sub(image).Scale(sourceDist/(sourceDist+receiverDist))))
+				if !pointWithinFace(point, obj, axis) {
+					continue
+				}
+				if isSegmentOccluded(sourcePos, point, source, receiver) ||
+					isSegmentOccluded(receiverPos, point, source, receiver) {
+					continue
+				}
+				reflections = append(reflections, EarlyReflection{
+					Surface:    obj,
+					FaceNormal: boxWorldDirection(obj, withAxisComponent(Vector3{}, axis, side)),
+					Point:      point,
+					PathLength: image.DistanceTo(localReceiver),
+				})
+			}
+		}
+	}
+	return reflections
+}
+
+// pointWithinFace checks that p lies within the box's extent on the two local axes other than faceAxis.
+func pointWithinFace(p Vector3, box *SceneObject, faceAxis int) bool {
+	local := boxLocalPoint(box, p)
+	for axis := 0; axis < 3; axis++ {
+		if axis == faceAxis {
+			continue
+		}
+		half := axisComponent(box.Scale, axis) / 2
+		if math.Abs(axisComponent(local, axis)) > half+EPSILON {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Echogram and Energy Decay Curve Export ---
+
+const (
+	ECHOGRAM_DEFAULT_RAYS = 5000
+	ECHOGRAM_FLOOR_DB     = -120.0 // Decay levels are clipped here; JSON has no -Inf
+)
+
+// EchogramExport is the JSON document returned by goGetEchogram. Bin i covers
+// [i*BinMs, (i+1)*BinMs) after emission. Energies are fractions of the emitted energy per band;
+// the broadband columns are the band means.
+type EchogramExport struct {
+	BinMs       float64     `json:"binMs"`
+	BandsHz     []float64   `json:"bandsHz"`
+	Energy      []float64   `json:"energy"`
+	BandEnergy  [][]float64 `json:"bandEnergy"` // [band][bin]
+	DecayDB     []float64   `json:"decayDb"`
+	BandDecayDB [][]float64 `json:"bandDecayDb"` // [band][bin]
+}
+
+// schroederDecayDB backward-integrates an energy histogram into its decay curve: entry i is the
+// energy arriving at bin i or later in dB relative to the total, clipped at ECHOGRAM_FLOOR_DB.
+func schroederDecayDB(energy []float64) []float64 {
+	decay := make([]float64, len(energy))
+	remaining := 0.0
+	for i := len(energy) - 1; i >= 0; i-- {
+		remaining += energy[i]
+		decay[i] = remaining
+	}
+	total := remaining
+	for i, e := range decay {
+		decay[i] = ECHOGRAM_FLOOR_DB
+		if total > 0 && e > 0 {
+			decay[i] = math.Max(ECHOGRAM_FLOOR_DB, 10*math.Log10(e/total))
+		}
+	}
+	return decay
+}
+
+// bandColumns splits a band echogram into one histogram per band plus the broadband mean.
+func bandColumns(eg *BandEchogram) (broadband []float64, bands [][]float64) {
+	broadband = make([]float64, len(eg.Energy))
+	bands = make([][]float64, len(eg.BandsHz))
+	for b := range bands {
+		bands[b] = make([]float64, len(eg.Energy))
+	}
+	for i, row := range eg.Energy {
+		for b, e := range row {
+			bands[b][i] = e
+			broadband[i] += e / float64(len(row))
+		}
+	}
+	return broadband, bands
+}
+
+// exportEchogram builds the echogram document for a band echogram.
+func exportEchogram(eg *BandEchogram) EchogramExport {
+	broadband, bands := bandColumns(eg)
+	export := EchogramExport{
+		BinMs:      eg.BinSec * 1000,
+		BandsHz:    eg.BandsHz,
+		Energy:     broadband,
+		BandEnergy: bands,
+		DecayDB:    schroederDecayDB(broadband),
+	}
+	for _, column := range bands {
+		export.BandDecayDB = append(export.BandDecayDB, schroederDecayDB(column))
+	}
+	return export
+}
+
+// goGetEchogram([rays, binMs, maxTime]) traces all unmuted sources to the listener and returns a
+// JSON string (see EchogramExport) with the raw echogram bins and the Schroeder decay curves,
+// broadband and per octave band. Returns null on invalid arguments.
+func goGetEchogram(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetEchogram")
+	rays, binMs, maxTime := ECHOGRAM_DEFAULT_RAYS, ENERGY_TRACE_DEFAULT_BIN*1000, ENERGY_TRACE_MAX_TIME
+	if len(args) >= 1 {
+		rays = args[0].Int()
+	}
+	if len(args) >= 2 {
+		binMs = args[1].Float()
+	}
+	if len(args) >= 3 {
+		maxTime = args[2].Float()
+	}
+	if rays < 1 || binMs <= 0 || maxTime <= 0 || maxTime > AURALIZATION_MAX_SECONDS {
+		log.Printf("Error: goGetEchogram needs positive rays and bin width and maxTime in (0, %.0f] s (got %d, %.3f, %.3f)",
+			AURALIZATION_MAX_SECONDS, rays, binMs, maxTime)
+		return nil
+	}
+	if soundSource == nil || listener == nil {
+		return nil
+	}
+	eg := roomBandEchogram(rays, binMs/1000, maxTime)
+	if eg == nil {
+		log.Println("goGetEchogram: every source is muted")
+		return nil
+	}
+	data, err := json.Marshal(exportEchogram(eg))
+	if err != nil {
+		log.Printf("Error encoding echogram: %v", err)
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log"
+	"syscall/js"
+)
+
+// --- Embedded Mode (visualization without the stock UI) ---
+//
+// A host page that only wants the 3D view sets `goEmbeddedMode = true` before starting the
+// module. The only required callback is then renderSceneJS; every other callback (legend,
+// sliders, records, learning progress, render hints, readiness) is called only if the page
+// defines it. Outside embedded mode all callbacks are expected, as with the stock page.
+
+const EMBEDDED_MODE_GLOBAL = "goEmbeddedMode"
+
+// requiredCallbacks must be defined by any host page, embedded or not.
+var requiredCallbacks = []string{"renderSceneJS"}
+
+var embeddedMode bool
+
+// initEmbeddedMode reads the host's embedding flag and reports missing required callbacks.
+func initEmbeddedMode() {
+	embeddedMode = jsGlobal.Get(EMBEDDED_MODE_GLOBAL).Truthy()
+	if !embeddedMode {
+		return
+	}
+	log.Println("Embedded mode: optional UI callbacks are skipped when not defined.")
+	for _, name := range requiredCallbacks {
+		if !isJSFunction(name) {
+			log.Printf("Error: embedded mode requires the %s callback", name)
+		}
+	}
+}
+
+func isJSFunction(name string) bool {
+	return jsGlobal.Get(name).Type() == js.TypeFunction
+}
+
+// callOptionalJS calls an optional UI callback. In embedded mode undefined callbacks are skipped.
+func callOptionalJS(name string, args ...interface{}) {
+	if embeddedMode && !isJSFunction(name) {
+		return
+	}
+	jsGlobal.Call(name, args...)
+}
@@ -0,0 +1,49 @@
+package main
+
+import "math"
+
+// --- Acoustic Energy Model (spreading, absorption, display opacity) ---
+//
+// A ray carries the energy fraction left after surface absorption (see energyAfterReflection).
+// Geometric spreading is not part of that energy: with rays emitted uniformly, the chance that a
+// ray reaches the listener sphere already falls with the inverse square of the path length, so
+// multiplying by it again would count the spreading twice. Scores therefore weight each arrival by
+// its absorbed energy, while drawing applies the inverse-square loss along the path explicitly so
+// a single drawn ray fades the way the sound does.
+
+const (
+	ENERGY_REFERENCE_DISTANCE float64 = 1.0  // Meters at which the spreading loss is 0 dB
+	ENERGY_DISPLAY_RANGE_DB   float64 = 40.0 // Energy this many dB below the emitted level is drawn transparent
+)
+
+// spreadingLoss is the inverse-square energy fraction left after pathLength meters, relative to
+// ENERGY_REFERENCE_DISTANCE. Points closer than the reference distance are not amplified.
+func spreadingLoss(pathLength float64) float64 {
+	if pathLength <= ENERGY_REFERENCE_DISTANCE {
+		return 1
+	}
+	r := ENERGY_REFERENCE_DISTANCE / pathLength
+	return r * r
+}
+
+// rayDisplayOpacity maps a segment's energy to its drawn opacity. The energy left after absorption
+// and spreading over pathLength (the distance travelled when the segment starts) is converted to dB
+// and mapped linearly onto [0, ENERGY_DISPLAY_RANGE_DB], so opacity follows loudness rather than
+// raw energy, which would make every ray past a few meters invisible. The initialRayOpacity slider
+// scaled by the source gain is the opacity at the emitted level. It is only used for drawing.
+func rayDisplayOpacity(energy, pathLength float64, source *SceneObject) float64 {
+	emitted := math.Min(1.0, initialRayOpacity*sourceEnergyGain(source))
+	e := energy * spreadingLoss(pathLength)
+	if e <= 0 {
+		return 0
+	}
+	level := 1 + 10*math.Log10(e)/ENERGY_DISPLAY_RANGE_DB
+	return emitted * math.Max(0, math.Min(1, level))
+}
+
+// arrivalScore is the score of one ray reaching the listener after the given number of
+// reflections with the given energy left after absorption: bounceScore weighted by that energy.
+// Misses (-1) score 0.
+func arrivalScore(bounces int, energy float64) float64 {
+	return float64(bounceScore(bounces)) * energy
+}
@@ -0,0 +1,136 @@
+package main
+
+import "math"
+
+// --- Band-Limited Energy Tracing (simulated impulse response) ---
+
+// OCTAVE_BANDS_HZ are the center frequencies of the octave bands energy is tracked in.
+var OCTAVE_BANDS_HZ = []float64{125, 250, 500, 1000, 2000, 4000}
+
+const (
+	ENERGY_TRACE_MIN_ENERGY  float64 = 1e-6  // Rays below this fraction of their emitted energy stop
+	ENERGY_TRACE_DEFAULT_BIN float64 = 0.001 // Histogram bin width in seconds
+	ENERGY_TRACE_MAX_TIME    float64 = 1.0   // Arrivals later than this are dropped, in seconds
+)
+
+// BandEchogram is an energy-time histogram at the listener with one column per octave band.
+// Energy is normalized so that a source's total emitted energy per band is 1.
+type BandEchogram struct {
+	BinSec  float64
+	BandsHz []float64
+	Energy  [][]float64 // [bin][band]
+}
+
+// materialBandAbsorption is the absorption coefficient of a material in one octave band.
+// Materials without band data use their broadband coefficient in every band.
+func materialBandAbsorption(mat MaterialProperties, band int) float64 {
+	if band >= 0 && band < len(mat.BandAbsorption) {
+		return mat.BandAbsorption[band]
+	}
+	return mat.Absorption
+}
+
+// raySphereEntry returns the distance along a ray segment of given length at which it enters the
+// sphere, if it does. Segments starting inside the sphere report 0.
+func raySphereEntry(origin, direction Vector3, length float64, center Vector3, radius float64) (float64, bool) {
+	oc := origin.Sub(center)
+	b := oc.Dot(direction)
+	c := oc.Dot(oc) - radius*radius
+	if c <= 0 {
+		return 0, true
+	}
+	disc := b*b - c
+	if disc < 0 {
+		return 0, false
+	}
+	t := -b - math.Sqrt(disc)
+	if t < 0 || t > length {
+		return 0, false
+	}
+	return t, true
+}
+
+// traceBandEchogram shoots numRays from source and records, per octave band, the energy of every
+// pass through the listener sphere in arrival-time bins. Rays keep reflecting after passing the
+// listener and lose energy at each surface according to its band absorption.
+func traceBandEchogram(source, receiver *SceneObject, numRays int, binSec, maxTimeSec float64) *BandEchogram {
+	if source == nil || receiver == nil {
+		return traceBandEchogramAt(nil, nil, Vector3{}, Vector3{}, 0, binSec, maxTimeSec)
+	}
+	return traceBandEchogramAt(source, receiver, source.Position, receiver.Position, numRays, binSec, maxTimeSec)
+}
+
+// traceBandEchogramAt is traceBandEchogram with the source and receiver placed at sourcePos and
+// receiverPos instead of their current positions, e.g. to score candidate placements.
+func traceBandEchogramAt(source, receiver *SceneObject, sourcePos, receiverPos Vector3, numRays int, binSec, maxTimeSec float64) *BandEchogram {
+	bins := int(math.Ceil(maxTimeSec / binSec))
+	eg := &BandEchogram{BinSec: binSec, BandsHz: OCTAVE_BANDS_HZ, Energy: make([][]float64, bins)}
+	for i := range eg.Energy {
+		eg.Energy[i] = make([]float64, len(OCTAVE_BANDS_HZ))
+	}
+	if source == nil || receiver == nil || numRays <= 0 {
+		return eg
+	}
+
+	var collidables []*SceneObject
+	for _, obj := range allSceneObjects {
+		if obj != source && obj != receiver {
+			collidables = append(collidables, obj)
+		}
+	}
+	maxDistance := maxTimeSec * environment.SpeedOfSound
+	receiverRadius := receiver.Scale.X
+	energy := make([]float64, len(OCTAVE_BANDS_HZ))
+	entry := raySphereEntry
+	if receiver == listener {
+		entry = listenerSegmentEntry // The listener may be an ellipsoid
+	}
+
+	emitters := emitterPoints(source, sourcePos)
+
+	for i := 0; i < numRays; i++ {
+		direction := sphereDirection(i, numRays, stratifiedSampling)
+		emitted := emissionGain(source, direction)
+		if emitted == 0 {
+			continue
+		}
+		origin := emitters[i%len(emitters)]
+		for b := range energy {
+			energy[b] = emitted / float64(numRays)
+		}
+		travelled := 0.0
+
+		for bounce := 0; bounce <= maxReflections && travelled < maxDistance; bounce++ {
+			hit := performRaycast(origin, direction, maxRayDistance, collidables, nil)
+			segment := hit.Distance
+			if t, ok := entry(origin, direction, segment, receiverPos, receiverRadius); ok {
+				bin := int((travelled + t) / environment.SpeedOfSound / binSec)
+				weight := 1.0
+				if receiver == listener {
+					weight = receiverGain(direction)
+				}
+				if bin < bins {
+					for b := range energy {
+						eg.Energy[bin][b] += energy[b] * airBandTransmission(b, t) * weight
+					}
+				}
+			}
+			if !hit.Hit || !surfaceContinuesPath(hit.Object, bounce+1) {
+				break
+			}
+			travelled += segment
+			strongest := 0.0
+			cosIncidence := incidenceCos(direction, hit.Normal)
+			for b := range energy {
+				energy[b] *= airBandTransmission(b, segment) * (1 - surfaceBandAbsorption(hit.Object.Material, b, cosIncidence))
+				strongest = math.Max(strongest, energy[b])
+			}
+			if strongest*float64(numRays) < ENERGY_TRACE_MIN_ENERGY {
+				break
+			}
+			direction = direction.Reflect(hit.Normal)
+			origin = reflectionOrigin(hit, direction)
+		}
+	}
+	return eg
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Environment Settings ---
+//
+// The propagation medium shared by the tracers and metrics: speed of sound for arrival times,
+// temperature and humidity for air absorption (see air_absorption.go), and air density for the
+// characteristic impedance. Changing the temperature re-derives the speed of sound and density
+// unless they are given explicitly in the same goSetEnvironment call.
+
+const (
+	DEFAULT_AIR_DENSITY float64 = 1.204    // kg/m³ at 20 °C and standard pressure
+	STANDARD_PRESSURE   float64 = 101325.0 // Pa
+	DRY_AIR_GAS_CONST   float64 = 287.058  // J/(kg·K)
+	MIN_SPEED_OF_SOUND  float64 = 100.0    // m/s; bounds accepted by goSetEnvironment
+	MAX_SPEED_OF_SOUND  float64 = 2000.0   // Allows e.g. scale-model media
+	MIN_AIR_DENSITY     float64 = 0.1      // kg/m³
+	MAX_AIR_DENSITY     float64 = 10.0
+)
+
+// EnvironmentSettings describes the air the scene is filled with.
+type EnvironmentSettings struct {
+	SpeedOfSound float64 `json:"speedOfSound"` // m/s
+	TemperatureC float64 `json:"temperature"`  // °C
+	HumidityPct  float64 `json:"humidity"`     // % relative humidity
+	AirDensity   float64 `json:"airDensity"`   // kg/m³
+}
+
+var environment = EnvironmentSettings{
+	SpeedOfSound: SPEED_OF_SOUND,
+	TemperatureC: DEFAULT_AIR_TEMPERATURE,
+	HumidityPct:  DEFAULT_AIR_HUMIDITY,
+	AirDensity:   DEFAULT_AIR_DENSITY,
+}
+
+// speedOfSoundAt is the speed of sound in dry air at tempC °C.
+func speedOfSoundAt(tempC float64) float64 {
+	return 331.3 * math.Sqrt(1+tempC/273.15)
+}
+
+// airDensityAt is the density of dry air at tempC °C and standard pressure.
+func airDensityAt(tempC float64) float64 {
+	return STANDARD_PRESSURE / (DRY_AIR_GAS_CONST * (tempC + 273.15))
+}
+
+// airImpedance is the characteristic impedance ρc of the air, in Pa·s/m.
+func airImpedance() float64 {
+	return environment.AirDensity * environment.SpeedOfSound
+}
+
+// environmentPatch is a goSetEnvironment argument; absent fields keep their value.
+type environmentPatch struct {
+	SpeedOfSound *float64 `json:"speedOfSound"`
+	TemperatureC *float64 `json:"temperature"`
+	HumidityPct  *float64 `json:"humidity"`
+	AirDensity   *float64 `json:"airDensity"`
+}
+
+// applyEnvironmentPatch applies the given fields, deriving speed and density from a new
+// temperature when they are not given.
+func applyEnvironmentPatch(p environmentPatch) {
+	if p.TemperatureC != nil || p.HumidityPct != nil {
+		tempC, humidity := environment.TemperatureC, environment.HumidityPct
+		if p.TemperatureC != nil {
+			tempC = *p.TemperatureC
+		}
+		if p.HumidityPct != nil {
+			humidity = *p.HumidityPct
+		}
+		setAirConditions(tempC, humidity)
+	}
+	if p.SpeedOfSound != nil {
+		environment.SpeedOfSound = boundedParam("speedOfSound", *p.SpeedOfSound, MIN_SPEED_OF_SOUND, MAX_SPEED_OF_SOUND)
+	}
+	if p.AirDensity != nil {
+		environment.AirDensity = boundedParam("airDensity", *p.AirDensity, MIN_AIR_DENSITY, MAX_AIR_DENSITY)
+	}
+}
+
+// goSetEnvironment([settings]) applies {speedOfSound, temperature, humidity, airDensity} (any
+// subset, as an object or JSON string) and returns the resulting settings. Without an argument it
+// only returns the current settings.
+func goSetEnvironment(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetEnvironment")
+	if len(args) >= 1 {
+		var patch environmentPatch
+		if err := json.Unmarshal([]byte(jsonArgString(args[0])), &patch); err != nil {
+			log.Printf("Error: goSetEnvironment could not parse settings: %v", err)
+			return nil
+		}
+		applyEnvironmentPatch(patch)
+		logEvent(LogInterop, LogNotice, "Environment: c=%.1f m/s, %.1f °C, %.0f%% RH, ρ=%.3f kg/m³",
+			environment.SpeedOfSound, environment.TemperatureC, environment.HumidityPct, environment.AirDensity)
+		debouncedVisualizeFunc()
+	}
+	return js.ValueOf(map[string]interface{}{
+		"speedOfSound": environment.SpeedOfSound,
+		"temperature":  environment.TemperatureC,
+		"humidity":     environment.HumidityPct,
+		"airDensity":   environment.AirDensity,
+	})
+}
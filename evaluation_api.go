@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"syscall/js"
+)
+
+// --- Batch Evaluation API (Go as a fast evaluator for external optimizers) ---
+
+// PlacementCandidate is one (source, listener) pair to score.
+// JSON form: {"source": {"x": 0, "y": 1.5, "z": 5}, "listener": {"x": 0, "y": 1.5, "z": -5}}
+type PlacementCandidate struct {
+	Source   Vector3 `json:"source"`
+	Listener Vector3 `json:"listener"`
+}
+
+const INFEASIBLE_PLACEMENT_SCORE = -1 // Returned for candidates that fail the occupancy check
+
+// isPlacementFeasible checks both objects against the occupancy cloud and each other.
+func isPlacementFeasible(p PlacementCandidate) bool {
+	if soundSource == nil || listener == nil {
+		return false
+	}
+	if occupancyCloud == nil {
+		return !spheresIntersect(p.Source, soundSource.Scale.X/2.0, p.Listener, listener.Scale.X/2.0)
+	}
+	return occupancyCloud.IsPositionAttemptValid(p.Source, soundSource.Scale, StateSoundSource, p.Listener, listener.Scale) &&
+		occupancyCloud.IsPositionAttemptValid(p.Listener, listener.Scale, StateListener, p.Source, soundSource.Scale)
+}
+
+// evaluatePlacements scores every candidate with calculateListenerScore on the worker pool. If checkFeasibility
+// is set, infeasible candidates get INFEASIBLE_PLACEMENT_SCORE instead of being evaluated.
+func evaluatePlacements(candidates []PlacementCandidate, checkFeasibility bool) []int {
+	scores := make([]int, len(candidates))
+	parallelFor(len(candidates), func(i int) {
+		p := candidates[i]
+		if checkFeasibility && !isPlacementFeasible(p) {
+			scores[i] = INFEASIBLE_PLACEMENT_SCORE
+			return
+		}
+		scores[i] = calculateListenerScore(p.Source, p.Listener)
+	})
+	return scores
+}
+
+// jsonArgString returns a JS argument as a JSON string, stringifying objects/arrays if needed.
+func jsonArgString(arg js.Value) string {
+	if arg.Type() == js.TypeString {
+		return arg.String()
+	}
+	return js.Global().Get("JSON").Call("stringify", arg).String()
+}
+
+// goEvaluatePlacements(candidatesJSON[, checkFeasibility]) scores many (source, listener)
+// candidates in one call and returns the score vector, without moving anything in the scene.
+func goEvaluatePlacements(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goEvaluatePlacements")
+	if len(args) < 1 || len(args) > 2 {
+		log.Println("Error: goEvaluatePlacements expects 1 or 2 arguments (candidatesJSON[, checkFeasibility])")
+		return nil
+	}
+	var candidates []PlacementCandidate
+	if err := json.Unmarshal([]byte(jsonArgString(args[0])), &candidates); err != nil {
+		log.Printf("Error: goEvaluatePlacements could not parse candidates: %v", err)
+		return nil
+	}
+	checkFeasibility := true
+	if len(args) == 2 {
+		checkFeasibility = args[1].Bool()
+	}
+
+	scores := evaluatePlacements(candidates, checkFeasibility)
+	jsScores := make([]interface{}, len(scores))
+	for i, score := range scores {
+		jsScores[i] = score
+	}
+	return js.ValueOf(jsScores)
+}
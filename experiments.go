@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"syscall/js"
+	"time"
+)
+
+// --- Experiment Sync ---
+//
+// goSyncExperiments snaps the session into an experiment artifact (the scene, the parameters in
+// effect, the records and the current metrics), posts it to the development server's experiment
+// database (cmd/server/experiments.go) under a project name, and reads back the project's history,
+// so a room-design project outlives the browser session. The requests run on a goroutine: fetch
+// promises only settle once the calling JS callback has returned.
+
+const (
+	EXPERIMENTS_API_PATH    = "/api/projects/" // Served by cmd/server, same origin as the page
+	EXPERIMENT_SYNC_TIMEOUT = 15 * time.Second
+	MAX_PROJECT_NAME_LENGTH = 64
+)
+
+// ExperimentArtifact is everything stored about one experiment. The scene is a patch of "add"
+// operations, so applying it after removing the objects rebuilds the layout.
+type ExperimentArtifact struct {
+	Name           string              `json:"name"`
+	SavedIteration int                 `json:"savedIteration"`
+	SceneHash      string              `json:"sceneHash"`
+	RoomWidth      float64             `json:"roomWidth"`
+	RoomDepth      float64             `json:"roomDepth"`
+	RoomHeight     float64             `json:"roomHeight"`
+	Environment    EnvironmentSettings `json:"environment"`
+	Scene          ScenePatch          `json:"scene"`
+	ForbiddenZones []ForbiddenZone     `json:"forbiddenZones"`
+	Parameters     BestScoreSettings   `json:"parameters"`
+	Records        []BestScoreSettings `json:"records"`
+	Metrics        ExperimentMetrics   `json:"metrics"`
+}
+
+// ExperimentMetrics are the results the experiment was taken with.
+type ExperimentMetrics struct {
+	ListenerScore int                    `json:"listenerScore"`
+	STI           float64                `json:"sti"`
+	BestScore     int                    `json:"bestScore"` // Of the current learning session
+	SessionStats  map[string]interface{} `json:"sessionStats"`
+}
+
+// validProjectName matches the server's rule: 1-64 letters, digits, '-' or '_'.
+func validProjectName(name string) bool {
+	if len(name) == 0 || len(name) > MAX_PROJECT_NAME_LENGTH {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// sceneAsPatch describes every object of the live scene as an "add" operation.
+func sceneAsPatch() ScenePatch {
+	patch := ScenePatch{Objects: make([]ObjectPatch, 0, len(allSceneObjects))}
+	for _, obj := range allSceneObjects {
+		o := *obj
+		op := ObjectPatch{
+			Name: o.Name, Op: "add", Shape: o.ShapeType,
+			Position: &o.Position, Rotation: &o.Rotation, Scale: &o.Scale,
+			Static: &o.IsStatic, Visible: &o.Visible, Seating: &o.isSeating,
+			Material: o.Material.Name, Absorption: &o.Material.Absorption,
+		}
+		if o.Mesh != nil {
+			op.Vertices, op.Triangles = o.Mesh.Vertices, o.Mesh.Triangles
+		}
+		patch.Objects = append(patch.Objects, op)
+	}
+	return patch
+}
+
+// snapExperiment collects the artifact of the running session.
+func snapExperiment(name string) ExperimentArtifact {
+	artifact := ExperimentArtifact{
+		Name:           name,
+		SavedIteration: currentLearningIteration,
+		SceneHash:      formatSceneHash(computeSceneHash()),
+		RoomWidth:      roomWidth,
+		RoomDepth:      roomDepth,
+		RoomHeight:     roomHeight,
+		Environment:    environment,
+		Scene:          sceneAsPatch(),
+		Parameters:     currentSettings(listenerRayScore),
+		Records:        append([]BestScoreSettings(nil), recordsManager.BestRecords...),
+		Metrics: ExperimentMetrics{
+			ListenerScore: listenerRayScore,
+			STI:           estimateSTI(),
+			BestScore:     globalBestScore,
+			SessionStats:  sessionStats.export(),
+		},
+	}
+	if occupancyCloud != nil {
+		artifact.ForbiddenZones = append([]ForbiddenZone(nil), occupancyCloud.ForbiddenZones...)
+	}
+	return artifact
+}
+
+// fetchText sends a request with an optional JSON body and returns the response text. It blocks
+// on the fetch promises, so it must not run on the JS event loop (see awaitJSValue).
+func fetchText(method, url, body string) (string, error) {
+	if !isJSFunction("fetch") {
+		return "", fmt.Errorf("fetch is not available")
+	}
+	init := map[string]interface{}{"method": method}
+	if body != "" {
+		init["headers"] = map[string]interface{}{"Content-Type": "application/json"}
+		init["body"] = body
+	}
+	response, ok := awaitJSValue(jsGlobal.Call("fetch", url, init), EXPERIMENT_SYNC_TIMEOUT)
+	if !ok {
+		return "", fmt.Errorf("%s %s failed or timed out", method, url)
+	}
+	text, ok := awaitJSValue(response.Call("text"), EXPERIMENT_SYNC_TIMEOUT)
+	if !ok {
+		return "", fmt.Errorf("%s %s: could not read the response", method, url)
+	}
+	if !response.Get("ok").Bool() {
+		return "", fmt.Errorf("%s %s: status %d: %s", method, url, response.Get("status").Int(), text.String())
+	}
+	return text.String(), nil
+}
+
+// syncExperiment posts an artifact to the project and hands the project's history (a JSON array
+// of experiment summaries, newest first) or the error to onExperimentsSynced.
+func syncExperiment(project, artifact string) {
+	defer recoverFromPanic("syncExperiment")
+	url := EXPERIMENTS_API_PATH + project + "/experiments"
+	history, err := fetchText("POST", url, artifact)
+	if err == nil {
+		history, err = fetchText("GET", url, "")
+	}
+	if err != nil {
+		log.Printf("Error: could not sync experiments of project %s: %v", project, err)
+		callOptionalJS("onExperimentsSynced", project, nil, err.Error())
+		return
+	}
+	logEvent(LogInterop, LogNotice, "Experiment saved to project %s.", project)
+	callOptionalJS("onExperimentsSynced", project, history, nil)
+}
+
+// goSyncExperiments(project[, name]) saves the session as an experiment of project on the server
+// and then calls onExperimentsSynced(project, historyJSON, error) with the project's experiment
+// summaries, or with null and the error message. Returns false if nothing was sent.
+func goSyncExperiments(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSyncExperiments")
+	if len(args) < 1 || len(args) > 2 || args[0].Type() != js.TypeString {
+		log.Println("Error: goSyncExperiments expects 1 or 2 arguments (project[, name])")
+		return false
+	}
+	project := args[0].String()
+	if !validProjectName(project) {
+		log.Printf("Error: project names are 1-%d letters, digits, '-' or '_', got %q", MAX_PROJECT_NAME_LENGTH, project)
+		return false
+	}
+	if soundSource == nil || listener == nil {
+		log.Println("Error: goSyncExperiments needs a scene with a sound source and a listener")
+		return false
+	}
+	data, err := json.Marshal(snapExperiment(optionalString(args, 1, "")))
+	if err != nil {
+		log.Printf("Error: could not encode the experiment: %v", err)
+		return false
+	}
+	go syncExperiment(project, string(data))
+	return true
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Listener Exposure (Occlusion Ratio) Map ---
+//
+// For every floor cell, the fraction of the listener's apparent disc that has a clear line of
+// sight from the cell. 1 means the listener is fully exposed to a talker there, 0 fully shielded;
+// partitions and furniture between them show up as the shadows a privacy or speech-masking
+// analysis of an open-plan layout needs.
+
+const EXPOSURE_DISC_SAMPLES = 24 // Points on the listener's apparent disc tested per cell
+
+// listenerDiscSamples returns points spread over the disc of radius around center that faces
+// from, using a sunflower spiral so coverage is even for any sample count.
+func listenerDiscSamples(from, center Vector3, radius float64, count int) []Vector3 {
+	view := center.Sub(from).Normalize()
+	helper := Vector3{0, 1, 0}
+	if math.Abs(view.Y) > 0.9 {
+		helper = Vector3{1, 0, 0}
+	}
+	u := view.Cross(helper).Normalize()
+	v := view.Cross(u)
+	goldenAngle := math.Pi * (3 - math.Sqrt(5))
+	samples := make([]Vector3, count)
+	for i := range samples {
+		r := radius * math.Sqrt((float64(i)+0.5)/float64(count))
+		a := float64(i) * goldenAngle
+		samples[i] = center.Add(u.Scale(r * math.Cos(a))).Add(v.Scale(r * math.Sin(a)))
+	}
+	return samples
+}
+
+// exposureAt is the visible fraction of the listener's disc from pos, NaN where nothing can stand.
+func exposureAt(pos Vector3, standScale Vector3) float64 {
+	if occupancyCloud != nil && !occupancyCloud.IsPositionAttemptValid(pos, standScale, StateListener, soundSource.Position, soundSource.Scale) {
+		return math.NaN()
+	}
+	if pos.DistanceTo(listener.Position) <= listener.Scale.X {
+		return 1
+	}
+	visible := 0
+	for _, p := range listenerDiscSamples(pos, listener.Position, listener.Scale.X, EXPOSURE_DISC_SAMPLES) {
+		if !isSegmentOccluded(pos, p, listener) {
+			visible++
+		}
+	}
+	return float64(visible) / EXPOSURE_DISC_SAMPLES
+}
+
+// computeExposureMap fills a resX x resZ grid at height with exposureAt.
+func computeExposureMap(resX, resZ int, height float64) *Heatmap {
+	if listener == nil || soundSource == nil || resX <= 0 || resZ <= 0 {
+		return nil
+	}
+	standScale := heatmapListenerScale()
+	hm := newHeatmap(resX, resZ, height)
+	parallelFor(resX*resZ, func(cell int) {
+		ix, iz := cell/resZ, cell%resZ
+		hm.Values[ix][iz] = exposureAt(hm.CellCenter(ix, iz), standScale)
+	})
+	return hm
+}
+
+// goRenderExposureMapPNG([resolution, height]) renders the listener exposure map as a PNG, with
+// talker positions sampled at height (default: the listener's height) on a 32x32 grid by default.
+// The PNG is normalized like the other heatmaps; min and max are exposure fractions in [0, 1].
+func goRenderExposureMapPNG(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goRenderExposureMapPNG")
+	if listener == nil {
+		return nil
+	}
+	resolution := 32
+	height := listener.Position.Y
+	if len(args) >= 1 {
+		resolution = args[0].Int()
+	}
+	if len(args) >= 2 {
+		height = args[1].Float()
+	}
+	if resolution <= 0 || resolution > 256 {
+		log.Printf("Error: exposure map resolution %d out of range (1-256)", resolution)
+		return nil
+	}
+	return heatmapPNGToJS(computeExposureMap(resolution, resolution, height), int(math.Max(1, 512/float64(resolution))))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Extended Sources ---
+//
+// A source with a non-zero extent (a line array, a loudspeaker panel) emits from points spread
+// over a box of that size around its position, turned with the source's rotation, instead of
+// from its center. Each pass uses sourceSampleCount points from a Halton sequence and hands them
+// to successive rays, so every point emits an even share of the pass's directions.
+
+const (
+	DEFAULT_SOURCE_SAMPLES = 16
+	MAX_SOURCE_SAMPLES     = 256
+)
+
+var sourceSampleCount = DEFAULT_SOURCE_SAMPLES // Slider "sourceSamples": emitting points per pass
+
+// haltonValue is element index of the van der Corput sequence in base, in [0, 1).
+func haltonValue(index, base int) float64 {
+	value, f := 0.0, 1.0
+	for i := index; i > 0; i /= base {
+		f /= float64(base)
+		value += f * float64(i%base)
+	}
+	return value
+}
+
+// emitterPoints returns where source emits from when placed at center: just center for a point
+// source, otherwise sourceSampleCount points spread over its extent.
+func emitterPoints(source *SceneObject, center Vector3) []Vector3 {
+	if source == nil || sourceSampleCount <= 1 {
+		return []Vector3{center}
+	}
+	settings, ok := sourceSettings[source.Name]
+	if !ok || settings.Extent == (Vector3{}) {
+		return []Vector3{center}
+	}
+	rot, rotated := boxRotation(source)
+	points := make([]Vector3, sourceSampleCount)
+	for i := range points {
+		local := Vector3{
+			(haltonValue(i+1, 2) - 0.5) * settings.Extent.X,
+			(haltonValue(i+1, 3) - 0.5) * settings.Extent.Y,
+			(haltonValue(i+1, 5) - 0.5) * settings.Extent.Z,
+		}
+		if rotated {
+			local = rot.Apply(local)
+		}
+		points[i] = center.Add(local)
+	}
+	return points
+}
+
+// goSetSourceExtent([name, sizeX, sizeY, sizeZ]) gives a source a physical size; all zeros makes
+// it a point source again. Returns true on success.
+func goSetSourceExtent(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetSourceExtent")
+	if len(args) < 4 {
+		log.Println("Error: goSetSourceExtent expects 4 arguments (name, sizeX, sizeY, sizeZ)")
+		return js.ValueOf(false)
+	}
+	src := findSoundSource(args[0].String())
+	if src == nil {
+		log.Printf("Error: unknown sound source %q", args[0].String())
+		return js.ValueOf(false)
+	}
+	extent := Vector3{args[1].Float(), args[2].Float(), args[3].Float()}
+	for _, v := range []float64{extent.X, extent.Y, extent.Z} {
+		if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+			log.Printf("Error: source extent must be non-negative, got %v", extent)
+			return js.ValueOf(false)
+		}
+	}
+	sourceSettingsFor(src).Extent = extent
+	visualizeSoundPropagation()
+	return js.ValueOf(true)
+}
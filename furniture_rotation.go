@@ -0,0 +1,160 @@
+package main
+
+import (
+	"log"
+	"math"
+)
+
+// --- Furniture Orientation in Learning ---
+
+const (
+	FURNITURE_ROTATION_STEP_DEG = 15.0 // Default increment between candidate orientations
+	FURNITURE_MOVE_STEP         = 0.5  // Meters; translation tried alongside each orientation
+)
+
+var (
+	optimizeFurniture     = false // Toggle "optimizeFurniture": learning also moves and turns movable furniture
+	furnitureRotationStep = FURNITURE_ROTATION_STEP_DEG
+)
+
+// FurniturePose is one candidate placement of a piece of furniture.
+type FurniturePose struct {
+	Position          Vector3
+	OrientationOffset float64 // Degrees about Y, relative to the authored rotation
+}
+
+// movableFurniture lists the boxes learning may re-place: visible, non-static objects that are
+// neither room boundaries nor the source or listener.
+func movableFurniture() []*SceneObject {
+	var furniture []*SceneObject
+	for _, obj := range allSceneObjects {
+		if obj.IsStatic || !obj.Visible || obj.ShapeType != "box" || obj.isWallOrCeiling ||
+			obj.isSoundSource || obj == listener {
+			continue
+		}
+		furniture = append(furniture, obj)
+	}
+	return furniture
+}
+
+// setFurniturePose moves obj to pose, keeping Rotation.Y (what JS draws) in step with the offset.
+func setFurniturePose(obj *SceneObject, pose FurniturePose) {
+	obj.Rotation.Y += pose.OrientationOffset - obj.orientationOffset
+	obj.orientationOffset = pose.OrientationOffset
+	obj.Position = pose.Position
+}
+
+// furniturePoseFits reports whether obj at its current pose stays inside the room, clear of the
+// static geometry the occupancy cloud was built from and of the other movable pieces, and clear
+// of every sound source and the listener. Meshes and curved reflectors count as their bounds.
+func furniturePoseFits(obj *SceneObject) bool {
+	half := boxHalfExtents(obj)
+	if obj.Position.X-half.X < -roomWidth/2 || obj.Position.X+half.X > roomWidth/2 ||
+		obj.Position.Z-half.Z < -roomDepth/2 || obj.Position.Z+half.Z > roomDepth/2 {
+		return false
+	}
+	static := staticSceneObjects
+	if occupancyCloud != nil && occupancyCloud.SDF != nil {
+		static = occupancyCloud.SDF.Objects
+	}
+	box := objectOrientedBox(obj)
+	for _, others := range [][]*SceneObject{static, movableFurniture()} {
+		for _, other := range others {
+			if other == obj || !other.Visible || isRoomBoundary(other) { // Furniture rests on the ground
+				continue
+			}
+			if other.ShapeType == "sphere" {
+				if sphereIntersectsBox(other.Position, other.Scale.X, obj) {
+					return false
+				}
+			} else if box.overlaps(objectOrientedBox(other)) {
+				return false
+			}
+		}
+	}
+	for _, src := range soundSources {
+		if sphereIntersectsBox(src.Position, src.Scale.X, obj) {
+			return false
+		}
+	}
+	return listener == nil || !sphereIntersectsBox(listener.Position, listener.Scale.X, obj)
+}
+
+// furniturePoses returns the pose of every movable piece by name, nil if there are none.
+func furniturePoses() map[string]FurniturePose {
+	var poses map[string]FurniturePose
+	for _, obj := range movableFurniture() {
+		if poses == nil {
+			poses = map[string]FurniturePose{}
+		}
+		poses[obj.Name] = FurniturePose{Position: obj.Position, OrientationOffset: obj.orientationOffset}
+	}
+	return poses
+}
+
+// restoreFurniturePoses puts the movable pieces back in the recorded poses. Pieces the record
+// does not know keep their pose.
+func restoreFurniturePoses(poses map[string]FurniturePose) {
+	for _, obj := range movableFurniture() {
+		if pose, ok := poses[obj.Name]; ok {
+			setFurniturePose(obj, pose)
+		}
+	}
+}
+
+// furnitureCandidates is the current pose, one step along each floor axis, and each of those
+// turned one rotation increment either way.
+func furnitureCandidates(obj *SceneObject) []FurniturePose {
+	offsets := []Vector3{{}, {X: FURNITURE_MOVE_STEP}, {X: -FURNITURE_MOVE_STEP}, {Z: FURNITURE_MOVE_STEP}, {Z: -FURNITURE_MOVE_STEP}}
+	turns := []float64{0, furnitureRotationStep, -furnitureRotationStep}
+	candidates := make([]FurniturePose, 0, len(offsets)*len(turns))
+	for _, off := range offsets {
+		for _, turn := range turns {
+			candidates = append(candidates, FurniturePose{
+				Position:          obj.Position.Add(off),
+				OrientationOffset: math.Mod(obj.orientationOffset+turn, 360),
+			})
+		}
+	}
+	return candidates
+}
+
+// optimizeFurnitureStep scores every candidate pose of each movable piece with the learning
+// objective, with the source and listener where they are, and keeps the best. Returns whether
+// anything moved.
+func optimizeFurnitureStep() bool {
+	if soundSource == nil || listener == nil || furnitureRotationStep <= 0 {
+		return false
+	}
+	moved := false
+	for _, obj := range movableFurniture() {
+		original := FurniturePose{Position: obj.Position, OrientationOffset: obj.orientationOffset}
+		best, bestScore := original, objectiveScore(soundSource.Position, listener.Position)
+		for _, pose := range furnitureCandidates(obj)[1:] { // The first candidate is the current pose
+			setFurniturePose(obj, pose)
+			if !furniturePoseFits(obj) {
+				continue
+			}
+			if score := objectiveScore(soundSource.Position, listener.Position); score > bestScore {
+				best, bestScore = pose, score
+			}
+		}
+		setFurniturePose(obj, best)
+		if best != original {
+			moved = true
+			logEvent(LogOptimizer, LogDetail, "Furniture %s -> (%.2f, %.2f), turned %.0f° (score %d)",
+				obj.Name, best.Position.X, best.Position.Z, best.OrientationOffset, bestScore)
+		}
+	}
+	return moved
+}
+
+// setFurnitureRotationStep sets the orientation increment in degrees; values outside (0, 90] are rejected.
+func setFurnitureRotationStep(deg float64) bool {
+	if math.IsNaN(deg) || deg <= 0 || deg > 90 {
+		log.Printf("Error: furniture rotation step must be in (0, 90] degrees, got %v", deg)
+		return false
+	}
+	furnitureRotationStep = deg
+	return true
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/binary"
+	"log"
+	"math"
+	"syscall/js"
+	"time"
+)
+
+// --- GPU Ray Kernel Bridge (optional WebGPU offload) ---
+//
+// Go flattens the scene and a batch of rays into Float32Arrays and hands them to a page-provided
+// compute kernel:
+//
+//	gpuIntersectRays(objects, objectCount, rays, rayCount, maxDist) -> Float32Array | Promise<Float32Array>
+//
+// objects holds GPU_OBJECT_STRIDE floats per object (shape, position, size); shape is 0 for boxes
+// (axis-aligned, size = full extents) and 1 for spheres (size.x = radius). rays holds
+// GPU_RAY_STRIDE floats per ray (origin, direction). The result holds GPU_HIT_STRIDE floats per
+// ray: hit distance and object index (-1 for a miss). Go rebuilds hit points and normals, so the
+// kernel only has to find the closest hit. Whenever the kernel is missing, fails or times out,
+// the batch is traced with the pure-Go performRaycast instead.
+
+const (
+	GPU_OBJECT_STRIDE   = 8 // shape, px, py, pz, sx, sy, sz, padding
+	GPU_RAY_STRIDE      = 6 // ox, oy, oz, dx, dy, dz
+	GPU_HIT_STRIDE      = 2 // distance, object index
+	GPU_MIN_BATCH_RAYS  = 256
+	GPU_KERNEL_TIMEOUT  = 2 * time.Second
+	GPU_KERNEL_FUNCTION = "gpuIntersectRays"
+)
+
+var (
+	gpuOffloadEnabled bool // Set by goSetGPUOffload; only takes effect when the kernel exists
+	gpuKernelFailures int  // Consecutive failures; the bridge disables itself after a few
+)
+
+const GPU_MAX_KERNEL_FAILURES = 3
+
+// gpuKernel returns the page's intersection kernel, if offload is enabled and available.
+func gpuKernel() (js.Value, bool) {
+	if !gpuOffloadEnabled {
+		return js.Value{}, false
+	}
+	kernel := jsGlobal.Get(GPU_KERNEL_FUNCTION)
+	return kernel, kernel.Type() == js.TypeFunction
+}
+
+// float32ArrayFromGo copies values into a new JS Float32Array.
+func float32ArrayFromGo(values []float32) js.Value {
+	buf := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	bytes := js.Global().Get("Uint8Array").New(len(buf))
+	js.CopyBytesToJS(bytes, buf)
+	return js.Global().Get("Float32Array").New(bytes.Get("buffer"))
+}
+
+// float32ArrayToGo copies a JS Float32Array into Go.
+func float32ArrayToGo(array js.Value) []float32 {
+	bytes := js.Global().Get("Uint8Array").New(array.Get("buffer"), array.Get("byteOffset"), array.Get("byteLength"))
+	buf := make([]byte, bytes.Get("length").Int())
+	js.CopyBytesToGo(buf, bytes)
+	values := make([]float32, len(buf)/4)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return values
+}
+
+// flattenObjectsForGPU packs the visible boxes and spheres of objects, returning the buffer and
+// the object each GPU index refers to. Boxes go unrotated; see gpuTraceable.
+func flattenObjectsForGPU(objects []*SceneObject) ([]float32, []*SceneObject) {
+	data := make([]float32, 0, GPU_OBJECT_STRIDE*len(objects))
+	var index []*SceneObject
+	for _, obj := range objects {
+		if !obj.Visible || (obj.ShapeType != "box" && obj.ShapeType != "sphere") {
+			continue
+		}
+		shape := float32(0)
+		if obj.ShapeType == "sphere" {
+			shape = 1
+		}
+		data = append(data, shape,
+			float32(obj.Position.X), float32(obj.Position.Y), float32(obj.Position.Z),
+			float32(obj.Scale.X), float32(obj.Scale.Y), float32(obj.Scale.Z), 0)
+		index = append(index, obj)
+	}
+	return data, index
+}
+
+// awaitJSValue resolves v if it is a Promise, waiting at most timeout. It must not be called on
+// the JS event loop (from a js.FuncOf callback), since the promise could never settle there.
+func awaitJSValue(v js.Value, timeout time.Duration) (js.Value, bool) {
+	if v.Type() != js.TypeObject || v.Get("then").Type() != js.TypeFunction {
+		return v, true
+	}
+	done := make(chan js.Value, 1)
+	failed := make(chan struct{}, 1)
+	onResolve := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- args[0]
+		return nil
+	})
+	onReject := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		failed <- struct{}{}
+		return nil
+	})
+	defer onResolve.Release()
+	defer onReject.Release()
+	v.Call("then", onResolve, onReject)
+	select {
+	case result := <-done:
+		return result, true
+	case <-failed:
+		return js.Value{}, false
+	case <-time.After(timeout):
+		return js.Value{}, false
+	}
+}
+
+// intersectBatchGPU traces one batch on the GPU kernel. ok is false if the batch must be traced in Go.
+func intersectBatchGPU(origins, directions []Vector3, objects []*SceneObject, maxDist float64) (results []RayIntersectionResult, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logEvent(LogInterop, LogNotice, "GPU kernel panicked, falling back to Go tracer: %v", r)
+			results, ok = nil, false
+		}
+	}()
+	kernel, available := gpuKernel()
+	if !available || len(origins) < GPU_MIN_BATCH_RAYS {
+		return nil, false
+	}
+
+	objectData, index := flattenObjectsForGPU(objects)
+	rayData := make([]float32, 0, GPU_RAY_STRIDE*len(origins))
+	for i, o := range origins {
+		d := directions[i]
+		rayData = append(rayData, float32(o.X), float32(o.Y), float32(o.Z), float32(d.X), float32(d.Y), float32(d.Z))
+	}
+
+	raw := kernel.Invoke(float32ArrayFromGo(objectData), len(index), float32ArrayFromGo(rayData), len(origins), maxDist)
+	resolved, settled := awaitJSValue(raw, GPU_KERNEL_TIMEOUT)
+	if !settled || resolved.Type() != js.TypeObject {
+		return nil, false
+	}
+	hits := float32ArrayToGo(resolved)
+	if len(hits) < GPU_HIT_STRIDE*len(origins) {
+		logEvent(LogInterop, LogNotice, "GPU kernel returned %d values for %d rays", len(hits), len(origins))
+		return nil, false
+	}
+
+	results = make([]RayIntersectionResult, len(origins))
+	for i := range origins {
+		results[i] = RayIntersectionResult{Distance: maxDist}
+		objIdx := int(hits[GPU_HIT_STRIDE*i+1])
+		dist := float64(hits[GPU_HIT_STRIDE*i])
+		if objIdx < 0 || objIdx >= len(index) || dist <= EPSILON || dist >= maxDist {
+			continue
+		}
+		obj := index[objIdx]
+		point := origins[i].Add(directions[i].Scale(dist))
+		results[i] = RayIntersectionResult{Hit: true, Point: point, Normal: objectNormalAt(obj, point), Distance: dist, Object: obj}
+	}
+	return results, true
+}
+
+// gpuTraceable reports whether the kernel can intersect every visible object; it only knows
+// spheres and axis-aligned boxes.
+func gpuTraceable(objects []*SceneObject) bool {
+	for _, obj := range objects {
+		if !obj.Visible {
+			continue
+		}
+		if obj.ShapeType != "box" && obj.ShapeType != "sphere" {
+			return false
+		}
+		if _, rotated := boxRotation(obj); rotated && obj.ShapeType == "box" {
+			return false
+		}
+	}
+	return true
+}
+
+// intersectBatch finds the closest hit of every ray, on the GPU kernel when possible.
+func intersectBatch(origins, directions []Vector3, objects []*SceneObject, maxDist float64) []RayIntersectionResult {
+	if !gpuTraceable(objects) {
+		// Traced in Go below; not a kernel failure
+	} else if results, ok := intersectBatchGPU(origins, directions, objects, maxDist); ok {
+		gpuKernelFailures = 0
+		return results
+	} else if _, available := gpuKernel(); available && len(origins) >= GPU_MIN_BATCH_RAYS {
+		gpuKernelFailures++
+		if gpuKernelFailures >= GPU_MAX_KERNEL_FAILURES {
+			log.Println("GPU ray kernel failed repeatedly; disabling GPU offload.")
+			gpuOffloadEnabled = false
+		}
+	}
+	results := make([]RayIntersectionResult, len(origins))
+	for i := range origins {
+		results[i] = performRaycast(origins[i], directions[i], maxDist, objects, nil)
+	}
+	return results
+}
+
+// gpuOffloadActive reports whether evaluations should use the batched GPU tracer. Batches may
+// wait on a kernel promise, so only the learning goroutine (never a JS callback) offloads.
+func gpuOffloadActive() bool {
+	_, available := gpuKernel()
+	return available && learningModeActive
+}
+
+// traceBounceCountsBatched is castRayAndGetBounceCountForEvaluation for a whole ray set, traced
+// bounce by bounce: every ray still in flight at a given reflection count is intersected in one
+// batch, which suits the GPU kernel. Returns each ray's bounce count at the listener (-1 for misses)
+// and the energy it arrived with (0 for misses). Rays start with source's emission gain, ray i
+// from emitters[i%len(emitters)].
+func traceBounceCountsBatched(source *SceneObject, emitters []Vector3, directions []Vector3, directCollidables []*SceneObject, listenerPos Vector3, listenerRadius float64, sets *CollidableSets) ([]int, []float64) {
+	bounces := make([]int, len(directions))
+	active := make([]int, len(directions))
+	origins := make([]Vector3, len(directions))
+	dirs := make([]Vector3, len(directions))
+	energies := make([]float64, len(directions))
+	arrivals := make([]float64, len(directions))
+	pathLengths := make([]float64, len(directions))
+	for i := range directions {
+		bounces[i] = -1
+		active[i] = i
+		origins[i] = emitters[i%len(emitters)]
+		dirs[i] = directions[i]
+		energies[i] = emissionGain(source, directions[i])
+	}
+
+	collidables := directCollidables
+	for reflection := 0; reflection <= maxReflections && len(active) > 0; reflection++ {
+		batchOrigins := make([]Vector3, len(active))
+		batchDirs := make([]Vector3, len(active))
+		for j, ray := range active {
+			batchOrigins[j], batchDirs[j] = origins[ray], dirs[ray]
+		}
+		hits := intersectBatch(batchOrigins, batchDirs, collidables, maxRayDistance)
+
+		next := active[:0]
+		for j, ray := range active {
+			hit := hits[j]
+			// The batch shares one reach, so each segment is cut at its own (see segmentReach)
+			if reach := segmentReach(pathLengths[ray]); hit.Distance >= reach {
+				hit = RayIntersectionResult{Distance: reach}
+			}
+			if dist, ok := listenerHitOnSegment(batchOrigins[j], batchDirs[j], hit, listenerPos, listenerRadius, sets); ok {
+				bounces[ray] = reflection
+				arrivals[ray] = energies[ray] * airTransmission(dist) * receiverGain(batchDirs[j])
+				continue
+			}
+			if !hit.Hit || reflection == maxReflections || !surfaceContinuesPath(hit.Object, reflection+1) {
+				continue
+			}
+			survivor, alive := rouletteSurvivor(energies[ray] * airTransmission(hit.Distance))
+			if !alive {
+				continue
+			}
+			reflectDirection := batchDirs[j].Reflect(hit.Normal)
+			origins[ray] = reflectionOrigin(hit, reflectDirection)
+			dirs[ray] = reflectDirection
+			energies[ray] = energyAfterReflection(survivor, hit.Object, batchDirs[j], hit.Normal)
+			pathLengths[ray] += hit.Distance
+			next = append(next, ray)
+		}
+		active = next
+		collidables = sets.Reflected
+	}
+	return bounces, arrivals
+}
+
+// goSetGPUOffload(enabled) turns the WebGPU kernel path on or off. Returns whether the kernel is
+// available, i.e. whether offload will actually happen.
+func goSetGPUOffload(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetGPUOffload")
+	if len(args) != 1 {
+		log.Println("Error: goSetGPUOffload expects 1 argument (enabled)")
+		return false
+	}
+	gpuOffloadEnabled = args[0].Bool()
+	gpuKernelFailures = 0
+	_, available := gpuKernel()
+	if gpuOffloadEnabled && !available {
+		log.Printf("GPU offload requested but %s() is not defined; using the Go tracer.", GPU_KERNEL_FUNCTION)
+	}
+	return available
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- 2D Heatmaps (coverage, energy slices) ---
+
+// Heatmap is a 2D grid of values over the room's XZ plane at a fixed height.
+// Values are indexed [ix][iz]; NaN marks cells with no meaningful value (e.g. blocked positions).
+type Heatmap struct {
+	Values     [][]float64
+	MinX, MinZ float64 // World position of the grid's corner
+	CellW      float64 // Cell size along X
+	CellD      float64 // Cell size along Z
+	Height     float64 // Y of the horizontal slice
+}
+
+func newHeatmap(resX, resZ int, height float64) *Heatmap {
+	values := make([][]float64, resX)
+	for i := range values {
+		values[i] = make([]float64, resZ)
+	}
+	return &Heatmap{
+		Values: values,
+		MinX:   -roomWidth / 2,
+		MinZ:   -roomDepth / 2,
+		CellW:  roomWidth / float64(resX),
+		CellD:  roomDepth / float64(resZ),
+		Height: height,
+	}
+}
+
+// CellCenter returns the world position at the center of heatmap cell (ix, iz).
+func (hm *Heatmap) CellCenter(ix, iz int) Vector3 {
+	return Vector3{
+		X: hm.MinX + (float64(ix)+0.5)*hm.CellW,
+		Y: hm.Height,
+		Z: hm.MinZ + (float64(iz)+0.5)*hm.CellD,
+	}
+}
+
+// Range returns the min and max of the finite values (0, 0 if there are none).
+func (hm *Heatmap) Range() (minVal, maxVal float64) {
+	minVal, maxVal = math.Inf(1), math.Inf(-1)
+	for _, column := range hm.Values {
+		for _, v := range column {
+			if math.IsNaN(v) {
+				continue
+			}
+			minVal = math.Min(minVal, v)
+			maxVal = math.Max(maxVal, v)
+		}
+	}
+	if math.IsInf(minVal, 1) {
+		return 0, 0
+	}
+	return minVal, maxVal
+}
+
+// computeCoverageHeatmap scores the listener at every cell center of a resX x resZ grid at the
+// given height, keeping the sound source fixed. Positions where the listener can't be placed are NaN.
+func computeCoverageHeatmap(resX, resZ int, height float64) *Heatmap {
+	if soundSource == nil || resX <= 0 || resZ <= 0 {
+		return nil
+	}
+	listenerScale := heatmapListenerScale()
+	hm := newHeatmap(resX, resZ, height)
+	parallelFor(resX*resZ, func(cell int) {
+		ix, iz := cell/resZ, cell%resZ
+		hm.Values[ix][iz] = coverageAt(hm.CellCenter(ix, iz), listenerScale)
+	})
+	return hm
+}
+
+// heatmapListenerScale is the listener size used for placement checks.
+func heatmapListenerScale() Vector3 {
+	if listener != nil {
+		return listener.Scale
+	}
+	return Vector3{0.25, 0.25, 0.25}
+}
+
+// coverageAt is the listener score at pos with the sound source fixed, NaN if the listener can't be placed there.
+func coverageAt(pos, listenerScale Vector3) float64 {
+	if occupancyCloud != nil && !occupancyCloud.IsPositionAttemptValid(pos, listenerScale, StateListener, soundSource.Position, soundSource.Scale) {
+		return math.NaN()
+	}
+	return float64(calculateListenerScore(soundSource.Position, pos))
+}
+
+// accumulateRayEnergyInCloud deposits the last pass's visualized rays into the cloud's per-cell
+// energy (segment opacity per cell crossed), replacing any previous accumulation.
+func accumulateRayEnergyInCloud() {
+	if occupancyCloud == nil {
+		return
+	}
+	occupancyCloud.ResetEnergy()
+	for _, ray := range rayVisuals {
+		start := Vector3{ray.Start.X, ray.Start.Y, ray.Start.Z}
+		end := Vector3{ray.End.X, ray.End.Y, ray.End.Z}
+		occupancyCloud.traverseCells(start, end, func(ix, iy, iz int, _ float64) bool {
+			meta := occupancyCloud.cellMetadata(ix, iy, iz, true)
+			meta.Energy += ray.Opacity
+			return true
+		})
+	}
+}
+
+// computeEnergySliceHeatmap samples the cloud's accumulated energy in the horizontal cell layer at height.
+func computeEnergySliceHeatmap(height float64) *Heatmap {
+	if occupancyCloud == nil {
+		return nil
+	}
+	oc := occupancyCloud
+	_, iy, _, inBounds := oc.worldToGridCoords(Vector3{oc.RoomMin.X, height, oc.RoomMin.Z})
+	if !inBounds {
+		return nil
+	}
+	hm := &Heatmap{
+		Values: make([][]float64, oc.CellsX),
+		MinX:   oc.RoomMin.X,
+		MinZ:   oc.RoomMin.Z,
+		CellW:  oc.CellSize.X,
+		CellD:  oc.CellSize.Z,
+		Height: height,
+	}
+	for ix := 0; ix < oc.CellsX; ix++ {
+		hm.Values[ix] = make([]float64, oc.CellsZ)
+		for iz := 0; iz < oc.CellsZ; iz++ {
+			cell := oc.Grid[ix][iy][iz]
+			if cell.State == StateStaticObstacle {
+				hm.Values[ix][iz] = math.NaN()
+			} else if cell.Meta != nil {
+				hm.Values[ix][iz] = cell.Meta.Energy
+			}
+		}
+	}
+	return hm
+}
+
+// heatmapColor maps t in [0, 1] to a blue -> cyan -> green -> yellow -> red ramp.
+func heatmapColor(t float64) color.RGBA {
+	t = math.Max(0, math.Min(1, t))
+	r := math.Max(0, math.Min(1, 4*t-2))
+	g := math.Max(0, math.Min(1, math.Min(4*t, 4-4*t)))
+	b := math.Max(0, math.Min(1, 2-4*t))
+	return color.RGBA{uint8(r * 255), uint8(g * 255), uint8(b * 255), 255}
+}
+
+// renderHeatmapPNG draws the heatmap with pixelsPerCell-sized squares. X runs left to right,
+// Z top to bottom (as seen from above). NaN cells are drawn dark gray.
+func renderHeatmapPNG(hm *Heatmap, pixelsPerCell int) ([]byte, error) {
+	resX := len(hm.Values)
+	resZ := 0
+	if resX > 0 {
+		resZ = len(hm.Values[0])
+	}
+	img := image.NewRGBA(image.Rect(0, 0, resX*pixelsPerCell, resZ*pixelsPerCell))
+	minVal, maxVal := hm.Range()
+	span := maxVal - minVal
+	blocked := color.RGBA{60, 60, 60, 255}
+
+	for ix := 0; ix < resX; ix++ {
+		for iz := 0; iz < resZ; iz++ {
+			c := blocked
+			if v := hm.Values[ix][iz]; !math.IsNaN(v) {
+				t := 0.0
+				if span > 0 {
+					t = (v - minVal) / span
+				}
+				c = heatmapColor(t)
+			}
+			for px := 0; px < pixelsPerCell; px++ {
+				for pz := 0; pz < pixelsPerCell; pz++ {
+					img.SetRGBA(ix*pixelsPerCell+px, iz*pixelsPerCell+pz, c)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// heatmapPNGToJS encodes the heatmap and returns {png: Uint8Array, width, height, min, max} to JS.
+func heatmapPNGToJS(hm *Heatmap, pixelsPerCell int) interface{} {
+	if hm == nil {
+		return nil
+	}
+	pngBytes, err := renderHeatmapPNG(hm, pixelsPerCell)
+	if err != nil {
+		log.Printf("Error encoding heatmap PNG: %v", err)
+		return nil
+	}
+	jsBytes := js.Global().Get("Uint8Array").New(len(pngBytes))
+	js.CopyBytesToJS(jsBytes, pngBytes)
+	minVal, maxVal := hm.Range()
+	return js.ValueOf(map[string]interface{}{
+		"png":    jsBytes,
+		"width":  len(hm.Values) * pixelsPerCell,
+		"height": len(hm.Values[0]) * pixelsPerCell,
+		"min":    minVal,
+		"max":    maxVal,
+	})
+}
+
+// goRenderCoverageHeatmapPNG([resolution, height]) renders listener coverage at a height as a PNG.
+// Defaults to a 32x32 grid at the listener's current height. The run also updates the sweet spot marker.
+func goRenderCoverageHeatmapPNG(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goRenderCoverageHeatmapPNG")
+	resolution := 32
+	height := 1.5
+	if listener != nil {
+		height = listener.Position.Y
+	}
+	if len(args) >= 1 {
+		resolution = args[0].Int()
+	}
+	if len(args) >= 2 {
+		height = args[1].Float()
+	}
+	if resolution <= 0 || resolution > 256 {
+		log.Printf("Error: heatmap resolution %d out of range (1-256)", resolution)
+		return nil
+	}
+	hm := computeCoverageHeatmap(resolution, resolution, height)
+	sweetSpot = findSweetSpot(hm)
+	refreshRayVisualsFromCache() // Re-render so the sweet spot marker shows up
+	return heatmapPNGToJS(hm, int(math.Max(1, 512/float64(resolution))))
+}
+
+// goRenderEnergySlicePNG([height]) renders the ray energy of the last pass in a horizontal slice as a PNG.
+func goRenderEnergySlicePNG(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goRenderEnergySlicePNG")
+	height := 1.5
+	if listener != nil {
+		height = listener.Position.Y
+	}
+	if len(args) >= 1 {
+		height = args[0].Float()
+	}
+	accumulateRayEnergyInCloud()
+	return heatmapPNGToJS(computeEnergySliceHeatmap(height), 8)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"log"
+	"syscall/js"
+)
+
+// --- Tracer Hooks (extension points for the visualization pass) ---
+
+// HookKind names the tracer event a hook listens for.
+type HookKind string
+
+const (
+	HookOnEmit         HookKind = "emit"         // A ray leaves a source
+	HookOnBounce       HookKind = "bounce"       // A ray reflects off a surface
+	HookOnListenerHit  HookKind = "listenerHit"  // A ray reaches the listener
+	HookOnPassComplete HookKind = "passComplete" // A full visualization pass finished
+)
+
+// RayEvent describes a ray at one tracer event. Surface is nil for emissions and listener hits.
+type RayEvent struct {
+	Source    *SceneObject
+	RayIndex  int
+	Bounces   int     // Reflections so far
+	Energy    float64 // Physical energy fraction left
+	Point     Vector3 // Emission, reflection or listener arrival point
+	Direction Vector3 // Direction of travel leaving Point (arrival direction for listener hits)
+	Surface   *SceneObject
+}
+
+// PassSummary is passed to OnPassComplete hooks after visualizeSoundPropagation.
+type PassSummary struct {
+	Score     int // Final listener score, including hook bonuses
+	HookBonus float64
+	Rays      int // Rays emitted across all sources
+	Segments  int // Traced segments cached for display
+}
+
+// ListenerHitHook may return a score bonus (0 for none). Bonuses are weighted by the source gain
+// and added to the pass score, which lets extensions layer custom scoring on the tracer.
+type (
+	RayHook          func(RayEvent)
+	ListenerHitHook  func(RayEvent) float64
+	PassCompleteHook func(PassSummary)
+)
+
+type registeredHook struct {
+	id           int
+	kind         HookKind
+	ray          RayHook
+	listenerHit  ListenerHitHook
+	passComplete PassCompleteHook
+}
+
+var (
+	tracerHooks    []registeredHook
+	nextHookID     = 1
+	hookScoreBonus float64 // Listener hit bonuses of the source currently being traced
+)
+
+func addHook(h registeredHook) int {
+	h.id = nextHookID
+	nextHookID++
+	tracerHooks = append(tracerHooks, h)
+	return h.id
+}
+
+// OnEmit registers fn to run for every ray leaving a source. Returns the hook ID.
+func OnEmit(fn RayHook) int { return addHook(registeredHook{kind: HookOnEmit, ray: fn}) }
+
+// OnBounce registers fn to run at every reflection. Returns the hook ID.
+func OnBounce(fn RayHook) int { return addHook(registeredHook{kind: HookOnBounce, ray: fn}) }
+
+// OnListenerHit registers fn to run whenever a ray reaches the listener. Returns the hook ID.
+func OnListenerHit(fn ListenerHitHook) int {
+	return addHook(registeredHook{kind: HookOnListenerHit, listenerHit: fn})
+}
+
+// OnPassComplete registers fn to run after each visualization pass. Returns the hook ID.
+func OnPassComplete(fn PassCompleteHook) int {
+	return addHook(registeredHook{kind: HookOnPassComplete, passComplete: fn})
+}
+
+// RemoveHook unregisters a hook by ID. Returns false if no such hook exists.
+func RemoveHook(id int) bool {
+	for i, h := range tracerHooks {
+		if h.id == id {
+			tracerHooks = append(tracerHooks[:i], tracerHooks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Hooks fire only during the visualization pass; the evaluation tracer used by the optimizer
+// stays hook-free so extensions cannot slow down learning.
+
+func fireRayHooks(kind HookKind, ev RayEvent) {
+	for _, h := range tracerHooks {
+		if h.kind == kind {
+			h.ray(ev)
+		}
+	}
+}
+
+func fireListenerHitHooks(ev RayEvent) {
+	for _, h := range tracerHooks {
+		if h.kind == HookOnListenerHit {
+			hookScoreBonus += h.listenerHit(ev)
+		}
+	}
+}
+
+func firePassCompleteHooks(summary PassSummary) {
+	for _, h := range tracerHooks {
+		if h.kind == HookOnPassComplete {
+			h.passComplete(summary)
+		}
+	}
+}
+
+// rayEventToJS converts a RayEvent into a plain JS object for script hooks.
+func rayEventToJS(ev RayEvent) map[string]interface{} {
+	data := map[string]interface{}{
+		"sourceId":  sourceID(ev.Source),
+		"rayIndex":  ev.RayIndex,
+		"bounces":   ev.Bounces,
+		"energy":    ev.Energy,
+		"point":     map[string]interface{}{"x": ev.Point.X, "y": ev.Point.Y, "z": ev.Point.Z},
+		"direction": map[string]interface{}{"x": ev.Direction.X, "y": ev.Direction.Y, "z": ev.Direction.Z},
+		"surface":   "",
+	}
+	if ev.Surface != nil {
+		data["surface"] = ev.Surface.Name
+	}
+	return data
+}
+
+// goRegisterHook(kind, fn) registers a JS function as a tracer hook and returns its ID (0 on error).
+// kind is "emit", "bounce", "listenerHit" or "passComplete". listenerHit functions may return a
+// number that is added to the score. Each call crosses into JS, so per-ray hooks are meant for
+// light scripting, not for every pass of a large ray count.
+func goRegisterHook(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goRegisterHook")
+	if len(args) != 2 || args[1].Type() != js.TypeFunction {
+		log.Println("Error: goRegisterHook expects 2 arguments (kind, function)")
+		return 0
+	}
+	fn := args[1]
+	switch HookKind(args[0].String()) {
+	case HookOnEmit:
+		return OnEmit(func(ev RayEvent) { fn.Invoke(rayEventToJS(ev)) })
+	case HookOnBounce:
+		return OnBounce(func(ev RayEvent) { fn.Invoke(rayEventToJS(ev)) })
+	case HookOnListenerHit:
+		return OnListenerHit(func(ev RayEvent) float64 {
+			result := fn.Invoke(rayEventToJS(ev))
+			if result.Type() != js.TypeNumber {
+				return 0
+			}
+			return result.Float()
+		})
+	case HookOnPassComplete:
+		return OnPassComplete(func(s PassSummary) {
+			fn.Invoke(map[string]interface{}{"score": s.Score, "hookBonus": s.HookBonus, "rays": s.Rays, "segments": s.Segments})
+		})
+	}
+	log.Printf("Error: goRegisterHook: unknown hook kind %q", args[0].String())
+	return 0
+}
+
+// goUnregisterHook(id) removes a hook registered with goRegisterHook.
+func goUnregisterHook(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goUnregisterHook")
+	if len(args) != 1 {
+		log.Println("Error: goUnregisterHook expects 1 argument (id)")
+		return false
+	}
+	return RemoveHook(args[0].Int())
+}
@@ -0,0 +1,99 @@
+package main
+
+import "math"
+
+// --- Hybrid Simulation (image sources for early paths, rays for the rest) ---
+//
+// With the "simulationMode" toggle on, the direct path and first-order specular reflections off
+// static boxes are computed exactly with image sources, and stochastic rays only count listener
+// hits from HYBRID_STOCHASTIC_MIN_ORDER reflections on. An exact path of length d contributes what
+// the same number of rays would on average: rays times the fraction of directions that hit the
+// listener sphere at distance d. The early part, which dominates the score, then no longer varies
+// between passes. Reflections off movable objects are not image sources and are only counted
+// from the second order on.
+
+const HYBRID_STOCHASTIC_MIN_ORDER = 2
+
+var (
+	hybridSimulation bool // Toggle "simulationMode"
+	imagePathsDrawn  int  // Exact paths drawn in the current pass, for their path IDs
+)
+
+// stochasticOrderCounts reports whether a ray reaching the listener after bounces reflections is
+// scored; in hybrid mode the image sources cover the lower orders.
+func stochasticOrderCounts(bounces int) bool {
+	return !hybridSimulation || bounces >= HYBRID_STOCHASTIC_MIN_ORDER
+}
+
+// listenerCapFraction is the fraction of uniformly emitted directions that hit a sphere of the
+// given radius at the given distance: the solid angle of its spherical cap over 4π.
+func listenerCapFraction(distance, radius float64) float64 {
+	if distance <= radius {
+		return 1
+	}
+	sinAlpha := radius / distance
+	return (1 - math.Sqrt(1-sinAlpha*sinAlpha)) / 2
+}
+
+// ImagePath is an exactly computed early path: Points run from the source to the listener.
+type ImagePath struct {
+	Points  []Vector3
+	Bounces int
+	Energy  float64 // Energy left after absorption and air at the listener
+	Length  float64
+}
+
+// earlyImagePaths returns the direct path (if unoccluded) and the first-order image-source paths
+// from source at sourcePos to the listener at listenerPos.
+func earlyImagePaths(source *SceneObject, sourcePos, listenerPos Vector3) []ImagePath {
+	var paths []ImagePath
+	if !isSegmentOccluded(sourcePos, listenerPos, source, listener) {
+		d := sourcePos.DistanceTo(listenerPos)
+		gain := emissionGain(source, listenerPos.Sub(sourcePos)) * receiverGain(listenerPos.Sub(sourcePos))
+		paths = append(paths, ImagePath{Points: []Vector3{sourcePos, listenerPos}, Energy: gain * airTransmission(d), Length: d})
+	}
+	for _, r := range firstOrderReflectionsAt(source, listener, sourcePos, listenerPos) {
+		paths = append(paths, ImagePath{
+			Points:  []Vector3{sourcePos, r.Point, listenerPos},
+			Bounces: 1,
+			Energy:  emissionGain(source, r.Point.Sub(sourcePos)) * receiverGain(listenerPos.Sub(r.Point)) * energyAfterReflection(airTransmission(r.PathLength), r.Surface, r.Point.Sub(sourcePos), r.FaceNormal) * surfaceContinuationWeight(r.Surface, 1),
+			Length:  r.PathLength,
+		})
+	}
+	return paths
+}
+
+// imagePathScore is the ray-equivalent score of exact paths for a pass of rays rays.
+func imagePathScore(paths []ImagePath, listenerRadius float64, rays int) float64 {
+	score := 0.0
+	for _, p := range paths {
+		score += float64(rays) * listenerCapFraction(p.Length, listenerRadius) * arrivalScore(p.Bounces, p.Energy)
+	}
+	return score
+}
+
+// addImagePathVisuals caches exact paths as listener-reaching segments of the pass. They get
+// negative path IDs so they never collide with emitted rays.
+func addImagePathVisuals(paths []ImagePath, source *SceneObject) {
+	for _, p := range paths {
+		imagePathsDrawn++
+		travelled := 0.0
+		for i := 0; i+1 < len(p.Points); i++ {
+			a, b := p.Points[i], p.Points[i+1]
+			travelled += a.DistanceTo(b)
+			tracedSegments = append(tracedSegments, TracedSegment{
+				Line: RayLine{
+					Start:      Point3D{a.X, a.Y, a.Z},
+					End:        Point3D{b.X, b.Y, b.Z},
+					Color:      sourceRayColor(source, listenerRayColor),
+					Opacity:    initialRayOpacity,
+					SourceID:   sourceID(source),
+					PathLength: travelled,
+					ArrivalMs:  arrivalTimeMs(travelled),
+				},
+				PathHitsListener: true,
+				PathID:           -imagePathsDrawn,
+			})
+		}
+	}
+}
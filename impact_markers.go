@@ -0,0 +1,121 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+	"syscall/js"
+)
+
+// --- Surface Impact Markers (where reflections concentrate) ---
+
+const (
+	IMPACT_DEFAULT_CELL_SIZE = 0.5 // Grid cell size (m) bounce points are clustered in
+	IMPACT_DEFAULT_MIN_COUNT = 3   // Clusters with fewer impacts are not reported
+)
+
+// ImpactPoint is one ray hitting a surface during the last visualization pass.
+type ImpactPoint struct {
+	Surface *SceneObject
+	Point   Vector3
+	Normal  Vector3
+	Energy  float64 // Incident energy, weighted by the source gain
+}
+
+// ImpactCluster is a hotspot of impacts on one surface.
+type ImpactCluster struct {
+	Surface  *SceneObject
+	Centroid Vector3 // Energy-weighted mean of the impact points
+	Normal   Vector3
+	Count    int
+	Energy   float64
+}
+
+var impactPoints []ImpactPoint // Filled by castRayAndAddVisuals, reset every pass
+
+// recordImpact stores a surface hit of the traced ray.
+func (t *RayTrace) recordImpact(hit RayIntersectionResult, energy float64) {
+	t.Impacts = append(t.Impacts, ImpactPoint{Surface: hit.Object, Point: hit.Point, Normal: hit.Normal, Energy: energy})
+}
+
+// clusterImpacts groups impacts per surface and face into cellSize grid cells and returns the
+// clusters with at least minCount impacts, most energetic first.
+func clusterImpacts(points []ImpactPoint, cellSize float64, minCount int) []ImpactCluster {
+	type cellKey struct {
+		surface *SceneObject
+		normal  Vector3
+		x, y, z int64
+	}
+	type accum struct {
+		sum    Vector3
+		weight float64
+		count  int
+	}
+	cells := map[cellKey]*accum{}
+	var order []cellKey // Keeps the output stable for equal energies
+	for _, p := range points {
+		key := cellKey{
+			surface: p.Surface,
+			normal:  p.Normal,
+			x:       int64(math.Floor(p.Point.X / cellSize)),
+			y:       int64(math.Floor(p.Point.Y / cellSize)),
+			z:       int64(math.Floor(p.Point.Z / cellSize)),
+		}
+		a, ok := cells[key]
+		if !ok {
+			a = &accum{}
+			cells[key] = a
+			order = append(order, key)
+		}
+		w := math.Max(p.Energy, 1e-12) // Zero-energy hits still place the centroid
+		a.sum = a.sum.Add(p.Point.Scale(w))
+		a.weight += w
+		a.count++
+	}
+
+	var clusters []ImpactCluster
+	for _, key := range order {
+		a := cells[key]
+		if a.count < minCount {
+			continue
+		}
+		clusters = append(clusters, ImpactCluster{
+			Surface:  key.surface,
+			Centroid: a.sum.Scale(1 / a.weight),
+			Normal:   key.normal,
+			Count:    a.count,
+			Energy:   a.weight,
+		})
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return clusters[i].Energy > clusters[j].Energy })
+	return clusters
+}
+
+// goGetImpactMarkers([cellSize, minCount]) clusters the last pass's bounce points into hotspots
+// and returns [{surface, position: {x,y,z}, normal: {x,y,z}, count, energy}], most energetic first.
+func goGetImpactMarkers(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetImpactMarkers")
+	cellSize, minCount := IMPACT_DEFAULT_CELL_SIZE, IMPACT_DEFAULT_MIN_COUNT
+	if len(args) >= 1 {
+		cellSize = args[0].Float()
+	}
+	if len(args) >= 2 {
+		minCount = args[1].Int()
+	}
+	if cellSize <= 0 {
+		log.Printf("Error: goGetImpactMarkers needs a positive cell size (got %.3f)", cellSize)
+		return nil
+	}
+	clusters := clusterImpacts(impactPoints, cellSize, minCount)
+	markers := make([]interface{}, len(clusters))
+	for i, c := range clusters {
+		markers[i] = map[string]interface{}{
+			"surface":  c.Surface.Name,
+			"position": map[string]interface{}{"x": c.Centroid.X, "y": c.Centroid.Y, "z": c.Centroid.Z},
+			"normal":   map[string]interface{}{"x": c.Normal.X, "y": c.Normal.Y, "z": c.Normal.Z},
+			"count":    c.Count,
+			"energy":   c.Energy,
+		}
+	}
+	return js.ValueOf(markers)
+}
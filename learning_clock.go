@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math"
+	"syscall/js"
+	"time"
+)
+
+// --- Learning Clock ---
+//
+// Times every learning iteration so the UI can show a rate and an estimate of the time left
+// instead of a bare counter. The rate is the moving average over the last LEARNING_CLOCK_WINDOW
+// iterations, so it follows the device slowing down or the time budget controller changing the
+// evaluation rays.
+
+const LEARNING_CLOCK_WINDOW = 50
+
+// LearningClock keeps the durations of the most recent learning iterations.
+type LearningClock struct {
+	start     time.Time
+	durations [LEARNING_CLOCK_WINDOW]time.Duration // Ring buffer of the last iterations
+	count     int                                  // Iterations recorded
+	windowSum time.Duration
+}
+
+var learningClock LearningClock
+
+// reset starts timing a learning run.
+func (c *LearningClock) reset() {
+	*c = LearningClock{start: time.Now()}
+}
+
+// tick records one finished iteration that took d.
+func (c *LearningClock) tick(d time.Duration) {
+	slot := c.count % LEARNING_CLOCK_WINDOW
+	c.windowSum += d - c.durations[slot]
+	c.durations[slot] = d
+	c.count++
+}
+
+// iterationsPerSecond is the moving-average rate, 0 before the first iteration.
+func (c *LearningClock) iterationsPerSecond() float64 {
+	n := min(c.count, LEARNING_CLOCK_WINDOW)
+	if n == 0 || c.windowSum <= 0 {
+		return 0
+	}
+	return float64(n) / c.windowSum.Seconds()
+}
+
+// etaSeconds estimates the time left: the rest of the time budget if one is set, otherwise the
+// remaining iterations at the current rate. -1 when there is no estimate yet.
+func (c *LearningClock) etaSeconds() float64 {
+	if learningTimeBudget > 0 {
+		return math.Max(0, (learningTimeBudget - time.Since(c.start)).Seconds())
+	}
+	rate := c.iterationsPerSecond()
+	if rate == 0 {
+		return -1
+	}
+	return float64(max(0, maxLearningIterations-currentLearningIteration)) / rate
+}
+
+// learningClockJS reports the clock for updateLearningProgress and goGetLearningClock.
+func learningClockJS() map[string]interface{} {
+	elapsed := 0.0
+	if !learningClock.start.IsZero() {
+		elapsed = time.Since(learningClock.start).Seconds()
+	}
+	return map[string]interface{}{
+		"iterationsPerSecond": learningClock.iterationsPerSecond(),
+		"etaSeconds":          learningClock.etaSeconds(),
+		"elapsedSeconds":      elapsed,
+		"timeBudgeted":        learningTimeBudget > 0,
+		"running":             learningModeActive,
+	}
+}
+
+// reportLearningProgress sends the iteration counter, best score and clock to the UI.
+func reportLearningProgress() {
+	callOptionalJS("updateLearningProgress", currentLearningIteration, maxLearningIterations, globalBestScore, learningClockJS())
+}
+
+// goGetLearningClock() returns {iterationsPerSecond, etaSeconds, elapsedSeconds, timeBudgeted,
+// running} for the current or last learning run; etaSeconds is -1 until there is a rate.
+func goGetLearningClock(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetLearningClock")
+	return js.ValueOf(learningClockJS())
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Listener Capture Radius Calibration ---
+//
+// Scores count the rays that pass through the listener, so a small listener with few rays gives
+// a handful of hits whose count jumps from pass to pass. Calibration picks the capture radius at
+// which numRays rays are expected to hit the listener directly a target number of times from a
+// source at the room's typical separation, half its diagonal: the cap fraction of the sphere seen
+// from there is (1 - cos α)/2 with sin α = r/d, so r = d·2√(f(1-f)) for f = target/numRays.
+// Scoring passes then use that radius in place of the listener's own; the body used for placement
+// and clearance is unchanged.
+
+const (
+	DEFAULT_TARGET_DIRECT_HITS = 20.0
+	MIN_CAPTURE_RADIUS         = 0.05 // m
+	MAX_CAPTURE_RADIUS         = 1.5  // m
+)
+
+var (
+	calibratedCaptureRadius float64                      // 0 scores with the listener's own radius
+	captureTargetHits       = DEFAULT_TARGET_DIRECT_HITS // Target of the last calibration
+	autoCalibrateCapture    bool                         // Toggle "autoListenerRadius": recalibrate when numRays changes
+)
+
+// listenerCaptureRadius is the radius scoring passes hit-test the listener with.
+func listenerCaptureRadius() float64 {
+	if calibratedCaptureRadius > 0 {
+		return calibratedCaptureRadius
+	}
+	if listener == nil {
+		return 0.25 // Default listener radius if the listener is not set up
+	}
+	return listener.Scale.X
+}
+
+// captureCalibrationDistance is the source–listener distance calibration plans for.
+func captureCalibrationDistance() float64 {
+	return Vector3{roomWidth, roomHeight, roomDepth}.Length() / 2
+}
+
+// captureRadiusForHits is the radius at which rays expects targetHits direct hits from distance,
+// clamped to [MIN_CAPTURE_RADIUS, MAX_CAPTURE_RADIUS]; clamped reports whether the bound applied.
+func captureRadiusForHits(targetHits float64, rays int, distance float64) (radius float64, clamped bool) {
+	f := math.Min(0.5, targetHits/float64(max(rays, 1)))
+	radius = distance * 2 * math.Sqrt(f*(1-f))
+	bounded := math.Max(MIN_CAPTURE_RADIUS, math.Min(MAX_CAPTURE_RADIUS, radius))
+	return bounded, bounded != radius
+}
+
+// calibrateCaptureRadius sets the scoring radius for targetHits expected direct hits at the
+// current numRays and room size.
+func calibrateCaptureRadius(targetHits float64) (float64, bool) {
+	radius, clamped := captureRadiusForHits(targetHits, numRays, captureCalibrationDistance())
+	calibratedCaptureRadius, captureTargetHits = radius, targetHits
+	return radius, clamped
+}
+
+// captureCalibrationJS reports the radius in effect and the direct hits it is expected to give.
+func captureCalibrationJS(clamped bool) map[string]interface{} {
+	radius, distance := listenerCaptureRadius(), captureCalibrationDistance()
+	return map[string]interface{}{
+		"radius":             radius,
+		"calibrated":         calibratedCaptureRadius > 0,
+		"targetHits":         captureTargetHits,
+		"expectedDirectHits": float64(numRays) * listenerCapFraction(distance, radius),
+		"distance":           distance,
+		"numRays":            numRays,
+		"clamped":            clamped,
+	}
+}
+
+// goCalibrateListenerRadius([targetHits]) picks the listener capture radius expected to give
+// targetHits direct hits per pass (DEFAULT_TARGET_DIRECT_HITS if omitted); a target of 0 goes
+// back to the listener's own radius. Returns {radius, calibrated, targetHits, expectedDirectHits,
+// distance, numRays, clamped} and re-renders with the new radius.
+func goCalibrateListenerRadius(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goCalibrateListenerRadius")
+	target := DEFAULT_TARGET_DIRECT_HITS
+	if len(args) >= 1 && args[0].Type() == js.TypeNumber {
+		target = args[0].Float()
+	}
+	if math.IsNaN(target) || target < 0 {
+		log.Printf("Error: goCalibrateListenerRadius expects a non-negative target hit count, got %v", target)
+		return nil
+	}
+	clamped := false
+	if target == 0 {
+		calibratedCaptureRadius = 0
+	} else {
+		var radius float64
+		radius, clamped = calibrateCaptureRadius(target)
+		log.Printf("Listener capture radius calibrated to %.3f m for %.0f direct hits with %d rays", radius, target, numRays)
+	}
+	debouncedVisualizeFunc()
+	return js.ValueOf(captureCalibrationJS(clamped))
+}
@@ -0,0 +1,55 @@
+package main
+
+import "time"
+
+// --- Listener Capture Bookkeeping (one score contribution per emitted ray) ---
+//
+// Every listener hit of the visualization pass is claimed for its emitted ray (see RayTrace).
+// A ray keeps the capture with the lowest bounce order; later hits of the same ray at the same or
+// a higher order are ignored, so no path is scored twice however the tracer reaches the listener.
+
+// ListenerCapture is the canonical listener hit of one emitted ray.
+type ListenerCapture struct {
+	Bounces      int
+	Energy       float64 // Energy left after absorption at the listener
+	ArrivalIndex int     // Index into arrivalPoints, or -1 if none was recorded
+}
+
+var pathCaptures = map[int]ListenerCapture{} // Keyed by path ID, reset every pass
+
+// resetListenerCaptures clears the bookkeeping before a pass.
+func resetListenerCaptures() {
+	clear(pathCaptures)
+}
+
+// claimListenerHit records a listener hit of the traced ray. It returns true for the ray's first
+// hit and for hits of a lower bounce order than the current capture (isNew is then false), and
+// false for hits that must not be counted.
+func (t *RayTrace) claimListenerHit(bounces int, energy float64) (claimed, isNew bool) {
+	if profilingEnabled.Load() {
+		defer profileSince(ProfileScoring, time.Now())
+	}
+	if t.Captured && t.Capture.Bounces <= bounces {
+		return false, false
+	}
+	isNew = !t.Captured
+	t.Captured, t.Capture = true, ListenerCapture{Bounces: bounces, Energy: energy, ArrivalIndex: -1}
+	return true, isNew
+}
+
+// setCaptureArrival sets the arrival marker of a claimed capture, replacing the marker of a
+// higher-order capture of the same ray if there was one.
+func (t *RayTrace) setCaptureArrival(arrival ArrivalPoint) {
+	t.Arrival = arrival
+}
+
+// capturedScore sums arrivalScore over the captures of paths [firstPath, firstPath+count).
+func capturedScore(firstPath, count int) float64 {
+	score := 0.0
+	for id := firstPath; id < firstPath+count; id++ {
+		if capture, ok := pathCaptures[id]; ok {
+			score += arrivalScore(capture.Bounces, capture.Energy)
+		}
+	}
+	return score
+}
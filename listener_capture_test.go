@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+// TestListenerCaptures replays listener hit sequences through the bookkeeping and checks that
+// every path counts once, with its minimum bounce order, so scores never double count.
+func TestListenerCaptures(t *testing.T) {
+	tests := []struct {
+		name    string
+		hits    []int  // Bounce orders, in the order the tracer reports them
+		claimed []bool // Whether each hit is claimed
+		want    int    // Bounce order kept, -1 for no capture
+	}{
+		{"no hit", nil, nil, -1},
+		{"single hit", []int{4}, []bool{true}, 4},
+		{"same hit twice", []int{2, 2}, []bool{true, false}, 2},
+		{"higher order after lower", []int{1, 3}, []bool{true, false}, 1},
+		{"lower order after higher", []int{3, 0}, []bool{true, true}, 0},
+		{"descending orders", []int{5, 3, 4, 1}, []bool{true, true, false, true}, 1},
+	}
+	resetListenerCaptures()
+	savedArrivals := arrivalPoints
+	defer func() { arrivalPoints = savedArrivals; resetListenerCaptures() }()
+	arrivalPoints = nil
+
+	wantScore, wantCaptures := 0.0, 0
+	for path, tt := range tests {
+		trace := RayTrace{PathID: path}
+		for i, bounces := range tt.hits {
+			claimed, isNew := trace.claimListenerHit(bounces, 1)
+			if claimed != tt.claimed[i] || isNew != (i == 0) {
+				t.Errorf("%s: hit %d (order %d) claimed=%v isNew=%v, want claimed=%v isNew=%v",
+					tt.name, i, bounces, claimed, isNew, tt.claimed[i], i == 0)
+			}
+		}
+		mergeRayTrace(&trace)
+		capture, ok := pathCaptures[path]
+		switch {
+		case tt.want < 0 && ok:
+			t.Errorf("%s: captured at order %d, want no capture", tt.name, capture.Bounces)
+		case tt.want >= 0 && !ok:
+			t.Errorf("%s: no capture, want order %d", tt.name, tt.want)
+		case ok && capture.Bounces != tt.want:
+			t.Errorf("%s: kept order %d, want %d", tt.name, capture.Bounces, tt.want)
+		}
+		if tt.want >= 0 {
+			wantScore += arrivalScore(tt.want, 1)
+			wantCaptures++
+		}
+	}
+	if len(pathCaptures) != wantCaptures {
+		t.Errorf("%d captures, want %d", len(pathCaptures), wantCaptures)
+	}
+	if got := capturedScore(0, len(tests)); got != wantScore {
+		t.Errorf("captured score %.1f, want %.1f", got, wantScore)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "math"
+
+// --- Ellipsoidal Listener ---
+//
+// The listener's Scale gives its semi-axes: X across the shoulders, Y up, Z front to back, turned
+// with listenerYawDeg. Hit tests take the radius they are given as the X semi-axis and keep the
+// listener's proportions for the other two, so callers that shrink or grow the receiver (e.g.
+// listener radius calibration) scale the whole body. A uniformly scaled listener is the usual sphere.
+
+// listenerIsSphere reports whether the listener is uniformly scaled.
+func listenerIsSphere() bool {
+	return listener == nil || (listener.Scale.Y == listener.Scale.X && listener.Scale.Z == listener.Scale.X)
+}
+
+// listenerUnitOffset maps a world offset (or direction) relative to the listener's center into
+// the frame where a listener of X semi-axis radius is the unit sphere.
+func listenerUnitOffset(offset Vector3, radius float64) Vector3 {
+	if listenerIsSphere() {
+		return offset.Scale(1 / radius)
+	}
+	local := eulerRotation(Vector3{0, listenerYawDeg, 0}).ApplyTranspose(offset)
+	axes := listener.Scale.Scale(radius / listener.Scale.X)
+	return Vector3{local.X / axes.X, local.Y / axes.Y, local.Z / axes.Z}
+}
+
+// listenerSegmentApproach is the ray parameter in [0, length] where the ray comes closest to the
+// listener's center in its unit frame, and whether the ray is inside the listener there.
+func listenerSegmentApproach(origin, direction Vector3, length float64, listenerPos Vector3, radius float64) (float64, bool) {
+	o := listenerUnitOffset(origin.Sub(listenerPos), radius)
+	d := listenerUnitOffset(direction, radius)
+	t := 0.0
+	if dd := d.Dot(d); dd > 0 {
+		t = math.Max(0, math.Min(length, -o.Dot(d)/dd))
+	}
+	return t, o.Add(d.Scale(t)).Length() < 1
+}
+
+// listenerSegmentEntry is raySphereEntry for the listener's ellipsoid: the ray parameter where
+// the segment enters it (0 if it starts inside).
+func listenerSegmentEntry(origin, direction Vector3, length float64, listenerPos Vector3, radius float64) (float64, bool) {
+	o := listenerUnitOffset(origin.Sub(listenerPos), radius)
+	d := listenerUnitOffset(direction, radius)
+	a, b, c := d.Dot(d), o.Dot(d), o.Dot(o)-1
+	if c <= 0 {
+		return 0, true
+	}
+	disc := b*b - a*c
+	if a == 0 || disc < 0 {
+		return 0, false
+	}
+	t := (-b - math.Sqrt(disc)) / a
+	if t < 0 || t > length {
+		return 0, false
+	}
+	return t, true
+}
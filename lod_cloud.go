@@ -0,0 +1,164 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+)
+
+// --- Level-of-Detail Occupancy Cloud (coarse-then-fine placement search) ---
+
+const (
+	LOD_COARSE_FACTOR     = 4   // Fine cells per coarse cell along each axis
+	LOD_MIN_FREE_FRACTION = 0.5 // Coarse cells with fewer placeable fine cells count as blocked
+	LOD_REFINE_TOP_K      = 3   // Best coarse cells refined on the fine grid
+)
+
+// Downsample builds a coarse mirror of the cloud with factor×factor×factor fine cells per coarse
+// cell. A coarse cell is empty if at least LOD_MIN_FREE_FRACTION of its fine cells are free of
+// static obstacles and forbidden zones; otherwise it is a static obstacle. The source and
+// listener are not mirrored, since they move during the search.
+func (oc *OccupancyCloud) Downsample(factor int) *OccupancyCloud {
+	coarse := NewOccupancyCloud(oc.RoomMin, oc.RoomMax, oc.CellSize.Scale(float64(factor)), false)
+	for cx := 0; cx < coarse.CellsX; cx++ {
+		for cy := 0; cy < coarse.CellsY; cy++ {
+			for cz := 0; cz < coarse.CellsZ; cz++ {
+				free, total := 0, 0
+				for ix := cx * factor; ix < minInt((cx+1)*factor, oc.CellsX); ix++ {
+					for iy := cy * factor; iy < minInt((cy+1)*factor, oc.CellsY); iy++ {
+						for iz := cz * factor; iz < minInt((cz+1)*factor, oc.CellsZ); iz++ {
+							total++
+							if !oc.Grid[ix][iy][iz].State.BlocksPlacement() {
+								free++
+							}
+						}
+					}
+				}
+				if total == 0 || float64(free) < LOD_MIN_FREE_FRACTION*float64(total) {
+					coarse.Grid[cx][cy][cz].State = StateStaticObstacle
+				}
+			}
+		}
+	}
+	return coarse
+}
+
+// cellCenter returns the world position at the center of cell (ix, iy, iz).
+func (oc *OccupancyCloud) cellCenter(ix, iy, iz int) Vector3 {
+	return Vector3{
+		X: oc.RoomMin.X + (float64(ix)+0.5)*oc.CellSize.X,
+		Y: oc.RoomMin.Y + (float64(iy)+0.5)*oc.CellSize.Y,
+		Z: oc.RoomMin.Z + (float64(iz)+0.5)*oc.CellSize.Z,
+	}
+}
+
+type scoredPosition struct {
+	pos   Vector3
+	score int
+}
+
+// scorePositions evaluates the learning objective with moving placed at each position.
+func scorePositions(moving *SceneObject, positions []Vector3) []scoredPosition {
+	scored := make([]scoredPosition, len(positions))
+	parallelFor(len(positions), func(i int) {
+		score := 0
+		if moving == soundSource {
+			score = objectiveScore(positions[i], listener.Position)
+		} else {
+			score = objectiveScore(soundSource.Position, positions[i])
+		}
+		scored[i] = scoredPosition{positions[i], score}
+	})
+	return scored
+}
+
+// coarseToFineSearch scores the moving object at every free coarse cell in its current height
+// layer, then refines the LOD_REFINE_TOP_K best coarse cells on the fine grid. Positions keep
+// the object's current height. Returns the best valid position found and its score.
+func coarseToFineSearch(moving, other *SceneObject, state PointState) (Vector3, int, bool) {
+	fine := occupancyCloud
+	coarse := fine.Downsample(LOD_COARSE_FACTOR)
+	height := moving.Position.Y
+	_, cy, _, inBounds := coarse.worldToGridCoords(moving.Position)
+	if !inBounds {
+		return Vector3{}, 0, false
+	}
+	valid := func(p Vector3) bool {
+		return fine.IsPositionAttemptValid(p, moving.Scale, state, other.Position, other.Scale)
+	}
+
+	var coarseCandidates []Vector3
+	for cx := 0; cx < coarse.CellsX; cx++ {
+		for cz := 0; cz < coarse.CellsZ; cz++ {
+			if coarse.Grid[cx][cy][cz].State.BlocksPlacement() {
+				continue
+			}
+			p := coarse.cellCenter(cx, cy, cz)
+			p.Y = height
+			if valid(p) {
+				coarseCandidates = append(coarseCandidates, p)
+			}
+		}
+	}
+	coarseScores := scorePositions(moving, coarseCandidates)
+	sort.SliceStable(coarseScores, func(i, j int) bool { return coarseScores[i].score > coarseScores[j].score })
+	if len(coarseScores) > LOD_REFINE_TOP_K {
+		coarseScores = coarseScores[:LOD_REFINE_TOP_K]
+	}
+
+	var fineCandidates []Vector3
+	for _, c := range coarseScores {
+		fineCandidates = append(fineCandidates, c.pos)
+		minX := c.pos.X - coarse.CellSize.X/2
+		minZ := c.pos.Z - coarse.CellSize.Z/2
+		for i := 0; i < LOD_COARSE_FACTOR; i++ {
+			for k := 0; k < LOD_COARSE_FACTOR; k++ {
+				p := Vector3{minX + (float64(i)+0.5)*fine.CellSize.X, height, minZ + (float64(k)+0.5)*fine.CellSize.Z}
+				if valid(p) {
+					fineCandidates = append(fineCandidates, p)
+				}
+			}
+		}
+	}
+	if len(fineCandidates) == 0 {
+		return Vector3{}, 0, false
+	}
+	best := scoredPosition{score: math.MinInt}
+	for _, s := range scorePositions(moving, fineCandidates) {
+		if s.score > best.score {
+			best = s
+		}
+	}
+	logEvent(LogOptimizer, LogNotice, "Coarse-to-fine search for %s: %d coarse and %d fine candidates, best score %d",
+		moving.Name, len(coarseCandidates), len(fineCandidates), best.score)
+	return best.pos, best.score, true
+}
+
+// runCoarseToFinePhase is the first learning phase of the "coarseToFine" strategy: each movable
+// object jumps to the best position of a coarse-then-fine search if it beats its current score.
+// Coordinate descent then continues locally from there.
+func runCoarseToFinePhase() {
+	if occupancyCloud == nil || soundSource == nil || listener == nil {
+		return
+	}
+	phases := []struct {
+		moving, other *SceneObject
+		state         PointState
+	}{
+		{soundSource, listener, StateSoundSource},
+		{listener, soundSource, StateListener},
+	}
+	for _, phase := range phases {
+		if positionLocked(phase.moving) || (phase.moving == listener && seatingObjectiveActive()) {
+			continue
+		}
+		current := objectiveScore(soundSource.Position, listener.Position)
+		pos, score, ok := coarseToFineSearch(phase.moving, phase.other, phase.state)
+		if !ok || score <= current {
+			continue
+		}
+		phase.moving.Position = pos
+		resyncDynamicObjectsInCloud()
+		log.Printf("Coarse-to-fine phase moved %s: score %d -> %d", phase.moving.Name, current, score)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestScorePositionsWithSeating scores placements on the worker pool with the seating objective,
+// whose seat scores are themselves spread over the pool.
+func TestScorePositionsWithSeating(t *testing.T) {
+	initTestScene(t)
+	savedObjective, savedRays := learningObjective, evalRayCountOverride
+	defer func() {
+		learningObjective, evalRayCountOverride = savedObjective, savedRays
+		setWorkerCount(1)
+	}()
+	learningObjective, evalRayCountOverride = LearningObjectiveSeatingAverage, 200
+	setWorkerCount(4)
+	if len(seatListeningPositions()) < 2 {
+		t.Fatal("the default scene has no seats to score")
+	}
+
+	positions := make([]Vector3, 8)
+	for i := range positions {
+		positions[i] = soundSource.Position.Add(Vector3{X: float64(i%4) - 1.5, Z: float64(i/4) - 0.5})
+	}
+	done := make(chan []scoredPosition, 1)
+	go func() { done <- scorePositions(soundSource, positions) }()
+	select {
+	case scored := <-done:
+		if len(scored) != len(positions) {
+			t.Fatalf("%d scores for %d positions", len(scored), len(positions))
+		}
+	case <-time.After(60 * time.Second):
+		t.Fatal("scorePositions did not return: nested parallelFor calls deadlocked the pool")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"syscall/js"
+	"time"
+)
+
+// --- Rate-Limited, Categorized Logging ---
+// Console output from WASM is slow: at tens of thousands of learning iterations the logging itself
+// dominates. Chatty messages go through logEvent, which filters by category and rate-limits detail.
+
+type LogCategory int
+
+const (
+	LogOptimizer LogCategory = iota // Learning loop, records, CMA-ES
+	LogCloud                        // Occupancy cloud bookkeeping
+	LogInterop                      // Go <-> JS calls and data exchange
+)
+
+var logCategoryNames = map[LogCategory]string{
+	LogOptimizer: "optimizer",
+	LogCloud:     "cloud",
+	LogInterop:   "interop",
+}
+
+type LogPriority int
+
+const (
+	LogDetail LogPriority = iota // Subject to the per-category rate limit
+	LogNotice                    // Always printed while the category is enabled
+)
+
+const DEFAULT_LOG_DETAIL_PER_SECOND = 20
+
+var (
+	logCategoryDisabled  = map[LogCategory]bool{}
+	logDetailPerSecond   = DEFAULT_LOG_DETAIL_PER_SECOND // 0 suppresses all detail messages
+	logWindowStart       time.Time
+	logWindowCounts      = map[LogCategory]int{}
+	logSuppressedInBatch = map[LogCategory]int{}
+)
+
+func (c LogCategory) String() string {
+	if name, ok := logCategoryNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("category(%d)", int(c))
+}
+
+// logEvent prints a categorized message. Detail messages beyond logDetailPerSecond per category are
+// dropped and summarized once the next one-second window starts.
+func logEvent(category LogCategory, priority LogPriority, format string, args ...interface{}) {
+	if logCategoryDisabled[category] {
+		return
+	}
+	now := time.Now()
+	if now.Sub(logWindowStart) >= time.Second {
+		flushSuppressedLogCounts()
+		logWindowStart = now
+		for c := range logWindowCounts {
+			logWindowCounts[c] = 0
+		}
+	}
+	if priority == LogDetail {
+		if logWindowCounts[category] >= logDetailPerSecond {
+			logSuppressedInBatch[category]++
+			return
+		}
+		logWindowCounts[category]++
+	}
+	log.Printf("["+category.String()+"] "+format, args...)
+}
+
+// flushSuppressedLogCounts reports how many detail messages the last window dropped.
+func flushSuppressedLogCounts() {
+	for category, n := range logSuppressedInBatch {
+		if n > 0 {
+			log.Printf("[%s] %d log messages suppressed by rate limit", category, n)
+		}
+		delete(logSuppressedInBatch, category)
+	}
+}
+
+// parseLogCategory maps a category name from JS to its LogCategory.
+func parseLogCategory(name string) (LogCategory, bool) {
+	for c, n := range logCategoryNames {
+		if strings.EqualFold(n, name) {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// goSetLogCategoryEnabled([category, enabled]) turns a log category ("optimizer", "cloud", "interop") on or off.
+func goSetLogCategoryEnabled(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetLogCategoryEnabled")
+	if len(args) < 2 {
+		log.Println("Error: goSetLogCategoryEnabled expects 2 arguments (category, enabled)")
+		return js.ValueOf(false)
+	}
+	category, ok := parseLogCategory(args[0].String())
+	if !ok {
+		log.Printf("Error: unknown log category %q", args[0].String())
+		return js.ValueOf(false)
+	}
+	logCategoryDisabled[category] = !args[1].Bool()
+	return js.ValueOf(true)
+}
+
+// goSetLogRateLimit([perSecond]) caps detail messages per category per second (0 silences them).
+func goSetLogRateLimit(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetLogRateLimit")
+	if len(args) < 1 {
+		log.Println("Error: goSetLogRateLimit expects 1 argument (messages per second)")
+		return js.ValueOf(false)
+	}
+	perSecond := args[0].Int()
+	if perSecond < 0 {
+		log.Printf("Error: log rate limit must be non-negative, got %d", perSecond)
+		return js.ValueOf(false)
+	}
+	logDetailPerSecond = perSecond
+	return js.ValueOf(true)
+}
@@ -12,11 +12,13 @@ import (
 
 // --- Global Constants ---
 const (
-	MAX_RAY_DISTANCE          float64 = 50.0
+	MAX_RAY_DISTANCE          float64 = 50.0 // Default for maxRayDistance
 	EPSILON                   float64 = 0.00001
-	OPTIMIZATION_STEP_SIZE    float64 = 0.5 // Step size for object movement in optimization
-	FIBONACCI_SCORE_CAP_INDEX int     = 20  // Cap Fibonacci index for scoring
-	BASE_DIRECT_HIT_SCORE     int     = 10  // Score for a direct hit
+	OPTIMIZATION_STEP_SIZE    float64 = 0.5   // Default step size for object movement in optimization
+	FIBONACCI_SCORE_CAP_INDEX int     = 20    // Cap Fibonacci index for scoring
+	BASE_DIRECT_HIT_SCORE     int     = 10    // Score for a direct hit
+	OCCUPANCY_CELL_SIZE       float64 = 0.5   // Edge length of an occupancy cloud cell
+	SPEED_OF_SOUND            float64 = 343.0 // Default speed of sound (m/s, air at ~20 °C); see environment
 )
 
 // --- Global State ---
@@ -48,46 +50,47 @@ var (
 	wallThickness float64 = 0.2
 
 	// Simulation parameters (can be changed by UI)
-	numRays                 int           = 1000
-	initialRayOpacity       float64       = 0.6
-	maxReflections          int           = 3
-	currentWallOpacity      float64       = 1.0  // Opacity for walls/ceiling
-	showOnlyListenerRays    bool          = true // Filter for ray visualization
-	currentDebounceTime     time.Duration = 500 * time.Millisecond
-	debouncedVisualizeFunc  func()               // Debounced version of visualizeSoundPropagation
-	volumeAttenuationFactor float64       = 0.85 // How much opacity reduces per bounce
-	explorationFactor       float64       = 1.0  // Multiplier for randomness in learning
+	numRays                  int           = 1000
+	initialRayOpacity        float64       = 0.6
+	maxReflections           int           = 3
+	currentWallOpacity       float64       = 1.0  // Opacity for walls/ceiling
+	showOnlyListenerRays     bool          = true // Filter for ray visualization
+	currentDebounceTime      time.Duration = 500 * time.Millisecond
+	debouncedVisualizeFunc   func()                // Debounced version of visualizeSoundPropagation
+	volumeAttenuationFactor  float64       = 0.85  // Former per-bounce opacity fade; drawing now follows the energy model (energy_model.go). Kept for records and the UI slider
+	explorationFactor        float64       = 1.0   // Multiplier for randomness in learning
+	useVisibilityPrefilter   bool          = false // Skip learning candidates that lose line of sight (cloud DDA check)
+	useSmartInitialPlacement bool          = true  // Seed learning from heuristic source/listener placements
+
+	// Ray termination (see ray_termination.go for bounds)
+	maxRayDistance   float64 = MAX_RAY_DISTANCE // Longest single ray segment that is traced
+	rayOpacityCutoff float64 = 0.01             // Segments fainter than this are not drawn (display only)
+	reflectionOffset float64 = 0.01             // Distance reflected rays start off the surface (along its normal), avoiding self-hits
+	rayEnergyCutoff  float64 = 0.001            // Physical energy fraction below which rays face Russian roulette
+	maxDecayTimeMs   float64 = 0                // Paths end once sound has travelled this long (0 = no limit); see maxPathLength
 
 	// Learning Mode State
 	learningModeActive       bool = false
 	currentLearningIteration int
 	maxLearningIterations    int               = 50000
-	globalBestScore          int               = -1                   // Stores the highest score found during learning
-	globalBestSettings       BestScoreSettings                        // Stores all settings related to globalBestScore
-	isSoundSourceTurn        bool              = true                 // For alternating moves in learning mode
-	randomJumpProbability    float64           = 0.1                  // Base probability of a random jump if no improvement
-	autoTurnDelay            time.Duration     = 5 * time.Microsecond // Delay between learning turns
-
-	// Ray colors
-	bounceColors = []uint32{
-		0xffff00, // 0 bounces (direct - though listenerRayColor often overrides)
-		0xffa500, // 1 bounce
-		0xff00ff, // 2 bounces
-		0x00ffff, // 3 bounces
-		0x00fa9a, // 4
-		0xdda0dd, // 5
-		0xfa8072, // 6
-		0xadd8e6, // 7
-		0xf0e68c, // 8
-		0x90ee90, // 9
-		0xffc0cb, // 10
-		// Add more if maxReflections can be higher and distinct colors are desired
-	}
-	listenerRayColor uint32 = 0x00ff00 // Green for rays hitting the listener
+	globalBestScore          int               = -1                     // Stores the highest score found during learning
+	globalBestSettings       BestScoreSettings                          // Stores all settings related to globalBestScore
+	isSoundSourceTurn        bool              = true                   // For alternating moves in learning mode
+	randomJumpProbability    float64           = 0.1                    // Base probability of a random jump if no improvement
+	optimizationStepSize     float64           = OPTIMIZATION_STEP_SIZE // Lattice step of coordinate moves and unit of random jumps
+	autoTurnDelay            time.Duration     = 5 * time.Microsecond   // Delay between learning turns
+	learningStrategy         string            = "coordinate"           // "coordinate" (alternating lattice moves), "cmaes", "coarseToFine" or "twoPhase"
+	learningTimeBudget       time.Duration                              // If > 0, learn for this wall-clock time instead of maxLearningIterations
+	evalRayCountOverride     int                                        // If > 0, rays per optimizer evaluation (set by the time budget controller)
+
+	// Ray colors of the active palette (see palettes.go and goSetPalette)
+	bounceColors            = rayPalettes[DEFAULT_PALETTE].Bounce
+	listenerRayColor uint32 = rayPalettes[DEFAULT_PALETTE].Listener
 
 	// Precomputed data
-	fibonacciSequence []int         // Stores Fibonacci numbers for scoring
-	recordsManager    RecordManager // Manages best score records
+	fibonacciSequence []int           // Stores Fibonacci numbers for scoring
+	recordsManager    RecordManager   // Manages best score records
+	occupancyCloud    *OccupancyCloud // Discretized room used for placement validity checks
 )
 
 func precomputeFibonacci(n int) {
@@ -113,7 +116,7 @@ func recoverFromPanic(funcName string) {
 		if funcName == "runLearningCycle" || funcName == "findAndApplyBestMoveForLearning" {
 			if learningModeActive {
 				learningModeActive = false
-				jsGlobal.Call("updateLearningButton", false, "Start Learning (Coop. Maximize)")
+				callOptionalJS("updateLearningButton", false, "Start Learning (Coop. Maximize)")
 			}
 		}
 	}
@@ -132,6 +135,7 @@ func main() {
 	defer recoverFromPanic("main") // Catch panics in the main setup
 
 	jsGlobal = js.Global()
+	initEmbeddedMode()
 	log.Println("Go WASM Initializing...")
 	rand.Seed(time.Now().UnixNano()) // Seed random number generator
 
@@ -139,25 +143,124 @@ func main() {
 	recordsManager = *NewRecordManager(10) // Store top 10 records
 
 	createSceneContent() // Initialize 3D objects
+	initOccupancyCloud() // Build the occupancy grid from the static scene
+	initWorkerPool()     // Size evaluation workers from navigator.hardwareConcurrency
 
 	// --- Register Go functions to be callable from JavaScript ---
-	jsGlobal.Set("goUpdateSliderValue", js.FuncOf(goUpdateSliderValue))
-	jsGlobal.Set("goUpdateToggleValue", js.FuncOf(goUpdateToggleValue))
-	jsGlobal.Set("goTriggerVisualizeSound", js.FuncOf(goTriggerVisualizeSound))
-	jsGlobal.Set("goTriggerClearRays", js.FuncOf(goTriggerClearRays))
-	jsGlobal.Set("goUpdateCameraState", js.FuncOf(goUpdateCameraState)) // For JS to inform Go about camera changes
-	jsGlobal.Set("goUpdateSoundSourcePositionAndVisualize", js.FuncOf(goUpdateSoundSourcePositionAndVisualize))
-	jsGlobal.Set("goUpdateListenerPositionAndVisualize", js.FuncOf(goUpdateListenerPositionAndVisualize))
+	exportJSFunc("goUpdateSliderValue", goUpdateSliderValue)
+	exportJSFunc("goUpdateToggleValue", goUpdateToggleValue)
+	exportJSFunc("goTriggerVisualizeSound", goTriggerVisualizeSound)
+	exportJSFunc("goTriggerClearRays", goTriggerClearRays)
+	exportJSFunc("goUpdateCameraState", goUpdateCameraState) // For JS to inform Go about camera changes
+	exportJSFunc("goUpdateSoundSourcePositionAndVisualize", goUpdateSoundSourcePositionAndVisualize)
+	exportJSFunc("goUpdateListenerPositionAndVisualize", goUpdateListenerPositionAndVisualize)
 
 	// Learning mode JS functions
-	jsGlobal.Set("goStartLearningMode", js.FuncOf(goStartLearningMode))
-	jsGlobal.Set("goStopLearningMode", js.FuncOf(goStopLearningMode))
-	jsGlobal.Set("goApplyRecordedSettingsByIndex", js.FuncOf(goApplyRecordedSettingsByIndex))
+	exportJSFunc("goStartLearningMode", goStartLearningMode)
+	exportJSFunc("goStopLearningMode", goStopLearningMode)
+	exportJSFunc("goSetLearningStrategy", goSetLearningStrategy)
+	exportJSFunc("goSetLearningTimeBudget", goSetLearningTimeBudget)
+	exportJSFunc("goEvaluatePlacements", goEvaluatePlacements)
+	exportJSFunc("goApplyRecordedSettingsByIndex", goApplyRecordedSettingsByIndex)
 	// jsGlobal.Set("goToggleAutoOptimization", js.FuncOf(goToggleAutoOptimization)) // If you add another optimization mode
 
+	// Occupancy cloud JS functions
+	exportJSFunc("goAddForbiddenZone", goAddForbiddenZone)
+	exportJSFunc("goRemoveForbiddenZone", goRemoveForbiddenZone)
+	exportJSFunc("goClearForbiddenZones", goClearForbiddenZones)
+	exportJSFunc("goGetForbiddenZones", goGetForbiddenZones)
+	exportJSFunc("goCheckCloudVisibility", goCheckCloudVisibility)
+	exportJSFunc("goQueryCloudState", goQueryCloudState)
+	exportJSFunc("goGetListenerPlacementField", goGetListenerPlacementField)
+	exportJSFunc("goQuerySDF", goQuerySDF)
+	exportJSFunc("goAddSoundSource", goAddSoundSource)
+	exportJSFunc("goRemoveSoundSource", goRemoveSoundSource)
+	exportJSFunc("goSetSourceGain", goSetSourceGain)
+	exportJSFunc("goSetSourceMuted", goSetSourceMuted)
+	exportJSFunc("goGetSoundSources", goGetSoundSources)
+	exportJSFunc("goSetSourceRaysVisible", goSetSourceRaysVisible)
+	exportJSFunc("goComputeSourceDelays", goComputeSourceDelays)
+	exportJSFunc("goEstimateCombFilter", goEstimateCombFilter)
+	exportJSFunc("goGetWaterfall", goGetWaterfall)
+	exportJSFunc("goSetLogCategoryEnabled", goSetLogCategoryEnabled)
+	exportJSFunc("goSetLogRateLimit", goSetLogRateLimit)
+	exportJSFunc("goSetRecordDiversity", goSetRecordDiversity)
+	exportJSFunc("goAnimateToRecord", goAnimateToRecord)
+	exportJSFunc("goInterpolateRecords", goInterpolateRecords)
+	exportJSFunc("goRegisterHook", goRegisterHook)
+	exportJSFunc("goUnregisterHook", goUnregisterHook)
+	exportJSFunc("goSetGPUOffload", goSetGPUOffload)
+	exportJSFunc("goSetWorkerCount", goSetWorkerCount)
+	exportJSFunc("goSetVisualQualityMode", goSetVisualQualityMode)
+	exportJSFunc("goAuralize", goAuralize)
+	exportJSFunc("goGetImpactMarkers", goGetImpactMarkers)
+	exportJSFunc("goGetSweetSpot", goGetSweetSpot)
+	exportJSFunc("goClearSweetSpot", goClearSweetSpot)
+	exportJSFunc("goGetSeatScores", goGetSeatScores)
+	exportJSFunc("goSetSeating", goSetSeating)
+	exportJSFunc("goSetLearningObjective", goSetLearningObjective)
+	exportJSFunc("goLockParameter", goLockParameter)
+	exportJSFunc("goPreviewRecord", goPreviewRecord)
+	exportJSFunc("goSetObjectMaterial", goSetObjectMaterial)
+	exportJSFunc("goGetMaterialPresets", goGetMaterialPresets)
+	exportJSFunc("goGetSessionStats", goGetSessionStats)
+	exportJSFunc("goApplyScenePatch", goApplyScenePatch)
+	exportJSFunc("goGetArrivalMarkers", goGetArrivalMarkers)
+	exportJSFunc("goGetCameraTour", goGetCameraTour)
+	exportJSFunc("goSetPalette", goSetPalette)
+	exportJSFunc("goGetPalettes", goGetPalettes)
+	exportJSFunc("goRenameObject", goRenameObject)
+	exportJSFunc("goComputeImpulseResponse", goComputeImpulseResponse)
+	exportJSFunc("goEstimateSimulationCost", goEstimateSimulationCost)
+	exportJSFunc("goSetSimulationBudget", goSetSimulationBudget)
+	exportJSFunc("goGetEchogram", goGetEchogram)
+	exportJSFunc("goGetClarityMetrics", goGetClarityMetrics)
+	exportJSFunc("goStartProgressiveHeatmap", goStartProgressiveHeatmap)
+	exportJSFunc("goCancelProgressiveHeatmap", goCancelProgressiveHeatmap)
+	exportJSFunc("goGetSTI", goGetSTI)
+	exportJSFunc("goGetFirstReflectionPatches", goGetFirstReflectionPatches)
+	exportJSFunc("goSetSourceDirectivity", goSetSourceDirectivity)
+	exportJSFunc("goRenderExposureMapPNG", goRenderExposureMapPNG)
+	exportJSFunc("goSetPrivacyPairs", goSetPrivacyPairs)
+	exportJSFunc("goGetSpeechPrivacy", goGetSpeechPrivacy)
+	exportJSFunc("goGetVenueIntelligibility", goGetVenueIntelligibility)
+	exportJSFunc("goSetEnvironment", goSetEnvironment)
+	exportJSFunc("goGetScoreMemory", goGetScoreMemory)
+	exportJSFunc("goSetMaterialTermination", goSetMaterialTermination)
+	exportJSFunc("goSetSourceExtent", goSetSourceExtent)
+	exportJSFunc("goSetMaterialImpedance", goSetMaterialImpedance)
+	exportJSFunc("goCalibrateListenerRadius", goCalibrateListenerRadius)
+	exportJSFunc("goSaveOptimizerProfile", goSaveOptimizerProfile)
+	exportJSFunc("goExportOptimizerProfile", goExportOptimizerProfile)
+	exportJSFunc("goImportOptimizerProfile", goImportOptimizerProfile)
+	exportJSFunc("goApplyOptimizerProfile", goApplyOptimizerProfile)
+	exportJSFunc("goListOptimizerProfiles", goListOptimizerProfiles)
+	exportJSFunc("goSetMaterialScattering", goSetMaterialScattering)
+	exportJSFunc("goGetLearningClock", goGetLearningClock)
+	exportJSFunc("goShutdown", goShutdown)
+	exportJSFunc("goRestoreState", goRestoreState)
+	exportJSFunc("goRunScenarioSuite", goRunScenarioSuite)
+	exportJSFunc("goGetRayConvergence", goGetRayConvergence)
+	exportJSFunc("goGetProfile", goGetProfile)
+	exportJSFunc("goBenchmarkRaycaster", goBenchmarkRaycaster)
+	exportJSFunc("goBeginScene", goBeginScene)
+	exportJSFunc("goSyncExperiments", goSyncExperiments)
+
+	// Scene layout tools
+	exportJSFunc("goSwapSourceListener", goSwapSourceListener)
+	exportJSFunc("goMirrorScene", goMirrorScene)
+	exportJSFunc("goSwitchActiveScene", goSwitchActiveScene)
+	exportJSFunc("goCompareSceneSlots", goCompareSceneSlots)
+
+	// Analysis JS functions
+	exportJSFunc("goComputeHeightProfile", goComputeHeightProfile)
+	exportJSFunc("goRenderCoverageHeatmapPNG", goRenderCoverageHeatmapPNG)
+	exportJSFunc("goRenderEnergySlicePNG", goRenderEnergySlicePNG)
+	exportJSFunc("goGetSceneStatistics", goGetSceneStatistics)
+
 	debouncedVisualizeFunc = debounce(visualizeSoundPropagation, currentDebounceTime)
 
-	jsGlobal.Call("goWasmReady") // Signal to JS that WASM is ready
+	callOptionalJS("goWasmReady") // Signal to JS that WASM is ready
 
 	// Perform initial visualization
 	go func() { // Run in a goroutine to avoid blocking main, though JS interop needs care
@@ -166,8 +269,10 @@ func main() {
 		updateRayLegendJS()
 	}()
 
-	log.Println("Go WASM setup complete. Entering blocking select to keep alive.")
-	select {} // Keep the Go program running (WASM requirement)
+	log.Println("Go WASM setup complete. Running until goShutdown.")
+	<-shutdownRequested // Keep the Go program running (WASM requirement) until the host unloads it
+	releaseResources()
+	log.Println("Go WASM shut down.")
 }
 
 // --- JS Interop Functions (Callbacks from JavaScript) ---
@@ -211,16 +316,88 @@ func goUpdateSliderValue(this js.Value, args []js.Value) interface{} {
 		}
 	// Ray & Simulation Parameters
 	case "numRays":
-		numRays = int(value)
+		if withinSimulationBudget(int(value), maxReflections) {
+			numRays = int(value)
+			if autoCalibrateCapture && calibratedCaptureRadius > 0 {
+				calibrateCaptureRadius(captureTargetHits)
+			}
+		} else {
+			needsVisualUpdate = false
+			resyncUISliders() // Snap the slider back to the value in effect
+		}
 	case "rayOpacity":
 		initialRayOpacity = value
 	case "maxBounces":
-		maxReflections = int(value)
-		updateRayLegendJS() // Legend depends on max bounces
+		if withinSimulationBudget(numRays, int(value)) {
+			maxReflections = int(value)
+			updateRayLegendJS() // Legend depends on max bounces
+		} else {
+			needsVisualUpdate = false
+			resyncUISliders() // Snap the slider back to the value in effect
+		}
 	case "volume": // This is volumeAttenuationFactor
 		volumeAttenuationFactor = value
 	case "explorationFactor":
 		explorationFactor = value
+	case "maxRayDistance":
+		maxRayDistance = boundedParam(sliderName, value, MIN_RAY_DISTANCE_LIMIT, MAX_RAY_DISTANCE_LIMIT)
+	case "rayOpacityCutoff":
+		rayOpacityCutoff = boundedParam(sliderName, value, MIN_RAY_CUTOFF, MAX_RAY_CUTOFF)
+	case "rayEnergyCutoff":
+		rayEnergyCutoff = boundedParam(sliderName, value, MIN_RAY_CUTOFF, MAX_RAY_CUTOFF)
+	case "decayTime": // Milliseconds of travel after which paths end, 0 for no limit
+		previous := maxDecayTimeMs
+		maxDecayTimeMs = boundedParam(sliderName, value, 0, MAX_DECAY_TIME_MS)
+		if !withinSimulationBudget(numRays, maxReflections) { // A longer limit lets deep passes run long
+			maxDecayTimeMs = previous
+			needsVisualUpdate = false
+			resyncUISliders() // Snap the slider back to the value in effect
+		}
+	case "reflectionOffset":
+		reflectionOffset = boundedParam(sliderName, value, MIN_REFLECTION_OFFSET, MAX_REFLECTION_OFFSET)
+	case "airTemperature":
+		setAirConditions(value, environment.HumidityPct)
+	case "airHumidity":
+		setAirConditions(environment.TemperatureC, value)
+	case "sourceYaw":
+		setSourceAim(value, sourceDirectivity.PitchDeg)
+	case "sourcePitch":
+		setSourceAim(sourceDirectivity.YawDeg, value)
+	case "sourceAperture": // Full opening angle of a cone source, in degrees
+		sourceDirectivity.ApertureDeg = boundedParam(sliderName, value, 1, 360)
+	case "listenerYaw": // Direction the listener faces, in degrees about +Y
+		listenerYawDeg = value
+	case "listenerRearGain": // Weight of arrivals from behind; 1 is an omnidirectional listener
+		listenerRearGain = boundedParam(sliderName, value, 0, 1)
+		updateRayLegendJS()
+	case "twoPhaseExploreFraction": // Share of the learning budget spent on random search
+		needsVisualUpdate = false
+		twoPhaseExploreFraction = boundedParam(sliderName, value, 0, 1)
+	case "rayTolerance": // Relative standard error at which adaptive rays stop
+		adaptiveRayTolerance = boundedParam(sliderName, value, MIN_ADAPTIVE_TOLERANCE, MAX_ADAPTIVE_TOLERANCE)
+	case "neeScattering": // Diffuse share next-event estimation credits at surfaces without a scattering coefficient
+		neeDefaultScattering = boundedParam(sliderName, value, 0, 1)
+	case "raySplitDepth": // Nested specular/diffuse splits per path
+		raySplitMaxDepth = int(boundedParam(sliderName, math.Round(value), 0, MAX_SPLIT_DEPTH))
+	case "diffuseChildren": // Diffuse rays per split
+		diffuseChildCount = int(boundedParam(sliderName, math.Round(value), 1, MAX_DIFFUSE_CHILDREN))
+	case "sourceSamples": // Emitting points per pass for sources with an extent
+		sourceSampleCount = int(boundedParam(sliderName, math.Round(value), 1, MAX_SOURCE_SAMPLES))
+	case "furnitureRotationStep": // Degrees between candidate furniture orientations
+		needsVisualUpdate = false
+		setFurnitureRotationStep(value)
+	// Clearance margins around the dynamic objects (occupancy cloud padding)
+	case "soundSourceMargin", "listenerMargin":
+		needsVisualUpdate = false // Only affects placement validity, not the rays
+		if occupancyCloud != nil {
+			state := StateSoundSource
+			if sliderName == "listenerMargin" {
+				state = StateListener
+			}
+			if occupancyCloud.SetClearanceMargin(state, value) {
+				resyncDynamicObjectsInCloud()
+			}
+		}
 	// Environment & Performance
 	case "wallOpacity":
 		currentWallOpacity = value
@@ -228,8 +405,8 @@ func goUpdateSliderValue(this js.Value, args []js.Value) interface{} {
 			wallObj.Material.Color[3] = float32(currentWallOpacity)
 			wallObj.Material.IsTransparent = currentWallOpacity < 1.0
 		}
-		needsVisualUpdate = false      // Does not require re-casting rays, just re-render
-		jsGlobal.Call("requestRender") // Tell JS to re-render the scene graph
+		needsVisualUpdate = false       // Does not require re-casting rays, just re-render
+		callOptionalJS("requestRender") // Tell JS to re-render the scene graph
 	case "debounceTime":
 		newDebounceTime := time.Duration(int(value)) * time.Millisecond
 		if newDebounceTime != currentDebounceTime {
@@ -290,10 +467,48 @@ func goUpdateToggleValue(this js.Value, args []js.Value) interface{} {
 	switch toggleName {
 	case "showOnlyListenerRays":
 		showOnlyListenerRays = checked
-		if !learningModeActive {
+		// Only the filter changed, so re-derive the rays from the last traced pass
+		refreshRayVisualsFromCache()
+	case "visibilityPrefilter":
+		useVisibilityPrefilter = checked
+	case "smartInitialPlacement":
+		useSmartInitialPlacement = checked
+	case "bidirectionalTracing":
+		bidirectionalTracing = checked
+		debouncedVisualizeFunc()
+	case "simulationMode": // Checked selects the hybrid image-source + ray tracing mode
+		hybridSimulation = checked
+		debouncedVisualizeFunc()
+	case "optimizeFurniture": // Learning also moves and turns non-static furniture
+		optimizeFurniture = checked
+	case "scoreMemory": // Learning skips cells its score memory has written off
+		useScoreMemory = checked
+	case "adaptiveRays": // Stop tracing once the listener score has converged
+		adaptiveRays = checked
+		debouncedVisualizeFunc()
+	case "profiling": // Time intersection, reflection, scoring and JS transfer (see goGetProfile)
+		profilingEnabled.Store(checked)
+	case "parallelRays": // Trace the visualization pass on the worker pool
+		parallelRayCasting = checked
+	case "rayGrid": // Walk the occupancy cloud instead of testing every object against every ray
+		useRayGrid = checked
+	case "stratifiedSampling": // Jitter every ray direction within its cell of the spiral
+		setStratifiedSampling(checked)
+		debouncedVisualizeFunc()
+	case "nextEventEstimation": // Credit every reflection through a shadow ray to the listener
+		useNextEventEstimation = checked
+		debouncedVisualizeFunc()
+	case "bidirectionalConnect": // Join source and listener rays at mutually visible reflection points
+		bidirectionalConnect = checked
+		debouncedVisualizeFunc()
+	case "raySplitting": // Split reflections off scattering surfaces into specular and diffuse rays
+		raySplitting = checked
+		debouncedVisualizeFunc()
+	case "autoListenerRadius": // Keep the calibrated capture radius in step with numRays
+		autoCalibrateCapture = checked
+		if checked {
+			calibrateCaptureRadius(captureTargetHits)
 			debouncedVisualizeFunc()
-		} else {
-			visualizeSoundPropagation()
 		}
 	default:
 		log.Printf("Unknown toggle: %s", toggleName)
@@ -334,106 +549,215 @@ func goUpdateCameraState(this js.Value, args []js.Value) interface{} {
 
 func clearRayVisualsAndNotifyJS() {
 	defer recoverFromPanic("clearRayVisualsAndNotifyJS")
-	rayVisuals = []*RayLine{}      // Clear the Go-side ray data
-	jsGlobal.Call("clearRaysJS")   // Tell JS to clear Three.js ray objects
-	jsGlobal.Call("requestRender") // Tell JS to re-render the (now empty of rays) scene
+	rayVisuals = []*RayLine{} // Clear the Go-side ray data
+	tracedSegments = nil
+	callOptionalJS("clearRaysJS")   // Tell JS to clear Three.js ray objects
+	callOptionalJS("requestRender") // Tell JS to re-render the (now empty of rays) scene
+}
+
+// refreshRayVisualsFromCache re-applies visualization filters to the last pass and re-renders
+// without re-tracing. Falls back to a full pass if nothing has been traced yet.
+func refreshRayVisualsFromCache() {
+	defer recoverFromPanic("refreshRayVisualsFromCache")
+	if !refreshCachedPass() {
+		visualizeSoundPropagation()
+	}
+}
+
+// refreshCachedPass rebuilds and renders the ray visuals of the last pass, holding off new
+// passes meanwhile. Returns false if nothing has been traced yet.
+func refreshCachedPass() bool {
+	passMu.Lock()
+	defer passMu.Unlock()
+	if len(tracedSegments) == 0 {
+		return false
+	}
+	rebuildRayVisualsFromCache()
+	renderScene()
+	return true
 }
 
 // --- Core Simulation & Visualization Logic ---
-func visualizeSoundPropagation() {
+
+// runVisualizationPass traces every source's rays, scores the listener and renders the result.
+// Call it through visualizeSoundPropagation, which keeps passes from overlapping.
+func runVisualizationPass() {
 	defer recoverFromPanic("visualizeSoundPropagation")
 
+	if shuttingDown {
+		return
+	}
 	if soundSource == nil || listener == nil {
 		log.Println("Sound source or listener is nil, cannot visualize.")
 		return
 	}
 
-	rayVisuals = []*RayLine{} // Clear previous rays before new calculation
-	currentWeightedScore := 0
+	passStart := time.Now()
+	beginPassProfile()
+	tracedSegments = tracedSegments[:0] // Clear previous rays before new calculation
+	impactPoints = impactPoints[:0]
+	arrivalPoints = arrivalPoints[:0]
+	resetListenerCaptures()
+	resetRaySplitBudget(numRays * len(soundSources))
+	imagePathsDrawn = 0
 
-	sourcePos := soundSource.Position
 	listenerPos := listener.Position
-	listenerRadius := listener.Scale.X // Assuming uniform scale for listener sphere
-
-	// Prepare collidable objects (all except the source itself for the first ray segment)
-	var collidables []*SceneObject
-	for _, obj := range allSceneObjects {
-		if obj != soundSource { // Direct rays from source don't collide with source itself
-			collidables = append(collidables, obj)
+	listenerRadius := listenerCaptureRadius()
+	weightedScore := 0.0
+	totalHookBonus := 0.0
+	raysEmitted, raysTraced := 0, 0
+	passConvergence := RayConvergence{}
+
+	for _, source := range soundSources {
+		gain := sourceEnergyGain(source)
+		if gain == 0 { // Muted sources emit nothing
+			continue
 		}
-	}
+		sourcePos := source.Position
 
-	for i := 0; i < numRays; i++ {
-		// Fibonacci sphere algorithm for even ray distribution
-		phi := math.Acos(-1 + (2*float64(i))/float64(numRays))
-		theta := math.Sqrt(float64(numRays)*math.Pi) * phi
-		direction := SetFromSphericalCoords(1, phi, theta).Normalize()
-
-		hitData := castRayAndAddVisuals(sourcePos, direction, 0, collidables, listenerPos, listenerRadius)
-		if hitData.hitListener {
-			if hitData.bounces == 0 {
-				currentWeightedScore += BASE_DIRECT_HIT_SCORE
-			} else {
-				fibIndex := hitData.bounces
-				if fibIndex > FIBONACCI_SCORE_CAP_INDEX {
-					fibIndex = FIBONACCI_SCORE_CAP_INDEX
-				}
-				if fibIndex >= 0 && fibIndex < len(fibonacciSequence) {
-					currentWeightedScore += fibonacciSequence[fibIndex]
-				}
+		sets := collidableSetsFor(source) // Direct rays from source don't collide with source itself
+		emitters := emitterPoints(source, sourcePos)
+		hookScoreBonus = 0
+		traces := make([]RayTrace, numRays)
+
+		convergence := traceAdaptively(numRays, adaptiveRays, tracesInParallel(), func(i int) float64 {
+			if shuttingDown {
+				return 0
 			}
+			direction := sphereDirection(i, numRays, stratifiedSampling)
+
+			emitted := emissionGain(source, direction)
+			if emitted == 0 { // Outside a cone source's aperture
+				return 0
+			}
+			trace := &traces[i]
+			trace.PathID, trace.RayIndex = raysEmitted+i, i
+			origin := emitters[i%len(emitters)]
+			if len(tracerHooks) > 0 {
+				fireRayHooks(HookOnEmit, RayEvent{Source: source, RayIndex: i, Energy: emitted, Point: origin, Direction: direction})
+			}
+			castRayAndAddVisuals(origin, direction, 0, emitted, 0, sets.Direct, listenerPos, listenerRadius, sets, trace)
+			return trace.score()
+		})
+		if shuttingDown { // Abandon the pass; nothing is drawn any more
+			return
+		}
+		for i := range traces {
+			mergeRayTrace(&traces[i])
+		}
+		sourceScore := convergence.Score
+		passConvergence.addConvergence(convergence, gain)
+		raysTraced += convergence.Rays
+		if hybridSimulation {
+			paths := earlyImagePaths(source, sourcePos, listenerPos)
+			sourceScore += imagePathScore(paths, listenerRadius, numRays)
+			addImagePathVisuals(paths, source)
+		}
+		if bidirectionalConnect {
+			sourceScore += connectionScore(source, sourcePos, listenerPos, listenerRadius, evaluationDirectionsFor(numRays))
+		}
+		if bidirectionalTracing {
+			sourceScore = float64(combineBidirectional(sourceScore, reverseTraceScore(source, sourcePos, listenerPos, listenerRadius, numRays)))
 		}
+		weightedScore += gain * (sourceScore + hookScoreBonus)
+		totalHookBonus += gain * hookScoreBonus
+		raysEmitted += numRays
 	}
+	currentWeightedScore := int(math.Round(weightedScore))
+	lastRayConvergence = passConvergence
+	recordPassCost(raysTraced, time.Since(passStart))
+	lastClarity = clarityFromArrivals(arrivalPoints)
+	sessionStats.Passes.Add(1)
+	sessionStats.VisualRays.Add(int64(raysTraced))
 
 	listenerRayScore = currentWeightedScore
+	rebuildRayVisualsFromCache()
+	if len(tracerHooks) > 0 {
+		firePassCompleteHooks(PassSummary{Score: listenerRayScore, HookBonus: totalHookBonus, Rays: raysTraced, Segments: len(tracedSegments)})
+	}
 
 	// If in learning mode, check if this is a new best score
-	if learningModeActive && listenerRayScore > globalBestScore {
-		globalBestScore = listenerRayScore
+	learningScore := listenerRayScore
+	if learningModeActive && learningObjective != LearningObjectiveListener {
+		learningScore = objectiveScore(soundSource.Position, listener.Position)
+	}
+	if learningModeActive && learningScore > globalBestScore {
+		globalBestScore = learningScore
 
 		// Capture all settings that led to this new best score
-		currentSettingsSnapshot := BestScoreSettings{
-			Score:                   globalBestScore,
-			Iteration:               currentLearningIteration,
-			NumRays:                 numRays,
-			InitialRayOpacity:       initialRayOpacity,
-			MaxReflections:          maxReflections,
-			VolumeAttenuationFactor: volumeAttenuationFactor,
-			ExplorationFactor:       explorationFactor,
-			SoundSourcePos:          soundSource.Position, // Current position that yielded this score
-			ListenerPos:             listener.Position,    // Current position
-			ShowOnlyListenerRays:    showOnlyListenerRays,
-			// AllObjectSnapshots:   takeSnapshots(), // If you want to save the state of ALL objects
-		}
+		currentSettingsSnapshot := currentSettings(globalBestScore)
 		recordsManager.AddRecord(currentSettingsSnapshot) // Add to historical records list
-		globalBestSettings = currentSettingsSnapshot      // This is the current best for this learning session
+		sessionStats.recordBestScore(globalBestScore, currentLearningIteration)
+		globalBestSettings = currentSettingsSnapshot // This is the current best for this learning session
 
-		log.Printf("New global best score in learning: %d (S: %.1f,%.1f,%.1f L: %.1f,%.1f,%.1f)",
+		logEvent(LogOptimizer, LogNotice, "New global best score in learning: %d (S: %.1f,%.1f,%.1f L: %.1f,%.1f,%.1f)",
 			globalBestScore,
 			soundSource.Position.X, soundSource.Position.Y, soundSource.Position.Z,
 			listener.Position.X, listener.Position.Y, listener.Position.Z)
-		jsGlobal.Call("updateLearningProgress", currentLearningIteration, maxLearningIterations, globalBestScore)
+		reportLearningProgress()
 		// No need to call updateRecordsDisplay here, AddRecord does it.
 	}
 
 	// Update JS display with current score and render the scene
-	jsGlobal.Call("updateListenerRayCountJS", listenerRayScore)
-	jsGlobal.Call("renderSceneJS", prepareSceneDataJS(), prepareRayDataJS())
+	callOptionalJS("updateListenerRayCountJS", listenerRayScore)
+	renderScene()
+	endPassProfile(time.Since(passStart), raysTraced)
+}
+
+// currentSettings captures the settings and placement in effect, recorded with score.
+func currentSettings(score int) BestScoreSettings {
+	return BestScoreSettings{
+		Score:                   score,
+		Iteration:               currentLearningIteration,
+		NumRays:                 numRays,
+		CaptureRadius:           calibratedCaptureRadius,
+		InitialRayOpacity:       initialRayOpacity,
+		MaxReflections:          maxReflections,
+		VolumeAttenuationFactor: volumeAttenuationFactor,
+		ExplorationFactor:       explorationFactor,
+		SoundSourcePos:          soundSource.Position, // Current position that yielded this score
+		ListenerPos:             listener.Position,    // Current position
+		SourceYawDeg:            sourceDirectivity.YawDeg,
+		SourcePitchDeg:          sourceDirectivity.PitchDeg,
+		ShowOnlyListenerRays:    showOnlyListenerRays,
+		SceneHash:               computeSceneHash(),
+		OptimizerProfile:        currentOptimizerProfile(activeOptimizerProfile),
+		FurniturePoses:          furniturePoses(),
+		// AllObjectSnapshots:   takeSnapshots(), // If you want to save the state of ALL objects
+	}
 }
 
 // --- Data Preparation for JavaScript ---
 
+// renderScene hands the scene and the current rays to three.js.
+func renderScene() {
+	if profilingEnabled.Load() {
+		defer profileSince(ProfileTransfer, time.Now())
+	}
+	jsGlobal.Call("renderSceneJS", prepareSceneDataJS(), prepareRayDataJS())
+}
+
 func prepareSceneDataJS() js.Value {
 	defer recoverFromPanic("prepareSceneDataJS")
 	jsObjects := make([]interface{}, len(allSceneObjects))
 	for i, obj := range allSceneObjects {
-		jsObjects[i] = map[string]interface{}{
+		jsObject := map[string]interface{}{
 			"name": obj.Name, "type": obj.ShapeType,
 			"position": map[string]interface{}{"x": obj.Position.X, "y": obj.Position.Y, "z": obj.Position.Z},
 			"scale":    map[string]interface{}{"x": obj.Scale.X, "y": obj.Scale.Y, "z": obj.Scale.Z},
 			"rotation": map[string]interface{}{"x": obj.Rotation.X, "y": obj.Rotation.Y, "z": obj.Rotation.Z}, // Degrees
 			"color":    map[string]interface{}{"r": obj.Material.Color[0], "g": obj.Material.Color[1], "b": obj.Material.Color[2], "a": obj.Material.Color[3]},
 		}
+		if obj.Mesh != nil {
+			jsObject["mesh"] = meshToJS(obj.Mesh)
+		}
+		if obj == listener && !listenerIsSphere() { // Show which way an ellipsoidal listener faces
+			jsObject["rotation"] = map[string]interface{}{"x": obj.Rotation.X, "y": obj.Rotation.Y + listenerYawDeg, "z": obj.Rotation.Z}
+		}
+		jsObjects[i] = jsObject
+	}
+	if sweetSpot != nil {
+		jsObjects = append(jsObjects, sweetSpotMarkerJS(sweetSpot))
 	}
 	return js.ValueOf(jsObjects)
 }
@@ -443,10 +767,13 @@ func prepareRayDataJS() js.Value {
 	jsRays := make([]interface{}, len(rayVisuals))
 	for i, ray := range rayVisuals {
 		jsRays[i] = map[string]interface{}{
-			"start":   map[string]interface{}{"x": ray.Start.X, "y": ray.Start.Y, "z": ray.Start.Z},
-			"end":     map[string]interface{}{"x": ray.End.X, "y": ray.End.Y, "z": ray.End.Z},
-			"color":   float64(ray.Color), // Pass color as a number (hex)
-			"opacity": ray.Opacity,
+			"start":      map[string]interface{}{"x": ray.Start.X, "y": ray.Start.Y, "z": ray.Start.Z},
+			"end":        map[string]interface{}{"x": ray.End.X, "y": ray.End.Y, "z": ray.End.Z},
+			"color":      float64(ray.Color), // Pass color as a number (hex)
+			"opacity":    ray.Opacity,
+			"sourceId":   ray.SourceID,
+			"pathLength": ray.PathLength,
+			"arrivalMs":  ray.ArrivalMs,
 		}
 	}
 	return js.ValueOf(jsRays)
@@ -458,7 +785,7 @@ func prepareRayDataJS() js.Value {
 // For now, learning mode is the primary "auto optimization".
 func goToggleAutoOptimization(this js.Value, args []js.Value) interface{} {
 	defer recoverFromPanic("goToggleAutoOptimization")
-	log.Println("General auto-optimization toggle called, but learning mode is primary focus. Use 'Start/Stop Learning' button.")
+	logEvent(LogInterop, LogNotice, "General auto-optimization toggle called, but learning mode is primary focus. Use 'Start/Stop Learning' button.")
 	// If you had a simpler, non-iterative auto-optimization, you might toggle it here.
 	return nil
 }
@@ -477,13 +804,18 @@ func updateRayLegendJS() {
 
 	// Add listener ray color first
 	legendData = append(legendData, map[string]interface{}{
-		"color": float64(listenerRayColor), // Ensure color is float64 for JS
-		"label": "Reaches Listener",
+		"color":   float64(listenerRayColor), // Ensure color is float64 for JS
+		"label":   "Reaches Listener",
+		"opacity": 1.0,
 	})
+	if entry := receiverLegendEntry(); entry != nil {
+		legendData = append(legendData, entry)
+	}
 
 	// Determine how many bounce colors to show in legend
 	displayBouncesInLegend := maxReflections
-	if displayBouncesInLegend > len(bounceColors)-1 { // Don't try to show more colors than defined
+	// Don't try to show more colors than defined, unless the palette tells bounces apart by opacity
+	if displayBouncesInLegend > len(bounceColors)-1 && !rayPalettes[activePalette].OpacityByBounce {
 		displayBouncesInLegend = len(bounceColors) - 1
 	}
 	if displayBouncesInLegend > 10 { // Cap legend items to a reasonable number
@@ -518,23 +850,25 @@ func updateRayLegendJS() {
 			label = strconv.Itoa(i) + suffix + " Bounce"
 		}
 		// Avoid duplicating "Direct Path" if bounceColors[0] is used for non-listener direct hits
-		if i == 0 && bounceColors[colorIdx] == listenerRayColor {
+		if i == 0 && bounceColors[colorIdx] == listenerRayColor && !rayPalettes[activePalette].OpacityByBounce {
 			continue
 		}
 
 		legendData = append(legendData, map[string]interface{}{
-			"color": float64(bounceColors[colorIdx]),
-			"label": label,
+			"color":   float64(bounceColors[colorIdx]),
+			"label":   label,
+			"opacity": paletteBounceOpacity(i),
 		})
 	}
 
 	// If there are more possible reflections than shown colors, add a generic "further bounces"
 	if maxReflections > displayBouncesInLegend && displayBouncesInLegend < len(bounceColors)-1 {
 		legendData = append(legendData, map[string]interface{}{
-			"color": float64(bounceColors[len(bounceColors)-1]), // Use the last defined color
-			"label": "Further Bounces",
+			"color":   float64(bounceColors[len(bounceColors)-1]), // Use the last defined color
+			"label":   "Further Bounces",
+			"opacity": paletteBounceOpacity(displayBouncesInLegend + 1),
 		})
 	}
 
-	jsGlobal.Call("updateLegendOnPage", js.ValueOf(legendData))
+	callOptionalJS("updateLegendOnPage", js.ValueOf(legendData))
 }
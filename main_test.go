@@ -0,0 +1,24 @@
+package main
+
+import (
+	"io"
+	"log"
+	"syscall/js"
+	"testing"
+)
+
+// initTestScene loads the default scene the way main does, in embedded mode on a page that only
+// defines the required renderSceneJS callback. Logging is silenced for the rest of the test.
+func initTestScene(t *testing.T) {
+	t.Helper()
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(output) })
+	jsGlobal = js.Global()
+	embeddedMode = true
+	jsGlobal.Set("renderSceneJS", js.FuncOf(func(this js.Value, args []js.Value) interface{} { return nil }))
+	precomputeFibonacci(FIBONACCI_SCORE_CAP_INDEX)
+	recordsManager = *NewRecordManager(10)
+	createSceneContent()
+	initOccupancyCloud()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Frequency-Dependent Materials ---
+
+// materialBandPresets are typical octave-band absorption coefficients (125 Hz to 4 kHz, matching
+// OCTAVE_BANDS_HZ) for common room surfaces.
+var materialBandPresets = map[string][]float64{
+	"concrete":   {0.01, 0.01, 0.015, 0.02, 0.02, 0.02},
+	"plaster":    {0.013, 0.015, 0.02, 0.03, 0.04, 0.05},
+	"brick":      {0.03, 0.03, 0.03, 0.04, 0.05, 0.07},
+	"glass":      {0.35, 0.25, 0.18, 0.12, 0.07, 0.04},
+	"wood":       {0.15, 0.11, 0.10, 0.07, 0.06, 0.07},
+	"carpet":     {0.02, 0.06, 0.14, 0.37, 0.60, 0.65},
+	"curtain":    {0.07, 0.31, 0.49, 0.75, 0.70, 0.60},
+	"upholstery": {0.35, 0.45, 0.57, 0.61, 0.59, 0.55},
+	"bookshelf":  {0.30, 0.35, 0.40, 0.40, 0.45, 0.45},
+	"acoustic":   {0.25, 0.60, 0.90, 0.95, 0.90, 0.85},
+}
+
+// setMaterialBands gives a material per-band coefficients and keeps its broadband Absorption at
+// their mean, so broadband users (ray energy, statistics) follow the bands.
+// A material given bands leaves the impedance model.
+func setMaterialBands(mat *MaterialProperties, bands []float64) {
+	mat.BandAbsorption = append([]float64(nil), bands...)
+	mat.Impedance = 0 // Tabulated coefficients replace an impedance model
+	sum := 0.0
+	for _, a := range bands {
+		sum += a
+	}
+	mat.Absorption = sum / float64(len(bands))
+}
+
+// applyMaterialPreset switches every object whose name is objectName (or whose material is named
+// objectName, to re-surface e.g. all "plaster" walls at once) to a band preset.
+// Returns the number of objects changed.
+func applyMaterialPreset(objectName, preset string) int {
+	bands, ok := materialBandPresets[preset]
+	if !ok {
+		return 0
+	}
+	changed := 0
+	for _, obj := range allSceneObjects {
+		if obj.Name != objectName && obj.Material.Name != objectName {
+			continue
+		}
+		setMaterialBands(&obj.Material, bands)
+		obj.Material.Name = preset
+		changed++
+	}
+	return changed
+}
+
+// goSetObjectMaterial(name, preset) applies a frequency-dependent material preset to an object, or to
+// every object with a material of that name. Returns the number of objects changed.
+func goSetObjectMaterial(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetObjectMaterial")
+	if len(args) != 2 {
+		log.Println("Error: goSetObjectMaterial expects 2 arguments (objectOrMaterialName, preset)")
+		return 0
+	}
+	preset := args[1].String()
+	if _, ok := materialBandPresets[preset]; !ok {
+		log.Printf("Error: unknown material preset %q", preset)
+		return 0
+	}
+	changed := applyMaterialPreset(args[0].String(), preset)
+	if changed > 0 {
+		debouncedVisualizeFunc()
+	}
+	return changed
+}
+
+// setMaterialTermination sets the termination probability and bounce-order cap of every object
+// named objectName or with a material of that name. Returns the number of objects changed.
+func setMaterialTermination(objectName string, probability float64, bounceOrderCap int) int {
+	changed := 0
+	for _, obj := range allSceneObjects {
+		if obj.Name != objectName && obj.Material.Name != objectName {
+			continue
+		}
+		obj.Material.TerminationProbability = probability
+		obj.Material.BounceOrderCap = bounceOrderCap
+		changed++
+	}
+	return changed
+}
+
+// goSetMaterialTermination(name, probability, bounceOrderCap) makes an object, or every object
+// with a material of that name, end paths: each reflection off it stops the path with the given
+// probability, and it reflects only within a path's first bounceOrderCap reflections (0 = no cap).
+// Returns the number of objects changed.
+func goSetMaterialTermination(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetMaterialTermination")
+	if len(args) != 3 {
+		log.Println("Error: goSetMaterialTermination expects 3 arguments (objectOrMaterialName, probability, bounceOrderCap)")
+		return 0
+	}
+	probability, bounceOrderCap := args[1].Float(), args[2].Int()
+	if math.IsNaN(probability) || probability < 0 || probability > 1 {
+		log.Printf("Error: termination probability must be in [0, 1], got %v", probability)
+		return 0
+	}
+	if bounceOrderCap < 0 {
+		log.Printf("Error: bounce order cap must not be negative, got %d", bounceOrderCap)
+		return 0
+	}
+	changed := setMaterialTermination(args[0].String(), probability, bounceOrderCap)
+	if changed > 0 {
+		debouncedVisualizeFunc()
+	}
+	return changed
+}
+
+// goGetMaterialPresets returns {bandsHz, presets: {name: [coefficients]}}.
+func goGetMaterialPresets(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetMaterialPresets")
+	presets := map[string]interface{}{}
+	for name, coefficients := range materialBandPresets {
+		bands := make([]interface{}, len(coefficients))
+		for i, a := range coefficients {
+			bands[i] = a
+		}
+		presets[name] = bands
+	}
+	bandsHz := make([]interface{}, len(OCTAVE_BANDS_HZ))
+	for i, f := range OCTAVE_BANDS_HZ {
+		bandsHz[i] = f
+	}
+	return js.ValueOf(map[string]interface{}{"bandsHz": bandsHz, "presets": presets})
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// --- Triangle Mesh Geometry ---
+//
+// A "mesh" object carries a triangle list in its local frame; Scale, Rotation and Position place
+// it like any other object, so a unit-sized shell can be stretched to the room. Triangles are
+// two-sided: a ray reflects off whichever side it reaches, which lets open surfaces such as a
+// sloped ceiling or a stage shell work without a closed volume.
+
+const MESH_MAX_TRIANGLES = 20000 // Meshes are intersected triangle by triangle
+
+// TriangleMesh is indexed triangle geometry in an object's local frame.
+type TriangleMesh struct {
+	Vertices  []Vector3
+	Triangles [][3]int
+	boundsMin Vector3 // Local bounds of the vertices, used to reject rays early
+	boundsMax Vector3
+}
+
+// newTriangleMesh validates the triangle indices and computes the local bounds.
+func newTriangleMesh(vertices []Vector3, triangles [][3]int) (*TriangleMesh, error) {
+	if len(triangles) == 0 {
+		return nil, fmt.Errorf("a mesh needs at least one triangle")
+	}
+	if len(triangles) > MESH_MAX_TRIANGLES {
+		return nil, fmt.Errorf("meshes are limited to %d triangles, got %d", MESH_MAX_TRIANGLES, len(triangles))
+	}
+	for i, tri := range triangles {
+		for _, v := range tri {
+			if v < 0 || v >= len(vertices) {
+				return nil, fmt.Errorf("triangle %d refers to vertex %d of %d", i, v, len(vertices))
+			}
+		}
+	}
+	mesh := &TriangleMesh{Vertices: vertices, Triangles: triangles, boundsMin: vertices[0], boundsMax: vertices[0]}
+	for _, v := range vertices[1:] {
+		mesh.boundsMin = Vector3{math.Min(mesh.boundsMin.X, v.X), math.Min(mesh.boundsMin.Y, v.Y), math.Min(mesh.boundsMin.Z, v.Z)}
+		mesh.boundsMax = Vector3{math.Max(mesh.boundsMax.X, v.X), math.Max(mesh.boundsMax.Y, v.Y), math.Max(mesh.boundsMax.Z, v.Z)}
+	}
+	return mesh, nil
+}
+
+// meshWorldVertex places a local mesh vertex of obj in the world.
+func meshWorldVertex(obj *SceneObject, v Vector3) Vector3 {
+	return boxWorldPoint(obj, Vector3{v.X * obj.Scale.X, v.Y * obj.Scale.Y, v.Z * obj.Scale.Z})
+}
+
+// meshWorldTriangle returns the corners of triangle i of obj's mesh in world space.
+func meshWorldTriangle(obj *SceneObject, i int) (a, b, c Vector3) {
+	tri := obj.Mesh.Triangles[i]
+	return meshWorldVertex(obj, obj.Mesh.Vertices[tri[0]]), meshWorldVertex(obj, obj.Mesh.Vertices[tri[1]]), meshWorldVertex(obj, obj.Mesh.Vertices[tri[2]])
+}
+
+// meshWorldBounds is the world-axis box enclosing obj's mesh.
+func meshWorldBounds(obj *SceneObject) (Vector3, Vector3) {
+	return unitFrameBoundsToWorld(obj, obj.Mesh.boundsMin, obj.Mesh.boundsMax)
+}
+
+// rayTriangle is the Möller–Trumbore test: the ray parameter t of the hit, and whether there is one.
+func rayTriangle(origin, direction, a, b, c Vector3) (float64, bool) {
+	edge1, edge2 := b.Sub(a), c.Sub(a)
+	p := direction.Cross(edge2)
+	det := edge1.Dot(p)
+	if math.Abs(det) < EPSILON*EPSILON { // Ray parallel to the triangle's plane
+		return 0, false
+	}
+	invDet := 1 / det
+	s := origin.Sub(a)
+	u := s.Dot(p) * invDet
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+	q := s.Cross(edge1)
+	v := direction.Dot(q) * invDet
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+	return edge2.Dot(q) * invDet, true
+}
+
+// rayHitsBounds is a slab test of the ray against [boxMin, boxMax] up to maxT.
+func rayHitsBounds(origin, direction, boxMin, boxMax Vector3, maxT float64) bool {
+	tMin, tMax := 0.0, maxT
+	o := [3]float64{origin.X, origin.Y, origin.Z}
+	d := [3]float64{direction.X, direction.Y, direction.Z}
+	lo := [3]float64{boxMin.X, boxMin.Y, boxMin.Z}
+	hi := [3]float64{boxMax.X, boxMax.Y, boxMax.Z}
+	for i := 0; i < 3; i++ {
+		if math.Abs(d[i]) < EPSILON {
+			if o[i] < lo[i]-EPSILON || o[i] > hi[i]+EPSILON {
+				return false
+			}
+			continue
+		}
+		t0, t1 := (lo[i]-o[i])/d[i], (hi[i]-o[i])/d[i]
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tMin, tMax = math.Max(tMin, t0), math.Min(tMax, t1)
+		if tMin > tMax+EPSILON {
+			return false
+		}
+	}
+	return true
+}
+
+// raycastMesh intersects a ray with obj's mesh in its unit frame (see unitFrameRay). The
+// returned normal faces the incoming ray.
+func raycastMesh(obj *SceneObject, origin, direction Vector3, maxDist float64) (float64, Vector3, bool) {
+	localOrigin, localDir := unitFrameRay(obj, origin, direction)
+	if !rayHitsBounds(localOrigin, localDir, obj.Mesh.boundsMin, obj.Mesh.boundsMax, maxDist) {
+		return 0, Vector3{}, false
+	}
+	closest, hitTriangle := maxDist, -1
+	for i, tri := range obj.Mesh.Triangles {
+		v := obj.Mesh.Vertices
+		if t, ok := rayTriangle(localOrigin, localDir, v[tri[0]], v[tri[1]], v[tri[2]]); ok && t > EPSILON && t < closest {
+			closest, hitTriangle = t, i
+		}
+	}
+	if hitTriangle < 0 {
+		return 0, Vector3{}, false
+	}
+	normal := meshTriangleNormal(obj, hitTriangle)
+	if normal.Dot(direction) > 0 {
+		normal = normal.Scale(-1)
+	}
+	return closest, normal, true
+}
+
+// meshTriangleNormal is the world-space unit normal of triangle i, oriented by its winding.
+func meshTriangleNormal(obj *SceneObject, i int) Vector3 {
+	tri := obj.Mesh.Triangles[i]
+	v := obj.Mesh.Vertices
+	return unitFrameNormalToWorld(obj, v[tri[1]].Sub(v[tri[0]]).Cross(v[tri[2]].Sub(v[tri[0]])))
+}
+
+// closestPointOnTriangle returns the point of triangle abc nearest to p (Ericson, Real-Time
+// Collision Detection, 5.1.5).
+func closestPointOnTriangle(p, a, b, c Vector3) Vector3 {
+	ab, ac, ap := b.Sub(a), c.Sub(a), p.Sub(a)
+	d1, d2 := ab.Dot(ap), ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+	bp := p.Sub(b)
+	d3, d4 := ab.Dot(bp), ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+	if vc := d1*d4 - d3*d2; vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return a.Add(ab.Scale(d1 / (d1 - d3)))
+	}
+	cp := p.Sub(c)
+	d5, d6 := ab.Dot(cp), ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+	if vb := d5*d2 - d1*d6; vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return a.Add(ac.Scale(d2 / (d2 - d6)))
+	}
+	if va := d3*d6 - d5*d4; va <= 0 && d4-d3 >= 0 && d5-d6 >= 0 {
+		return b.Add(c.Sub(b).Scale((d4 - d3) / ((d4 - d3) + (d5 - d6))))
+	}
+	va, vb, vc := d3*d6-d5*d4, d5*d2-d1*d6, d1*d4-d3*d2
+	denom := 1 / (va + vb + vc)
+	return a.Add(ab.Scale(vb * denom)).Add(ac.Scale(vc * denom))
+}
+
+// meshNearestTriangle returns the index of the triangle nearest to p and the distance to it.
+func meshNearestTriangle(obj *SceneObject, p Vector3) (int, float64) {
+	nearest, best := -1, math.Inf(1)
+	for i := range obj.Mesh.Triangles {
+		a, b, c := meshWorldTriangle(obj, i)
+		if d := p.DistanceTo(closestPointOnTriangle(p, a, b, c)); d < best {
+			nearest, best = i, d
+		}
+	}
+	return nearest, best
+}
+
+// meshSurfaceArea is the total world-space area of obj's triangles.
+func meshSurfaceArea(obj *SceneObject) float64 {
+	area := 0.0
+	for i := range obj.Mesh.Triangles {
+		a, b, c := meshWorldTriangle(obj, i)
+		area += b.Sub(a).Cross(c.Sub(a)).Length() / 2
+	}
+	return area
+}
+
+// meshToJS flattens obj's mesh for three.js: local vertex coordinates and triangle indices.
+func meshToJS(mesh *TriangleMesh) map[string]interface{} {
+	vertices := make([]interface{}, 0, 3*len(mesh.Vertices))
+	for _, v := range mesh.Vertices {
+		vertices = append(vertices, v.X, v.Y, v.Z)
+	}
+	indices := make([]interface{}, 0, 3*len(mesh.Triangles))
+	for _, tri := range mesh.Triangles {
+		indices = append(indices, tri[0], tri[1], tri[2])
+	}
+	return map[string]interface{}{"vertices": vertices, "indices": indices}
+}
@@ -0,0 +1,61 @@
+package main
+
+import "math"
+
+// --- Next-Event Estimation ---
+//
+// With few rays, whether a reflection happens to be aimed through the listener is mostly luck.
+// Next-event estimation credits every reflection deterministically instead: the diffusely
+// scattered share of its energy (the material's Scattering, or neeDefaultScattering for surfaces
+// without one) is connected to the listener by a shadow ray, and if nothing blocks it the
+// reflection scores the chance that a Lambert-scattered ray would have reached the listener,
+// cos θ·Ω/π for the listener's solid angle Ω. The traced ray continues specularly with the rest of
+// the energy, so the scattered share is not counted twice.
+
+const DEFAULT_NEE_SCATTERING = 0.2
+
+var (
+	useNextEventEstimation bool                     // Toggle "nextEventEstimation"
+	neeDefaultScattering   = DEFAULT_NEE_SCATTERING // Slider "neeScattering": diffuse share of surfaces without a Scattering
+)
+
+// neeScattering is the share of a reflection off obj that next-event estimation and path
+// connections (see bidirectional_connect.go) handle instead of the traced ray.
+func neeScattering(obj *SceneObject) float64 {
+	if !useNextEventEstimation && !bidirectionalConnect {
+		return 0
+	}
+	return diffuseShare(obj)
+}
+
+// nextEventCredit is the expected score of the scattered share of a reflection at hit reaching
+// the listener by the shadow ray, 0 when it is blocked. energy is the ray's energy after the
+// reflection's absorption and bounces the reflection's order.
+func nextEventCredit(hit RayIntersectionResult, direction Vector3, energy float64, bounces int, listenerPos Vector3, listenerRadius float64, sets *CollidableSets) float64 {
+	if !useNextEventEstimation {
+		return 0
+	}
+	s := diffuseShare(hit.Object)
+	toListener := listenerPos.Sub(hit.Point)
+	distance := toListener.Length()
+	if s <= 0 || distance < EPSILON || !stochasticOrderCounts(bounces) {
+		return 0
+	}
+	shadow := toListener.Scale(1 / distance)
+	normal := hit.Normal
+	if normal.Dot(direction) > 0 { // The side the ray arrived on
+		normal = normal.Scale(-1)
+	}
+	cosOut := shadow.Dot(normal)
+	if cosOut <= 0 { // The listener is behind the surface
+		return 0
+	}
+	if distance > listenerRadius {
+		start := reflectionOrigin(hit, shadow)
+		if blocker := performRaycast(start, shadow, distance-listenerRadius, sets.Reflected, nil); blocker.Hit {
+			return 0
+		}
+	}
+	p := math.Min(1, 4*cosOut*listenerCapFraction(distance, listenerRadius)) // cos θ·Ω/π, Ω = 4π·cap fraction
+	return arrivalScore(bounces, energy*s*p*airTransmission(distance)*arrivalGain(sets, shadow))
+}
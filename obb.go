@@ -0,0 +1,155 @@
+package main
+
+import "math"
+
+// --- Oriented Boxes ---
+//
+// Boxes are rotated by their Euler angles (degrees) in X, Y, Z order, R = Rx·Ry·Rz, the
+// convention the renderer uses. Ray tests run in the box's local frame, where it is an
+// axis-aligned box of half size Scale/2 centered on the origin.
+
+// Mat3 is a row-major 3x3 matrix.
+type Mat3 [3][3]float64
+
+// eulerRotation is the rotation matrix for Euler angles in degrees, applied in X, Y, Z order.
+func eulerRotation(deg Vector3) Mat3 {
+	toRad := math.Pi / 180
+	sx, cx := math.Sincos(deg.X * toRad)
+	sy, cy := math.Sincos(deg.Y * toRad)
+	sz, cz := math.Sincos(deg.Z * toRad)
+	return Mat3{
+		{cy * cz, -cy * sz, sy},
+		{cx*sz + sx*sy*cz, cx*cz - sx*sy*sz, -sx * cy},
+		{sx*sz - cx*sy*cz, sx*cz + cx*sy*sz, cx * cy},
+	}
+}
+
+// Apply returns m·v.
+func (m Mat3) Apply(v Vector3) Vector3 {
+	return Vector3{
+		m[0][0]*v.X + m[0][1]*v.Y + m[0][2]*v.Z,
+		m[1][0]*v.X + m[1][1]*v.Y + m[1][2]*v.Z,
+		m[2][0]*v.X + m[2][1]*v.Y + m[2][2]*v.Z,
+	}
+}
+
+// ApplyTranspose returns mᵀ·v, the inverse rotation of v.
+func (m Mat3) ApplyTranspose(v Vector3) Vector3 {
+	return Vector3{
+		m[0][0]*v.X + m[1][0]*v.Y + m[2][0]*v.Z,
+		m[0][1]*v.X + m[1][1]*v.Y + m[2][1]*v.Z,
+		m[0][2]*v.X + m[1][2]*v.Y + m[2][2]*v.Z,
+	}
+}
+
+// boxRotation returns obj's rotation matrix, and false for unrotated objects (the common case,
+// which callers keep on the cheaper axis-aligned path).
+func boxRotation(obj *SceneObject) (Mat3, bool) {
+	if obj.Rotation == (Vector3{}) {
+		return Mat3{}, false
+	}
+	return eulerRotation(obj.Rotation), true
+}
+
+// boxLocalPoint converts a world point into obj's local frame.
+func boxLocalPoint(obj *SceneObject, p Vector3) Vector3 {
+	if rot, ok := boxRotation(obj); ok {
+		return rot.ApplyTranspose(p.Sub(obj.Position))
+	}
+	return p.Sub(obj.Position)
+}
+
+// boxWorldPoint converts a point in obj's local frame back to world space.
+func boxWorldPoint(obj *SceneObject, p Vector3) Vector3 {
+	if rot, ok := boxRotation(obj); ok {
+		return rot.Apply(p).Add(obj.Position)
+	}
+	return p.Add(obj.Position)
+}
+
+// boxWorldDirection rotates a local-frame direction of obj into world space.
+func boxWorldDirection(obj *SceneObject, d Vector3) Vector3 {
+	if rot, ok := boxRotation(obj); ok {
+		return rot.Apply(d)
+	}
+	return d
+}
+
+// objectWorldBounds is the world-axis box enclosing obj: the mesh or shell bounds for meshes and
+// curved reflectors, the box around Position given by boxHalfExtents otherwise.
+func objectWorldBounds(obj *SceneObject) (Vector3, Vector3) {
+	if obj.ShapeType == "mesh" && obj.Mesh != nil {
+		return meshWorldBounds(obj)
+	}
+	if isCurvedShape(obj.ShapeType) {
+		lo, hi := curvedUnitBounds(obj.ShapeType)
+		return unitFrameBoundsToWorld(obj, lo, hi)
+	}
+	half := boxHalfExtents(obj)
+	return obj.Position.Sub(half), obj.Position.Add(half)
+}
+
+// boxHalfExtents returns the half size of the world-axis box enclosing obj, for the checks that
+// only handle axis-aligned boxes (occupancy cells, room bounds).
+func boxHalfExtents(obj *SceneObject) Vector3 {
+	half := obj.Scale.Scale(0.5)
+	rot, ok := boxRotation(obj)
+	if !ok {
+		return half
+	}
+	return Vector3{
+		math.Abs(rot[0][0])*half.X + math.Abs(rot[0][1])*half.Y + math.Abs(rot[0][2])*half.Z,
+		math.Abs(rot[1][0])*half.X + math.Abs(rot[1][1])*half.Y + math.Abs(rot[1][2])*half.Z,
+		math.Abs(rot[2][0])*half.X + math.Abs(rot[2][1])*half.Y + math.Abs(rot[2][2])*half.Z,
+	}
+}
+
+// orientedBox is a box given by its center, its unit axes in world space and its half size
+// along each of them.
+type orientedBox struct {
+	Center Vector3
+	Axes   [3]Vector3
+	Half   [3]float64
+}
+
+// objectOrientedBox is obj's box, or for meshes and curved reflectors the world-axis box
+// enclosing them.
+func objectOrientedBox(obj *SceneObject) orientedBox {
+	if obj.ShapeType == "box" {
+		b := orientedBox{Center: obj.Position, Half: [3]float64{obj.Scale.X / 2, obj.Scale.Y / 2, obj.Scale.Z / 2}}
+		for i, axis := range []Vector3{{X: 1}, {Y: 1}, {Z: 1}} {
+			b.Axes[i] = boxWorldDirection(obj, axis)
+		}
+		return b
+	}
+	lo, hi := objectWorldBounds(obj)
+	half := hi.Sub(lo).Scale(0.5)
+	return orientedBox{Center: lo.Add(half), Axes: [3]Vector3{{X: 1}, {Y: 1}, {Z: 1}}, Half: [3]float64{half.X, half.Y, half.Z}}
+}
+
+// overlaps reports whether the boxes share interior points. Two boxes are disjoint exactly when
+// a face normal of either, or the cross product of an edge of each, separates their projections.
+func (a orientedBox) overlaps(b orientedBox) bool {
+	d := b.Center.Sub(a.Center)
+	separates := func(axis Vector3) bool {
+		if axis.Length() < EPSILON { // Parallel edges; the face normals cover this case
+			return false
+		}
+		reach := 0.0
+		for i := 0; i < 3; i++ {
+			reach += math.Abs(axis.Dot(a.Axes[i]))*a.Half[i] + math.Abs(axis.Dot(b.Axes[i]))*b.Half[i]
+		}
+		return math.Abs(d.Dot(axis)) >= reach
+	}
+	for i := 0; i < 3; i++ {
+		if separates(a.Axes[i]) || separates(b.Axes[i]) {
+			return false
+		}
+		for j := 0; j < 3; j++ {
+			if separates(a.Axes[i].Cross(b.Axes[j])) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -8,19 +8,20 @@ import (
 	"time"
 )
 
-// Basic Sphere-AABB intersection check (can be used as a utility or fallback)
+// Basic sphere-box intersection check, in the box's local frame so rotated boxes are exact
 func sphereIntersectsBox(spherePos Vector3, sphereRadius float64, box *SceneObject) bool {
 	if box.ShapeType != "box" {
 		return false
 	}
-	boxMin := box.Position.Sub(box.Scale.Scale(0.5))
-	boxMax := box.Position.Add(box.Scale.Scale(0.5))
-	closestX := math.Max(boxMin.X, math.Min(spherePos.X, boxMax.X))
-	closestY := math.Max(boxMin.Y, math.Min(spherePos.Y, boxMax.Y))
-	closestZ := math.Max(boxMin.Z, math.Min(spherePos.Z, boxMax.Z))
-	distanceSq := (closestX-spherePos.X)*(closestX-spherePos.X) +
-		(closestY-spherePos.Y)*(closestY-spherePos.Y) +
-		(closestZ-spherePos.Z)*(closestZ-spherePos.Z)
+	local := boxLocalPoint(box, spherePos)
+	boxMax := box.Scale.Scale(0.5)
+	boxMin := boxMax.Scale(-1)
+	closestX := math.Max(boxMin.X, math.Min(local.X, boxMax.X))
+	closestY := math.Max(boxMin.Y, math.Min(local.Y, boxMax.Y))
+	closestZ := math.Max(boxMin.Z, math.Min(local.Z, boxMax.Z))
+	distanceSq := (closestX-local.X)*(closestX-local.X) +
+		(closestY-local.Y)*(closestY-local.Y) +
+		(closestZ-local.Z)*(closestZ-local.Z)
 	return distanceSq < (sphereRadius * sphereRadius)
 }
 
@@ -41,19 +42,27 @@ func findAndApplyBestMoveForLearning(movingObject *SceneObject, fixedObject *Sce
 	var otherObjScale Vector3
 
 	if movingObject == soundSource {
-		currentScore = calculateListenerScore(originalPos, fixedObject.Position)
+		currentScore = objectiveScore(originalPos, fixedObject.Position)
 		movingObjCloudState = StateSoundSource
 	} else { // movingObject is listener
-		currentScore = calculateListenerScore(fixedObject.Position, originalPos)
+		currentScore = objectiveScore(fixedObject.Position, originalPos)
 		movingObjCloudState = StateListener
 	}
 	otherObjCurrentPos = fixedObject.Position
 	otherObjScale = fixedObject.Scale
 
+	if occupancyCloud != nil {
+		occupancyCloud.RecordScore(originalPos, movingObjCloudState, currentScore)
+	}
 	bestScore := currentScore
 	bestPositions := []Vector3{originalPos}
 
-	offsets := []float64{-OPTIMIZATION_STEP_SIZE, 0, OPTIMIZATION_STEP_SIZE}
+	hasLineOfSight := false
+	if useVisibilityPrefilter && occupancyCloud != nil {
+		hasLineOfSight = occupancyCloud.IsLineOfSightClear(originalPos, otherObjCurrentPos)
+	}
+
+	offsets := []float64{-optimizationStepSize, 0, optimizationStepSize}
 	candidateTestPositions := []Vector3{}
 
 	for _, dx := range offsets {
@@ -82,6 +91,14 @@ func findAndApplyBestMoveForLearning(movingObject *SceneObject, fixedObject *Sce
 				// Use OccupancyCloud for collision checks
 				if occupancyCloud != nil {
 					isValidCloudPos := occupancyCloud.IsPositionAttemptValid(testPos, movingObject.Scale, movingObjCloudState, otherObjCurrentPos, otherObjScale)
+					if !isValidCloudPos && occupancyCloud.SDF != nil {
+						// Slide candidates that graze an obstacle back out along the SDF gradient instead of dropping them
+						clearance := math.Max(movingObject.Scale.X, math.Max(movingObject.Scale.Y, movingObject.Scale.Z))/2.0 + occupancyCloud.ClearanceMargin(movingObjCloudState)
+						if nudged, ok := occupancyCloud.SDF.NudgeToClearance(testPos, clearance); ok {
+							testPos = nudged
+							isValidCloudPos = occupancyCloud.IsPositionAttemptValid(testPos, movingObject.Scale, movingObjCloudState, otherObjCurrentPos, otherObjScale)
+						}
+					}
 					if !isValidCloudPos {
 						if occupancyCloud.DebugLogging {
 							// log.Printf("Cloud: Candidate pos %v for %s rejected.", testPos, movingObject.Name)
@@ -106,6 +123,18 @@ func findAndApplyBestMoveForLearning(movingObject *SceneObject, fixedObject *Sce
 					}
 				}
 
+				// Cells the score memory has written off are not worth another evaluation
+				if occupancyCloud != nil && occupancyCloud.IsExploredLowPotential(testPos, movingObjCloudState) {
+					continue
+				}
+
+				// Cheap pre-filter: if we currently see the other object, don't pay for a full
+				// evaluation of candidates the cloud says would lose that line of sight.
+				if useVisibilityPrefilter && occupancyCloud != nil && hasLineOfSight &&
+					!occupancyCloud.IsLineOfSightClear(testPos, otherObjCurrentPos) {
+					continue
+				}
+
 				isDuplicate := false
 				for _, p := range candidateTestPositions {
 					if math.Abs(p.X-testPos.X) < EPSILON && math.Abs(p.Y-testPos.Y) < EPSILON && math.Abs(p.Z-testPos.Z) < EPSILON {
@@ -128,9 +157,12 @@ func findAndApplyBestMoveForLearning(movingObject *SceneObject, fixedObject *Sce
 	for _, testPos := range candidateTestPositions {
 		var score int
 		if movingObject == soundSource {
-			score = calculateListenerScore(testPos, fixedObject.Position)
+			score = objectiveScore(testPos, fixedObject.Position)
 		} else {
-			score = calculateListenerScore(fixedObject.Position, testPos)
+			score = objectiveScore(fixedObject.Position, testPos)
+		}
+		if occupancyCloud != nil {
+			occupancyCloud.RecordScore(testPos, movingObjCloudState, score)
 		}
 
 		if goal == "maximize" {
@@ -157,11 +189,11 @@ func findAndApplyBestMoveForLearning(movingObject *SceneObject, fixedObject *Sce
 		if bestScore > currentScore {
 			chosenPos = bestPositions[rand.Intn(len(bestPositions))]
 		} else { // No improvement or score is the same
-			if rand.Float64() < randomJumpProbability*explorationFactor {
+			if learningStrategy != "twoPhase" && rand.Float64() < randomJumpProbability*explorationFactor { // Two-phase learning explores up front instead
 				jumpMagnitude := (rand.Float64()*2.0 + 2.0) * explorationFactor
-				dx := (rand.Float64()*2 - 1) * OPTIMIZATION_STEP_SIZE * jumpMagnitude
-				dy := (rand.Float64()*0.5 - 0.25) * OPTIMIZATION_STEP_SIZE * jumpMagnitude // Smaller vertical jumps
-				dz := (rand.Float64()*2 - 1) * OPTIMIZATION_STEP_SIZE * jumpMagnitude
+				dx := (rand.Float64()*2 - 1) * optimizationStepSize * jumpMagnitude
+				dy := (rand.Float64()*0.5 - 0.25) * optimizationStepSize * jumpMagnitude // Smaller vertical jumps
+				dz := (rand.Float64()*2 - 1) * optimizationStepSize * jumpMagnitude
 
 				jumpPos := Vector3{
 					X: math.Max(occupancyCloud.RoomMin.X+movingObject.Scale.X/2, math.Min(occupancyCloud.RoomMax.X-movingObject.Scale.X/2, originalPos.X+dx)),
@@ -179,7 +211,8 @@ func findAndApplyBestMoveForLearning(movingObject *SceneObject, fixedObject *Sce
 
 				isValidJump := false
 				if occupancyCloud != nil {
-					isValidJump = occupancyCloud.IsPositionAttemptValid(jumpPos, movingObject.Scale, movingObjCloudState, otherObjCurrentPos, otherObjScale)
+					isValidJump = occupancyCloud.IsPositionAttemptValid(jumpPos, movingObject.Scale, movingObjCloudState, otherObjCurrentPos, otherObjScale) &&
+						!occupancyCloud.IsExploredLowPotential(jumpPos, movingObjCloudState)
 				} else {
 					// Fallback jump collision check
 					if !spheresIntersect(jumpPos, movingObject.Scale.X/2.0, otherObjCurrentPos, otherObjScale.X/2.0) {
@@ -199,7 +232,7 @@ func findAndApplyBestMoveForLearning(movingObject *SceneObject, fixedObject *Sce
 				if isValidJump {
 					chosenPos = jumpPos
 					if occupancyCloud.DebugLogging {
-						log.Printf("Cloud: %s made a random jump to %v", movingObject.Name, chosenPos)
+						logEvent(LogCloud, LogDetail, "%s made a random jump to %v", movingObject.Name, chosenPos)
 					}
 				} else if len(bestPositions) > 0 { // Fallback if jump is invalid
 					chosenPos = bestPositions[rand.Intn(len(bestPositions))]
@@ -231,10 +264,128 @@ func findAndApplyBestMoveForLearning(movingObject *SceneObject, fixedObject *Sce
 	}
 }
 
+// placementHeuristicCandidates returns candidate XZ positions (at the given height) that tend
+// to be good starting points: rule-of-thirds intersections and points a quarter of the room
+// away from the walls.
+func placementHeuristicCandidates(height float64) []Vector3 {
+	candidates := []Vector3{}
+	for _, fx := range []float64{-1.0 / 6, 1.0 / 6} { // Rule-of-thirds lines (thirds measured from the room center)
+		for _, fz := range []float64{-1.0 / 6, 1.0 / 6} {
+			candidates = append(candidates, Vector3{fx * roomWidth, height, fz * roomDepth})
+		}
+	}
+	for _, f := range []float64{-0.25, 0.25} { // Away-from-walls offsets along each axis
+		candidates = append(candidates, Vector3{f * roomWidth, height, 0})
+		candidates = append(candidates, Vector3{0, height, f * roomDepth})
+	}
+	return candidates
+}
+
+// seedInitialPlacementForLearning evaluates heuristic (source, listener) pairs, including
+// mirror-symmetric pairs, and moves both objects to the best valid pair if it beats the
+// current placement. Coordinate descent then starts from there.
+func seedInitialPlacementForLearning() {
+	if soundSource == nil || listener == nil {
+		return
+	}
+	bestSourcePos, bestListenerPos := soundSource.Position, listener.Position
+	bestScore := objectiveScore(bestSourcePos, bestListenerPos)
+	startScore := bestScore
+
+	isValidPair := func(sourcePos, listenerPos Vector3) bool {
+		if occupancyCloud == nil {
+			return !spheresIntersect(sourcePos, soundSource.Scale.X/2.0, listenerPos, listener.Scale.X/2.0)
+		}
+		return occupancyCloud.IsPositionAttemptValid(sourcePos, soundSource.Scale, StateSoundSource, listenerPos, listener.Scale) &&
+			occupancyCloud.IsPositionAttemptValid(listenerPos, listener.Scale, StateListener, sourcePos, soundSource.Scale)
+	}
+
+	sourceCandidates := placementHeuristicCandidates(soundSource.Position.Y)
+	listenerCandidates := placementHeuristicCandidates(listener.Position.Y)
+	type pair struct{ source, listener Vector3 }
+	pairs := []pair{}
+	for _, s := range sourceCandidates {
+		for _, l := range listenerCandidates {
+			if s.X != l.X || s.Z != l.Z {
+				pairs = append(pairs, pair{s, l})
+			}
+		}
+		// Mirror-symmetric partners of the source candidate
+		pairs = append(pairs, pair{s, Vector3{-s.X, listener.Position.Y, s.Z}})
+		pairs = append(pairs, pair{s, Vector3{s.X, listener.Position.Y, -s.Z}})
+	}
+
+	for _, p := range pairs {
+		p.source, p.listener = lockedPlacement(p.source, p.listener)
+		if !isValidPair(p.source, p.listener) {
+			continue
+		}
+		score := objectiveScore(p.source, p.listener)
+		if score > bestScore {
+			bestScore = score
+			bestSourcePos, bestListenerPos = p.source, p.listener
+		}
+	}
+
+	if bestScore > startScore {
+		soundSource.Position = bestSourcePos
+		listener.Position = bestListenerPos
+		resyncDynamicObjectsInCloud()
+		log.Printf("Initial placement seeded from heuristics: score %d -> %d (%d pairs evaluated)", startScore, bestScore, len(pairs))
+	} else {
+		log.Printf("Initial placement kept: no heuristic pair beat the current score %d", startScore)
+	}
+}
+
+// Time-budgeted learning: the controller aims for roughly this many iterations within the
+// budget, scaling evaluation rays between the limits below.
+const (
+	TIME_BUDGET_TARGET_ITERATIONS = 500
+	TIME_BUDGET_MIN_EVAL_RAYS     = 10
+	TIME_BUDGET_MAX_EVAL_RAYS     = 400
+)
+
+// adaptEvalRaysToTimeBudget adjusts evalRayCountOverride so the remaining budget fits a sensible
+// number of iterations: fewer rays when iterations are too slow, more when there is slack.
+func adaptEvalRaysToTimeBudget(lastIterationDuration, elapsed time.Duration) {
+	remaining := learningTimeBudget - elapsed
+	if remaining <= 0 {
+		return
+	}
+	targetIterationDuration := learningTimeBudget / TIME_BUDGET_TARGET_ITERATIONS
+	switch {
+	case lastIterationDuration > targetIterationDuration*6/5:
+		evalRayCountOverride = evalRayCountOverride * 4 / 5
+	case lastIterationDuration < targetIterationDuration*4/5:
+		evalRayCountOverride = evalRayCountOverride * 5 / 4
+	}
+	evalRayCountOverride = clampInt(evalRayCountOverride, TIME_BUDGET_MIN_EVAL_RAYS, TIME_BUDGET_MAX_EVAL_RAYS)
+}
+
+// learningBudgetRemaining reports whether the loop may run another iteration.
+func learningBudgetRemaining(startTime time.Time) bool {
+	if learningTimeBudget > 0 {
+		return time.Since(startTime) < learningTimeBudget
+	}
+	return currentLearningIteration < maxLearningIterations
+}
+
 func runLearningCycle() {
 	defer recoverFromPanic("runLearningCycle")
 	log.Println("Learning cycle goroutine started.")
 
+	if useSmartInitialPlacement {
+		seedInitialPlacementForLearning()
+	}
+	cmaesOptimizer = nil
+	twoPhase = nil
+	if learningStrategy == "cmaes" && soundSource != nil && listener != nil {
+		startCMAESLearning()
+	}
+	if learningStrategy == "coarseToFine" {
+		runCoarseToFinePhase() // Coordinate descent refines from the coarse-to-fine result
+	}
+
 	// Initial cloud update for sound source and listener based on their starting positions in the scene
 	if occupancyCloud != nil {
 		if soundSource != nil {
@@ -245,19 +396,31 @@ func runLearningCycle() {
 		}
 	}
 
-	for currentLearningIteration < maxLearningIterations && learningModeActive {
+	learningStartTime := time.Now()
+	learningClock.reset() // Seeding and coarse phases above are not iterations
+	if learningTimeBudget > 0 {
+		evalRayCountOverride = evaluationRayCount()
+		log.Printf("Learning with a time budget of %v (starting at %d eval rays)", learningTimeBudget, evalRayCountOverride)
+	}
+
+	for learningBudgetRemaining(learningStartTime) && learningModeActive {
 		currentLearningIteration++
+		sessionStats.LearningIterations.Add(1)
+		iterationStart := time.Now()
 
 		var movingObject *SceneObject
 		var fixedObject *SceneObject
 
-		if isSoundSourceTurn {
+		if isSoundSourceTurn || seatingObjectiveActive() { // Seats, not the listener, are scored
 			movingObject = soundSource
 			fixedObject = listener
 		} else {
 			movingObject = listener
 			fixedObject = soundSource
 		}
+		if positionLocked(movingObject) { // Let the other object move instead
+			movingObject, fixedObject = fixedObject, movingObject
+		}
 
 		if movingObject == nil || fixedObject == nil {
 			log.Println("Error: soundSource or listener is nil in learning cycle.")
@@ -265,17 +428,32 @@ func runLearningCycle() {
 			break
 		}
 
-		findAndApplyBestMoveForLearning(movingObject, fixedObject, "maximize")
-		// Note: OccupancyCloud is updated *inside* findAndApplyBestMoveForLearning after the move.
+		if learningStrategy == "cmaes" {
+			runCMAESLearningStep() // Moves both objects at once
+		} else if learningStrategy == "twoPhase" {
+			runTwoPhaseLearningStep(learningStartTime, movingObject, fixedObject)
+		} else if !positionLocked(movingObject) { // With both positions locked there is nothing to move
+			findAndApplyBestMoveForLearning(movingObject, fixedObject, "maximize")
+			// Note: OccupancyCloud is updated *inside* findAndApplyBestMoveForLearning after the move.
+		}
+		optimizeSourceAimStep()
+		if optimizeFurniture {
+			optimizeFurnitureStep()
+		}
 
 		visualizeSoundPropagation() // This updates global listenerRayScore and sends data to JS
 
-		js.Global().Call("updateLearningProgress", currentLearningIteration, maxLearningIterations, globalBestScore)
-		js.Global().Call("updateSliderValuesForObject", "SoundSource", soundSource.Position.X, soundSource.Position.Y, soundSource.Position.Z)
-		js.Global().Call("updateSliderValuesForObject", "Listener", listener.Position.X, listener.Position.Y, listener.Position.Z)
+		learningClock.tick(time.Since(iterationStart))
+		reportLearningProgress()
+		callOptionalJS("updateSliderValuesForObject", "SoundSource", soundSource.Position.X, soundSource.Position.Y, soundSource.Position.Z)
+		callOptionalJS("updateSliderValuesForObject", "Listener", listener.Position.X, listener.Position.Y, listener.Position.Z)
 
 		isSoundSourceTurn = !isSoundSourceTurn
 
+		if learningTimeBudget > 0 {
+			adaptEvalRaysToTimeBudget(time.Since(iterationStart), time.Since(learningStartTime))
+		}
+
 		if autoTurnDelay > 0 {
 			time.Sleep(autoTurnDelay)
 		}
@@ -286,13 +464,22 @@ func runLearningCycle() {
 	}
 
 	if learningModeActive {
-		log.Println("Max learning iterations reached.")
+		if learningTimeBudget > 0 {
+			log.Printf("Learning time budget of %v used (%d iterations, final eval rays %d).", learningTimeBudget, currentLearningIteration, evalRayCountOverride)
+		} else {
+			log.Println("Max learning iterations reached.")
+		}
 	}
+	evalRayCountOverride = 0 // Back to default evaluation quality
 	learningModeActive = false
-	jsGlobal.Call("updateLearningButton", false, "Start Learning (Coop. Maximize)")
+	if shuttingDown { // The results were flushed by goShutdown; apply nothing
+		return
+	}
+	callOptionalJS("updateLearningButton", false, "Start Learning (Coop. Maximize)")
 
 	if soundSource != nil && listener != nil && globalBestSettings.Score > -1 {
 		log.Printf("Learning finished. Applying global best settings. Score: %d", globalBestSettings.Score)
+		globalBestSettings = keepLockedParameters(globalBestSettings)
 
 		originalSoundSourcePos := soundSource.Position
 		originalListenerPos := listener.Position
@@ -313,18 +500,19 @@ func runLearningCycle() {
 		explorationFactor = globalBestSettings.ExplorationFactor
 		showOnlyListenerRays = globalBestSettings.ShowOnlyListenerRays
 
-		jsGlobal.Call("updateAllUISliders",
+		callOptionalJS("updateAllUISliders",
 			numRays, initialRayOpacity, maxReflections, volumeAttenuationFactor, explorationFactor,
 			soundSource.Position.X, soundSource.Position.Y, soundSource.Position.Z,
 			listener.Position.X, listener.Position.Y, listener.Position.Z,
 			showOnlyListenerRays,
 		)
-		jsGlobal.Call("updateLearningProgress", currentLearningIteration, maxLearningIterations, globalBestScore)
-		visualizeSoundPropagation()
-		log.Printf("Best settings applied: %+v", globalBestSettings)
+		reportLearningProgress()
+		visualizeSoundPropagation() // Full-quality (numRays) evaluation of the best candidate
+		log.Printf("Best settings applied: %+v (full-quality score: %d)", globalBestSettings, listenerRayScore)
 	} else {
 		log.Println("Learning finished. No global best settings to apply or objects are nil.")
 	}
+	callOptionalJS("updateRecordsDisplay", recordsManager.prepareRecordsForJS()) // Estimates the STI skipped during learning
 	log.Printf("Learning cycle finished. Final best score: %d. Iterations: %d", globalBestScore, currentLearningIteration)
 }
 
@@ -337,6 +525,7 @@ func goStartLearningMode(this js.Value, args []js.Value) interface{} {
 	log.Println("Starting Learning Mode (Cooperative Maximize)...")
 	learningModeActive = true
 	currentLearningIteration = 0
+	sessionStats.LearningSessions.Add(1)
 	globalBestScore = -1
 
 	if soundSource != nil {
@@ -369,13 +558,62 @@ func goStartLearningMode(this js.Value, args []js.Value) interface{} {
 		}
 	}
 
-	jsGlobal.Call("updateLearningButton", true, "Stop Learning (Coop. Maximize)")
-	jsGlobal.Call("updateLearningProgress", 0, maxLearningIterations, globalBestScore)
+	callOptionalJS("updateLearningButton", true, "Stop Learning (Coop. Maximize)")
+	learningClock.reset()
+	reportLearningProgress()
 
 	go runLearningCycle()
 	return nil
 }
 
+// goSetLearningStrategy(name) selects the learning strategy: "coordinate" (default), "cmaes",
+// "coarseToFine" or "twoPhase".
+func goSetLearningStrategy(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetLearningStrategy")
+	if len(args) != 1 {
+		log.Println("Error: goSetLearningStrategy expects 1 argument (strategy name)")
+		return false
+	}
+	if learningModeActive {
+		log.Println("Cannot change the learning strategy while learning mode is running.")
+		return false
+	}
+	strategy := args[0].String()
+	if !isLearningStrategy(strategy) {
+		log.Printf("Unknown learning strategy: %s", strategy)
+		return false
+	}
+	learningStrategy = strategy
+	log.Printf("Learning strategy set to %s", strategy)
+	return true
+}
+
+// isLearningStrategy reports whether name is a strategy goSetLearningStrategy accepts.
+func isLearningStrategy(name string) bool {
+	switch name {
+	case "coordinate", "cmaes", "coarseToFine", "twoPhase":
+		return true
+	}
+	return false
+}
+
+// goSetLearningTimeBudget(seconds) makes learning run for a wall-clock budget instead of a
+// fixed iteration count. 0 restores iteration-count mode.
+func goSetLearningTimeBudget(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetLearningTimeBudget")
+	if len(args) != 1 {
+		log.Println("Error: goSetLearningTimeBudget expects 1 argument (seconds)")
+		return false
+	}
+	seconds := args[0].Float()
+	if seconds < 0 {
+		log.Printf("Error: learning time budget must be non-negative, got %.1f", seconds)
+		return false
+	}
+	learningTimeBudget = time.Duration(seconds * float64(time.Second))
+	return true
+}
+
 func goStopLearningMode(this js.Value, args []js.Value) interface{} {
 	defer recoverFromPanic("goStopLearningMode")
 	if !learningModeActive {
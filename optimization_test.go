@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLearningInEmbeddedMode runs a short learning session on a page without the stock UI
+// callbacks and checks that it completes, applies its best placement, and estimates the STI of
+// its records only once it has finished.
+func TestLearningInEmbeddedMode(t *testing.T) {
+	initTestScene(t)
+	const iterations = 3
+	savedIterations, savedRays := maxLearningIterations, numRays
+	defer func() {
+		maxLearningIterations, numRays = savedIterations, savedRays
+		learningModeActive = false
+	}()
+	maxLearningIterations, numRays = iterations, 200
+
+	// Start a session as goStartLearningMode does, but run it here so its end can be awaited
+	learningModeActive, currentLearningIteration, globalBestScore = true, 0, -1
+	globalBestSettings = currentSettings(-1)
+	isSoundSourceTurn = true
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runLearningCycle()
+	}()
+	select {
+	case <-done:
+	case <-time.After(60 * time.Second):
+		t.Fatalf("learning still running after %d of %d iterations", currentLearningIteration, iterations)
+	}
+	if currentLearningIteration != iterations {
+		t.Errorf("learning stopped after %d iterations, want %d", currentLearningIteration, iterations)
+	}
+	if evalRayCountOverride != 0 {
+		t.Errorf("evaluation rays left overridden at %d", evalRayCountOverride)
+	}
+	if soundSource.Position != globalBestSettings.SoundSourcePos || listener.Position != globalBestSettings.ListenerPos {
+		t.Errorf("best placement not applied: source %v listener %v, best %v %v",
+			soundSource.Position, listener.Position, globalBestSettings.SoundSourcePos, globalBestSettings.ListenerPos)
+	}
+	for i, rec := range recordsManager.BestRecords {
+		if !rec.STIEstimated {
+			t.Errorf("record %d has no STI estimate after learning", i)
+		}
+	}
+}
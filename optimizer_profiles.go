@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"syscall/js"
+	"time"
+)
+
+// --- Optimizer Profiles ---
+//
+// A profile bundles every knob that shapes a learning run (strategy, step sizes, jump
+// probability, budgets, objective and constraints) under a name, so a setup that tuned one room
+// well can be exported as JSON and reused in another. New records carry the profile that was in
+// effect when they were found.
+
+const (
+	MIN_PROFILE_STEP_SIZE   = 0.05
+	MAX_PROFILE_STEP_SIZE   = 5.0
+	MIN_PROFILE_CMAES_SIGMA = 0.1
+	MAX_PROFILE_CMAES_SIGMA = 20.0
+	MAX_PROFILE_EXPLORATION = 10.0
+)
+
+// OptimizerProfile is the JSON form of the learning settings.
+type OptimizerProfile struct {
+	Name                    string   `json:"name"`
+	Strategy                string   `json:"strategy"`
+	StepSize                float64  `json:"stepSize"`   // Coordinate lattice step and jump unit (m)
+	CMAESSigma              float64  `json:"cmaesSigma"` // Initial CMA-ES step before explorationFactor (m)
+	ExplorationFactor       float64  `json:"explorationFactor"`
+	JumpProbability         float64  `json:"jumpProbability"`
+	MaxIterations           int      `json:"maxIterations"`
+	TimeBudgetSeconds       float64  `json:"timeBudgetSeconds"` // 0 runs for maxIterations
+	TwoPhaseExploreFraction float64  `json:"twoPhaseExploreFraction"`
+	Objective               string   `json:"objective"`
+	LockedParameters        []string `json:"lockedParameters"`
+	VisibilityPrefilter     bool     `json:"visibilityPrefilter"`
+	SmartInitialPlacement   bool     `json:"smartInitialPlacement"`
+	OptimizeFurniture       bool     `json:"optimizeFurniture"`
+	ScoreMemory             bool     `json:"scoreMemory"`
+}
+
+var (
+	optimizerProfiles      = map[string]OptimizerProfile{}
+	activeOptimizerProfile string // Name of the last profile applied or saved, "" if none
+)
+
+// currentOptimizerProfile captures the learning settings in effect under name.
+func currentOptimizerProfile(name string) OptimizerProfile {
+	locks := make([]string, 0, len(lockedParameters))
+	for _, n := range lockedParameterNames() {
+		locks = append(locks, n.(string))
+	}
+	return OptimizerProfile{
+		Name:                    name,
+		Strategy:                learningStrategy,
+		StepSize:                optimizationStepSize,
+		CMAESSigma:              cmaesInitialSigma,
+		ExplorationFactor:       explorationFactor,
+		JumpProbability:         randomJumpProbability,
+		MaxIterations:           maxLearningIterations,
+		TimeBudgetSeconds:       learningTimeBudget.Seconds(),
+		TwoPhaseExploreFraction: twoPhaseExploreFraction,
+		Objective:               learningObjective,
+		LockedParameters:        locks,
+		VisibilityPrefilter:     useVisibilityPrefilter,
+		SmartInitialPlacement:   useSmartInitialPlacement,
+		OptimizeFurniture:       optimizeFurniture,
+		ScoreMemory:             useScoreMemory,
+	}
+}
+
+// validate reports the first setting of p that learning cannot use.
+func (p OptimizerProfile) validate() error {
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("profile needs a name")
+	case !isLearningStrategy(p.Strategy):
+		return fmt.Errorf("unknown strategy %q", p.Strategy)
+	case !isLearningObjective(p.Objective):
+		return fmt.Errorf("unknown objective %q", p.Objective)
+	case p.StepSize < MIN_PROFILE_STEP_SIZE || p.StepSize > MAX_PROFILE_STEP_SIZE:
+		return fmt.Errorf("stepSize must be in [%g, %g]", MIN_PROFILE_STEP_SIZE, MAX_PROFILE_STEP_SIZE)
+	case p.CMAESSigma < MIN_PROFILE_CMAES_SIGMA || p.CMAESSigma > MAX_PROFILE_CMAES_SIGMA:
+		return fmt.Errorf("cmaesSigma must be in [%g, %g]", MIN_PROFILE_CMAES_SIGMA, MAX_PROFILE_CMAES_SIGMA)
+	case p.ExplorationFactor < 0 || p.ExplorationFactor > MAX_PROFILE_EXPLORATION:
+		return fmt.Errorf("explorationFactor must be in [0, %g]", MAX_PROFILE_EXPLORATION)
+	case p.JumpProbability < 0 || p.JumpProbability > 1:
+		return fmt.Errorf("jumpProbability must be in [0, 1]")
+	case p.MaxIterations < 1:
+		return fmt.Errorf("maxIterations must be at least 1")
+	case p.TimeBudgetSeconds < 0:
+		return fmt.Errorf("timeBudgetSeconds must be non-negative")
+	case p.TwoPhaseExploreFraction < 0 || p.TwoPhaseExploreFraction > 1:
+		return fmt.Errorf("twoPhaseExploreFraction must be in [0, 1]")
+	}
+	for _, name := range p.LockedParameters {
+		if !lockableParameters[name] {
+			return fmt.Errorf("%q cannot be locked", name)
+		}
+	}
+	return nil
+}
+
+// applyOptimizerProfile makes p's settings the learning settings. p must be valid.
+func applyOptimizerProfile(p OptimizerProfile) {
+	learningStrategy = p.Strategy
+	optimizationStepSize = p.StepSize
+	cmaesInitialSigma = p.CMAESSigma
+	explorationFactor = p.ExplorationFactor
+	randomJumpProbability = p.JumpProbability
+	maxLearningIterations = p.MaxIterations
+	learningTimeBudget = time.Duration(p.TimeBudgetSeconds * float64(time.Second))
+	twoPhaseExploreFraction = p.TwoPhaseExploreFraction
+	if learningObjective != p.Objective && occupancyCloud != nil {
+		occupancyCloud.ClearScoreMemory() // Scores of different objectives are not comparable
+	}
+	learningObjective = p.Objective
+	lockedParameters = map[string]bool{}
+	for _, name := range p.LockedParameters {
+		lockedParameters[name] = true
+	}
+	useVisibilityPrefilter = p.VisibilityPrefilter
+	useSmartInitialPlacement = p.SmartInitialPlacement
+	optimizeFurniture = p.OptimizeFurniture
+	useScoreMemory = p.ScoreMemory
+	activeOptimizerProfile = p.Name
+	resyncUISliders() // explorationFactor has a slider
+	log.Printf("Optimizer profile %q applied (%s strategy, %s objective)", p.Name, p.Strategy, p.Objective)
+}
+
+// optimizerProfileNames returns the stored profile names, sorted.
+func optimizerProfileNames() []interface{} {
+	names := make([]string, 0, len(optimizerProfiles))
+	for name := range optimizerProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]interface{}, len(names))
+	for i, n := range names {
+		result[i] = n
+	}
+	return result
+}
+
+// goSaveOptimizerProfile(name) stores the current learning settings as a profile and returns
+// the list of profile names.
+func goSaveOptimizerProfile(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSaveOptimizerProfile")
+	if len(args) != 1 || args[0].String() == "" {
+		log.Println("Error: goSaveOptimizerProfile expects 1 argument (profile name)")
+		return nil
+	}
+	name := args[0].String()
+	optimizerProfiles[name] = currentOptimizerProfile(name)
+	activeOptimizerProfile = name
+	return js.ValueOf(optimizerProfileNames())
+}
+
+// goExportOptimizerProfile([name]) returns a stored profile, or the current settings without a
+// name, as a JSON string.
+func goExportOptimizerProfile(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goExportOptimizerProfile")
+	profile := currentOptimizerProfile(activeOptimizerProfile)
+	if len(args) >= 1 {
+		stored, ok := optimizerProfiles[args[0].String()]
+		if !ok {
+			log.Printf("Error: unknown optimizer profile %q", args[0].String())
+			return nil
+		}
+		profile = stored
+	}
+	data, err := json.Marshal(profile)
+	if err != nil {
+		log.Printf("Error: goExportOptimizerProfile could not encode profile: %v", err)
+		return nil
+	}
+	return string(data)
+}
+
+// goImportOptimizerProfile(profileJSON[, apply]) validates and stores a profile (an object or
+// JSON string, as exported), applying it too if apply is true. Fields missing from the JSON keep
+// the current settings. Returns the profile's name, or null if it was rejected.
+func goImportOptimizerProfile(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goImportOptimizerProfile")
+	if len(args) < 1 || len(args) > 2 {
+		log.Println("Error: goImportOptimizerProfile expects 1 or 2 arguments (profileJSON[, apply])")
+		return nil
+	}
+	profile := currentOptimizerProfile("")
+	if err := json.Unmarshal([]byte(jsonArgString(args[0])), &profile); err != nil {
+		log.Printf("Error: goImportOptimizerProfile could not parse profile: %v", err)
+		return nil
+	}
+	if err := profile.validate(); err != nil {
+		log.Printf("Error: optimizer profile rejected: %v", err)
+		return nil
+	}
+	apply := len(args) == 2 && args[1].Bool()
+	if apply && learningModeActive {
+		log.Println("Cannot apply an optimizer profile while learning mode is running.")
+		return nil
+	}
+	optimizerProfiles[profile.Name] = profile
+	if apply {
+		applyOptimizerProfile(profile)
+	}
+	return profile.Name
+}
+
+// goApplyOptimizerProfile(nameOrRecordIndex) applies a stored profile by name, or the profile a
+// record was found with by its index. Returns true on success.
+func goApplyOptimizerProfile(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goApplyOptimizerProfile")
+	if len(args) != 1 {
+		log.Println("Error: goApplyOptimizerProfile expects 1 argument (profile name or record index)")
+		return false
+	}
+	if learningModeActive {
+		log.Println("Cannot apply an optimizer profile while learning mode is running.")
+		return false
+	}
+	var profile OptimizerProfile
+	if args[0].Type() == js.TypeNumber {
+		index := args[0].Int()
+		if index < 0 || index >= len(recordsManager.BestRecords) {
+			log.Printf("Error: Invalid record index %d. Max index %d", index, len(recordsManager.BestRecords)-1)
+			return false
+		}
+		profile = recordsManager.BestRecords[index].OptimizerProfile
+	} else {
+		stored, ok := optimizerProfiles[args[0].String()]
+		if !ok {
+			log.Printf("Error: unknown optimizer profile %q", args[0].String())
+			return false
+		}
+		profile = stored
+	}
+	if profile.Name == "" {
+		profile.Name = "unnamed"
+	}
+	if err := profile.validate(); err != nil {
+		log.Printf("Error: optimizer profile rejected: %v", err)
+		return false
+	}
+	applyOptimizerProfile(profile)
+	return true
+}
+
+// goListOptimizerProfiles() returns the stored profile names and the active one.
+func goListOptimizerProfiles(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goListOptimizerProfiles")
+	return js.ValueOf(map[string]interface{}{
+		"profiles": optimizerProfileNames(),
+		"active":   activeOptimizerProfile,
+	})
+}
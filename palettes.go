@@ -0,0 +1,117 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+	"syscall/js"
+)
+
+// --- Ray Color Palettes ---
+
+const (
+	DEFAULT_PALETTE        = "default"
+	MONOCHROME_BOUNCE_FADE = 0.65 // Opacity multiplier per bounce for palettes that encode bounces by opacity
+)
+
+// RayPalette colors ray segments by bounce count. Bounce colors cycle when there are more
+// reflections than colors. OpacityByBounce palettes use one hue and fade each bounce instead.
+type RayPalette struct {
+	Bounce          []uint32 // Index = reflections before the segment
+	Listener        uint32   // Segments that reach the listener
+	OpacityByBounce bool
+}
+
+// rayPalettes are the built-in palettes selectable with goSetPalette.
+var rayPalettes = map[string]RayPalette{
+	DEFAULT_PALETTE: {
+		Bounce: []uint32{
+			0xffff00, // 0 bounces (direct - though the listener color often overrides)
+			0xffa500, // 1 bounce
+			0xff00ff, // 2 bounces
+			0x00ffff, // 3 bounces
+			0x00fa9a, // 4
+			0xdda0dd, // 5
+			0xfa8072, // 6
+			0xadd8e6, // 7
+			0xf0e68c, // 8
+			0x90ee90, // 9
+			0xffc0cb, // 10
+		},
+		Listener: 0x00ff00, // Green for rays hitting the listener
+	},
+	"viridis": { // Perceptually uniform ramp, light for direct paths to dark for late bounces
+		Bounce:   []uint32{0xfde725, 0xbddf26, 0x7ad151, 0x44bf70, 0x22a884, 0x21918c, 0x2a788e, 0x355f8d, 0x414487, 0x482475, 0x440154},
+		Listener: 0xff3b30,
+	},
+	"colorblindSafe": { // Okabe-Ito colors, distinguishable with deuteranopia and protanopia
+		Bounce:   []uint32{0xf0e442, 0xe69f00, 0x56b4e9, 0xcc79a7, 0x0072b2, 0xd55e00, 0x999999},
+		Listener: 0x009e73,
+	},
+	"monochrome": {
+		Bounce:          []uint32{0xffffff},
+		Listener:        0xffffff,
+		OpacityByBounce: true,
+	},
+}
+
+var activePalette = DEFAULT_PALETTE
+
+// paletteBounceOpacity is the extra opacity factor the active palette applies to a segment after
+// the given number of reflections: 1 unless the palette encodes bounces by opacity.
+func paletteBounceOpacity(bounces int) float64 {
+	if !rayPalettes[activePalette].OpacityByBounce {
+		return 1
+	}
+	return math.Pow(MONOCHROME_BOUNCE_FADE, float64(bounces))
+}
+
+// setPalette makes a built-in palette active by updating bounceColors and listenerRayColor.
+func setPalette(name string) bool {
+	palette, ok := rayPalettes[name]
+	if !ok {
+		return false
+	}
+	activePalette = name
+	bounceColors = palette.Bounce
+	listenerRayColor = palette.Listener
+	return true
+}
+
+// paletteNames lists the built-in palettes, sorted.
+func paletteNames() []string {
+	names := make([]string, 0, len(rayPalettes))
+	for name := range rayPalettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// goSetPalette(name) switches the ray palette, regenerates the legend and re-traces so the rays
+// pick up the new colors. Returns false for unknown names.
+func goSetPalette(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetPalette")
+	if len(args) != 1 {
+		log.Println("Error: goSetPalette expects 1 argument (name)")
+		return false
+	}
+	name := args[0].String()
+	if !setPalette(name) {
+		log.Printf("Error: unknown palette %q (available: %v)", name, paletteNames())
+		return false
+	}
+	updateRayLegendJS()
+	visualizeSoundPropagation()
+	return true
+}
+
+// goGetPalettes returns {active, names}.
+func goGetPalettes(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetPalettes")
+	names := make([]interface{}, 0, len(rayPalettes))
+	for _, name := range paletteNames() {
+		names = append(names, name)
+	}
+	return js.ValueOf(map[string]interface{}{"active": activePalette, "names": names})
+}
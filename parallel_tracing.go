@@ -0,0 +1,71 @@
+package main
+
+// --- Parallel Ray Casting ---
+//
+// Each emitted ray of a visualization pass is traced into its own RayTrace: its segments,
+// surface impacts, listener capture and next-event credit. Nothing else is written while tracing,
+// so the rays of a batch are spread over the evaluation pool in chunks and merged into the pass in
+// ray order afterwards, giving the same segments, markers and score as tracing them one by one.
+// Tracer hooks run user callbacks that share state, so passes with hooks trace serially.
+
+const RAY_CHUNKS_PER_WORKER = 4 // Chunks per pool worker, so uneven rays still balance out
+
+var parallelRayCasting = true // Toggle "parallelRays"
+
+// RayTrace is everything tracing one emitted ray produces.
+type RayTrace struct {
+	PathID    int // Unique across the sources of a pass
+	RayIndex  int // Index of the ray within its source, for RayEvent
+	Segments  []TracedSegment
+	Impacts   []ImpactPoint
+	Captured  bool
+	Capture   ListenerCapture // The ray's lowest-order listener hit, when Captured
+	Arrival   ArrivalPoint    // Marker of Capture, when Captured
+	NeeCredit float64
+}
+
+// score is the ray's contribution to its source's listener score.
+func (t *RayTrace) score() float64 {
+	s := t.NeeCredit
+	if t.Captured {
+		s += arrivalScore(t.Capture.Bounces, t.Capture.Energy)
+	}
+	return s
+}
+
+// mergeRayTrace appends a traced ray's output to the pass state.
+func mergeRayTrace(t *RayTrace) {
+	tracedSegments = append(tracedSegments, t.Segments...)
+	impactPoints = append(impactPoints, t.Impacts...)
+	if t.Captured {
+		capture := t.Capture
+		arrivalPoints = append(arrivalPoints, t.Arrival)
+		capture.ArrivalIndex = len(arrivalPoints) - 1
+		pathCaptures[t.PathID] = capture
+	}
+}
+
+// tracesInParallel reports whether the visualization pass may use the evaluation pool.
+func tracesInParallel() bool {
+	return parallelRayCasting && len(tracerHooks) == 0 && evaluationPool != nil && evaluationPool.size > 1
+}
+
+// forEachChunked runs fn(i) for every i in [0, n), on the evaluation pool in contiguous chunks
+// when parallel is set. fn must only write to its own index.
+func forEachChunked(n int, parallel bool, fn func(i int)) {
+	if !parallel {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	chunks := evaluationPool.size * RAY_CHUNKS_PER_WORKER
+	if chunks > n {
+		chunks = n
+	}
+	parallelFor(chunks, func(c int) {
+		for i := c * n / chunks; i < (c+1)*n/chunks; i++ {
+			fn(i)
+		}
+	})
+}
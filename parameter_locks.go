@@ -0,0 +1,113 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"syscall/js"
+)
+
+// --- Parameter Locks (values the optimizer and record restores must not change) ---
+
+const (
+	LockSoundSourcePosition     = "soundSourcePosition"
+	LockListenerPosition        = "listenerPosition"
+	LockNumRays                 = "numRays"
+	LockInitialRayOpacity       = "initialRayOpacity"
+	LockMaxReflections          = "maxReflections"
+	LockVolumeAttenuationFactor = "volumeAttenuationFactor"
+	LockExplorationFactor       = "explorationFactor"
+	LockShowOnlyListenerRays    = "showOnlyListenerRays"
+)
+
+var lockableParameters = map[string]bool{
+	LockSoundSourcePosition: true, LockListenerPosition: true, LockNumRays: true,
+	LockInitialRayOpacity: true, LockMaxReflections: true, LockVolumeAttenuationFactor: true,
+	LockExplorationFactor: true, LockShowOnlyListenerRays: true,
+}
+
+var lockedParameters = map[string]bool{}
+
+// isParameterLocked reports whether name is frozen.
+func isParameterLocked(name string) bool {
+	return lockedParameters[name]
+}
+
+// positionLocked reports whether obj (the sound source or listener) may not be moved by learning.
+func positionLocked(obj *SceneObject) bool {
+	return (obj == soundSource && isParameterLocked(LockSoundSourcePosition)) ||
+		(obj == listener && isParameterLocked(LockListenerPosition))
+}
+
+// lockedPlacement replaces the locked parts of a candidate placement with the current positions.
+func lockedPlacement(sourcePos, listenerPos Vector3) (Vector3, Vector3) {
+	if isParameterLocked(LockSoundSourcePosition) && soundSource != nil {
+		sourcePos = soundSource.Position
+	}
+	if isParameterLocked(LockListenerPosition) && listener != nil {
+		listenerPos = listener.Position
+	}
+	return sourcePos, listenerPos
+}
+
+// keepLockedParameters returns settings with every locked field replaced by its current value,
+// so applying the result leaves locked parameters untouched.
+func keepLockedParameters(settings BestScoreSettings) BestScoreSettings {
+	settings.SoundSourcePos, settings.ListenerPos = lockedPlacement(settings.SoundSourcePos, settings.ListenerPos)
+	if isParameterLocked(LockNumRays) {
+		settings.NumRays = numRays
+	}
+	if isParameterLocked(LockInitialRayOpacity) {
+		settings.InitialRayOpacity = initialRayOpacity
+	}
+	if isParameterLocked(LockMaxReflections) {
+		settings.MaxReflections = maxReflections
+	}
+	if isParameterLocked(LockVolumeAttenuationFactor) {
+		settings.VolumeAttenuationFactor = volumeAttenuationFactor
+	}
+	if isParameterLocked(LockExplorationFactor) {
+		settings.ExplorationFactor = explorationFactor
+	}
+	if isParameterLocked(LockShowOnlyListenerRays) {
+		settings.ShowOnlyListenerRays = showOnlyListenerRays
+	}
+	return settings
+}
+
+// lockedParameterNames returns the locked parameters, sorted.
+func lockedParameterNames() []interface{} {
+	names := make([]string, 0, len(lockedParameters))
+	for name, locked := range lockedParameters {
+		if locked {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	result := make([]interface{}, len(names))
+	for i, n := range names {
+		result[i] = n
+	}
+	return result
+}
+
+// goLockParameter(name[, locked]) freezes (default) or unfreezes a parameter and returns the
+// list of locked parameters, or null for an unknown name.
+func goLockParameter(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goLockParameter")
+	if len(args) < 1 || len(args) > 2 {
+		log.Println("Error: goLockParameter expects 1 or 2 arguments (name[, locked])")
+		return nil
+	}
+	name := args[0].String()
+	if !lockableParameters[name] {
+		log.Printf("Error: goLockParameter: %q cannot be locked", name)
+		return nil
+	}
+	locked := len(args) < 2 || args[1].Bool()
+	if locked {
+		lockedParameters[name] = true
+	} else {
+		delete(lockedParameters, name)
+	}
+	return js.ValueOf(lockedParameterNames())
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// --- Pass Serialization ---
+//
+// Visualization passes are requested from several goroutines: debounced UI changes, the learning
+// loop, animations. Passes rebuild shared state (tracedSegments, rayVisuals, captures), so only
+// one runs at a time. Requests made while a pass is running wait for it and are then served
+// together by a single new pass, which reads the scene as the latest request left it; the other
+// waiting requests are dropped and counted in the session statistics. Every caller still returns
+// only after a pass that started after its request has finished, so listenerRayScore is current.
+
+var (
+	passMu        sync.Mutex   // Held for the whole of a pass, and while its ray visuals are rebuilt
+	passRequested atomic.Int64 // Number of passes requested so far
+	passServed    int64        // Requests covered by the last pass started; guarded by passMu
+)
+
+// visualizeSoundPropagation runs a visualization pass, or returns once a pass that started after
+// this call has finished.
+func visualizeSoundPropagation() {
+	request := passRequested.Add(1)
+	passMu.Lock()
+	defer passMu.Unlock()
+	if passServed >= request { // Served by a pass that began after this request
+		sessionStats.DroppedPasses.Add(1)
+		return
+	}
+	passServed = passRequested.Load()
+	runVisualizationPass()
+}
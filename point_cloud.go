@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"math"
+	"strconv"
 	"syscall/js"
 )
 
@@ -15,21 +16,105 @@ const (
 	StateSoundSource    PointState = 2 // Cell is currently occupied by the sound source
 	StateListener       PointState = 3 // Cell is currently occupied by the listener
 	StateOutOfBounds    PointState = 4 // Query was for a point outside the defined cloud boundaries
-	// Future states could include: StateExploredLowPotential, StateExploredHighPotential, etc.
+	StateForbidden      PointState = 5 // Cell lies inside a user-defined do-not-disturb zone
+	// How promising a cell is for learning is kept in its metadata (see score_memory.go), since
+	// an explored cell can be empty or occupied. New states should be added to pointStateInfo below.
 )
 
+// pointStateInfo describes each state so new states only need one entry here
+// to be named for JS and honored by placement checks.
+var pointStateInfo = map[PointState]struct {
+	Name            string
+	BlocksPlacement bool // Dynamic objects may not occupy cells in this state
+}{
+	StateEmpty:          {"empty", false},
+	StateStaticObstacle: {"staticObstacle", true},
+	StateSoundSource:    {"soundSource", false},
+	StateListener:       {"listener", false},
+	StateOutOfBounds:    {"outOfBounds", true},
+	StateForbidden:      {"forbidden", true},
+}
+
+// String returns the state's name, or "state<N>" for unregistered states.
+func (s PointState) String() string {
+	if info, ok := pointStateInfo[s]; ok {
+		return info.Name
+	}
+	return "state" + strconv.Itoa(int(s))
+}
+
+// BlocksPlacement reports whether the sound source or listener may not be placed in a cell with this state.
+func (s PointState) BlocksPlacement() bool {
+	return pointStateInfo[s].BlocksPlacement
+}
+
+// CellMetadata is optional per-cell bookkeeping. It is only allocated for cells that need it,
+// so the common case (an empty cell) stays a single byte plus a nil pointer.
+type CellMetadata struct {
+	OwnerID              string          // Name/ID of the object that last claimed this cell
+	LastUpdatedIteration int             // Learning iteration at which the cell was last written
+	Energy               float64         // Accumulated acoustic energy (e.g. from rays passing through)
+	SourceScores         CellScoreMemory // Learning scores with the sound source in this cell
+	ListenerScores       CellScoreMemory // Learning scores with the listener in this cell
+	Objects              []*SceneObject  // Static objects whose bounds overlap the cell (see ray_grid.go)
+}
+
+// CloudCell is one grid entry: its occupancy state and optional metadata.
+type CloudCell struct {
+	State PointState
+	Meta  *CellMetadata
+}
+
 // OccupancyCloud represents the discretized 3D space.
 // For simplicity, this initial version uses a 3D grid.
 // An Octree could be a future optimization for sparse environments.
 type OccupancyCloud struct {
-	Grid         [][][]PointState // The 3D grid storing the state of each cell
-	RoomMin      Vector3          // Min corner of the room in world coordinates (e.g., floor, back-left)
-	RoomMax      Vector3          // Max corner of the room in world coordinates (e.g., ceiling, front-right)
-	CellSize     Vector3          // Size of each cell in world units (x, y, z)
-	CellsX       int              // Number of cells along X-axis
-	CellsY       int              // Number of cells along Y-axis
-	CellsZ       int              // Number of cells along Z-axis
+	Grid         [][][]CloudCell // The 3D grid storing the state (and optional metadata) of each cell
+	RoomMin      Vector3         // Min corner of the room in world coordinates (e.g., floor, back-left)
+	RoomMax      Vector3         // Max corner of the room in world coordinates (e.g., ceiling, front-right)
+	CellSize     Vector3         // Size of each cell in world units (x, y, z)
+	CellsX       int             // Number of cells along X-axis
+	CellsY       int             // Number of cells along Y-axis
+	CellsZ       int             // Number of cells along Z-axis
 	DebugLogging bool
+
+	ForbiddenZones   []ForbiddenZone            // User-defined exclusion regions (doorway clearance, walking paths, ...)
+	ClearanceMargins map[PointState]float64     // Per dynamic object (by its state) padding; see ClearanceMargin
+	SDF              *SignedDistanceField       // Distance to static geometry, rebuilt by MarkStaticObstacles
+	ScoreRanges      map[PointState]*ScoreRange // Per moving object, the range of per-cell best scores
+	Indexed          map[*SceneObject]bool      // Static objects registered in the cells they overlap
+}
+
+// DEFAULT_CLEARANCE_MARGIN is the padding kept around a dynamic object when none was configured.
+const DEFAULT_CLEARANCE_MARGIN float64 = 0.1
+
+// ClearanceMargin returns the padding used for the dynamic object marked with the given state,
+// both when rasterizing it into the cloud and when validating its placement.
+func (oc *OccupancyCloud) ClearanceMargin(state PointState) float64 {
+	if margin, ok := oc.ClearanceMargins[state]; ok {
+		return margin
+	}
+	return DEFAULT_CLEARANCE_MARGIN
+}
+
+// SetClearanceMargin configures the padding for a dynamic object. Negative margins are rejected.
+func (oc *OccupancyCloud) SetClearanceMargin(state PointState, margin float64) bool {
+	if margin < 0 || math.IsNaN(margin) {
+		log.Printf("Error: clearance margin for %s must be non-negative, got %.3f", state, margin)
+		return false
+	}
+	if oc.ClearanceMargins == nil {
+		oc.ClearanceMargins = map[PointState]float64{}
+	}
+	oc.ClearanceMargins[state] = margin
+	return true
+}
+
+// ForbiddenZone is an axis-aligned region where neither the sound source nor the listener may be placed.
+type ForbiddenZone struct {
+	Name string
+	Min  Vector3
+	Max  Vector3
 }
 
 // NewOccupancyCloud creates and initializes a new occupancy cloud.
@@ -55,20 +140,20 @@ func NewOccupancyCloud(roomMin, roomMax Vector3, cellSize Vector3, debugLogging
 		cellsZ = 1
 	}
 
-	grid := make([][][]PointState, cellsX)
+	grid := make([][][]CloudCell, cellsX)
 	for i := range grid {
-		grid[i] = make([][]PointState, cellsY)
+		grid[i] = make([][]CloudCell, cellsY)
 		for j := range grid[i] {
-			grid[i][j] = make([]PointState, cellsZ)
+			grid[i][j] = make([]CloudCell, cellsZ)
 			// All cells initially empty
 			for k := range grid[i][j] {
-				grid[i][j][k] = StateEmpty
+				grid[i][j][k] = CloudCell{State: StateEmpty}
 			}
 		}
 	}
 	if debugLogging {
-		log.Printf("OccupancyCloud initialized: Dimensions [%.1f, %.1f, %.1f] to [%.1f, %.1f, %.1f]", roomMin.X, roomMin.Y, roomMin.Z, roomMax.X, roomMax.Y, roomMax.Z)
-		log.Printf("OccupancyCloud initialized: Cells %d x %d x %d, CellSize: %.2f x %.2f x %.2f", cellsX, cellsY, cellsZ, cellSize.X, cellSize.Y, cellSize.Z)
+		logEvent(LogCloud, LogNotice, "OccupancyCloud initialized: Dimensions [%.1f, %.1f, %.1f] to [%.1f, %.1f, %.1f]", roomMin.X, roomMin.Y, roomMin.Z, roomMax.X, roomMax.Y, roomMax.Z)
+		logEvent(LogCloud, LogNotice, "OccupancyCloud initialized: Cells %d x %d x %d, CellSize: %.2f x %.2f x %.2f", cellsX, cellsY, cellsZ, cellSize.X, cellSize.Y, cellSize.Z)
 	}
 
 	return &OccupancyCloud{
@@ -83,6 +168,48 @@ func NewOccupancyCloud(roomMin, roomMax Vector3, cellSize Vector3, debugLogging
 	}
 }
 
+// initOccupancyCloud (re)builds the global occupancy cloud for the current room and static scene.
+// Forbidden zones from a previous cloud are carried over.
+func initOccupancyCloud() {
+	var previousZones []ForbiddenZone
+	var previousMargins map[PointState]float64
+	if occupancyCloud != nil {
+		previousZones = occupancyCloud.ForbiddenZones
+		previousMargins = occupancyCloud.ClearanceMargins
+	}
+	roomMin := Vector3{-roomWidth / 2, 0, -roomDepth / 2}
+	roomMax := Vector3{roomWidth / 2, roomHeight, roomDepth / 2}
+	invalidateCollidableSets() // Their ray grids refer to the old cloud
+	occupancyCloud = NewOccupancyCloud(roomMin, roomMax, Vector3{OCCUPANCY_CELL_SIZE, OCCUPANCY_CELL_SIZE, OCCUPANCY_CELL_SIZE}, false)
+	occupancyCloud.MarkStaticObstacles(staticSceneObjects)
+	occupancyCloud.ClearanceMargins = previousMargins
+	for _, zone := range previousZones {
+		occupancyCloud.AddForbiddenZone(zone.Name, zone.Min, zone.Max)
+	}
+	if soundSource != nil {
+		occupancyCloud.UpdateObjectInCloud(soundSource.Name, soundSource.Position, soundSource.Position, soundSource.Scale, StateSoundSource)
+	}
+	if listener != nil {
+		occupancyCloud.UpdateObjectInCloud(listener.Name, listener.Position, listener.Position, listener.Scale, StateListener)
+	}
+}
+
+// resyncDynamicObjectsInCloud clears and re-marks the sound source and listener cells.
+// Call after moving both objects at once, where incremental updates could erase each other's cells.
+func resyncDynamicObjectsInCloud() {
+	if occupancyCloud == nil {
+		return
+	}
+	occupancyCloud.ClearState(StateSoundSource)
+	occupancyCloud.ClearState(StateListener)
+	if soundSource != nil {
+		occupancyCloud.UpdateObjectInCloud(soundSource.Name, soundSource.Position, soundSource.Position, soundSource.Scale, StateSoundSource)
+	}
+	if listener != nil {
+		occupancyCloud.UpdateObjectInCloud(listener.Name, listener.Position, listener.Position, listener.Scale, StateListener)
+	}
+}
+
 // worldToGridCoords converts world coordinates to grid cell indices.
 // Returns indices and a bool indicating if the coordinates are within bounds.
 func (oc *OccupancyCloud) worldToGridCoords(worldPos Vector3) (ix, iy, iz int, inBounds bool) {
@@ -114,21 +241,135 @@ func clampInt(val, min, max int) int {
 	return val
 }
 
+// gridIndexUnclamped returns the cell indices containing worldPos without any bounds handling,
+// so positions outside the cloud map to indices < 0 or >= Cells*.
+func (oc *OccupancyCloud) gridIndexUnclamped(worldPos Vector3) (ix, iy, iz int) {
+	ix = int(math.Floor((worldPos.X - oc.RoomMin.X) / oc.CellSize.X))
+	iy = int(math.Floor((worldPos.Y - oc.RoomMin.Y) / oc.CellSize.Y))
+	iz = int(math.Floor((worldPos.Z - oc.RoomMin.Z) / oc.CellSize.Z))
+	return ix, iy, iz
+}
+
+// CellRange is an inclusive range of cell indices.
+type CellRange struct {
+	MinIX, MinIY, MinIZ int
+	MaxIX, MaxIY, MaxIZ int
+}
+
+// cellRangeForAABB returns the (unclamped) index range of cells touched by an AABB.
+// Indices may lie outside the grid; getCellState reports those as StateOutOfBounds.
+func (oc *OccupancyCloud) cellRangeForAABB(aabbMin, aabbMax Vector3) CellRange {
+	minIX, minIY, minIZ := oc.gridIndexUnclamped(aabbMin)
+	maxIX, maxIY, maxIZ := oc.gridIndexUnclamped(aabbMax)
+	return CellRange{minIX, minIY, minIZ, maxIX, maxIY, maxIZ}
+}
+
+// clampedCellRangeForAABB returns the range of in-grid cells touched by an AABB. An AABB that
+// only partially overlaps the cloud is clipped to it; overlaps is false if it lies fully outside.
+func (oc *OccupancyCloud) clampedCellRangeForAABB(aabbMin, aabbMax Vector3) (r CellRange, overlaps bool) {
+	r = oc.cellRangeForAABB(aabbMin, aabbMax)
+	if r.MaxIX < 0 || r.MaxIY < 0 || r.MaxIZ < 0 ||
+		r.MinIX >= oc.CellsX || r.MinIY >= oc.CellsY || r.MinIZ >= oc.CellsZ {
+		return CellRange{}, false
+	}
+	r.MinIX, r.MaxIX = clampInt(r.MinIX, 0, oc.CellsX-1), clampInt(r.MaxIX, 0, oc.CellsX-1)
+	r.MinIY, r.MaxIY = clampInt(r.MinIY, 0, oc.CellsY-1), clampInt(r.MaxIY, 0, oc.CellsY-1)
+	r.MinIZ, r.MaxIZ = clampInt(r.MinIZ, 0, oc.CellsZ-1), clampInt(r.MaxIZ, 0, oc.CellsZ-1)
+	return r, true
+}
+
+// isAABBInsideCloud reports whether an AABB lies entirely within the cloud bounds.
+func (oc *OccupancyCloud) isAABBInsideCloud(aabbMin, aabbMax Vector3) bool {
+	r := oc.cellRangeForAABB(aabbMin, aabbMax)
+	return r.MinIX >= 0 && r.MinIY >= 0 && r.MinIZ >= 0 &&
+		r.MaxIX < oc.CellsX && r.MaxIY < oc.CellsY && r.MaxIZ < oc.CellsZ
+}
+
+// StateAt returns the state of the cell containing worldPos, or StateOutOfBounds outside the cloud.
+func (oc *OccupancyCloud) StateAt(worldPos Vector3) PointState {
+	ix, iy, iz, inBounds := oc.worldToGridCoords(worldPos)
+	if !inBounds {
+		return StateOutOfBounds
+	}
+	return oc.getCellState(ix, iy, iz)
+}
+
 // getCellState retrieves the state of a cell by its grid indices.
 func (oc *OccupancyCloud) getCellState(ix, iy, iz int) PointState {
 	if ix < 0 || ix >= oc.CellsX || iy < 0 || iy >= oc.CellsY || iz < 0 || iz >= oc.CellsZ {
 		return StateOutOfBounds
 	}
-	return oc.Grid[ix][iy][iz]
+	return oc.Grid[ix][iy][iz].State
 }
 
 // setCellState sets the state of a cell by its grid indices.
+// If the cell carries metadata, its last-updated iteration is refreshed.
 func (oc *OccupancyCloud) setCellState(ix, iy, iz int, state PointState) {
 	if ix >= 0 && ix < oc.CellsX && iy >= 0 && iy < oc.CellsY && iz >= 0 && iz < oc.CellsZ {
-		oc.Grid[ix][iy][iz] = state
+		cell := &oc.Grid[ix][iy][iz]
+		cell.State = state
+		if cell.Meta != nil {
+			cell.Meta.LastUpdatedIteration = currentLearningIteration
+		}
 	} else {
 		if oc.DebugLogging {
-			log.Printf("Attempted to set state for out-of-bounds cell: (%d, %d, %d)", ix, iy, iz)
+			logEvent(LogCloud, LogDetail, "Attempted to set state for out-of-bounds cell: (%d, %d, %d)", ix, iy, iz)
+		}
+	}
+}
+
+// setCellStateWithOwner sets the state of a cell and records which object claimed it.
+func (oc *OccupancyCloud) setCellStateWithOwner(ix, iy, iz int, state PointState, ownerID string) {
+	meta := oc.cellMetadata(ix, iy, iz, true)
+	if meta == nil {
+		return // Out of bounds
+	}
+	meta.OwnerID = ownerID
+	oc.setCellState(ix, iy, iz, state)
+}
+
+// cellMetadata returns the metadata for a cell, allocating it if create is true.
+// Returns nil for out-of-bounds cells or cells without metadata when create is false.
+func (oc *OccupancyCloud) cellMetadata(ix, iy, iz int, create bool) *CellMetadata {
+	if ix < 0 || ix >= oc.CellsX || iy < 0 || iy >= oc.CellsY || iz < 0 || iz >= oc.CellsZ {
+		return nil
+	}
+	cell := &oc.Grid[ix][iy][iz]
+	if cell.Meta == nil && create {
+		cell.Meta = &CellMetadata{LastUpdatedIteration: currentLearningIteration}
+	}
+	return cell.Meta
+}
+
+// CellMetadataAt returns the metadata of the cell containing worldPos, or nil if it has none.
+func (oc *OccupancyCloud) CellMetadataAt(worldPos Vector3) *CellMetadata {
+	ix, iy, iz, inBounds := oc.worldToGridCoords(worldPos)
+	if !inBounds {
+		return nil
+	}
+	return oc.cellMetadata(ix, iy, iz, false)
+}
+
+// AccumulateEnergy adds energy to the cell containing worldPos.
+func (oc *OccupancyCloud) AccumulateEnergy(worldPos Vector3, energy float64) {
+	ix, iy, iz, inBounds := oc.worldToGridCoords(worldPos)
+	if !inBounds {
+		return
+	}
+	meta := oc.cellMetadata(ix, iy, iz, true)
+	meta.Energy += energy
+	meta.LastUpdatedIteration = currentLearningIteration
+}
+
+// ResetEnergy clears accumulated energy in all cells, keeping the rest of the metadata.
+func (oc *OccupancyCloud) ResetEnergy() {
+	for ix := 0; ix < oc.CellsX; ix++ {
+		for iy := 0; iy < oc.CellsY; iy++ {
+			for iz := 0; iz < oc.CellsZ; iz++ {
+				if meta := oc.Grid[ix][iy][iz].Meta; meta != nil {
+					meta.Energy = 0
+				}
+			}
 		}
 	}
 }
@@ -137,42 +378,41 @@ func (oc *OccupancyCloud) setCellState(ix, iy, iz int, state PointState) {
 // This should be called once after scene creation.
 func (oc *OccupancyCloud) MarkStaticObstacles(staticObjects []*SceneObject) {
 	if oc.DebugLogging {
-		log.Printf("Marking %d static obstacles in occupancy cloud...", len(staticObjects))
+		logEvent(LogCloud, LogDetail, "Marking %d static obstacles in occupancy cloud...", len(staticObjects))
 	}
 	for _, obj := range staticObjects {
 		if !obj.IsStatic { // Should only be static objects
 			continue
 		}
 		// For each object, determine the AABB of cells it occupies.
-		// This is a simplification; more accurate rasterization might be needed for non-box shapes or rotated boxes.
-		objMin := obj.Position.Sub(obj.Scale.Scale(0.5)) // Assumes scale is full dimensions
-		objMax := obj.Position.Add(obj.Scale.Scale(0.5))
-
-		minIX, minIY, minIZ, inBoundsMin := oc.worldToGridCoords(objMin)
-		maxIX, maxIY, maxIZ, inBoundsMax := oc.worldToGridCoords(objMax)
-
-		if !(inBoundsMin && inBoundsMax) {
-			// If even parts of the object are out of bounds, it might be an issue with room/cloud setup.
-			// For now, we'll only mark the parts that are in bounds.
-			if oc.DebugLogging {
-				log.Printf("Static object %s partially or fully out of cloud bounds during marking.", obj.Name)
-			}
+		// This is a simplification; rotated boxes mark their whole enclosing box.
+		objMin, objMax := objectWorldBounds(obj)
+
+		if !oc.isAABBInsideCloud(objMin, objMax) && oc.DebugLogging {
+			// Walls and the floor straddle the room boundary; only their in-bounds part is marked.
+			logEvent(LogCloud, LogDetail, "Static object %s partially or fully out of cloud bounds during marking.", obj.Name)
+		}
+		r, overlaps := oc.clampedCellRangeForAABB(objMin, objMax)
+		if !overlaps {
+			continue
 		}
 
-		for ix := minIX; ix <= maxIX; ix++ {
-			for iy := minIY; iy <= maxIY; iy++ {
-				for iz := minIZ; iz <= maxIZ; iz++ {
+		for ix := r.MinIX; ix <= r.MaxIX; ix++ {
+			for iy := r.MinIY; iy <= r.MaxIY; iy++ {
+				for iz := r.MinIZ; iz <= r.MaxIZ; iz++ {
 					// Further check if cell center is within object for non-box shapes (approx)
 					// For boxes aligned with grid, this AABB approach is okay.
 					// For spheres, one would check if cell_center to obj_center distance < radius
 					// This basic version marks the AABB of the object's AABB in the grid.
-					oc.setCellState(ix, iy, iz, StateStaticObstacle)
+					oc.setCellStateWithOwner(ix, iy, iz, StateStaticObstacle, obj.ID)
 				}
 			}
 		}
+		oc.indexObject(obj)
 	}
+	oc.SDF = NewSignedDistanceField(oc, staticObjects)
 	if oc.DebugLogging {
-		log.Println("Static obstacles marked.")
+		logEvent(LogCloud, LogDetail, "Static obstacles marked.")
 	}
 }
 
@@ -186,18 +426,17 @@ func (oc *OccupancyCloud) UpdateObjectInCloud(objName string, oldPosition, newPo
 	// This needs to know the object's extent (e.g., radius for sphere, AABB for box)
 	// For simplicity, assume spherical objects for dynamic ones initially.
 	// Effective radius for cell marking (can be larger than actual radius to be conservative)
-	markRadius := math.Max(objScale.X, math.Max(objScale.Y, objScale.Z))/2.0 + oc.CellSize.X // Add cellsize for safety margin
+	markRadius := math.Max(objScale.X, math.Max(objScale.Y, objScale.Z))/2.0 + oc.ClearanceMargin(newState) // Add the object's clearance margin
 
 	// Clear old cells
 	// Iterate over a bounding box of cells around the old position
 	oldMin := oldPosition.Sub(Vector3{markRadius, markRadius, markRadius})
 	oldMax := oldPosition.Add(Vector3{markRadius, markRadius, markRadius})
-	oldMinIX, oldMinIY, oldMinIZ, _ := oc.worldToGridCoords(oldMin)
-	oldMaxIX, oldMaxIY, oldMaxIZ, _ := oc.worldToGridCoords(oldMax)
+	oldRange, oldOverlaps := oc.clampedCellRangeForAABB(oldMin, oldMax) // Clipped, so objects at the room edge still clear their cells
 
-	for ix := oldMinIX; ix <= oldMaxIX; ix++ {
-		for iy := oldMinIY; iy <= oldMaxIY; iy++ {
-			for iz := oldMinIZ; iz <= oldMaxIZ; iz++ {
+	for ix := oldRange.MinIX; oldOverlaps && ix <= oldRange.MaxIX; ix++ {
+		for iy := oldRange.MinIY; iy <= oldRange.MaxIY; iy++ {
+			for iz := oldRange.MinIZ; iz <= oldRange.MaxIZ; iz++ {
 				if oc.getCellState(ix, iy, iz) == newState { // Only clear if it was marked by this object type
 					oc.setCellState(ix, iy, iz, StateEmpty)
 				}
@@ -208,12 +447,11 @@ func (oc *OccupancyCloud) UpdateObjectInCloud(objName string, oldPosition, newPo
 	// 2. Mark the new position
 	newMin := newPosition.Sub(Vector3{markRadius, markRadius, markRadius})
 	newMax := newPosition.Add(Vector3{markRadius, markRadius, markRadius})
-	newMinIX, newMinIY, newMinIZ, _ := oc.worldToGridCoords(newMin)
-	newMaxIX, newMaxIY, newMaxIZ, _ := oc.worldToGridCoords(newMax)
+	newRange, newOverlaps := oc.clampedCellRangeForAABB(newMin, newMax)
 
-	for ix := newMinIX; ix <= newMaxIX; ix++ {
-		for iy := newMinIY; iy <= newMaxIY; iy++ {
-			for iz := newMinIZ; iz <= newMaxIZ; iz++ {
+	for ix := newRange.MinIX; newOverlaps && ix <= newRange.MaxIX; ix++ {
+		for iy := newRange.MinIY; iy <= newRange.MaxIY; iy++ {
+			for iz := newRange.MinIZ; iz <= newRange.MaxIZ; iz++ {
 				// Check if cell is within actual object sphere at new position
 				cellCenterX := oc.RoomMin.X + (float64(ix)+0.5)*oc.CellSize.X
 				cellCenterY := oc.RoomMin.Y + (float64(iy)+0.5)*oc.CellSize.Y
@@ -223,7 +461,7 @@ func (oc *OccupancyCloud) UpdateObjectInCloud(objName string, oldPosition, newPo
 				if cellCenter.Sub(newPosition).Length() < markRadius { // Using markRadius, effectively rasterizing a sphere
 					currentState := oc.getCellState(ix, iy, iz)
 					if currentState == StateEmpty { // Only mark if empty, don't overwrite obstacles
-						oc.setCellState(ix, iy, iz, newState)
+						oc.setCellStateWithOwner(ix, iy, iz, newState, objName)
 					} else if currentState != StateStaticObstacle && oc.DebugLogging {
 						// log.Printf("Cloud conflict: %s wants to occupy cell (%d,%d,%d) with state %d, but it's %d", objName, ix,iy,iz, newState, currentState)
 					}
@@ -236,50 +474,245 @@ func (oc *OccupancyCloud) UpdateObjectInCloud(objName string, oldPosition, newPo
 	}
 }
 
+// ClearState resets every cell currently in the given state to StateEmpty.
+// Useful when dynamic objects jump (swap, mirror, restore) and their old cells are not known precisely.
+func (oc *OccupancyCloud) ClearState(state PointState) {
+	for ix := 0; ix < oc.CellsX; ix++ {
+		for iy := 0; iy < oc.CellsY; iy++ {
+			for iz := 0; iz < oc.CellsZ; iz++ {
+				if oc.Grid[ix][iy][iz].State == state {
+					oc.setCellState(ix, iy, iz, StateEmpty)
+				}
+			}
+		}
+	}
+}
+
 // IsPositionAttemptValid checks if a proposed position for a dynamic object is valid according to the cloud.
 // It checks against static obstacles and the *other* dynamic object.
 // movingObjType should be StateSoundSource or StateListener.
 // otherObjCurrentPos is the current center of the *other* dynamic object.
 // otherObjScale is the scale of the *other* dynamic object.
 func (oc *OccupancyCloud) IsPositionAttemptValid(proposedPos Vector3, movingObjScale Vector3, movingObjType PointState, otherObjCurrentPos Vector3, otherObjScale Vector3) bool {
-	// Determine cells the moving object would occupy at proposedPos
 	objRadius := math.Max(movingObjScale.X, math.Max(movingObjScale.Y, movingObjScale.Z)) / 2.0
+	clearanceRadius := objRadius + oc.ClearanceMargin(movingObjType) // Keep the margin free of obstacles and zones
 
-	// Iterate over a bounding box of cells the object might touch
-	objMin := proposedPos.Sub(Vector3{objRadius, objRadius, objRadius})
-	objMax := proposedPos.Add(Vector3{objRadius, objRadius, objRadius})
-	minIX, minIY, minIZ, _ := oc.worldToGridCoords(objMin)
-	maxIX, maxIY, maxIZ, _ := oc.worldToGridCoords(objMax)
+	// Forbidden zones are tested exactly; small objects may not cover any of their cell centers
+	if oc.intersectsForbiddenZone(proposedPos, clearanceRadius) {
+		return false
+	}
 
-	for ix := minIX; ix <= maxIX; ix++ {
-		for iy := minIY; iy <= maxIY; iy++ {
-			for iz := minIZ; iz <= maxIZ; iz++ {
-				cellCenterX := oc.RoomMin.X + (float64(ix)+0.5)*oc.CellSize.X
-				cellCenterY := oc.RoomMin.Y + (float64(iy)+0.5)*oc.CellSize.Y
+	// Static geometry comes from the SDF rather than per-cell sphere tests; the room bounds are
+	// checked exactly since the SDF lattice is clamped at the cloud edge. The interpolated sample
+	// misses geometry thinner than a cell, so it only settles positions clearly free or clearly
+	// blocked, and the exact distance decides the rest.
+	distance := -boxSDF(proposedPos, oc.RoomMin, oc.RoomMax)
+	if oc.SDF != nil {
+		sample := oc.SDF.Sample(proposedPos)
+		if math.Abs(sample-clearanceRadius) <= oc.SDF.SampleError() {
+			sample = oc.SDF.Exact(proposedPos)
+		}
+		distance = math.Min(distance, sample)
+	}
+	if distance < clearanceRadius {
+		return false
+	}
+
+	// Check collision with the *other* dynamic object directly (more accurate than relying on its cloud state for this check)
+	if spheresIntersect(proposedPos, objRadius, otherObjCurrentPos, math.Max(otherObjScale.X, otherObjScale.Z)/2.0) {
+		return false
+	}
+	return true // Position is valid according to the cloud and direct other-object check
+}
+
+// AddForbiddenZone registers (or replaces, if the name is already used) a do-not-disturb zone
+// and marks its cells as StateForbidden. Corners may be given in any order.
+func (oc *OccupancyCloud) AddForbiddenZone(name string, corner1, corner2 Vector3) {
+	zone := ForbiddenZone{
+		Name: name,
+		Min:  Vector3{math.Min(corner1.X, corner2.X), math.Min(corner1.Y, corner2.Y), math.Min(corner1.Z, corner2.Z)},
+		Max:  Vector3{math.Max(corner1.X, corner2.X), math.Max(corner1.Y, corner2.Y), math.Max(corner1.Z, corner2.Z)},
+	}
+	for i, existing := range oc.ForbiddenZones {
+		if existing.Name == name {
+			oc.ForbiddenZones[i] = zone
+			oc.remarkForbiddenZones()
+			return
+		}
+	}
+	oc.ForbiddenZones = append(oc.ForbiddenZones, zone)
+	oc.markForbiddenZone(zone)
+	if oc.DebugLogging {
+		logEvent(LogCloud, LogDetail, "Forbidden zone %s added: [%.1f, %.1f, %.1f] to [%.1f, %.1f, %.1f]", name, zone.Min.X, zone.Min.Y, zone.Min.Z, zone.Max.X, zone.Max.Y, zone.Max.Z)
+	}
+}
+
+// RemoveForbiddenZone deletes a zone by name. Returns false if no such zone exists.
+func (oc *OccupancyCloud) RemoveForbiddenZone(name string) bool {
+	for i, zone := range oc.ForbiddenZones {
+		if zone.Name == name {
+			oc.ForbiddenZones = append(oc.ForbiddenZones[:i], oc.ForbiddenZones[i+1:]...)
+			oc.remarkForbiddenZones() // Zones may overlap, so rebuild rather than clearing just this one
+			return true
+		}
+	}
+	return false
+}
+
+// ClearForbiddenZones removes all do-not-disturb zones.
+func (oc *OccupancyCloud) ClearForbiddenZones() {
+	oc.ForbiddenZones = nil
+	oc.remarkForbiddenZones()
+}
+
+// markForbiddenZone marks all empty cells whose centers fall inside the zone.
+// Obstacles and current object cells are left untouched.
+func (oc *OccupancyCloud) markForbiddenZone(zone ForbiddenZone) {
+	for ix := 0; ix < oc.CellsX; ix++ {
+		cellCenterX := oc.RoomMin.X + (float64(ix)+0.5)*oc.CellSize.X
+		if cellCenterX < zone.Min.X || cellCenterX > zone.Max.X {
+			continue
+		}
+		for iy := 0; iy < oc.CellsY; iy++ {
+			cellCenterY := oc.RoomMin.Y + (float64(iy)+0.5)*oc.CellSize.Y
+			if cellCenterY < zone.Min.Y || cellCenterY > zone.Max.Y {
+				continue
+			}
+			for iz := 0; iz < oc.CellsZ; iz++ {
 				cellCenterZ := oc.RoomMin.Z + (float64(iz)+0.5)*oc.CellSize.Z
-				cellCenter := Vector3{cellCenterX, cellCenterY, cellCenterZ}
+				if cellCenterZ < zone.Min.Z || cellCenterZ > zone.Max.Z {
+					continue
+				}
+				if oc.Grid[ix][iy][iz].State == StateEmpty {
+					oc.setCellStateWithOwner(ix, iy, iz, StateForbidden, zone.Name)
+				}
+			}
+		}
+	}
+}
 
-				// Check if this cell center is actually within the sphere of the moving object
-				if cellCenter.Sub(proposedPos).Length() < objRadius {
-					cellState := oc.getCellState(ix, iy, iz)
-
-					if cellState == StateOutOfBounds {
-						return false
-					} // Trying to move out of defined cloud
-					if cellState == StateStaticObstacle {
-						return false
-					} // Collision with static obstacle
-
-					// Check collision with the *other* dynamic object directly (more accurate than relying on its cloud state for this check)
-					// This avoids issues if the other object's cloud state hasn't updated yet or for precision.
-					if spheresIntersect(proposedPos, objRadius, otherObjCurrentPos, math.Max(otherObjScale.X, otherObjScale.Z)/2.0) {
-						return false
-					}
+// remarkForbiddenZones clears every StateForbidden cell and marks the remaining zones again.
+func (oc *OccupancyCloud) remarkForbiddenZones() {
+	for ix := 0; ix < oc.CellsX; ix++ {
+		for iy := 0; iy < oc.CellsY; iy++ {
+			for iz := 0; iz < oc.CellsZ; iz++ {
+				if oc.Grid[ix][iy][iz].State == StateForbidden {
+					oc.setCellState(ix, iy, iz, StateEmpty)
 				}
 			}
 		}
 	}
-	return true // Position is valid according to the cloud and direct other-object check
+	for _, zone := range oc.ForbiddenZones {
+		oc.markForbiddenZone(zone)
+	}
+}
+
+// intersectsForbiddenZone reports whether a sphere overlaps any forbidden zone (sphere-AABB test).
+func (oc *OccupancyCloud) intersectsForbiddenZone(pos Vector3, radius float64) bool {
+	for _, zone := range oc.ForbiddenZones {
+		closestX := math.Max(zone.Min.X, math.Min(pos.X, zone.Max.X))
+		closestY := math.Max(zone.Min.Y, math.Min(pos.Y, zone.Max.Y))
+		closestZ := math.Max(zone.Min.Z, math.Min(pos.Z, zone.Max.Z))
+		distanceSq := (closestX-pos.X)*(closestX-pos.X) +
+			(closestY-pos.Y)*(closestY-pos.Y) +
+			(closestZ-pos.Z)*(closestZ-pos.Z)
+		if distanceSq < radius*radius {
+			return true
+		}
+	}
+	return false
+}
+
+// traverseCells walks every cell crossed by the segment from -> to, in order, using the
+// Amanatides-Woo 3D-DDA algorithm. The segment is clipped to the cloud bounds first.
+// visit also receives where the segment leaves the cell (0 at from, 1 at to), and returns false
+// to stop the traversal early.
+func (oc *OccupancyCloud) traverseCells(from, to Vector3, visit func(ix, iy, iz int, leave float64) bool) {
+	origin := [3]float64{from.X, from.Y, from.Z}
+	dir := [3]float64{to.X - from.X, to.Y - from.Y, to.Z - from.Z}
+	gridMin := [3]float64{oc.RoomMin.X, oc.RoomMin.Y, oc.RoomMin.Z}
+	cellSize := [3]float64{oc.CellSize.X, oc.CellSize.Y, oc.CellSize.Z}
+	cellCounts := [3]int{oc.CellsX, oc.CellsY, oc.CellsZ}
+
+	// Clip the segment (parameterized over t in [0, 1]) against the grid bounds
+	tEnter, tExit := 0.0, 1.0
+	for a := 0; a < 3; a++ {
+		lo := gridMin[a]
+		hi := gridMin[a] + float64(cellCounts[a])*cellSize[a]
+		if math.Abs(dir[a]) < EPSILON {
+			if origin[a] < lo || origin[a] > hi {
+				return // Parallel to and outside this slab
+			}
+			continue
+		}
+		t0 := (lo - origin[a]) / dir[a]
+		t1 := (hi - origin[a]) / dir[a]
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tEnter = math.Max(tEnter, t0)
+		tExit = math.Min(tExit, t1)
+		if tEnter > tExit {
+			return // Segment misses the grid
+		}
+	}
+
+	var cell, step [3]int
+	var tMax, tDelta [3]float64
+	for a := 0; a < 3; a++ {
+		entry := origin[a] + dir[a]*tEnter
+		cell[a] = clampInt(int(math.Floor((entry-gridMin[a])/cellSize[a])), 0, cellCounts[a]-1)
+		switch {
+		case dir[a] > EPSILON:
+			step[a] = 1
+			tMax[a] = (gridMin[a] + float64(cell[a]+1)*cellSize[a] - origin[a]) / dir[a]
+			tDelta[a] = cellSize[a] / dir[a]
+		case dir[a] < -EPSILON:
+			step[a] = -1
+			tMax[a] = (gridMin[a] + float64(cell[a])*cellSize[a] - origin[a]) / dir[a]
+			tDelta[a] = -cellSize[a] / dir[a]
+		default:
+			tMax[a] = math.Inf(1)
+			tDelta[a] = math.Inf(1)
+		}
+	}
+
+	for {
+		// Find the axis whose next cell boundary is closest
+		a := 0
+		if tMax[1] < tMax[a] {
+			a = 1
+		}
+		if tMax[2] < tMax[a] {
+			a = 2
+		}
+		if !visit(cell[0], cell[1], cell[2], math.Min(tMax[a], tExit)) {
+			return
+		}
+		if tMax[a] > tExit {
+			return // Reached the end of the segment
+		}
+		cell[a] += step[a]
+		if cell[a] < 0 || cell[a] >= cellCounts[a] {
+			return
+		}
+		tMax[a] += tDelta[a]
+	}
+}
+
+// IsLineOfSightClear is a cheap, approximate occlusion test: it reports whether the segment
+// from -> to crosses no StateStaticObstacle cell. Accuracy is limited by the cell size, so it is
+// meant for pre-filtering candidates, not as a replacement for performRaycast.
+func (oc *OccupancyCloud) IsLineOfSightClear(from, to Vector3) bool {
+	clear := true
+	oc.traverseCells(from, to, func(ix, iy, iz int, _ float64) bool {
+		if oc.Grid[ix][iy][iz].State == StateStaticObstacle {
+			clear = false
+			return false
+		}
+		return true
+	})
+	return clear
 }
 
 // PrepareCloudForJS converts the occupancy cloud data into a format suitable for JavaScript/Three.js visualization.
@@ -293,27 +726,119 @@ func (oc *OccupancyCloud) PrepareCloudForJS() js.Value {
 	for ix := 0; ix < oc.CellsX; ix++ {
 		for iy := 0; iy < oc.CellsY; iy++ {
 			for iz := 0; iz < oc.CellsZ; iz++ {
-				state := oc.Grid[ix][iy][iz]
-				if state != StateEmpty { // Only send non-empty cells
+				cell := oc.Grid[ix][iy][iz]
+				if cell.State != StateEmpty { // Only send non-empty cells
 					// Calculate world position of the cell's center
 					worldX := oc.RoomMin.X + (float64(ix)+0.5)*oc.CellSize.X
 					worldY := oc.RoomMin.Y + (float64(iy)+0.5)*oc.CellSize.Y
 					worldZ := oc.RoomMin.Z + (float64(iz)+0.5)*oc.CellSize.Z
-					occupiedCells = append(occupiedCells, map[string]interface{}{
-						"x":     worldX,
-						"y":     worldY,
-						"z":     worldZ,
-						"state": uint8(state), // Send state as a number
-						"sizeX": oc.CellSize.X,
-						"sizeY": oc.CellSize.Y,
-						"sizeZ": oc.CellSize.Z,
-					})
+					jsCell := map[string]interface{}{
+						"x":         worldX,
+						"y":         worldY,
+						"z":         worldZ,
+						"state":     uint8(cell.State), // Send state as a number
+						"stateName": cell.State.String(),
+						"sizeX":     oc.CellSize.X,
+						"sizeY":     oc.CellSize.Y,
+						"sizeZ":     oc.CellSize.Z,
+					}
+					if cell.Meta != nil {
+						jsCell["owner"] = cell.Meta.OwnerID
+						jsCell["lastUpdated"] = cell.Meta.LastUpdatedIteration
+						jsCell["energy"] = cell.Meta.Energy
+					}
+					occupiedCells = append(occupiedCells, jsCell)
 				}
 			}
 		}
 	}
 	if oc.DebugLogging && len(occupiedCells) > 0 {
-		log.Printf("Preparing %d occupied cloud cells for JS.", len(occupiedCells))
+		logEvent(LogInterop, LogDetail, "Preparing %d occupied cloud cells for JS.", len(occupiedCells))
 	}
 	return js.ValueOf(occupiedCells)
 }
+
+// --- JS Interop for do-not-disturb zones ---
+
+// goAddForbiddenZone(name, x1, y1, z1, x2, y2, z2) adds or replaces a named exclusion zone.
+func goAddForbiddenZone(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goAddForbiddenZone")
+	if len(args) != 7 {
+		log.Println("Error: goAddForbiddenZone expects 7 arguments (name, x1, y1, z1, x2, y2, z2)")
+		return false
+	}
+	if occupancyCloud == nil {
+		return false
+	}
+	name := args[0].String()
+	corner1 := Vector3{args[1].Float(), args[2].Float(), args[3].Float()}
+	corner2 := Vector3{args[4].Float(), args[5].Float(), args[6].Float()}
+	occupancyCloud.AddForbiddenZone(name, corner1, corner2)
+	return true
+}
+
+// goRemoveForbiddenZone(name) removes a zone; returns false if it did not exist.
+func goRemoveForbiddenZone(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goRemoveForbiddenZone")
+	if len(args) != 1 {
+		log.Println("Error: goRemoveForbiddenZone expects 1 argument (name)")
+		return false
+	}
+	if occupancyCloud == nil {
+		return false
+	}
+	return occupancyCloud.RemoveForbiddenZone(args[0].String())
+}
+
+func goClearForbiddenZones(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goClearForbiddenZones")
+	if occupancyCloud != nil {
+		occupancyCloud.ClearForbiddenZones()
+	}
+	return nil
+}
+
+// goGetForbiddenZones returns the zones as [{name, min: {x,y,z}, max: {x,y,z}}, ...] for drawing.
+func goGetForbiddenZones(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetForbiddenZones")
+	if occupancyCloud == nil {
+		return js.ValueOf([]interface{}{})
+	}
+	jsZones := make([]interface{}, len(occupancyCloud.ForbiddenZones))
+	for i, zone := range occupancyCloud.ForbiddenZones {
+		jsZones[i] = map[string]interface{}{
+			"name": zone.Name,
+			"min":  map[string]interface{}{"x": zone.Min.X, "y": zone.Min.Y, "z": zone.Min.Z},
+			"max":  map[string]interface{}{"x": zone.Max.X, "y": zone.Max.Y, "z": zone.Max.Z},
+		}
+	}
+	return js.ValueOf(jsZones)
+}
+
+// goCheckCloudVisibility(x1, y1, z1, x2, y2, z2) returns whether the cloud sees a clear line between two points.
+func goCheckCloudVisibility(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goCheckCloudVisibility")
+	if len(args) != 6 {
+		log.Println("Error: goCheckCloudVisibility expects 6 arguments (x1, y1, z1, x2, y2, z2)")
+		return nil
+	}
+	if occupancyCloud == nil {
+		return nil
+	}
+	from := Vector3{args[0].Float(), args[1].Float(), args[2].Float()}
+	to := Vector3{args[3].Float(), args[4].Float(), args[5].Float()}
+	return occupancyCloud.IsLineOfSightClear(from, to)
+}
+
+// goQueryCloudState(x, y, z) returns the state name of the cell at a world position ("outOfBounds" outside the cloud).
+func goQueryCloudState(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goQueryCloudState")
+	if len(args) != 3 {
+		log.Println("Error: goQueryCloudState expects 3 arguments (x, y, z)")
+		return nil
+	}
+	if occupancyCloud == nil {
+		return nil
+	}
+	return occupancyCloud.StateAt(Vector3{args[0].Float(), args[1].Float(), args[2].Float()}).String()
+}
@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+// TestPlacementNearThinGeometry places a shelf thinner than a cell between the SDF lattice
+// samples, where the interpolated distance alone misjudges positions on and around it.
+func TestPlacementNearThinGeometry(t *testing.T) {
+	cellSize := Vector3{OCCUPANCY_CELL_SIZE, OCCUPANCY_CELL_SIZE, OCCUPANCY_CELL_SIZE}
+	oc := NewOccupancyCloud(Vector3{-5, 0, -5}, Vector3{5, 3, 5}, cellSize, false)
+	shelf := NewSceneObject("Shelf", "box")
+	shelf.Position = Vector3{3, 1, 3} // Halfway between the samples at y = 0.75 and y = 1.25
+	shelf.Scale = Vector3{1, 0.1, 1}
+	oc.MarkStaticObstacles([]*SceneObject{shelf})
+
+	listenerScale := Vector3{0.25, 0.25, 0.25}
+	source, sourceScale := Vector3{-3, 1, -3}, Vector3{0.3, 0.3, 0.3}
+	tests := []struct {
+		name   string
+		pos    Vector3
+		margin float64
+		valid  bool
+	}{
+		{"inside the shelf", Vector3{3, 1, 3}, 0.02, false},
+		{"touching the shelf top", Vector3{3, 1.1, 3}, 0.02, false},
+		{"just above the shelf", Vector3{3, 1.2, 3}, 0.02, true},
+		{"below the shelf", Vector3{3, 0.5, 3}, DEFAULT_CLEARANCE_MARGIN, true},
+		{"beside the shelf", Vector3{3.8, 1, 3}, DEFAULT_CLEARANCE_MARGIN, true},
+		{"at the shelf edge", Vector3{3.55, 1, 3}, DEFAULT_CLEARANCE_MARGIN, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oc.SetClearanceMargin(StateListener, tt.margin)
+			if got := oc.IsPositionAttemptValid(tt.pos, listenerScale, StateListener, source, sourceScale); got != tt.valid {
+				t.Errorf("IsPositionAttemptValid(%v) = %v, want %v (sample %.3f, exact %.3f)",
+					tt.pos, got, tt.valid, oc.SDF.Sample(tt.pos), staticObjectSDF(tt.pos, shelf))
+			}
+		})
+	}
+}
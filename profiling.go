@@ -0,0 +1,157 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sync/atomic"
+	"syscall/js"
+	"time"
+)
+
+// --- Profiling ---
+//
+// With profiling on, timing scopes measure where a pass spends its time: intersection
+// (performRaycast, shadow rays included), reflection (surface energy and ray splitting), scoring
+// (listener hit tests and capture bookkeeping) and transfer (building the JS scene and ray data
+// and handing it to three.js). Scopes never nest, so whatever a pass spends outside them is its
+// "other" time. Reading the clock costs time itself, so profiled passes run somewhat slower; with
+// profiling off each scope is a single flag check.
+
+const (
+	ProfileIntersection = iota
+	ProfileReflection
+	ProfileScoring
+	ProfileTransfer
+	profileScopeCount
+)
+
+var profileScopeNames = [profileScopeCount]string{"intersection", "reflection", "scoring", "transfer"}
+
+// ProfileCounters is the time and call count of every scope. Counters are atomic because
+// evaluations may run on the worker pool.
+type ProfileCounters struct {
+	Nanos [profileScopeCount]atomic.Int64
+	Calls [profileScopeCount]atomic.Int64
+}
+
+// PassProfile is the breakdown of one visualization pass.
+type PassProfile struct {
+	Total time.Duration
+	Rays  int
+	Nanos [profileScopeCount]int64
+	Calls [profileScopeCount]int64
+}
+
+var (
+	profilingEnabled atomic.Bool // Toggle "profiling"
+	profileTotals    ProfileCounters
+	passProfileStart [2][profileScopeCount]int64 // Totals (nanos, calls) when the current pass began
+	lastPassProfile  PassProfile
+)
+
+// profileSince adds the time since start to scope; use as
+// `if profilingEnabled.Load() { defer profileSince(scope, time.Now()) }`.
+func profileSince(scope int, start time.Time) {
+	profileTotals.Nanos[scope].Add(int64(time.Since(start)))
+	profileTotals.Calls[scope].Add(1)
+}
+
+// beginPassProfile remembers the totals at the start of a visualization pass.
+func beginPassProfile() {
+	for s := 0; s < profileScopeCount; s++ {
+		passProfileStart[0][s] = profileTotals.Nanos[s].Load()
+		passProfileStart[1][s] = profileTotals.Calls[s].Load()
+	}
+}
+
+// endPassProfile stores what the pass begun by beginPassProfile spent in every scope.
+func endPassProfile(total time.Duration, rays int) {
+	if !profilingEnabled.Load() {
+		return
+	}
+	p := PassProfile{Total: total, Rays: rays}
+	for s := 0; s < profileScopeCount; s++ {
+		p.Nanos[s] = profileTotals.Nanos[s].Load() - passProfileStart[0][s]
+		p.Calls[s] = profileTotals.Calls[s].Load() - passProfileStart[1][s]
+	}
+	lastPassProfile = p
+}
+
+// profileScopesJS describes every scope's time, calls and share of total (if any).
+func profileScopesJS(nanos, calls [profileScopeCount]int64, total time.Duration) map[string]interface{} {
+	scopes := map[string]interface{}{}
+	for s, name := range profileScopeNames {
+		scope := map[string]interface{}{"ms": float64(nanos[s]) / 1e6, "calls": calls[s]}
+		if total > 0 {
+			scope["share"] = float64(nanos[s]) / float64(total)
+		}
+		scopes[name] = scope
+	}
+	return scopes
+}
+
+// goGetProfile([reset]) returns {enabled, lastPass: {totalMs, rays, otherMs, scopes}, totals} where
+// scopes maps each scope to {ms, calls, share}; totals cover every profiled pass and evaluation
+// since the last reset. A true argument clears the totals after reading them.
+func goGetProfile(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetProfile")
+	p := lastPassProfile
+	scoped := int64(0)
+	for _, n := range p.Nanos {
+		scoped += n
+	}
+	var totalNanos, totalCalls [profileScopeCount]int64
+	for s := 0; s < profileScopeCount; s++ {
+		totalNanos[s], totalCalls[s] = profileTotals.Nanos[s].Load(), profileTotals.Calls[s].Load()
+	}
+	result := map[string]interface{}{
+		"enabled": profilingEnabled.Load(),
+		"lastPass": map[string]interface{}{
+			"totalMs": float64(p.Total) / 1e6,
+			"rays":    p.Rays,
+			"otherMs": math.Max(0, float64(int64(p.Total)-scoped)/1e6),
+			"scopes":  profileScopesJS(p.Nanos, p.Calls, p.Total),
+		},
+		"totals": profileScopesJS(totalNanos, totalCalls, 0),
+	}
+	if len(args) > 0 && args[0].Truthy() {
+		for s := 0; s < profileScopeCount; s++ {
+			profileTotals.Nanos[s].Store(0)
+			profileTotals.Calls[s].Store(0)
+		}
+		lastPassProfile = PassProfile{}
+	}
+	return js.ValueOf(result)
+}
+
+// goBenchmarkRaycaster([rays]) times performRaycast alone for rays spread evenly around the
+// listener against the current scene (default 10000), and returns {rays, hits, totalMs, nsPerRay,
+// raysPerSecond}.
+func goBenchmarkRaycaster(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goBenchmarkRaycaster")
+	rays := 10000
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		rays = args[0].Int()
+	}
+	if rays <= 0 || listener == nil {
+		log.Println("Error: goBenchmarkRaycaster needs a listener and a positive ray count")
+		return nil
+	}
+	directions := sphereDirections(rays, false)
+	sets := collidableSetsFor(soundSource)
+	hits := 0
+	start := time.Now()
+	for _, d := range directions {
+		if performRaycast(listener.Position, d, maxRayDistance, sets.Reflected, listener).Hit {
+			hits++
+		}
+	}
+	elapsed := time.Since(start)
+	return js.ValueOf(map[string]interface{}{
+		"rays":          rays,
+		"hits":          hits,
+		"totalMs":       float64(elapsed) / 1e6,
+		"nsPerRay":      float64(elapsed) / float64(rays),
+		"raysPerSecond": float64(rays) / math.Max(elapsed.Seconds(), 1e-9),
+	})
+}
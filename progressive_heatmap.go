@@ -0,0 +1,106 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+	"time"
+)
+
+// --- Progressive Coverage Heatmap ---
+//
+// The coverage heatmap is computed at increasing resolutions; each level is handed to a JS
+// callback as soon as it is done, so a rough picture appears quickly and sharpens. A run can be
+// cancelled between grid rows, and starting a new run cancels the previous one.
+
+const PROGRESSIVE_HEATMAP_YIELD = time.Millisecond // Pause between rows so JS events (e.g. cancel) get through
+
+var (
+	progressiveHeatmapLevels = []int{8, 16, 64}
+	progressiveHeatmapID     int // Incremented to cancel a running refinement
+)
+
+// cancelProgressiveHeatmap stops a running refinement after its current row.
+func cancelProgressiveHeatmap() {
+	progressiveHeatmapID++
+}
+
+// computeCoverageHeatmapRows is computeCoverageHeatmap row by row, yielding to JS after each row.
+// Returns nil if cancelled reports true between rows.
+func computeCoverageHeatmapRows(res int, height float64, cancelled func() bool) *Heatmap {
+	listenerScale := heatmapListenerScale()
+	hm := newHeatmap(res, res, height)
+	for ix := 0; ix < res; ix++ {
+		if cancelled() {
+			return nil
+		}
+		parallelFor(res, func(iz int) {
+			hm.Values[ix][iz] = coverageAt(hm.CellCenter(ix, iz), listenerScale)
+		})
+		time.Sleep(PROGRESSIVE_HEATMAP_YIELD)
+	}
+	return hm
+}
+
+// runProgressiveHeatmap computes every level and passes
+// {level, levels, resolution, final, png, width, height, min, max} to callback after each one.
+// The final level also updates the sweet spot marker.
+func runProgressiveHeatmap(id int, height float64, callback js.Value) {
+	defer recoverFromPanic("runProgressiveHeatmap")
+	cancelled := func() bool { return id != progressiveHeatmapID || soundSource == nil }
+	for level, res := range progressiveHeatmapLevels {
+		start := time.Now()
+		hm := computeCoverageHeatmapRows(res, height, cancelled)
+		if hm == nil {
+			log.Printf("Progressive heatmap cancelled at %dx%d", res, res)
+			return
+		}
+		final := level == len(progressiveHeatmapLevels)-1
+		if final {
+			sweetSpot = findSweetSpot(hm)
+			refreshRayVisualsFromCache()
+		}
+		result := heatmapPNGToJS(hm, int(math.Max(1, 512/float64(res))))
+		if result == nil {
+			return
+		}
+		resultJS := result.(js.Value)
+		resultJS.Set("level", level)
+		resultJS.Set("levels", len(progressiveHeatmapLevels))
+		resultJS.Set("resolution", res)
+		resultJS.Set("final", final)
+		logEvent(LogInterop, LogDetail, "Progressive heatmap level %dx%d done in %v", res, res, time.Since(start))
+		callback.Invoke(resultJS)
+	}
+}
+
+// goStartProgressiveHeatmap(callback[, height]) starts refining the coverage heatmap at the given
+// height (default: the listener's) and calls callback with each level (see runProgressiveHeatmap).
+// A running refinement is cancelled first. Returns false if callback is not a function.
+func goStartProgressiveHeatmap(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goStartProgressiveHeatmap")
+	if len(args) < 1 || args[0].Type() != js.TypeFunction {
+		log.Println("Error: goStartProgressiveHeatmap expects a callback function and an optional height")
+		return false
+	}
+	if soundSource == nil {
+		return false
+	}
+	height := 1.5
+	if listener != nil {
+		height = listener.Position.Y
+	}
+	if len(args) >= 2 {
+		height = args[1].Float()
+	}
+	cancelProgressiveHeatmap()
+	go runProgressiveHeatmap(progressiveHeatmapID, height, args[0])
+	return true
+}
+
+// goCancelProgressiveHeatmap stops a running refinement; levels already delivered stay valid.
+func goCancelProgressiveHeatmap(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goCancelProgressiveHeatmap")
+	cancelProgressiveHeatmap()
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+// --- Ray Grid ---
+//
+// The occupancy cloud doubles as a spatial index for performRaycast. MarkStaticObstacles registers
+// every static object lying inside the cloud in the cells its bounds overlap, and a ray walks the
+// cells it crosses (see traverseCells), testing only the objects registered there, each once.
+// Walls, floor and ceiling straddle the cloud bounds and movable objects change cells, so they stay
+// unindexed and are tested against every ray first; their closest hit also shortens the walk. The
+// walk stops in the first cell the closest hit so far lies in, since every object not tested yet
+// only occupies cells further along the ray.
+//
+// performRaycast is handed plain object lists, so a grid is registered for each collidable list
+// (see collidables.go) and looked up by the list's identity. Other lists, and lists missing an
+// indexed object, are tested object by object.
+
+var useRayGrid = true // Toggle "rayGrid"
+
+// RayGrid is how the rays cast against one collidable list use the cloud.
+type RayGrid struct {
+	cloud     *OccupancyCloud
+	Unindexed []*SceneObject // Members of the list that are in no cell
+}
+
+// rayListKey identifies a collidable list by its backing array and length.
+type rayListKey struct {
+	first **SceneObject
+	n     int
+}
+
+var rayGrids = map[rayListKey]*RayGrid{} // Registered with, and dropped along with, the collidable sets
+
+// rayBounds returns the box every ray hit on obj lies in. performRaycast intersects spheres with
+// radius Scale.X, which reaches past objectWorldBounds.
+func rayBounds(obj *SceneObject) (Vector3, Vector3) {
+	if obj.ShapeType == "sphere" {
+		r := Vector3{obj.Scale.X, obj.Scale.X, obj.Scale.X}
+		return obj.Position.Sub(r), obj.Position.Add(r)
+	}
+	return objectWorldBounds(obj)
+}
+
+// indexObject registers a static object in every cell its ray bounds overlap, padded by EPSILON
+// for hits on cell faces. Objects reaching outside the cloud are left out, since rays are only
+// walked through it.
+func (oc *OccupancyCloud) indexObject(obj *SceneObject) {
+	objMin, objMax := rayBounds(obj)
+	if !oc.isAABBInsideCloud(objMin, objMax) {
+		return
+	}
+	pad := Vector3{EPSILON, EPSILON, EPSILON}
+	r, _ := oc.clampedCellRangeForAABB(objMin.Sub(pad), objMax.Add(pad))
+	for ix := r.MinIX; ix <= r.MaxIX; ix++ {
+		for iy := r.MinIY; iy <= r.MaxIY; iy++ {
+			for iz := r.MinIZ; iz <= r.MaxIZ; iz++ {
+				meta := oc.cellMetadata(ix, iy, iz, true)
+				meta.Objects = append(meta.Objects, obj)
+			}
+		}
+	}
+	if oc.Indexed == nil {
+		oc.Indexed = map[*SceneObject]bool{}
+	}
+	oc.Indexed[obj] = true
+}
+
+// registerRayGrid prepares a collidable list for tracing through the current cloud. Cells offer
+// every indexed object, so lists that leave one out are not registered.
+func registerRayGrid(objects []*SceneObject) {
+	if occupancyCloud == nil || len(objects) == 0 {
+		return
+	}
+	grid := &RayGrid{cloud: occupancyCloud}
+	members := 0
+	for _, obj := range objects {
+		if occupancyCloud.Indexed[obj] {
+			members++
+		} else {
+			grid.Unindexed = append(grid.Unindexed, obj)
+		}
+	}
+	if members < len(occupancyCloud.Indexed) {
+		return
+	}
+	rayGrids[rayListKey{&objects[0], len(objects)}] = grid
+}
+
+// rayGridFor returns the grid registered for objects, or nil if rays against it test every object.
+func rayGridFor(objects []*SceneObject) *RayGrid {
+	if !useRayGrid || len(objects) == 0 {
+		return nil
+	}
+	grid := rayGrids[rayListKey{&objects[0], len(objects)}]
+	if grid == nil || grid.cloud != occupancyCloud {
+		return nil
+	}
+	return grid
+}
+
+// raycast finds the same closest hit as testing every object of the grid's list.
+func (g *RayGrid) raycast(hit *RayIntersectionResult, origin, direction Vector3, maxDist float64, ignoreObject *SceneObject) {
+	for _, obj := range g.Unindexed {
+		if obj != ignoreObject && obj.Visible {
+			hit.testObject(obj, origin, direction, maxDist)
+		}
+	}
+	var testedBuf [32]*SceneObject
+	tested := testedBuf[:0]
+	reach := hit.Distance
+	g.cloud.traverseCells(origin, origin.Add(direction.Scale(reach)), func(ix, iy, iz int, leave float64) bool {
+		if meta := g.cloud.Grid[ix][iy][iz].Meta; meta != nil {
+			for _, obj := range meta.Objects {
+				if obj == ignoreObject || !obj.Visible || containsObject(tested, obj) {
+					continue
+				}
+				tested = append(tested, obj)
+				hit.testObject(obj, origin, direction, maxDist)
+			}
+		}
+		return hit.Distance > leave*reach
+	})
+}
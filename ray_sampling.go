@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// --- Ray Direction Sampling ---
+//
+// Rays leave a source along a golden-angle spiral: ray i of n sits at height y = 1 − (2i+1)/n,
+// so every ray owns an equal-area band of the sphere, and turns by the golden angle π(3 − √5)
+// from the ray before it, which keeps neighbouring bands from lining up. The earlier formula
+// (azimuth √(nπ)·φ) bunched rays near the poles and left gaps along seams.
+//
+// With stratified sampling on, each ray is jittered inside its own cell (anywhere in its height
+// band, up to half a cell either way in azimuth), trading the fixed pattern's aliasing for noise
+// that averages out over passes. Evaluations keep one jittered set until the ray count or the
+// mode changes, so the candidates an optimizer compares are traced along the same directions.
+
+var (
+	goldenAngle        = math.Pi * (3 - math.Sqrt(5))
+	stratifiedSampling = false // Toggle "stratifiedSampling": jitter each ray within its cell
+)
+
+// sphereDirection is the unit direction of ray i of n on the golden-angle spiral, jittered within
+// its cell when jitter is set.
+func sphereDirection(i, n int, jitter bool) Vector3 {
+	offset, azimuth := 0.5, float64(i)*goldenAngle
+	if jitter {
+		offset = rand.Float64()
+	}
+	y := 1 - 2*(float64(i)+offset)/float64(n)
+	r := math.Sqrt(math.Max(0, 1-y*y))
+	if jitter && r > EPSILON {
+		// A cell is about √(4π/n) across; at radius r that spans this much azimuth
+		cell := math.Min(2*math.Pi, math.Sqrt(4*math.Pi/float64(n))/r)
+		azimuth += (rand.Float64() - 0.5) * cell
+	}
+	return Vector3{r * math.Sin(azimuth), y, r * math.Cos(azimuth)}
+}
+
+// sphereDirections returns n directions from sphereDirection.
+func sphereDirections(n int, jitter bool) []Vector3 {
+	directions := make([]Vector3, n)
+	for i := range directions {
+		directions[i] = sphereDirection(i, n, jitter)
+	}
+	return directions
+}
+
+// setStratifiedSampling switches jittering on or off and drops the cached evaluation directions.
+func setStratifiedSampling(on bool) {
+	stratifiedSampling = on
+	evaluationDirections = nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"syscall/js"
+	"time"
+)
+
+// --- Specular/Diffuse Ray Splitting ---
+//
+// A material's Scattering is the share of reflected energy that leaves diffusely rather than
+// mirror-like. With splitting on, the visual pass (castRayAndAddVisuals) turns a reflection off
+// such a surface into a specular child carrying (1 - s) of the energy and diffuseChildCount
+// children with cosine-distributed directions sharing the rest, so the children together carry
+// exactly the reflected energy. Splits nest at most raySplitMaxDepth deep along a path, and a pass
+// spawns at most RAY_SPLIT_BUDGET_FACTOR diffuse children per emitted ray; beyond either limit,
+// or when a child's share would fall below rayEnergyCutoff, the ray reflects specularly with all
+// of its energy as it does without splitting. Optimizer evaluations always reflect specularly.
+
+const (
+	DEFAULT_SPLIT_DEPTH      = 1
+	MAX_SPLIT_DEPTH          = 4
+	DEFAULT_DIFFUSE_CHILDREN = 2
+	MAX_DIFFUSE_CHILDREN     = 8
+	RAY_SPLIT_BUDGET_FACTOR  = 4 // Diffuse children a pass may spawn per emitted ray
+)
+
+var (
+	raySplitting      bool                       // Toggle "raySplitting"
+	raySplitMaxDepth  = DEFAULT_SPLIT_DEPTH      // Slider "raySplitDepth": nested splits per path
+	diffuseChildCount = DEFAULT_DIFFUSE_CHILDREN // Slider "diffuseChildren": diffuse rays per split
+
+	raySplitBudget atomic.Int64 // Diffuse children the current pass may still spawn
+)
+
+// RayChild is one outgoing ray of a reflection.
+type RayChild struct {
+	Direction Vector3
+	Energy    float64
+}
+
+// resetRaySplitBudget starts a pass of rays emitted rays.
+func resetRaySplitBudget(rays int) {
+	raySplitBudget.Store(int64(rays * RAY_SPLIT_BUDGET_FACTOR))
+}
+
+// cosineDirection is a random direction about normal with density proportional to cos θ (Lambert).
+func cosineDirection(normal Vector3) Vector3 {
+	tangent := Vector3{1, 0, 0}
+	if math.Abs(normal.X) > 0.9 {
+		tangent = Vector3{0, 1, 0}
+	}
+	u := normal.Cross(tangent).Normalize()
+	v := normal.Cross(u)
+	r, phi := math.Sqrt(rand.Float64()), 2*math.Pi*rand.Float64()
+	return u.Scale(r * math.Cos(phi)).Add(v.Scale(r * math.Sin(phi))).Add(normal.Scale(math.Sqrt(1 - r*r))).Normalize()
+}
+
+// reflectionChildren returns the rays leaving a reflection of a ray travelling along direction,
+// which keeps energy after absorption: the specular ray alone, or its split (see above). depth is
+// the number of splits above the reflection on its path.
+func reflectionChildren(direction Vector3, hit RayIntersectionResult, energy float64, depth int) []RayChild {
+	if profilingEnabled.Load() {
+		defer profileSince(ProfileReflection, time.Now())
+	}
+	specular := RayChild{direction.Reflect(hit.Normal), energy}
+	s := math.Min(1, hit.Object.Material.Scattering)
+	if !raySplitting || s <= 0 || depth >= raySplitMaxDepth {
+		return []RayChild{specular}
+	}
+	share := energy * s / float64(diffuseChildCount)
+	if share < rayEnergyCutoff {
+		return []RayChild{specular}
+	}
+	if raySplitBudget.Add(-int64(diffuseChildCount)) < 0 { // Spent; rays may be traced in parallel
+		raySplitBudget.Add(int64(diffuseChildCount))
+		return []RayChild{specular}
+	}
+	normal := hit.Normal
+	if normal.Dot(direction) > 0 { // Scatter back to the side the ray came from
+		normal = normal.Scale(-1)
+	}
+	children := make([]RayChild, 0, diffuseChildCount+1)
+	if s < 1 {
+		specular.Energy = energy * (1 - s)
+		children = append(children, specular)
+	}
+	for i := 0; i < diffuseChildCount; i++ {
+		children = append(children, RayChild{cosineDirection(normal), share})
+	}
+	return children
+}
+
+// setMaterialScattering sets the scattering coefficient of an object, or of every object with a
+// material of that name, and returns the number changed.
+func setMaterialScattering(objectName string, scattering float64) int {
+	changed := 0
+	for _, obj := range allSceneObjects {
+		if obj.Name != objectName && obj.Material.Name != objectName {
+			continue
+		}
+		obj.Material.Scattering = scattering
+		changed++
+	}
+	return changed
+}
+
+// goSetMaterialScattering(name, scattering) sets the share of reflected energy an object, or every
+// object with a material of that name, scatters diffusely when ray splitting is on. Returns the
+// number of objects changed.
+func goSetMaterialScattering(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetMaterialScattering")
+	if len(args) != 2 {
+		log.Println("Error: goSetMaterialScattering expects 2 arguments (objectOrMaterialName, scattering)")
+		return 0
+	}
+	scattering := args[1].Float()
+	if math.IsNaN(scattering) || scattering < 0 || scattering > 1 {
+		log.Printf("Error: scattering must be in [0, 1], got %v", scattering)
+		return 0
+	}
+	changed := setMaterialScattering(args[0].String(), scattering)
+	if changed > 0 && raySplitting {
+		debouncedVisualizeFunc()
+	}
+	return changed
+}
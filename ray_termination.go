@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// --- Ray Termination Criteria ---
+
+const (
+	MIN_RAY_DISTANCE_LIMIT float64 = 1.0
+	MAX_RAY_DISTANCE_LIMIT float64 = 500.0
+	MIN_RAY_CUTOFF         float64 = 1e-6
+	MAX_RAY_CUTOFF         float64 = 0.5
+	MIN_REFLECTION_OFFSET  float64 = 1e-4
+	MAX_REFLECTION_OFFSET  float64 = 0.1
+	MAX_DECAY_TIME_MS      float64 = 10000.0 // Longest decay time the path-length limit accepts
+)
+
+// maxPathLength is the cumulative length after which a path ends regardless of its bounce count:
+// the distance sound travels in maxDecayTimeMs, or +Inf when no decay time is set.
+func maxPathLength() float64 {
+	if maxDecayTimeMs <= 0 {
+		return math.Inf(1)
+	}
+	return maxDecayTimeMs / 1000 * environment.SpeedOfSound
+}
+
+// segmentReach is how far a segment starting pathLength meters along its path is traced, so a
+// path never goes past maxPathLength. Hits are only found closer than the reach, so reflected
+// segments always have some length left.
+func segmentReach(pathLength float64) float64 {
+	return math.Min(maxRayDistance, maxPathLength()-pathLength)
+}
+
+// rouletteSurvivor applies Russian-roulette termination before a ray reflects again. Rays at or
+// above rayEnergyCutoff always continue. Weaker rays survive with probability energy/rayEnergyCutoff
+// and continue carrying rayEnergyCutoff, so the expected energy is unchanged and deep bounces stay
+// unbiased while most weak rays stop early. Returns the energy to continue with and whether the
+// ray survives. Only the physical energy decides, so display settings (opacity, fade per bounce)
+// cannot change what is traced or scored.
+func rouletteSurvivor(energy float64) (float64, bool) {
+	if energy >= rayEnergyCutoff {
+		return energy, true
+	}
+	if energy <= 0 || rand.Float64()*rayEnergyCutoff >= energy {
+		return 0, false
+	}
+	return rayEnergyCutoff, true
+}
+
+// surfaceContinuesPath reports whether a path reflecting off obj as its order-th reflection
+// (1 = first) goes on, applying the material's bounce-order cap and termination probability.
+func surfaceContinuesPath(obj *SceneObject, order int) bool {
+	if obj == nil {
+		return true
+	}
+	if obj.Material.BounceOrderCap > 0 && order > obj.Material.BounceOrderCap {
+		return false
+	}
+	return obj.Material.TerminationProbability <= 0 || rand.Float64() >= obj.Material.TerminationProbability
+}
+
+// surfaceContinuationWeight is the expected share of paths surfaceContinuesPath lets through, for
+// exact (image-source) paths that are weighted rather than sampled.
+func surfaceContinuationWeight(obj *SceneObject, order int) float64 {
+	if obj == nil {
+		return 1
+	}
+	if obj.Material.BounceOrderCap > 0 && order > obj.Material.BounceOrderCap {
+		return 0
+	}
+	return 1 - obj.Material.TerminationProbability
+}
+
+// energyAfterReflection is the energy a ray travelling along direction keeps after bouncing off
+// obj's material where its surface normal is normal. The tracer carries one broadband energy, so
+// materials with band data attenuate by their band mean; impedance surfaces depend on the angle.
+func energyAfterReflection(energy float64, obj *SceneObject, direction, normal Vector3) float64 {
+	if profilingEnabled.Load() {
+		defer profileSince(ProfileReflection, time.Now())
+	}
+	if obj == nil {
+		return energy
+	}
+	return energy * (1 - surfaceAbsorption(obj.Material, incidenceCos(direction, normal)))
+}
+
+// boundedParam clamps a runtime parameter to [min, max], logging when the value had to change.
+func boundedParam(name string, value, min, max float64) float64 {
+	if value < min || value > max || math.IsNaN(value) {
+		clamped := min
+		if value > max {
+			clamped = max
+		}
+		log.Printf("%s=%g is outside [%g, %g]; using %g", name, value, min, max, clamped)
+		return clamped
+	}
+	return value
+}
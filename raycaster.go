@@ -1,6 +1,9 @@
 package main
 
-import "math"
+import (
+	"math"
+	"time"
+)
 
 type RayIntersectionResult struct {
 	Hit           bool
@@ -10,330 +13,464 @@ type RayIntersectionResult struct {
 }
 
 func performRaycast(origin Vector3, direction Vector3, maxDist float64, objects []*SceneObject, ignoreObject *SceneObject) RayIntersectionResult {
+	if profilingEnabled.Load() {
+		defer profileSince(ProfileIntersection, time.Now())
+	}
 	closestHit := RayIntersectionResult{Hit: false, Distance: maxDist}
+	if grid := rayGridFor(objects); grid != nil { // Only test objects in the cells the ray crosses
+		grid.raycast(&closestHit, origin, direction, maxDist, ignoreObject)
+		return closestHit
+	}
 	for _, obj := range objects {
-		if obj == ignoreObject || !obj.Visible {
-			continue
+		if obj != ignoreObject && obj.Visible {
+			closestHit.testObject(obj, origin, direction, maxDist)
 		}
-		var hitDistance float64 = -1
-		if obj.ShapeType == "sphere" {
-			oc := origin.Sub(obj.Position)
-			a := direction.Dot(direction)
-			b := 2.0 * oc.Dot(direction)
-			c := oc.Dot(oc) - obj.Scale.X*obj.Scale.X // Assuming uniform scale for sphere radius
-			discriminant := b*b - 4*a*c
-			if discriminant >= 0 {
-				t := (-b - math.Sqrt(discriminant)) / (2.0 * a)
-				if t > EPSILON && t < closestHit.Distance {
-					hitDistance = t
-				}
-			}
-		} else if obj.ShapeType == "box" {
-			// Simplified AABB intersection (assumes box is not rotated relative to world axes)
-			// For rotated boxes, a more complex OBB intersection would be needed.
-			minBound := obj.Position.Sub(obj.Scale.Scale(0.5))
-			maxBound := obj.Position.Add(obj.Scale.Scale(0.5))
-			tMin, tMax := 0.0, maxDist
-			hitCurrentBox := true
-
-			for i := 0; i < 3; i++ { // Iterate over X, Y, Z axes
-				var invD, oComp, minB_i, maxB_i float64
-				rayDirComp := 0.0
-
-				switch i {
-				case 0: // X
-					rayDirComp = direction.X
-					oComp = origin.X
-					minB_i = minBound.X
-					maxB_i = maxBound.X
-				case 1: // Y
-					rayDirComp = direction.Y
-					oComp = origin.Y
-					minB_i = minBound.Y
-					maxB_i = maxBound.Y
-				case 2: // Z
-					rayDirComp = direction.Z
-					oComp = origin.Z
-					minB_i = minBound.Z
-					maxB_i = maxBound.Z
-				}
-
-				if math.Abs(rayDirComp) < EPSILON { // Ray is parallel to this slab.
-					if oComp < minB_i || oComp > maxB_i { // Origin is outside the slab.
-						hitCurrentBox = false
-						break
-					}
-					continue // Ray is parallel and inside slab, continue checking other slabs.
-				}
-				invD = 1.0 / rayDirComp
-
-				t0 := (minB_i - oComp) * invD
-				t1 := (maxB_i - oComp) * invD
-				if invD < 0 {
-					t0, t1 = t1, t0 // Swap if invD is negative
-				}
+	}
+	return closestHit
+}
 
-				if t0 > tMin {
-					tMin = t0
-				}
-				if t1 < tMax {
-					tMax = t1
-				}
+// testObject intersects the ray with obj and keeps the hit if it is closer than the current one.
+func (hit *RayIntersectionResult) testObject(obj *SceneObject, origin, direction Vector3, maxDist float64) {
+	var hitDistance float64 = -1
+	if obj.ShapeType == "sphere" {
+		oc := origin.Sub(obj.Position)
+		a := direction.Dot(direction)
+		b := 2.0 * oc.Dot(direction)
+		c := oc.Dot(oc) - obj.Scale.X*obj.Scale.X // Assuming uniform scale for sphere radius
+		discriminant := b*b - 4*a*c
+		if discriminant >= 0 {
+			t := (-b - math.Sqrt(discriminant)) / (2.0 * a)
+			if t > EPSILON && t < hit.Distance {
+				hitDistance = t
+			}
+		}
+	} else if obj.ShapeType == "box" {
+		// Slab test in the box's local frame (see obb.go); rotations keep distances, so t is
+		// the same in both frames.
+		localOrigin, localDir := origin.Sub(obj.Position), direction
+		if rot, rotated := boxRotation(obj); rotated {
+			localOrigin, localDir = rot.ApplyTranspose(localOrigin), rot.ApplyTranspose(direction)
+		}
+		maxBound := obj.Scale.Scale(0.5)
+		minBound := maxBound.Scale(-1)
+		tMin, tMax := 0.0, maxDist
+		hitCurrentBox := true
+
+		for i := 0; i < 3; i++ { // Iterate over X, Y, Z axes
+			var invD, oComp, minB_i, maxB_i float64
+			rayDirComp := 0.0
+
+			switch i {
+			case 0: // X
+				rayDirComp = localDir.X
+				oComp = localOrigin.X
+				minB_i = minBound.X
+				maxB_i = maxBound.X
+			case 1: // Y
+				rayDirComp = localDir.Y
+				oComp = localOrigin.Y
+				minB_i = minBound.Y
+				maxB_i = maxBound.Y
+			case 2: // Z
+				rayDirComp = localDir.Z
+				oComp = localOrigin.Z
+				minB_i = minBound.Z
+				maxB_i = maxBound.Z
+			}
 
-				if tMin > tMax { // Ray misses the box
+			if math.Abs(rayDirComp) < EPSILON { // Ray is parallel to this slab.
+				if oComp < minB_i || oComp > maxB_i { // Origin is outside the slab.
 					hitCurrentBox = false
 					break
 				}
-			} // End loop over axes
-
-			if hitCurrentBox && tMin > EPSILON && tMin < closestHit.Distance {
-				hitDistance = tMin
+				continue // Ray is parallel and inside slab, continue checking other slabs.
 			}
-		} // End box intersection
-
-		if hitDistance > EPSILON && hitDistance < closestHit.Distance {
-			closestHit.Hit = true
-			closestHit.Distance = hitDistance
-			closestHit.Point = origin.Add(direction.Scale(hitDistance))
-			closestHit.Object = obj
-			// Calculate normal (simplified for AABB and sphere)
-			if obj.ShapeType == "sphere" {
-				closestHit.Normal = closestHit.Point.Sub(obj.Position).Normalize()
-			} else if obj.ShapeType == "box" {
-				// Simplified normal calculation for AABB
-				p := closestHit.Point
-				c := obj.Position
-				d := obj.Scale.Scale(0.5) // half dimensions
-				if math.Abs(p.X-(c.X-d.X)) < EPSILON {
-					closestHit.Normal = Vector3{-1, 0, 0}
-				} else if math.Abs(p.X-(c.X+d.X)) < EPSILON {
-					closestHit.Normal = Vector3{1, 0, 0}
-				} else if math.Abs(p.Y-(c.Y-d.Y)) < EPSILON {
-					closestHit.Normal = Vector3{0, -1, 0}
-				} else if math.Abs(p.Y-(c.Y+d.Y)) < EPSILON {
-					closestHit.Normal = Vector3{0, 1, 0}
-				} else if math.Abs(p.Z-(c.Z-d.Z)) < EPSILON {
-					closestHit.Normal = Vector3{0, 0, -1}
-				} else if math.Abs(p.Z-(c.Z+d.Z)) < EPSILON {
-					closestHit.Normal = Vector3{0, 0, 1}
-				} else {
-					// Fallback (should ideally not happen for precise AABB hits on faces)
-					closestHit.Normal = p.Sub(c).Normalize()
-				}
+			invD = 1.0 / rayDirComp
+
+			t0 := (minB_i - oComp) * invD
+			t1 := (maxB_i - oComp) * invD
+			if invD < 0 {
+				t0, t1 = t1, t0 // Swap if invD is negative
 			}
-		}
-	}
-	return closestHit
-}
 
-// castRayAndGetBounceCountForEvaluation: returns bounce count if listener hit, -1 otherwise. No visuals.
-func castRayAndGetBounceCountForEvaluation(origin Vector3, direction Vector3, currentReflections int, collidables []*SceneObject, listenerPos Vector3, listenerRadius float64) int {
-	if currentReflections > maxReflections {
-		return -1
-	}
+			if t0 > tMin {
+				tMin = t0
+			}
+			if t1 < tMax {
+				tMax = t1
+			}
 
-	// Ensure soundSource is collidable for reflected rays
-	effectiveCollidables := collidables
-	if currentReflections > 0 { // For reflected rays, the source itself can be an occluder
-		sourceInCollidables := false
-		for _, obj := range collidables {
-			if obj == soundSource {
-				sourceInCollidables = true
+			if tMin > tMax { // Ray misses the box
+				hitCurrentBox = false
 				break
 			}
+		} // End loop over axes
+
+		if hitCurrentBox && tMin > EPSILON && tMin < hit.Distance {
+			hitDistance = tMin
+		}
+	} else if obj.ShapeType == "mesh" && obj.Mesh != nil { // End box intersection; meshes set the hit themselves
+		if t, normal, found := raycastMesh(obj, origin, direction, hit.Distance); found {
+			*hit = RayIntersectionResult{Hit: true, Distance: t, Point: origin.Add(direction.Scale(t)), Normal: normal, Object: obj}
 		}
-		if !sourceInCollidables && soundSource != nil { // Add soundSource if not already present
-			tempCollidables := make([]*SceneObject, len(collidables)+1)
-			copy(tempCollidables, collidables)
-			tempCollidables[len(collidables)] = soundSource
-			effectiveCollidables = tempCollidables
+		return // The triangle normal is known here; objectNormalAt would have to search for it
+	} else if isCurvedShape(obj.ShapeType) {
+		if t, normal, found := raycastCurved(obj, origin, direction, hit.Distance); found {
+			*hit = RayIntersectionResult{Hit: true, Distance: t, Point: origin.Add(direction.Scale(t)), Normal: normal, Object: obj}
 		}
+		return
 	}
 
-	intersection := performRaycast(origin, direction, MAX_RAY_DISTANCE, effectiveCollidables, nil)
+	if hitDistance > EPSILON && hitDistance < hit.Distance {
+		hit.Hit = true
+		hit.Distance = hitDistance
+		hit.Point = origin.Add(direction.Scale(hitDistance))
+		hit.Object = obj
+		hit.Normal = objectNormalAt(obj, hit.Point)
+	}
+}
 
-	rayLength := MAX_RAY_DISTANCE
-	if intersection.Hit {
-		rayLength = intersection.Distance
+// objectNormalAt returns the outward surface normal of obj at a point on its surface
+// (spheres, boxes via the face the point lies on in the box's local frame, meshes via the
+// nearest triangle, and curved shells via their implicit gradient).
+func objectNormalAt(obj *SceneObject, p Vector3) Vector3 {
+	if obj.ShapeType == "sphere" {
+		return p.Sub(obj.Position).Normalize()
 	}
-	endPoint := origin.Add(direction.Scale(rayLength))
+	if obj.ShapeType == "mesh" && obj.Mesh != nil {
+		nearest, _ := meshNearestTriangle(obj, p)
+		return meshTriangleNormal(obj, nearest)
+	}
+	if isCurvedShape(obj.ShapeType) {
+		return curvedNormalAt(obj, p)
+	}
+	local := boxLocalPoint(obj, p)
+	d := obj.Scale.Scale(0.5) // half dimensions
+	if math.Abs(local.X+d.X) < EPSILON {
+		return boxWorldDirection(obj, Vector3{-1, 0, 0})
+	} else if math.Abs(local.X-d.X) < EPSILON {
+		return boxWorldDirection(obj, Vector3{1, 0, 0})
+	} else if math.Abs(local.Y+d.Y) < EPSILON {
+		return boxWorldDirection(obj, Vector3{0, -1, 0})
+	} else if math.Abs(local.Y-d.Y) < EPSILON {
+		return boxWorldDirection(obj, Vector3{0, 1, 0})
+	} else if math.Abs(local.Z+d.Z) < EPSILON {
+		return boxWorldDirection(obj, Vector3{0, 0, -1})
+	} else if math.Abs(local.Z-d.Z) < EPSILON {
+		return boxWorldDirection(obj, Vector3{0, 0, 1})
+	}
+	// Fallback (should ideally not happen for precise hits on faces)
+	return p.Sub(obj.Position).Normalize()
+}
 
-	// Check if current ray segment hits the listener (sphere intersection)
-	// Simplified: Check distance from listener center to the ray line segment
-	dirToListener := listenerPos.Sub(origin)
-	t := dirToListener.Dot(direction) // Project listener's origin onto the ray
-	var closestPointOnLine Vector3
-
-	if t <= 0 { // Closest point is the ray origin
-		closestPointOnLine = origin
-	} else if t >= rayLength { // Closest point is the ray endpoint (or hit point)
-		closestPointOnLine = endPoint
-	} else { // Closest point is on the segment
-		closestPointOnLine = origin.Add(direction.Scale(t))
-	}
-
-	if closestPointOnLine.Sub(listenerPos).Length() < listenerRadius {
-		// Check if this hit is occluded by anything *before* the listener along this segment
-		distToClosestPointOnLine := origin.Sub(closestPointOnLine).Length()
-		if !intersection.Hit || intersection.Distance > distToClosestPointOnLine {
-			return currentReflections // Hit listener
-		}
+// rayBounce is the per-bounce state of a traced path: the segment it casts next and what the path
+// has accumulated up to the segment's origin.
+type rayBounce struct {
+	Origin      Vector3
+	Direction   Vector3
+	Bounces     int            // Reflections before this segment
+	Energy      float64        // Remaining physical energy fraction at Origin (1 when emitted)
+	PathLength  float64        // Meters travelled to Origin
+	Collidables []*SceneObject // Occluders of this segment
+}
+
+// reflectedBounce is the state after reflecting b at hit into direction with energy left.
+func (b rayBounce) reflectedBounce(hit RayIntersectionResult, direction Vector3, energy float64, sets *CollidableSets) rayBounce {
+	return rayBounce{
+		Origin:      reflectionOrigin(hit, direction), // Offset to avoid self-intersection
+		Direction:   direction,
+		Bounces:     b.Bounces + 1,
+		Energy:      energy,
+		PathLength:  b.PathLength + hit.Distance,
+		Collidables: sets.Reflected,
 	}
+}
 
-	// If ray hit an object and we haven't exceeded max reflections
-	if intersection.Hit && currentReflections < maxReflections {
-		// Check for attenuation - if ray is too weak, stop.
-		currentSegmentOpacity := initialRayOpacity * math.Pow(volumeAttenuationFactor, float64(currentReflections))
-		if currentSegmentOpacity < 0.01 { // Threshold for ray being too weak
-			return -1
+// castRayAndGetBounceCountForEvaluation: returns bounce count and the energy left if the listener
+// is hit, -1 and 0 otherwise. No visuals.
+// collidables are the occluders for this segment; reflected segments use sets.Reflected, which
+// includes the emitting source.
+// energy is the ray's remaining physical energy fraction (1 when emitted). Paths end at
+// maxPathLength as well as after maxReflections.
+// If nee is not nil, next-event estimation credits are added to it (see next_event.go).
+func castRayAndGetBounceCountForEvaluation(origin Vector3, direction Vector3, currentReflections int, energy float64, collidables []*SceneObject, listenerPos Vector3, listenerRadius float64, sets *CollidableSets, nee *float64) (int, float64) {
+	ray := rayBounce{Origin: origin, Direction: direction, Bounces: currentReflections, Energy: energy, Collidables: collidables}
+	for ray.Bounces <= maxReflections {
+		intersection := performRaycast(ray.Origin, ray.Direction, segmentReach(ray.PathLength), ray.Collidables, nil)
+
+		if dist, hit := listenerHitOnSegment(ray.Origin, ray.Direction, intersection, listenerPos, listenerRadius, sets); hit {
+			return ray.Bounces, ray.Energy * airTransmission(dist) * arrivalGain(sets, ray.Direction) // Hit listener
+		}
+		// The path ends unless the ray hit an object it may reflect off
+		if !intersection.Hit || ray.Bounces >= maxReflections || !surfaceContinuesPath(intersection.Object, ray.Bounces+1) {
+			break
+		}
+		// Weak rays are culled by Russian roulette; survivors carry the culled energy
+		survivor, alive := rouletteSurvivor(ray.Energy * airTransmission(intersection.Distance))
+		if !alive {
+			break
 		}
 
-		reflectDirection := direction.Reflect(intersection.Normal)
-		reflectionOrigin := intersection.Point.Add(reflectDirection.Scale(0.01)) // Move slightly off surface
-		return castRayAndGetBounceCountForEvaluation(reflectionOrigin, reflectDirection, currentReflections+1, collidables, listenerPos, listenerRadius)
+		reflectDirection := ray.Direction.Reflect(intersection.Normal)
+		reflected := energyAfterReflection(survivor, intersection.Object, ray.Direction, intersection.Normal)
+		if nee != nil {
+			*nee += nextEventCredit(intersection, ray.Direction, reflected, ray.Bounces+1, listenerPos, listenerRadius, sets)
+			reflected *= 1 - neeScattering(intersection.Object)
+		}
+		ray = ray.reflectedBounce(intersection, reflectDirection, reflected, sets)
 	}
+	return -1, 0 // No listener hit along this path
+}
 
-	return -1 // No listener hit along this path
+// listenerHitOnSegment checks whether the segment from origin along direction, ending at the
+// traced intersection (or the end of its reach), passes through the listener (an ellipsoid with X
+// semi-axis listenerRadius, see listener_shape.go) before anything blocks it. Reverse rays end on
+// the source instead, a sphere of radius listenerRadius. Returns the distance along the ray to
+// the point of closest approach.
+func listenerHitOnSegment(origin, direction Vector3, intersection RayIntersectionResult, listenerPos Vector3, listenerRadius float64, sets *CollidableSets) (float64, bool) {
+	if profilingEnabled.Load() {
+		defer profileSince(ProfileScoring, time.Now())
+	}
+	rayLength := intersection.Distance // The segment's reach when nothing was hit
+	var t float64
+	if sets != nil && sets.Reverse {
+		t = listenerPos.Sub(origin).Dot(direction) // Project the target's center onto the ray
+		t = math.Max(0, math.Min(rayLength, t))    // Clamp to the segment
+		if origin.Add(direction.Scale(t)).Sub(listenerPos).Length() >= listenerRadius {
+			return 0, false
+		}
+	} else {
+		var inside bool
+		if t, inside = listenerSegmentApproach(origin, direction, rayLength, listenerPos, listenerRadius); !inside {
+			return 0, false
+		}
+	}
+	// Check if this hit is occluded by anything *before* the listener along this segment
+	if intersection.Hit && intersection.Distance <= t {
+		return 0, false
+	}
+	return t, true
 }
 
 type HitData struct {
 	hitListener bool
 	bounces     int
+	energy      float64 // Energy left after absorption when the listener is reached
+	pathLength  float64 // Meters travelled to the listener
+	arrivalMs   float64 // Time of arrival at the listener
+}
+
+// arrivalTimeMs is the time in milliseconds sound takes to travel pathLength meters.
+func arrivalTimeMs(pathLength float64) float64 {
+	return pathLength / environment.SpeedOfSound * 1000
+}
+
+// TracedSegment is one visible ray segment from the last full pass, kept regardless of
+// visualization filters so filter changes can re-derive rayVisuals without re-tracing.
+type TracedSegment struct {
+	Line             RayLine
+	PathHitsListener bool // This segment is part of a path that reaches the listener
+	PathID           int  // Emitted ray the segment belongs to, unique across sources in a pass
+}
+
+var tracedSegments []TracedSegment // Cache of the last pass, merged from every RayTrace
+
+// rebuildRayVisualsFromCache applies the current visualization filters to the cached segments.
+func rebuildRayVisualsFromCache() {
+	hiddenSources := map[string]bool{}
+	for _, src := range soundSources {
+		if settings, ok := sourceSettings[src.Name]; ok && settings.HideRays {
+			hiddenSources[src.ID] = true
+		}
+	}
+	kept := make([]*TracedSegment, 0, len(tracedSegments))
+	for i := range tracedSegments {
+		seg := &tracedSegments[i]
+		if showOnlyListenerRays && !seg.PathHitsListener {
+			continue
+		}
+		if hiddenSources[seg.Line.SourceID] {
+			continue
+		}
+		kept = append(kept, seg)
+	}
+	rayVisuals = applyVisualQuality(kept)
+}
+
+// addReflection folds the result of a path reflected off this segment into it: the path hits the
+// listener if any of its reflections does, at the lowest bounce order (the first one on ties).
+func (h *HitData) addReflection(child HitData) {
+	if !child.hitListener {
+		return
+	}
+	h.hitListener = true
+	if h.bounces == -1 || child.bounces < h.bounces {
+		h.bounces, h.energy = child.bounces, child.energy
+		h.pathLength, h.arrivalMs = child.pathLength, child.arrivalMs
+	}
+}
+
+// visualSegment is one segment of the path tree castRayAndAddVisuals traces. Splitting gives a
+// segment several reflections, and a segment is only stored once they are all traced, since its
+// PathHitsListener depends on them.
+type visualSegment struct {
+	rayBounce
+	parent     int          // Index of the segment this one reflected off, -1 for the emitted one
+	splitDepth int          // Splits above this segment
+	surface    *SceneObject // Reflected off at Origin, nil for the emitted segment
+	bounceAt   Vector3      // Reflection point (Origin before the offset)
+	traced     bool         // Cast, with its reflections queued
+	line       RayLine      // Set once traced
+	result     HitData      // Own listener hit, then those of its reflections
 }
 
-// castRayAndAddVisuals: adds to rayVisuals and returns HitData.
-func castRayAndAddVisuals(origin Vector3, direction Vector3, currentReflections int, collidables []*SceneObject, listenerPos Vector3, listenerRadius float64) HitData {
+// castRayAndAddVisuals: adds visible segments, impacts and the listener capture to trace and
+// returns HitData.
+// Tracing does not depend on visualization filters; see rebuildRayVisualsFromCache.
+// sets.Source is the emitting object; its gain scales the emitted ray opacity.
+// energy is the ray's remaining physical energy fraction (1 when emitted) and pathLength the
+// distance it has travelled to origin; both set the segment's opacity (see rayDisplayOpacity).
+// Paths end at maxPathLength as well as after maxReflections.
+// The path tree is walked depth first with an explicit stack, in the order a recursive tracer
+// would: a reflection's whole subtree is traced before the next reflection off the same surface,
+// and a segment is stored after its reflections.
+func castRayAndAddVisuals(origin Vector3, direction Vector3, currentReflections int, energy, pathLength float64, collidables []*SceneObject, listenerPos Vector3, listenerRadius float64, sets *CollidableSets, trace *RayTrace) HitData {
 	if currentReflections > maxReflections {
 		return HitData{hitListener: false, bounces: -1}
 	}
-
-	effectiveCollidables := collidables
-	if currentReflections > 0 {
-		sourceInCollidables := false
-		for _, obj := range collidables {
-			if obj == soundSource {
-				sourceInCollidables = true
-				break
+	segments := []visualSegment{{
+		rayBounce: rayBounce{Origin: origin, Direction: direction, Bounces: currentReflections, Energy: energy, PathLength: pathLength, Collidables: collidables},
+		parent:    -1,
+	}}
+	pending := []int{0} // Indices into segments; a segment's reflections are stacked above it
+	for len(pending) > 0 {
+		i := pending[len(pending)-1]
+		if segments[i].traced {
+			pending = pending[:len(pending)-1]
+			seg := &segments[i]
+			// Cache every visible segment; the showOnlyListenerRays filter is applied afterwards
+			if seg.line.Opacity >= rayOpacityCutoff {
+				trace.Segments = append(trace.Segments, TracedSegment{Line: seg.line, PathHitsListener: seg.result.hitListener, PathID: trace.PathID})
+			}
+			if seg.parent >= 0 {
+				segments[seg.parent].result.addReflection(seg.result)
 			}
+			continue
+		}
+		reflections := traceVisualSegment(&segments[i], listenerPos, listenerRadius, sets, trace)
+		for k := range reflections {
+			reflections[k].parent = i
 		}
-		if !sourceInCollidables && soundSource != nil {
-			tempCollidables := make([]*SceneObject, len(collidables)+1)
-			copy(tempCollidables, collidables)
-			tempCollidables[len(collidables)] = soundSource
-			effectiveCollidables = tempCollidables
+		segments = append(segments, reflections...)
+		for k := len(segments) - 1; k >= len(segments)-len(reflections); k-- { // First reflection on top
+			pending = append(pending, k)
 		}
 	}
+	return segments[0].result
+}
+
+// traceVisualSegment casts seg, records its impact and listener capture, and returns the segments
+// reflected off the surface it hit (several when the ray splits), still to be traced.
+func traceVisualSegment(seg *visualSegment, listenerPos Vector3, listenerRadius float64, sets *CollidableSets, trace *RayTrace) []visualSegment {
+	source := sets.Source
+	origin, direction, currentReflections, energy := seg.Origin, seg.Direction, seg.Bounces, seg.Energy
+	seg.traced = true
+	seg.result = HitData{hitListener: false, bounces: -1}
+	if seg.surface != nil && len(tracerHooks) > 0 {
+		fireRayHooks(HookOnBounce, RayEvent{Source: source, RayIndex: trace.RayIndex, Bounces: currentReflections, Energy: energy, Point: seg.bounceAt, Direction: direction, Surface: seg.surface})
+	}
 
-	intersection := performRaycast(origin, direction, MAX_RAY_DISTANCE, effectiveCollidables, nil)
+	intersection := performRaycast(origin, direction, segmentReach(seg.PathLength), seg.Collidables, nil)
 
 	rayColorIdx := currentReflections
 	if rayColorIdx >= len(bounceColors) {
 		rayColorIdx = rayColorIdx % len(bounceColors) // Cycle through colors if not enough
 	}
-	rayColor := bounceColors[rayColorIdx]
+	rayColor := sourceRayColor(source, bounceColors[rayColorIdx])
 
-	rayLength := MAX_RAY_DISTANCE
-	if intersection.Hit {
-		rayLength = intersection.Distance
-	}
+	rayLength := intersection.Distance // The segment's reach when nothing was hit
 	endPoint := origin.Add(direction.Scale(rayLength))
 
-	currentSegmentOpacity := initialRayOpacity * math.Pow(volumeAttenuationFactor, float64(currentReflections))
-
-	result := HitData{hitListener: false, bounces: -1}
-
-	// Check for listener intersection along this segment
-	dirToListener := listenerPos.Sub(origin)
-	t := dirToListener.Dot(direction)
-	var closestPointOnLine Vector3
-	if t <= 0 {
-		closestPointOnLine = origin
-	} else if t >= rayLength { // If projection is beyond current segment end
-		closestPointOnLine = endPoint
-	} else {
-		closestPointOnLine = origin.Add(direction.Scale(t))
-	}
-
-	listenerHitThisSegment := false
-	if closestPointOnLine.Sub(listenerPos).Length() < listenerRadius {
-		// Ensure no object is hit *before* the listener on this segment
-		distToClosestPointOnLine := origin.Sub(closestPointOnLine).Length()
-		if !intersection.Hit || intersection.Distance > distToClosestPointOnLine {
-			listenerHitThisSegment = true
-		}
-	}
+	currentSegmentOpacity := rayDisplayOpacity(energy, seg.PathLength, source) * paletteBounceOpacity(currentReflections)
 
+	// Check for listener intersection along this segment; the listener absorbs the ray there
+	result := &seg.result
+	listenerDist, listenerHitThisSegment := listenerHitOnSegment(origin, direction, intersection, listenerPos, listenerRadius, sets)
 	if listenerHitThisSegment {
-		rayColor = listenerRayColor
+		rayColor = sourceRayColor(source, listenerRayColor)
 		result.hitListener = true
 		result.bounces = currentReflections
-		currentSegmentOpacity = initialRayOpacity // Make listener rays fully opaque for clarity
-	}
-
-	// Store data for subsequent bounces even if this segment itself didn't hit the listener directly
-	// The final hitListener status will be determined by the deepest reflection that hits.
-	reflectionHitData := HitData{hitListener: false, bounces: -1}
-	if intersection.Hit && currentReflections < maxReflections {
-		if currentSegmentOpacity >= 0.01 || (showOnlyListenerRays && result.hitListener) { // Only reflect if ray is strong enough or it's a listener path
-			reflectDirection := direction.Reflect(intersection.Normal)
-			reflectionOrigin := intersection.Point.Add(reflectDirection.Scale(0.01)) // Offset to avoid self-intersection
-			reflectionHitData = castRayAndAddVisuals(reflectionOrigin, reflectDirection, currentReflections+1, collidables, listenerPos, listenerRadius)
-
-			if reflectionHitData.hitListener {
-				result.hitListener = true // Propagate listener hit status upwards
-				// If this path also hit listener, keep the lower bounce count. If not, take the reflection's.
-				if result.bounces == -1 || reflectionHitData.bounces < result.bounces {
-					result.bounces = reflectionHitData.bounces
-				}
+		weight := receiverGain(direction)
+		result.energy = energy * airTransmission(listenerDist) * weight
+		currentSegmentOpacity = initialRayOpacity * weight // Listener rays stand out, dimmed only by receiver directivity
+		endPoint = origin.Add(direction.Scale(listenerDist))
+		result.pathLength = seg.PathLength + listenerDist
+		result.arrivalMs = arrivalTimeMs(result.pathLength)
+		// Each emitted ray is scored once, at its lowest bounce order (see listener_capture.go).
+		// In hybrid mode image sources score the low orders, so those hits only end the ray.
+		if !stochasticOrderCounts(currentReflections) {
+			result.hitListener, result.bounces = false, -1
+		} else if claimed, isNew := trace.claimListenerHit(currentReflections, result.energy); claimed {
+			trace.setCaptureArrival(newArrival(source, origin, direction, listenerPos, listenerRadius, currentReflections, result.energy, seg.PathLength))
+			if isNew && len(tracerHooks) > 0 {
+				fireListenerHitHooks(RayEvent{Source: source, RayIndex: trace.RayIndex, Bounces: currentReflections, Energy: energy, Point: endPoint, Direction: direction})
 			}
 		}
 	}
 
-	// Determine if this ray segment should be drawn
-	shouldDraw := false
-	if currentSegmentOpacity >= 0.01 { // Basic visibility
-		if !showOnlyListenerRays {
-			shouldDraw = true
-		} else if result.hitListener || reflectionHitData.hitListener { // If showing only listener rays, and this path (current or future segment) hits.
-			shouldDraw = true
-			if listenerHitThisSegment { // if this segment is the one hitting, ensure its color is listenerRayColor
-				rayColor = listenerRayColor
-				currentSegmentOpacity = initialRayOpacity // And full opacity for the hitting segment
-			} else if reflectionHitData.hitListener {
-				// If a future segment hits, this segment's color remains its bounce color.
-				// Opacity might be low, but it's part of a successful path.
-			}
-		}
+	travelled := seg.PathLength + endPoint.DistanceTo(origin)
+	seg.line = RayLine{
+		Start:      Point3D{origin.X, origin.Y, origin.Z},
+		End:        Point3D{endPoint.X, endPoint.Y, endPoint.Z},
+		Color:      rayColor, // Already listenerRayColor (at full opacity) if this segment hits
+		Opacity:    currentSegmentOpacity,
+		SourceID:   sourceID(source),
+		PathLength: travelled,
+		ArrivalMs:  arrivalTimeMs(travelled),
 	}
 
-	if shouldDraw {
-		rayVisuals = append(rayVisuals, &RayLine{
-			Start:   Point3D{origin.X, origin.Y, origin.Z},
-			End:     Point3D{endPoint.X, endPoint.Y, endPoint.Z},
-			Color:   rayColor,
-			Opacity: currentSegmentOpacity,
-		})
+	// Store data for subsequent bounces even if this segment itself didn't hit the listener directly
+	// The final hitListener status will be determined by the deepest reflection that hits.
+	if intersection.Hit && !listenerHitThisSegment {
+		trace.recordImpact(intersection, energy*airTransmission(rayLength)*sourceEnergyGain(source))
 	}
 
-	return result
-}
-
-func calculateListenerScore(testSourcePos, testListenerPos Vector3) int {
-	currentListenerScore := 0
-	var tempCollidables []*SceneObject
-
-	// Create a temporary list of collidables for this specific evaluation
-	// Exclude the object being tested if it's the sound source,
-	// but include it if it's a reflection point.
-	for _, obj := range allSceneObjects {
-		isCurrentTestedSource := (obj.Name == "SoundSource" && obj.Position.X == testSourcePos.X && obj.Position.Y == testSourcePos.Y && obj.Position.Z == testSourcePos.Z)
-		// The listener itself should always be a target, not an occluder for its own rays.
-		// The sound source is the origin, so it's not an occluder for direct rays.
-		if !isCurrentTestedSource && obj.Name != "Listener" {
-			tempCollidables = append(tempCollidables, obj)
+	if !intersection.Hit || currentReflections >= maxReflections || listenerHitThisSegment || !surfaceContinuesPath(intersection.Object, currentReflections+1) {
+		return nil
+	}
+	survivor, alive := rouletteSurvivor(energy * airTransmission(rayLength)) // Weak rays may be culled; invisible segments still count
+	if !alive {
+		return nil
+	}
+	reflected := energyAfterReflection(survivor, intersection.Object, direction, intersection.Normal)
+	trace.NeeCredit += nextEventCredit(intersection, direction, reflected, currentReflections+1, listenerPos, listenerRadius, sets)
+	reflected *= 1 - neeScattering(intersection.Object)
+	children := reflectionChildren(direction, intersection, reflected, seg.splitDepth)
+	splitDepth := seg.splitDepth
+	if len(children) > 1 {
+		splitDepth++
+	}
+	reflections := make([]visualSegment, len(children))
+	for k, child := range children {
+		reflections[k] = visualSegment{
+			rayBounce:  seg.reflectedBounce(intersection, child.Direction, child.Energy, sets),
+			splitDepth: splitDepth,
+			surface:    intersection.Object,
+			bounceAt:   intersection.Point,
 		}
 	}
+	return reflections
+}
 
+// evaluationRayCount returns how many rays calculateListenerScore casts. By default this is a
+// fraction of numRays; the time-budgeted learning loop may override it to fit its budget.
+func evaluationRayCount() int {
+	if evalRayCountOverride > 0 {
+		return evalRayCountOverride
+	}
+	if gpuOffloadActive() { // The GPU kernel makes full-quality evaluations affordable
+		return numRays
+	}
 	evalNumRays := numRays / 50 // Use fewer rays for faster evaluation during optimization
 	if evalNumRays < 10 {
 		evalNumRays = 10
@@ -341,33 +478,101 @@ func calculateListenerScore(testSourcePos, testListenerPos Vector3) int {
 	if evalNumRays > 100 { // Cap eval rays
 		evalNumRays = 100
 	}
+	return evalNumRays
+}
+
+// calculateListenerScore scores a placement of the primary sound source and the listener.
+// Every other sound source contributes from its current position; each contribution is
+// weighted by the source's energy gain, so muted sources add nothing.
+func calculateListenerScore(testSourcePos, testListenerPos Vector3) int {
+	if len(soundSources) == 0 { // Source registry not built yet, score the primary source alone
+		return calculateSourceScore(soundSource, testSourcePos, testListenerPos)
+	}
+	total := 0.0
+	for _, src := range soundSources {
+		gain := sourceEnergyGain(src)
+		if gain == 0 {
+			continue
+		}
+		sourcePos := src.Position
+		if src == soundSource {
+			sourcePos = testSourcePos
+		}
+		total += gain * float64(calculateSourceScore(src, sourcePos, testListenerPos))
+	}
+	return int(math.Round(total))
+}
 
-	var listenerObjForRadius *SceneObject
-	if listener != nil && listener.Position.X == testListenerPos.X && listener.Position.Y == testListenerPos.Y && listener.Position.Z == testListenerPos.Z {
-		listenerObjForRadius = listener
-	} else { // Create a temporary listener representative if needed (e.g. if listener is nil globally)
-		listenerObjForRadius = &SceneObject{Scale: Vector3{X: 0.25, Y: 0.25, Z: 0.25}} // Default listener radius
+// calculateSourceScore is the unweighted evaluation score of rays cast from one source position.
+// It reuses the cached evaluation context and directions, so it does not allocate per call.
+func calculateSourceScore(source *SceneObject, testSourcePos, testListenerPos Vector3) int {
+	currentListenerScore := 0.0
+	sets := collidableSetsFor(source)
+
+	// The source is not an occluder for its own direct rays. When it is tested away from its
+	// current position, its body at that position still occludes, as for reflected rays.
+	directCollidables := sets.Reflected
+	if source != nil && source.Position == testSourcePos {
+		directCollidables = sets.Direct
 	}
-	listenerRadius := listenerObjForRadius.Scale.X // Assuming uniform scale for radius
 
-	for i := 0; i < evalNumRays; i++ {
-		// Fibonacci spiral for even distribution
-		phi := math.Acos(-1 + (2*float64(i))/float64(evalNumRays))
-		theta := math.Sqrt(float64(evalNumRays)*math.Pi) * phi
-		direction := SetFromSphericalCoords(1, phi, theta).Normalize()
+	listenerRadius := listenerCaptureRadius()
 
-		hitBounceCount := castRayAndGetBounceCountForEvaluation(testSourcePos, direction, 0, tempCollidables, testListenerPos, listenerRadius)
-		if hitBounceCount == 0 { // Direct hit
-			currentListenerScore += BASE_DIRECT_HIT_SCORE
-		} else if hitBounceCount > 0 { // Indirect hit
-			fibIndex := hitBounceCount
-			if fibIndex > FIBONACCI_SCORE_CAP_INDEX { // Cap Fibonacci index
-				fibIndex = FIBONACCI_SCORE_CAP_INDEX
-			}
-			if fibIndex < len(fibonacciSequence) { // Ensure index is within bounds
-				currentListenerScore += fibonacciSequence[fibIndex]
-			}
+	directions := evaluationDirectionsFor(evaluationRayCount())
+	emitters := emitterPoints(source, testSourcePos)
+	sessionStats.Evaluations.Add(1)
+	var batchedBounces []int
+	var batchedEnergies []float64
+	if gpuOffloadActive() && !useNextEventEstimation && !bidirectionalConnect { // The GPU batch has no shadow rays or scattered shares
+		batchedBounces, batchedEnergies = traceBounceCountsBatched(source, emitters, directions, directCollidables, testListenerPos, listenerRadius, sets)
+	}
+	// The GPU batch has already traced every ray, so there is nothing for adaptive rays to save
+	estimate := traceAdaptively(len(directions), adaptiveRays && batchedBounces == nil, false, func(i int) float64 {
+		var hitBounceCount int
+		var hitEnergy float64
+		rayScore := 0.0 // Next-event credit, then the ray's own arrival
+		if batchedBounces != nil {
+			hitBounceCount, hitEnergy = batchedBounces[i], batchedEnergies[i]
+		} else if emitted := emissionGain(source, directions[i]); emitted > 0 {
+			hitBounceCount, hitEnergy = castRayAndGetBounceCountForEvaluation(emitters[i%len(emitters)], directions[i], 0, emitted, directCollidables, testListenerPos, listenerRadius, sets, &rayScore)
+		} else {
+			return 0 // Outside a cone source's aperture
+		}
+		if stochasticOrderCounts(hitBounceCount) {
+			rayScore += arrivalScore(hitBounceCount, hitEnergy)
 		}
+		return rayScore
+	})
+	sessionStats.EvaluationRays.Add(int64(estimate.Rays))
+	currentListenerScore += estimate.Score
+	if bidirectionalConnect {
+		currentListenerScore += connectionScore(source, testSourcePos, testListenerPos, listenerRadius, directions)
+	}
+	if hybridSimulation {
+		currentListenerScore += imagePathScore(earlyImagePaths(source, testSourcePos, testListenerPos), listenerRadius, len(directions))
+	}
+	if bidirectionalTracing {
+		return combineBidirectional(currentListenerScore, reverseTraceScore(source, testSourcePos, testListenerPos, listenerRadius, len(directions)))
+	}
+	return int(math.Round(currentListenerScore))
+}
+
+// bounceScore is the score of one ray reaching the listener after the given number of
+// reflections: BASE_DIRECT_HIT_SCORE for direct hits, the capped Fibonacci number otherwise.
+// Misses (-1) score 0.
+func bounceScore(bounces int) int {
+	if bounces == 0 { // Direct hit
+		return BASE_DIRECT_HIT_SCORE
+	}
+	if bounces < 0 {
+		return 0
+	}
+	fibIndex := bounces
+	if fibIndex > FIBONACCI_SCORE_CAP_INDEX { // Cap Fibonacci index
+		fibIndex = FIBONACCI_SCORE_CAP_INDEX
+	}
+	if fibIndex < len(fibonacciSequence) { // Ensure index is within bounds
+		return fibonacciSequence[fibIndex]
 	}
-	return currentListenerScore
+	return 0
 }
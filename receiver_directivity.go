@@ -0,0 +1,52 @@
+package main
+
+import "math"
+
+// --- Listener (Receiver) Directivity ---
+//
+// The listener faces along listenerYawDeg. Arrivals are weighted by a cardioid-like pattern that
+// blends from 1 for sound arriving from the front to listenerRearGain for sound from behind;
+// a rear gain of 1 is an omnidirectional receiver.
+
+var (
+	listenerYawDeg   = 0.0 // About +Y; 0 faces +Z
+	listenerRearGain = 1.0 // Weight of arrivals from directly behind, in [0, 1]
+)
+
+// listenerFacing is the unit vector the listener faces along (horizontal).
+func listenerFacing() Vector3 {
+	yaw := listenerYawDeg * math.Pi / 180
+	return Vector3{math.Sin(yaw), 0, math.Cos(yaw)}
+}
+
+// receiverGain weights a ray reaching the listener while travelling along propagation. The sound
+// comes from -propagation, so a ray travelling against the facing direction is a frontal arrival.
+func receiverGain(propagation Vector3) float64 {
+	if listenerRearGain >= 1 {
+		return 1
+	}
+	cosAngle := -propagation.Normalize().Dot(listenerFacing())
+	return listenerRearGain + (1-listenerRearGain)*(1+cosAngle)/2
+}
+
+// arrivalGain is the directional weight of a ray ending on its target. Forward rays end on the
+// listener; reverse rays start at the listener and end on the source, which they reach travelling
+// against the direction the equivalent forward ray was emitted in.
+func arrivalGain(sets *CollidableSets, propagation Vector3) float64 {
+	if sets != nil && sets.Reverse {
+		return emissionGain(sets.Source, propagation.Scale(-1))
+	}
+	return receiverGain(propagation)
+}
+
+// receiverLegendEntry describes the rear weighting for the legend; nil for an omnidirectional listener.
+func receiverLegendEntry() map[string]interface{} {
+	if listenerRearGain >= 1 {
+		return nil
+	}
+	return map[string]interface{}{
+		"color":   float64(listenerRayColor),
+		"label":   "Reaches Listener From Behind",
+		"opacity": listenerRearGain,
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+	"time"
+)
+
+// --- Animated Record Application ---
+
+const (
+	RECORD_ANIMATION_FRAME_INTERVAL = 50 * time.Millisecond // Time between intermediate frames
+	RECORD_ANIMATION_RAY_DIVISOR    = 4                     // Intermediate frames trace numRays / divisor rays
+	RECORD_ANIMATION_MIN_RAYS       = 50
+)
+
+var recordAnimationID int // Incremented to cancel a running animation
+
+// cancelRecordAnimation stops any running record animation at its current frame.
+func cancelRecordAnimation() {
+	recordAnimationID++
+}
+
+// smoothStep eases t in [0,1] so motion starts and ends gently.
+func smoothStep(t float64) float64 {
+	t = math.Max(0, math.Min(1, t))
+	return t * t * (3 - 2*t)
+}
+
+// lerpVector blends a and b by t.
+func lerpVector(a, b Vector3, t float64) Vector3 {
+	return a.Add(b.Sub(a).Scale(t))
+}
+
+// animateToRecord moves the source and listener to the record's positions over duration,
+// re-visualizing each frame with a reduced ray count, then applies the record at full quality.
+func animateToRecord(settings BestScoreSettings, duration time.Duration) {
+	cancelRecordAnimation()
+	id := recordAnimationID
+	settings = keepLockedParameters(settings)
+	startSource, startListener := soundSource.Position, listener.Position
+
+	go func() {
+		defer recoverFromPanic("animateToRecord")
+		start := time.Now()
+		for {
+			if id != recordAnimationID || learningModeActive {
+				return // Superseded by another animation, a direct apply, or learning
+			}
+			t := float64(time.Since(start)) / float64(duration)
+			if t >= 1 {
+				break
+			}
+			eased := smoothStep(t)
+			soundSource.Position = lerpVector(startSource, settings.SoundSourcePos, eased)
+			listener.Position = lerpVector(startListener, settings.ListenerPos, eased)
+
+			fullRays := numRays
+			numRays = fullRays / RECORD_ANIMATION_RAY_DIVISOR
+			if numRays < RECORD_ANIMATION_MIN_RAYS {
+				numRays = RECORD_ANIMATION_MIN_RAYS
+			}
+			notifyMovableObjectsChanged()
+			numRays = fullRays
+
+			time.Sleep(RECORD_ANIMATION_FRAME_INTERVAL)
+		}
+		applyRecordedSettings(settings)
+	}()
+}
+
+// goAnimateToRecord([index, durationMs, force]) animates the source and listener to a record's
+// positions and then applies the record. force works as in goApplyRecordedSettingsByIndex.
+func goAnimateToRecord(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goAnimateToRecord")
+	if len(args) < 2 {
+		log.Println("Error: goAnimateToRecord expects 2 or 3 arguments (index, durationMs, force)")
+		return nil
+	}
+	if learningModeActive {
+		log.Println("Cannot animate to a record while learning mode is running.")
+		return nil
+	}
+	if soundSource == nil || listener == nil {
+		return nil
+	}
+	index := args[0].Int()
+	durationMs := args[1].Float()
+	force := len(args) >= 3 && args[2].Truthy()
+	settings, ok := recordForIndex(index, force)
+	if !ok {
+		return nil
+	}
+	if durationMs <= 0 {
+		cancelRecordAnimation()
+		applyRecordedSettings(settings)
+		return nil
+	}
+	logEvent(LogInterop, LogNotice, "Animating to record %d (Score: %d) over %.0f ms", index, settings.Score, durationMs)
+	animateToRecord(settings, time.Duration(durationMs*float64(time.Millisecond)))
+	return nil
+}
+
+// blendRecords mixes two records: positions and continuous parameters linearly, integer
+// parameters rounded. Display-only flags come from whichever record t is closer to.
+func blendRecords(a, b BestScoreSettings, t float64) BestScoreSettings {
+	lerp := func(x, y float64) float64 { return x + (y-x)*t }
+	blended := a
+	if t >= 0.5 {
+		blended = b
+	}
+	blended.NumRays = int(math.Round(lerp(float64(a.NumRays), float64(b.NumRays))))
+	blended.MaxReflections = int(math.Round(lerp(float64(a.MaxReflections), float64(b.MaxReflections))))
+	blended.InitialRayOpacity = lerp(a.InitialRayOpacity, b.InitialRayOpacity)
+	blended.VolumeAttenuationFactor = lerp(a.VolumeAttenuationFactor, b.VolumeAttenuationFactor)
+	blended.ExplorationFactor = lerp(a.ExplorationFactor, b.ExplorationFactor)
+	blended.SoundSourcePos = lerpVector(a.SoundSourcePos, b.SoundSourcePos, t)
+	blended.ListenerPos = lerpVector(a.ListenerPos, b.ListenerPos, t)
+	return blended
+}
+
+// goInterpolateRecords([indexA, indexB, t, force]) applies the blend of two records at t in [0,1]
+// and re-scores it, returning {score, source: [x, y, z], listener: [x, y, z]}.
+func goInterpolateRecords(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goInterpolateRecords")
+	if len(args) < 3 {
+		log.Println("Error: goInterpolateRecords expects 3 or 4 arguments (indexA, indexB, t, force)")
+		return nil
+	}
+	if learningModeActive {
+		log.Println("Cannot interpolate records while learning mode is running.")
+		return nil
+	}
+	if soundSource == nil || listener == nil {
+		return nil
+	}
+	force := len(args) >= 4 && args[3].Truthy()
+	a, okA := recordForIndex(args[0].Int(), force)
+	b, okB := recordForIndex(args[1].Int(), force)
+	if !okA || !okB {
+		return nil
+	}
+	t := math.Max(0, math.Min(1, args[2].Float()))
+
+	cancelRecordAnimation()
+	applyRecordedSettings(blendRecords(a, b, t)) // Re-visualizes, which updates listenerRayScore
+	return js.ValueOf(map[string]interface{}{
+		"score":    listenerRayScore,
+		"source":   []interface{}{soundSource.Position.X, soundSource.Position.Y, soundSource.Position.Z},
+		"listener": []interface{}{listener.Position.X, listener.Position.Y, listener.Position.Z},
+	})
+}
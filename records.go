@@ -1,8 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"sort"
+	"strings"
 
 	"syscall/js"
 )
@@ -12,31 +14,83 @@ type BestScoreSettings struct {
 	Score                   int
 	Iteration               int
 	NumRays                 int
+	CaptureRadius           float64 // Calibrated listener capture radius, 0 for the listener's own (see listener_calibration.go)
 	InitialRayOpacity       float64
 	MaxReflections          int
 	VolumeAttenuationFactor float64
 	ExplorationFactor       float64
 	SoundSourcePos          Vector3
 	ListenerPos             Vector3
+	SourceYawDeg            float64 // Aim of the primary source (see directivity.go)
+	SourcePitchDeg          float64
 	ShowOnlyListenerRays    bool
-	AllObjectSnapshots      []SceneObjectSnapshot // Optional: for restoring entire scene states
+	AllObjectSnapshots      []SceneObjectSnapshot    // Optional: for restoring entire scene states
+	SceneHash               uint64                   // computeSceneHash() of the scene the record was found in
+	STI                     float64                  // Speech Transmission Index estimate of the placement (see sti.go)
+	STIEstimated            bool                     // Whether STI is set; it is deferred until the record is shown outside learning
+	OptimizerProfile        OptimizerProfile         // Learning settings the record was found with (see optimizer_profiles.go)
+	FurniturePoses          map[string]FurniturePose // Movable furniture by name, which learning may re-place (see furniture_rotation.go)
 }
 
+// Defaults for record diversity (see RecordManager.diversify)
+const (
+	DEFAULT_RECORD_CLUSTER_RADIUS float64 = 2.0 // Meters; closer source and listener positions count as the same solution
+	DEFAULT_RECORD_GLOBAL_TOP     int     = 3   // Best records kept even if they are neighbors
+)
+
 // RecordManager handles storing and retrieving best scores
 type RecordManager struct {
-	BestRecords []BestScoreSettings
-	MaxRecords  int
+	BestRecords    []BestScoreSettings
+	MaxRecords     int
+	ClusterRadius  float64 // Records whose source and listener are both within this distance share a cluster
+	GlobalTopCount int     // This many top records are always kept, regardless of clustering
 }
 
 func NewRecordManager(maxRecords int) *RecordManager {
 	return &RecordManager{
-		BestRecords: make([]BestScoreSettings, 0, maxRecords),
-		MaxRecords:  maxRecords,
+		BestRecords:    make([]BestScoreSettings, 0, maxRecords),
+		MaxRecords:     maxRecords,
+		ClusterRadius:  DEFAULT_RECORD_CLUSTER_RADIUS,
+		GlobalTopCount: DEFAULT_RECORD_GLOBAL_TOP,
+	}
+}
+
+// sameCluster reports whether two records describe essentially the same placement.
+// Records from different scenes never share a cluster.
+func (rm *RecordManager) sameCluster(a, b BestScoreSettings) bool {
+	return a.SceneHash == b.SceneHash &&
+		a.SoundSourcePos.DistanceTo(b.SoundSourcePos) < rm.ClusterRadius &&
+		a.ListenerPos.DistanceTo(b.ListenerPos) < rm.ClusterRadius
+}
+
+// diversify keeps the best record of each position cluster plus the global top few, so the list
+// shows genuinely different solutions. Records must already be sorted by descending score.
+func (rm *RecordManager) diversify() {
+	if rm.ClusterRadius <= 0 {
+		return
+	}
+	var representatives []BestScoreSettings
+	kept := rm.BestRecords[:0:0]
+	for i, rec := range rm.BestRecords {
+		isRepresentative := true
+		for _, r := range representatives {
+			if rm.sameCluster(rec, r) {
+				isRepresentative = false
+				break
+			}
+		}
+		if isRepresentative {
+			representatives = append(representatives, rec)
+		}
+		if isRepresentative || i < rm.GlobalTopCount {
+			kept = append(kept, rec)
+		}
 	}
+	rm.BestRecords = kept
 }
 
 func (rm *RecordManager) AddRecord(settings BestScoreSettings) {
-	log.Printf("New record candidate: Score %d at iter %d", settings.Score, settings.Iteration)
+	logEvent(LogOptimizer, LogDetail, "New record candidate: Score %d at iter %d", settings.Score, settings.Iteration)
 
 	// Add the new record
 	rm.BestRecords = append(rm.BestRecords, settings)
@@ -46,51 +100,110 @@ func (rm *RecordManager) AddRecord(settings BestScoreSettings) {
 		return rm.BestRecords[i].Score > rm.BestRecords[j].Score
 	})
 
+	rm.diversify()
+
 	// If the number of records exceeds MaxRecords, truncate the list
 	if len(rm.BestRecords) > rm.MaxRecords {
 		rm.BestRecords = rm.BestRecords[:rm.MaxRecords]
 	}
 
-	log.Printf("RecordManager updated. Current top %d scores: ", len(rm.BestRecords))
+	var summary strings.Builder
 	for i, rec := range rm.BestRecords {
-		log.Printf("  %d. Score: %d, Iter: %d", i+1, rec.Score, rec.Iteration)
+		fmt.Fprintf(&summary, "\n  %d. Score: %d, Iter: %d", i+1, rec.Score, rec.Iteration)
 	}
+	logEvent(LogOptimizer, LogDetail, "RecordManager updated. Current top %d scores:%s", len(rm.BestRecords), summary.String())
 
 	// Notify JavaScript to update the records display
-	jsGlobal.Call("updateRecordsDisplay", rm.prepareRecordsForJS())
+	callOptionalJS("updateRecordsDisplay", rm.prepareRecordsForJS())
+}
+
+// estimateMissingSTI fills in the STI of records from the current scene that have none yet.
+// Each costs an STI_RAYS trace, so this is skipped while learning and done once per record.
+func (rm *RecordManager) estimateMissingSTI() {
+	if learningModeActive {
+		return
+	}
+	sceneHash := computeSceneHash()
+	for i := range rm.BestRecords {
+		rec := &rm.BestRecords[i]
+		if !rec.STIEstimated && rec.SceneHash == sceneHash {
+			rec.STI, rec.STIEstimated = estimateSTIAt(rec.SoundSourcePos, rec.ListenerPos), true
+		}
+	}
 }
 
 func (rm *RecordManager) prepareRecordsForJS() js.Value {
+	rm.estimateMissingSTI()
 	jsRecords := make([]interface{}, len(rm.BestRecords))
 	for i, rec := range rm.BestRecords {
+		var sti interface{} // null until estimated
+		if rec.STIEstimated {
+			sti = rec.STI
+		}
 		jsRecords[i] = map[string]interface{}{
-			"score":     rec.Score,
-			"iteration": rec.Iteration,
-			"numRays":   rec.NumRays, // Example of including more data
+			"score":        rec.Score,
+			"iteration":    rec.Iteration,
+			"numRays":      rec.NumRays, // Example of including more data
+			"sceneHash":    formatSceneHash(rec.SceneHash),
+			"sceneMatches": rec.SceneHash == computeSceneHash(),
+			"sti":          sti,
+			"sourceYaw":    rec.SourceYawDeg,
+			"sourcePitch":  rec.SourcePitchDeg,
+			"profile":      rec.OptimizerProfile.Name,
 			// Add other relevant fields if you want them in the JS display object
 		}
 	}
 	return js.ValueOf(jsRecords)
 }
 
-func goApplyRecordedSettingsByIndex(this js.Value, args []js.Value) interface{} {
-	defer recoverFromPanic("goApplyRecordedSettingsByIndex")
-	if len(args) != 1 {
-		log.Println("Error: goApplyRecordedSettingsByIndex expects 1 argument (index)")
+// goSetRecordDiversity([clusterRadius, globalTopCount]) configures record clustering. A radius of 0
+// disables clustering. The current list is re-filtered immediately.
+func goSetRecordDiversity(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetRecordDiversity")
+	if len(args) != 2 {
+		log.Println("Error: goSetRecordDiversity expects 2 arguments (clusterRadius, globalTopCount)")
 		return nil
 	}
-	index := args[0].Int()
+	radius, top := args[0].Float(), args[1].Int()
+	if radius < 0 || top < 0 {
+		log.Printf("Error: record diversity settings must be non-negative, got radius %.2f and top %d", radius, top)
+		return nil
+	}
+	recordsManager.ClusterRadius = radius
+	recordsManager.GlobalTopCount = top
+	recordsManager.diversify()
+	callOptionalJS("updateRecordsDisplay", recordsManager.prepareRecordsForJS())
+	return nil
+}
 
+// recordForIndex returns the record at index if it may be applied to the current scene.
+// Records found in a different scene (see computeSceneHash) are refused unless force is true,
+// in which case a warning is logged.
+func recordForIndex(index int, force bool) (BestScoreSettings, bool) {
 	if index < 0 || index >= len(recordsManager.BestRecords) {
 		log.Printf("Error: Invalid record index %d. Max index %d", index, len(recordsManager.BestRecords)-1)
-		return nil
+		return BestScoreSettings{}, false
 	}
-
 	settings := recordsManager.BestRecords[index]
-	log.Printf("Applying recorded settings from record %d (Score: %d)", index, settings.Score)
+	if currentHash := computeSceneHash(); settings.SceneHash != currentHash {
+		if !force {
+			log.Printf("Refusing to apply record %d: it was found in scene %s, the current scene is %s. Pass force=true to apply anyway.",
+				index, formatSceneHash(settings.SceneHash), formatSceneHash(currentHash))
+			return BestScoreSettings{}, false
+		}
+		log.Printf("Warning: applying record %d from a different scene (%s, current %s); its score may not be reproducible.",
+			index, formatSceneHash(settings.SceneHash), formatSceneHash(currentHash))
+	}
+	return settings, true
+}
+
+// applyRecordedSettings restores a record's parameters and positions, updates the UI and re-visualizes.
+func applyRecordedSettings(settings BestScoreSettings) {
+	settings = keepLockedParameters(settings) // Locked parameters keep their current values
 
 	// Apply settings
 	numRays = settings.NumRays
+	calibratedCaptureRadius = settings.CaptureRadius
 	initialRayOpacity = settings.InitialRayOpacity
 	maxReflections = settings.MaxReflections
 	volumeAttenuationFactor = settings.VolumeAttenuationFactor
@@ -99,18 +212,22 @@ func goApplyRecordedSettingsByIndex(this js.Value, args []js.Value) interface{}
 
 	if soundSource != nil {
 		soundSource.Position = settings.SoundSourcePos
+		setSourceAim(settings.SourceYawDeg, settings.SourcePitchDeg)
+		callOptionalJS("updateSourceAim", sourceDirectivity.YawDeg, sourceDirectivity.PitchDeg)
 	}
 	if listener != nil {
 		listener.Position = settings.ListenerPos
 	}
+	resyncDynamicObjectsInCloud()
+	restoreFurniturePoses(settings.FurniturePoses)
 
 	// TODO: If AllObjectSnapshots were populated and you want to restore them, do it here.
 	// This would involve iterating settings.AllObjectSnapshots and updating allSceneObjects.
 	// Be careful with this, as it could be complex if objects can be added/removed.
-	// For now, we only restore sound source and listener positions.
+	// For now, we only restore the sound source, listener and movable furniture poses.
 
 	// Update UI sliders to reflect the applied settings
-	jsGlobal.Call("updateAllUISliders",
+	callOptionalJS("updateAllUISliders",
 		numRays, initialRayOpacity, maxReflections, volumeAttenuationFactor, explorationFactor,
 		soundSource.Position.X, soundSource.Position.Y, soundSource.Position.Z,
 		listener.Position.X, listener.Position.Y, listener.Position.Z,
@@ -119,5 +236,73 @@ func goApplyRecordedSettingsByIndex(this js.Value, args []js.Value) interface{}
 
 	visualizeSoundPropagation() // Re-visualize with the new settings
 	updateRayLegendJS()         // Update legend if maxReflections changed
+}
+
+// goApplyRecordedSettingsByIndex([index, force]) restores a record; see recordForIndex for force.
+func goApplyRecordedSettingsByIndex(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goApplyRecordedSettingsByIndex")
+	if len(args) < 1 || len(args) > 2 {
+		log.Println("Error: goApplyRecordedSettingsByIndex expects 1 or 2 arguments (index, force)")
+		return nil
+	}
+	index := args[0].Int()
+	force := len(args) == 2 && args[1].Truthy()
+
+	settings, ok := recordForIndex(index, force)
+	if !ok {
+		return nil
+	}
+	cancelRecordAnimation()
+	logEvent(LogInterop, LogNotice, "Applying recorded settings from record %d (Score: %d)", index, settings.Score)
+	applyRecordedSettings(settings)
 	return nil
 }
+
+// previewRecord scores a record against the current scene geometry without applying it: the
+// record's positions (respecting parameter locks) and reflection depth are evaluated at
+// evaluation quality, and the scene is left as it was.
+func previewRecord(settings BestScoreSettings) map[string]interface{} {
+	settings = keepLockedParameters(settings)
+	currentScore := calculateListenerScore(soundSource.Position, listener.Position)
+
+	savedReflections := maxReflections
+	maxReflections = settings.MaxReflections
+	previewScore := calculateListenerScore(settings.SoundSourcePos, settings.ListenerPos)
+	maxReflections = savedReflections
+
+	lineOfSight := true
+	if occupancyCloud != nil {
+		lineOfSight = occupancyCloud.IsLineOfSightClear(settings.SoundSourcePos, settings.ListenerPos)
+	}
+	return map[string]interface{}{
+		"recordedScore": settings.Score,
+		"previewScore":  previewScore,
+		"currentScore":  currentScore,
+		"delta":         previewScore - currentScore,
+		"feasible":      isPlacementFeasible(PlacementCandidate{Source: settings.SoundSourcePos, Listener: settings.ListenerPos}),
+		"lineOfSight":   lineOfSight,
+		"distance":      settings.SoundSourcePos.Sub(settings.ListenerPos).Length(),
+		"sceneMatches":  settings.SceneHash == computeSceneHash(),
+	}
+}
+
+// goPreviewRecord(index) returns what applying a record would yield in the current scene:
+// {recordedScore, previewScore, currentScore, delta, feasible, lineOfSight, distance, sceneMatches}.
+// Scores are at evaluation quality so they compare with each other, not with the displayed score.
+// Nothing is moved, and records from other scenes can be previewed safely.
+func goPreviewRecord(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goPreviewRecord")
+	if len(args) != 1 {
+		log.Println("Error: goPreviewRecord expects 1 argument (index)")
+		return nil
+	}
+	index := args[0].Int()
+	if index < 0 || index >= len(recordsManager.BestRecords) {
+		log.Printf("Error: Invalid record index %d. Max index %d", index, len(recordsManager.BestRecords)-1)
+		return nil
+	}
+	if soundSource == nil || listener == nil {
+		return nil
+	}
+	return js.ValueOf(previewRecord(recordsManager.BestRecords[index]))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math"
+	"syscall/js"
+)
+
+// --- First-Reflection Coverage Patches (where to place absorbers) ---
+//
+// For each room surface, the patch whose single-bounce specular reflections carry sound from the
+// source into the listener sphere. Mirroring the source across the surface gives its image;
+// projecting the listener sphere from the image onto the surface outlines the patch.
+
+const FIRST_REFLECTION_PATCH_SAMPLES = 64 // Points on the listener sphere projected onto each surface
+
+// ReflectionPatch is a first-reflection zone on one face of a room surface. U and V run along the
+// face's two in-plane axes (X before Y before Z) from 0 at the face's minimum corner to 1.
+type ReflectionPatch struct {
+	Surface    *SceneObject
+	FaceNormal Vector3
+	Center     Vector3 // Specular point for the listener's center
+	Min, Max   Vector3 // World-space bounds of the patch on the face
+	UMin, UMax float64
+	VMin, VMax float64
+	Occluded   bool // The path through Center is blocked, so this patch does not currently matter
+}
+
+// faceUV returns p's coordinates on the face of box perpendicular to faceAxis, in [0, 1].
+func faceUV(p Vector3, box *SceneObject, faceAxis int) (u, v float64) {
+	var coords []float64
+	for axis := 0; axis < 3; axis++ {
+		if axis == faceAxis {
+			continue
+		}
+		size := axisComponent(box.Scale, axis)
+		start := axisComponent(box.Position, axis) - size/2
+		coords = append(coords, (axisComponent(p, axis)-start)/size)
+	}
+	return coords[0], coords[1]
+}
+
+// firstReflectionPatches computes the patch on every room surface face that both the source and
+// the receiver are in front of. Faces where no projected point lands on the surface are skipped.
+func firstReflectionPatches(source, receiver *SceneObject) []ReflectionPatch {
+	radius := receiver.Scale.X
+	samples := sphereDirections(FIRST_REFLECTION_PATCH_SAMPLES, false)
+	for i := range samples {
+		samples[i] = receiver.Position.Add(samples[i].Scale(radius))
+	}
+
+	var patches []ReflectionPatch
+	for _, obj := range allSceneObjects {
+		if !isRoomBoundary(obj) || !obj.Visible || obj.ShapeType != "box" {
+			continue
+		}
+		half := obj.Scale.Scale(0.5)
+		for axis := 0; axis < 3; axis++ {
+			for _, side := range []float64{-1, 1} {
+				plane := axisComponent(obj.Position, axis) + side*axisComponent(half, axis)
+				sourceDist := (axisComponent(source.Position, axis) - plane) * side
+				receiverDist := (axisComponent(receiver.Position, axis) - plane) * side
+				if sourceDist <= EPSILON || receiverDist <= EPSILON {
+					continue
+				}
+				image := withAxisComponent(source.Position, axis, plane-side*sourceDist)
+				project := func(p Vector3) (Vector3, bool) {
+					pDist := (axisComponent(p, axis) - plane) * side
+					if pDist <= EPSILON {
+						return Vector3{}, false
+					}
+					return image.Add(p.Sub(image).Scale(sourceDist / (sourceDist + pDist))), true
+				}
+
+				patch := ReflectionPatch{Surface: obj, FaceNormal: withAxisComponent(Vector3{}, axis, side)}
+				minP := Vector3{math.Inf(1), math.Inf(1), math.Inf(1)}
+				maxP := Vector3{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+				onFace := 0
+				for _, s := range samples {
+					q, ok := project(s)
+					if !ok || !pointWithinFace(q, obj, axis) {
+						continue
+					}
+					onFace++
+					minP = Vector3{math.Min(minP.X, q.X), math.Min(minP.Y, q.Y), math.Min(minP.Z, q.Z)}
+					maxP = Vector3{math.Max(maxP.X, q.X), math.Max(maxP.Y, q.Y), math.Max(maxP.Z, q.Z)}
+				}
+				if onFace == 0 {
+					continue
+				}
+				patch.Min, patch.Max = minP, maxP
+				patch.UMin, patch.VMin = faceUV(minP, obj, axis)
+				patch.UMax, patch.VMax = faceUV(maxP, obj, axis)
+				center, _ := project(receiver.Position)
+				if !pointWithinFace(center, obj, axis) {
+					center = minP.Add(maxP).Scale(0.5)
+				}
+				patch.Center = center
+				patch.Occluded = isSegmentOccluded(source.Position, center, source, receiver, obj) ||
+					isSegmentOccluded(receiver.Position, center, source, receiver, obj)
+				patches = append(patches, patch)
+			}
+		}
+	}
+	return patches
+}
+
+// goGetFirstReflectionPatches returns the first-reflection patch of each room surface for the
+// primary source and the listener as [{surface, normal, center, min, max, uMin, uMax, vMin, vMax,
+// occluded}] with positions as {x,y,z}, for overlays showing where absorbers would help.
+func goGetFirstReflectionPatches(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetFirstReflectionPatches")
+	if soundSource == nil || listener == nil {
+		return nil
+	}
+	patches := firstReflectionPatches(soundSource, listener)
+	result := make([]interface{}, len(patches))
+	for i, p := range patches {
+		result[i] = map[string]interface{}{
+			"surface":  p.Surface.Name,
+			"normal":   map[string]interface{}{"x": p.FaceNormal.X, "y": p.FaceNormal.Y, "z": p.FaceNormal.Z},
+			"center":   map[string]interface{}{"x": p.Center.X, "y": p.Center.Y, "z": p.Center.Z},
+			"min":      map[string]interface{}{"x": p.Min.X, "y": p.Min.Y, "z": p.Min.Z},
+			"max":      map[string]interface{}{"x": p.Max.X, "y": p.Max.Y, "z": p.Max.Z},
+			"uMin":     p.UMin,
+			"uMax":     p.UMax,
+			"vMin":     p.VMin,
+			"vMax":     p.VMax,
+			"occluded": p.Occluded,
+		}
+	}
+	return js.ValueOf(result)
+}
@@ -0,0 +1,26 @@
+package main
+
+import "math"
+
+// --- Self-Intersection Offset for Reflected Rays ---
+
+// SCENE_EPSILON_SCALE is the relative float64 precision budget for hit points: the offset never
+// drops below this fraction of the hit point's largest coordinate, so far-off surfaces stay clear.
+const SCENE_EPSILON_SCALE = 1e-9
+
+// sceneEpsilon is the smallest offset that reliably separates a point at p from its surface.
+func sceneEpsilon(p Vector3) float64 {
+	return math.Max(EPSILON, SCENE_EPSILON_SCALE*math.Max(math.Abs(p.X), math.Max(math.Abs(p.Y), math.Abs(p.Z))))
+}
+
+// reflectionOrigin is where a reflected ray restarts: the hit point pushed off the surface along
+// its normal, towards the side the reflected direction leaves on. Offsetting along the reflected
+// direction instead moves the origin almost parallel to the surface at grazing incidence, which
+// let rays re-hit the surface they had just left.
+func reflectionOrigin(hit RayIntersectionResult, reflectDirection Vector3) Vector3 {
+	normal := hit.Normal
+	if reflectDirection.Dot(normal) < 0 {
+		normal = normal.Scale(-1)
+	}
+	return hit.Point.Add(normal.Scale(math.Max(reflectionOffset, sceneEpsilon(hit.Point))))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestReflectionOffset fires grazing rays at convex surfaces of every shape and checks that no
+// reflection restarting at reflectionOrigin re-hits the surface it left.
+func TestReflectionOffset(t *testing.T) {
+	box := NewSceneObject("Box", "box")
+	box.Scale = Vector3{4, 1, 4}
+	rotatedBox := NewSceneObject("RotatedBox", "box")
+	rotatedBox.Scale, rotatedBox.Rotation = Vector3{3, 1, 2}, Vector3{20, 35, -50}
+	sphere := NewSceneObject("Sphere", "sphere")
+	sphere.Scale = Vector3{1.5, 1.5, 1.5}
+	ellipsoid := NewSceneObject("Ellipsoid", "ellipsoid")
+	ellipsoid.Scale, ellipsoid.Rotation = Vector3{3, 1, 2}, Vector3{0, 30, 10}
+	dish := NewSceneObject("Dish", "paraboloid")
+	dish.Scale = Vector3{2, 0.5, 2}
+	tetrahedron := NewSceneObject("Tetrahedron", "mesh")
+	tetrahedron.Rotation = Vector3{15, 40, 0}
+	tetrahedron.Mesh, _ = newTriangleMesh(
+		[]Vector3{{0, 1.5, 0}, {-1.5, -1, -1}, {1.5, -1, -1}, {0, -1, 1.5}},
+		[][3]int{{0, 1, 2}, {0, 2, 3}, {0, 3, 1}, {1, 3, 2}})
+	panel := NewSceneObject("Panel", "mesh")
+	panel.Rotation = Vector3{-60, 0, 25}
+	panel.Mesh, _ = newTriangleMesh([]Vector3{{-2, 0, -2}, {2, 0, -2}, {2, 0, 2}, {-2, 0, 2}}, [][3]int{{0, 1, 2}, {0, 2, 3}})
+
+	tests := []struct {
+		name   string
+		obj    *SceneObject
+		origin Vector3 // Outside the object, on its convex side
+		aim    Vector3 // Where the probe ray is aimed, to find the surface point tested
+	}{
+		{"box top", box, Vector3{0.3, 3, 0.1}, Vector3{0.3, 0, 0.1}},
+		{"rotated box", rotatedBox, Vector3{0.5, 4, 0.3}, Vector3{}},
+		{"rotated box side", rotatedBox, Vector3{-4, 0.2, 0.5}, Vector3{}},
+		{"sphere", sphere, Vector3{2, 3, -1}, Vector3{}},
+		{"ellipsoid", ellipsoid, Vector3{1, 4, 2}, Vector3{}},
+		{"paraboloid underside", dish, Vector3{0.4, -3, 0.2}, Vector3{0.4, 0, 0.2}},
+		{"mesh face", tetrahedron, Vector3{1, 4, 3}, Vector3{}},
+		{"rotated mesh panel", panel, Vector3{0.5, 3, 3}, Vector3{0.2, 0, 0.1}},
+	}
+	savedOffset := reflectionOffset
+	defer func() { reflectionOffset = savedOffset }()
+	for _, tt := range tests {
+		for _, c := range []struct {
+			name     string
+			position Vector3
+			offset   float64
+		}{
+			{"default offset", Vector3{2, 2, -1}, savedOffset},
+			{"minimum offset", Vector3{2, 2, -1}, MIN_REFLECTION_OFFSET},
+			{"far off", Vector3{900, 2, -700}, MIN_REFLECTION_OFFSET}, // Where hit points lose precision
+		} {
+			t.Run(tt.name+", "+c.name, func(t *testing.T) {
+				tt.obj.Position, reflectionOffset = c.position, c.offset
+				objects := []*SceneObject{tt.obj}
+				origin, aim := tt.origin.Add(tt.obj.Position), tt.aim.Add(tt.obj.Position)
+				probeDir := aim.Sub(origin).Normalize()
+				probe := performRaycast(origin, probeDir, 20, objects, nil)
+				if !probe.Hit {
+					t.Fatalf("probe ray missed")
+				}
+				normal := probe.Normal
+				if normal.Dot(probeDir) > 0 {
+					normal = normal.Scale(-1)
+				}
+				tangent := normal.Cross(Vector3{0.3, 0.5, 0.8}).Normalize()
+				bitangent := normal.Cross(tangent)
+				for _, grazingDeg := range []float64{0.01, 0.1, 1, 5, 30} {
+					rad := grazingDeg * math.Pi / 180
+					for _, heading := range []Vector3{tangent, bitangent, tangent.Scale(-0.6).Add(bitangent.Scale(0.8))} {
+						direction := heading.Scale(math.Cos(rad)).Sub(normal.Scale(math.Sin(rad))).Normalize()
+						hit := performRaycast(probe.Point.Sub(direction), direction, 10, objects, nil)
+						if !hit.Hit {
+							t.Fatalf("%.2f° ray missed the surface", grazingDeg)
+						}
+						reflected := direction.Reflect(hit.Normal)
+						if again := performRaycast(reflectionOrigin(hit, reflected), reflected, 10, objects, nil); again.Hit {
+							t.Errorf("%.2f° reflection re-hit its surface after %.2g m", grazingDeg, again.Distance)
+						}
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Scenario Regression Suite ---
+//
+// Canned rooms with the metric ranges a sane build produces in them. goRunScenarioSuite builds
+// each room in place of the live scene, measures it with fixed tracing settings (so the user's
+// sliders and modes don't move the results), checks every metric against its range and restores
+// the live scene. A failure points at broken physics on this build or device, not at the room.
+
+const (
+	SCENARIO_EVAL_RAYS        = 2000 // Evaluation rays per score
+	SCENARIO_MAX_REFLECTIONS  = 3
+	SCENARIO_CAPTURE_RADIUS   = 0.5 // m; large enough for stable hit counts at SCENARIO_EVAL_RAYS
+	SCENARIO_COVERAGE_RES     = 6   // Coverage grid cells per side
+	SCENARIO_COVERAGE_DENSITY = 25  // Score per 1000 rays at which a cell counts as covered
+	SCENARIO_LISTENER_HEIGHT  = 1.5
+	SCENARIO_TREATED_ABSORBER = 0.8
+)
+
+// MetricRange is the accepted interval of one metric.
+type MetricRange struct {
+	Metric   string
+	Min, Max float64
+}
+
+// Scenario is a canned room and the ranges its metrics must fall in.
+type Scenario struct {
+	Name   string
+	Build  func() // Fills the (emptied) live scene
+	Ranges []MetricRange
+}
+
+// ScenarioResult is one metric of one scenario, checked against its range.
+type ScenarioResult struct {
+	Scenario string
+	MetricRange
+	Value float64
+	Pass  bool
+}
+
+// buildScenarioShoebox makes an empty width×height×depth room with the default materials and the
+// source and listener on a diagonal at ear height.
+func buildScenarioShoebox(width, height, depth float64) {
+	roomWidth, roomHeight, roomDepth = width, height, depth
+	createEnvironment()
+	createSoundSourceAndListener()
+	soundSource.Position = Vector3{-width / 5, SCENARIO_LISTENER_HEIGHT, depth / 4}
+	listener.Position = Vector3{width / 5, SCENARIO_LISTENER_HEIGHT, -depth / 4}
+}
+
+var scenarios = []Scenario{
+	{
+		Name:  "emptyShoebox",
+		Build: func() { buildScenarioShoebox(10, 3, 8) },
+		Ranges: []MetricRange{
+			{"scoreDensity", 35, 90},
+			{"sabineRT60", 1.8, 2.6}, // Sabine: 0.161·240 m³ / ≈17.5 m² sabins
+			{"coverage", 0.9, 1},
+		},
+	},
+	{
+		Name: "treatedRoom",
+		Build: func() {
+			buildScenarioShoebox(10, 3, 8)
+			for _, obj := range allSceneObjects {
+				if obj.IsStatic {
+					obj.Material.Name = "absorber"
+					obj.Material.Absorption = SCENARIO_TREATED_ABSORBER
+					obj.Material.BandAbsorption, obj.Material.Impedance = nil, 0
+				}
+			}
+		},
+		Ranges: []MetricRange{
+			{"scoreDensity", 15, 40},
+			{"sabineRT60", 0.14, 0.24},
+			{"coverage", 0.4, 0.9}, // Reflections too weak to cover the far cells
+		},
+	},
+	{
+		Name: "clutteredRoom",
+		Build: func() {
+			buildScenarioShoebox(10, 3, 8)
+			wood := MaterialProperties{Name: "wood", Color: [4]float32{0.63, 0.32, 0.18, 1.0}, Absorption: 0.1}
+			shelf := MaterialProperties{Name: "bookshelf", Color: [4]float32{0.54, 0.27, 0.07, 1.0}, Absorption: 0.3}
+			createObject("Scenario-Screen", "box", Vector3{0, 1.2, 0}, Vector3{0, 30, 0}, Vector3{2.5, 2.4, 0.2}, wood, false, true)
+			createObject("Scenario-Shelf-Left", "box", Vector3{-3.5, 1, -2}, Vector3{}, Vector3{1, 2, 3}, shelf, false, true)
+			createObject("Scenario-Shelf-Right", "box", Vector3{3.5, 1, 2}, Vector3{}, Vector3{1, 2, 3}, shelf, false, true)
+			createObject("Scenario-Table", "box", Vector3{-1, 0.75, -2.5}, Vector3{}, Vector3{2, 0.1, 1}, wood, false, true)
+			createObject("Scenario-Pillar", "box", Vector3{1.5, 1.5, 1}, Vector3{}, Vector3{0.5, 3, 0.5}, shelf, false, true)
+		},
+		Ranges: []MetricRange{
+			{"scoreDensity", 12, 40},
+			{"sabineRT60", 0.8, 1.4},
+			{"coverage", 0.6, 1},
+		},
+	},
+}
+
+// scenarioCoverage is the share of placeable cells of a coarse grid at ear height where the
+// listener's score density reaches SCENARIO_COVERAGE_DENSITY.
+func scenarioCoverage() float64 {
+	hm := computeCoverageHeatmap(SCENARIO_COVERAGE_RES, SCENARIO_COVERAGE_RES, SCENARIO_LISTENER_HEIGHT)
+	placeable, covered := 0, 0
+	for _, row := range hm.Values {
+		for _, v := range row {
+			if math.IsNaN(v) {
+				continue
+			}
+			placeable++
+			if v*1000/SCENARIO_EVAL_RAYS >= SCENARIO_COVERAGE_DENSITY {
+				covered++
+			}
+		}
+	}
+	if placeable == 0 {
+		return 0
+	}
+	return float64(covered) / float64(placeable)
+}
+
+// measureScenario builds s in the live scene and returns its metrics.
+func measureScenario(s Scenario) map[string]float64 {
+	allSceneObjects = make([]*SceneObject, 0)
+	sceneObjectsByName = map[string]*SceneObject{}
+	staticSceneObjects = make([]*SceneObject, 0)
+	wallCeilingMeshes = make([]*SceneObject, 0)
+	s.Build()
+	rebuildSceneIndexes()
+	initOccupancyCloud()
+	occupancyCloud.ClearForbiddenZones()
+
+	score := calculateListenerScore(soundSource.Position, listener.Position)
+	return map[string]float64{
+		"scoreDensity": float64(score) * 1000 / SCENARIO_EVAL_RAYS,
+		"sabineRT60":   computeSceneStatistics().SabineRT60,
+		"coverage":     scenarioCoverage(),
+	}
+}
+
+// runScenarioSuite measures every scenario with pinned tracing settings and restores the live
+// scene and settings afterwards. Passes are held off meanwhile, since the scenarios replace the
+// live scene; nothing here may request one.
+func runScenarioSuite() []ScenarioResult {
+	passMu.Lock()
+	defer passMu.Unlock()
+	backup := captureSceneSlot()
+	savedReflections, savedEvalRays, savedRadius := maxReflections, evalRayCountOverride, calibratedCaptureRadius
+	savedHybrid, savedBidirectional, savedNEE, savedConnect := hybridSimulation, bidirectionalTracing, useNextEventEstimation, bidirectionalConnect
+	defer func() {
+		maxReflections, evalRayCountOverride, calibratedCaptureRadius = savedReflections, savedEvalRays, savedRadius
+		hybridSimulation, bidirectionalTracing, useNextEventEstimation, bidirectionalConnect = savedHybrid, savedBidirectional, savedNEE, savedConnect
+		restoreSceneSlot(backup)
+	}()
+	maxReflections, evalRayCountOverride, calibratedCaptureRadius = SCENARIO_MAX_REFLECTIONS, SCENARIO_EVAL_RAYS, SCENARIO_CAPTURE_RADIUS
+	hybridSimulation, bidirectionalTracing, useNextEventEstimation, bidirectionalConnect = false, false, false, false
+
+	var results []ScenarioResult
+	for _, s := range scenarios {
+		metrics := measureScenario(s)
+		for _, r := range s.Ranges {
+			v := metrics[r.Metric]
+			results = append(results, ScenarioResult{Scenario: s.Name, MetricRange: r, Value: v, Pass: v >= r.Min && v <= r.Max})
+		}
+	}
+	return results
+}
+
+// goRunScenarioSuite() runs the scenario regression suite and returns {passed, results: [{scenario,
+// metric, value, min, max, pass}]}, logging every metric outside its range.
+func goRunScenarioSuite(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goRunScenarioSuite")
+	if learningModeActive {
+		log.Println("Cannot run the scenario suite while learning mode is running.")
+		return nil
+	}
+	results := runScenarioSuite()
+	passed := true
+	jsResults := make([]interface{}, len(results))
+	for i, r := range results {
+		if !r.Pass {
+			passed = false
+			log.Printf("Scenario %s: %s = %.3f outside [%g, %g]", r.Scenario, r.Metric, r.Value, r.Min, r.Max)
+		}
+		jsResults[i] = map[string]interface{}{
+			"scenario": r.Scenario, "metric": r.Metric, "value": r.Value,
+			"min": r.Min, "max": r.Max, "pass": r.Pass,
+		}
+	}
+	log.Printf("Scenario suite: %d metrics checked, passed=%t", len(results), passed)
+	debouncedVisualizeFunc()
+	return js.ValueOf(map[string]interface{}{"passed": passed, "results": jsResults})
+}
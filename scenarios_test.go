@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+// TestScenarioSuite builds every canned room and fails on any metric outside its range.
+func TestScenarioSuite(t *testing.T) {
+	initTestScene(t)
+
+	results := runScenarioSuite()
+	checked := 0
+	for _, s := range scenarios {
+		checked += len(s.Ranges)
+	}
+	if len(results) != checked {
+		t.Fatalf("%d results for %d metric ranges", len(results), checked)
+	}
+	for _, r := range results {
+		if !r.Pass {
+			t.Errorf("%s: %s = %.3f outside [%g, %g]", r.Scenario, r.Metric, r.Value, r.Min, r.Max)
+		}
+	}
+}
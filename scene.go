@@ -7,21 +7,38 @@ import (
 
 // --- Scene & Object Representation ---
 type MaterialProperties struct {
+	Name          string     // Material name, used to group surfaces in statistics
 	Color         [4]float32 // R, G, B, A (0.0 to 1.0)
 	IsTransparent bool
+	Absorption    float64 // Broadband absorption coefficient (0 = fully reflective, 1 = fully absorbing)
+
+	// BandAbsorption holds one coefficient per OCTAVE_BANDS_HZ entry; nil means Absorption in
+	// every band. When set, Absorption is kept at the band mean (see setMaterialBands).
+	BandAbsorption []float64
+
+	// Path termination for "dead" surfaces such as heavy curtains; both zero values leave paths alone.
+	TerminationProbability float64 // Chance that a path ends at this surface instead of reflecting
+	BounceOrderCap         int     // If > 0, the surface only reflects as one of a path's first BounceOrderCap reflections
+
+	Impedance  float64 // Surface impedance in Pa·s/m for angle-dependent reflection (see surface_impedance.go); 0 = off
+	Scattering float64 // Share of reflected energy scattered diffusely when rays are split (see ray_splitting.go)
 }
 
 type SceneObject struct {
-	Name            string
-	ID              string
-	Position        Vector3
-	Rotation        Vector3 // Euler angles in degrees
-	Scale           Vector3
-	Visible         bool
-	IsStatic        bool // True if the object cannot be moved by optimization/learning
-	Material        MaterialProperties
-	isWallOrCeiling bool
-	ShapeType       string // "box", "sphere"
+	Name              string
+	ID                string
+	Position          Vector3
+	Rotation          Vector3 // Euler angles in degrees
+	Scale             Vector3
+	Visible           bool
+	IsStatic          bool // True if the object cannot be moved by optimization/learning
+	Material          MaterialProperties
+	isWallOrCeiling   bool
+	isSoundSource     bool          // Emits rays; see soundSources
+	isSeating         bool          // Listeners sit here; see seatListeningPositions
+	orientationOffset float64       // Degrees about Y that learning turned the object, included in Rotation.Y
+	ShapeType         string        // "box", "sphere", "mesh", "ellipsoid", "paraboloid"
+	Mesh              *TriangleMesh // Triangles of a "mesh" object, in its local frame
 }
 
 // Snapshot of an object's state for recording
@@ -44,7 +61,9 @@ func NewSceneObject(name, shapeType string) *SceneObject {
 		IsStatic:  true, // Default to static
 		ShapeType: shapeType,
 		Material: MaterialProperties{
-			Color: [4]float32{0.7, 0.7, 0.7, 1.0},
+			Name:       "default",
+			Color:      [4]float32{0.7, 0.7, 0.7, 1.0},
+			Absorption: 0.1,
 		},
 	}
 }
@@ -54,10 +73,14 @@ type RayLine struct {
 	Start, End Point3D
 	Color      uint32
 	Opacity    float64
+	SourceID   string  // ID of the sound source that emitted the ray
+	PathLength float64 // Meters travelled from the source to End
+	ArrivalMs  float64 // Time sound takes to travel PathLength
 }
 
 func createSceneContent() {
 	allSceneObjects = make([]*SceneObject, 0)
+	sceneObjectsByName = map[string]*SceneObject{}
 	staticSceneObjects = make([]*SceneObject, 0)
 	wallCeilingMeshes = make([]*SceneObject, 0)
 	createEnvironment()
@@ -65,6 +88,36 @@ func createSceneContent() {
 	createSoundSourceAndListener()
 }
 
+// rebuildSceneIndexes recomputes the derived object lists and the source/listener pointers
+// from allSceneObjects, e.g. after the whole scene has been replaced.
+func rebuildSceneIndexes() {
+	invalidateCollidableSets()
+	rebuildNameIndex()
+	staticSceneObjects = make([]*SceneObject, 0)
+	wallCeilingMeshes = make([]*SceneObject, 0)
+	soundSource, listener = nil, nil
+	soundSources = make([]*SceneObject, 0)
+	for _, obj := range allSceneObjects {
+		if obj.isWallOrCeiling {
+			wallCeilingMeshes = append(wallCeilingMeshes, obj)
+		}
+		if obj.isSoundSource && obj.Name != "SoundSource" {
+			soundSources = append(soundSources, obj)
+		}
+		switch obj.Name {
+		case "SoundSource":
+			soundSource = obj
+			soundSources = append([]*SceneObject{obj}, soundSources...)
+		case "Listener":
+			listener = obj
+		default:
+			if obj.IsStatic {
+				staticSceneObjects = append(staticSceneObjects, obj)
+			}
+		}
+	}
+}
+
 func createObject(name, shapeType string, pos, rotDegrees, scale Vector3, matProps MaterialProperties, isWall, isStatic bool) *SceneObject {
 	obj := NewSceneObject(name, shapeType)
 	obj.Position = pos
@@ -73,7 +126,7 @@ func createObject(name, shapeType string, pos, rotDegrees, scale Vector3, matPro
 	obj.Material = matProps
 	obj.isWallOrCeiling = isWall
 	obj.IsStatic = isStatic
-	allSceneObjects = append(allSceneObjects, obj)
+	addSceneObject(obj)
 	if isWall {
 		wallCeilingMeshes = append(wallCeilingMeshes, obj)
 	}
@@ -84,9 +137,10 @@ func createObject(name, shapeType string, pos, rotDegrees, scale Vector3, matPro
 }
 
 func createEnvironment() {
-	groundMat := MaterialProperties{Color: [4]float32{0.6, 0.6, 0.6, 1.0}}
+	groundMat := MaterialProperties{Name: "floor", Color: [4]float32{0.6, 0.6, 0.6, 1.0}, Absorption: 0.1}
+	setMaterialImpedance(&groundMat, DEFAULT_FLOOR_IMPEDANCE) // Grazing floor reflections differ from normal incidence
 	createObject("Ground", "box", Vector3{0, 0, 0}, Vector3{}, Vector3{roomWidth, wallThickness, roomDepth}, groundMat, false, true)
-	wallMat := MaterialProperties{Color: [4]float32{0.8, 0.8, 0.8, float32(currentWallOpacity)}, IsTransparent: currentWallOpacity < 1.0}
+	wallMat := MaterialProperties{Name: "plaster", Color: [4]float32{0.8, 0.8, 0.8, float32(currentWallOpacity)}, IsTransparent: currentWallOpacity < 1.0, Absorption: 0.05}
 	createObject("BackWall", "box", Vector3{0, roomHeight / 2, -roomDepth / 2}, Vector3{}, Vector3{roomWidth, roomHeight, wallThickness}, wallMat, true, true)
 	createObject("FrontWall", "box", Vector3{0, roomHeight / 2, roomDepth / 2}, Vector3{}, Vector3{roomWidth, roomHeight, wallThickness}, wallMat, true, true)
 	createObject("LeftWall", "box", Vector3{-roomWidth / 2, roomHeight / 2, 0}, Vector3{}, Vector3{wallThickness, roomHeight, roomDepth}, wallMat, true, true)
@@ -95,13 +149,13 @@ func createEnvironment() {
 }
 
 func createFurniture() {
-	bookshelfMat := MaterialProperties{Color: [4]float32{0.54, 0.27, 0.07, 1.0}}
-	tableMat := MaterialProperties{Color: [4]float32{0.63, 0.32, 0.18, 1.0}}
-	pillarMat := MaterialProperties{Color: [4]float32{0.5, 0.5, 0.5, 1.0}}
-	plantPotMat := MaterialProperties{Color: [4]float32{0.4, 0.2, 0.1, 1.0}}
-	plantLeavesMat := MaterialProperties{Color: [4]float32{0.1, 0.5, 0.1, 1.0}}
-	couchMat := MaterialProperties{Color: [4]float32{0.3, 0.3, 0.4, 1.0}}
-	lampMat := MaterialProperties{Color: [4]float32{0.9, 0.9, 0.7, 1.0}}
+	bookshelfMat := MaterialProperties{Name: "bookshelf", Color: [4]float32{0.54, 0.27, 0.07, 1.0}, Absorption: 0.3}
+	tableMat := MaterialProperties{Name: "wood", Color: [4]float32{0.63, 0.32, 0.18, 1.0}, Absorption: 0.1}
+	pillarMat := MaterialProperties{Name: "concrete", Color: [4]float32{0.5, 0.5, 0.5, 1.0}, Absorption: 0.02}
+	plantPotMat := MaterialProperties{Name: "ceramic", Color: [4]float32{0.4, 0.2, 0.1, 1.0}, Absorption: 0.02}
+	plantLeavesMat := MaterialProperties{Name: "foliage", Color: [4]float32{0.1, 0.5, 0.1, 1.0}, Absorption: 0.2}
+	couchMat := MaterialProperties{Name: "upholstery", Color: [4]float32{0.3, 0.3, 0.4, 1.0}, Absorption: 0.5}
+	lampMat := MaterialProperties{Name: "fabric", Color: [4]float32{0.9, 0.9, 0.7, 1.0}, Absorption: 0.25}
 
 	createObject("Bookshelf-Main-Left", "box", Vector3{-roomWidth/2 + 5, 1.5, 0}, Vector3{}, Vector3{2, 3, 6}, bookshelfMat, false, true)
 	createObject("Bookshelf-Main-Right", "box", Vector3{roomWidth/2 - 5, 1.5, 0}, Vector3{}, Vector3{2, 3, 6}, bookshelfMat, false, true)
@@ -120,9 +174,10 @@ func createFurniture() {
 	createObject("Pillar-BackLeft", "box", Vector3{-roomWidth / 3, pillarHeight / 2, -roomDepth / 3}, Vector3{}, Vector3{0.6, pillarHeight, 0.6}, pillarMat, false, true)
 	createObject("Pillar-BackRight", "box", Vector3{roomWidth / 3, pillarHeight / 2, -roomDepth / 3}, Vector3{}, Vector3{0.6, pillarHeight, 0.6}, pillarMat, false, true)
 
-	createObject("Couch-Left", "box", Vector3{-roomWidth/2 + 4, 0.5, roomDepth / 3}, Vector3{0, 90, 0}, Vector3{3, 1, 1.5}, couchMat, false, true)
-	createObject("Couch-Right", "box", Vector3{roomWidth/2 - 4, 0.5, -roomDepth / 3}, Vector3{0, -90, 0}, Vector3{3, 1, 1.5}, couchMat, false, true)
-	createObject("Armchair-Center", "box", Vector3{0, 0.4, -roomDepth / 4}, Vector3{0, 180, 0}, Vector3{1.2, 0.8, 1.2}, couchMat, false, true)
+	couchLeft := createObject("Couch-Left", "box", Vector3{-roomWidth/2 + 4, 0.5, roomDepth / 3}, Vector3{0, 90, 0}, Vector3{3, 1, 1.5}, couchMat, false, true)
+	couchRight := createObject("Couch-Right", "box", Vector3{roomWidth/2 - 4, 0.5, -roomDepth / 3}, Vector3{0, -90, 0}, Vector3{3, 1, 1.5}, couchMat, false, true)
+	armchair := createObject("Armchair-Center", "box", Vector3{0, 0.4, -roomDepth / 4}, Vector3{0, 180, 0}, Vector3{1.2, 0.8, 1.2}, couchMat, false, true)
+	couchLeft.isSeating, couchRight.isSeating, armchair.isSeating = true, true, true
 
 	createObject("PlantPot1", "box", Vector3{-roomWidth/2 + 1.5, 0.25, roomDepth/2 - 1.5}, Vector3{}, Vector3{0.5, 0.5, 0.5}, plantPotMat, false, true)
 	createObject("PlantLeaves1", "sphere", Vector3{-roomWidth/2 + 1.5, 1.0, roomDepth/2 - 1.5}, Vector3{}, Vector3{0.7, 1.0, 0.7}, plantLeavesMat, false, true)
@@ -137,8 +192,10 @@ func createFurniture() {
 }
 
 func createSoundSourceAndListener() {
-	sourceMat := MaterialProperties{Color: [4]float32{1, 0, 0, 1.0}}
+	sourceMat := MaterialProperties{Name: "source", Color: [4]float32{1, 0, 0, 1.0}}
 	soundSource = createObject("SoundSource", "sphere", Vector3{0, 1.5, 5}, Vector3{}, Vector3{0.3, 0.3, 0.3}, sourceMat, false, false)
-	listenerMat := MaterialProperties{Color: [4]float32{0, 0, 1, 1.0}}
+	soundSource.isSoundSource = true
+	soundSources = []*SceneObject{soundSource}
+	listenerMat := MaterialProperties{Name: "listener", Color: [4]float32{0, 0, 1, 1.0}}
 	listener = createObject("Listener", "sphere", Vector3{0, 1.5, -5}, Vector3{}, Vector3{0.25, 0.25, 0.25}, listenerMat, false, false)
 }
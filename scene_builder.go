@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"syscall/js"
+)
+
+// --- Scene Builder ---
+//
+// goBeginScene() returns a builder for constructing a whole room from the page:
+//
+//	goBeginScene().addRoom(12, 3, 9).setMaterial("panel", 0.7, 0.9, 0.9, 0.8)
+//	    .addBox("Desk", 0, 0.75, 0, 2, 0.1, 1).addSphere("Lamp", 1, 2, 1, 0.3, "panel")
+//	    .placeSource(-3, 1.5, 2).placeListener(3, 1.5, -2).commit()
+//
+// Every method except commit and discard returns the builder, so calls chain. Nothing touches the
+// live scene until commit, which validates the whole draft (room size, unique names, objects and
+// positions inside the room, known materials) and then replaces the active scene in one step, or
+// leaves it alone and reports every problem. Materials may be defined after the objects using them.
+
+const (
+	MIN_BUILDER_ROOM_SIZE = 1.0   // m, per dimension
+	MAX_BUILDER_ROOM_SIZE = 200.0 // m, per dimension
+	BUILDER_DEFAULT_MAT   = "default"
+	BUILDER_EAR_HEIGHT    = 1.5 // m; source and listener height unless placed
+)
+
+// DraftObject is an object of a scene under construction; Material is resolved at commit.
+type DraftObject struct {
+	Name     string
+	Shape    string
+	Position Vector3
+	Rotation Vector3
+	Scale    Vector3
+	Material string
+}
+
+// SceneDraft is a scene under construction.
+type SceneDraft struct {
+	Room        *Vector3 // Width, height, depth; nil until addRoom
+	Materials   map[string]MaterialProperties
+	Objects     []DraftObject
+	SourcePos   *Vector3
+	ListenerPos *Vector3
+	Errors      []string // Bad method arguments, reported by commit
+}
+
+var (
+	sceneDrafts        = map[int]*SceneDraft{} // Keyed by the builder's id
+	nextSceneDraftID   = 1
+	sceneBuilderMethod = map[string]js.Func{} // Shared by every builder, created on first use
+)
+
+// builderCreatedNames are the objects commit creates itself.
+var builderCreatedNames = map[string]bool{
+	"Ground": true, "BackWall": true, "FrontWall": true, "LeftWall": true, "RightWall": true,
+	"Ceiling": true, "SoundSource": true, "Listener": true,
+}
+
+// fail records a problem with a builder call.
+func (d *SceneDraft) fail(format string, args ...interface{}) {
+	d.Errors = append(d.Errors, fmt.Sprintf(format, args...))
+}
+
+// draftNumbers reads args[from:from+n] as finite numbers.
+func draftNumbers(args []js.Value, from, n int) ([]float64, bool) {
+	if len(args) < from+n {
+		return nil, false
+	}
+	values := make([]float64, n)
+	for i := range values {
+		v := args[from+i]
+		if v.Type() != js.TypeNumber || math.IsNaN(v.Float()) || math.IsInf(v.Float(), 0) {
+			return nil, false
+		}
+		values[i] = v.Float()
+	}
+	return values, true
+}
+
+// optionalString is args[i] if it is a string, otherwise fallback.
+func optionalString(args []js.Value, i int, fallback string) string {
+	if len(args) > i && args[i].Type() == js.TypeString {
+		return args[i].String()
+	}
+	return fallback
+}
+
+// addRoom(width, height, depth) sets the room the walls, floor and ceiling are built around.
+func (d *SceneDraft) addRoom(args []js.Value) {
+	v, ok := draftNumbers(args, 0, 3)
+	if !ok {
+		d.fail("addRoom expects (width, height, depth)")
+		return
+	}
+	d.Room = &Vector3{v[0], v[1], v[2]}
+}
+
+// addBox(name, x, y, z, sizeX, sizeY, sizeZ[, material[, yawDeg]]) adds a box centered at (x, y, z).
+func (d *SceneDraft) addBox(args []js.Value) {
+	v, ok := draftNumbers(args, 1, 6)
+	if !ok || args[0].Type() != js.TypeString {
+		d.fail("addBox expects (name, x, y, z, sizeX, sizeY, sizeZ[, material[, yawDeg]])")
+		return
+	}
+	obj := DraftObject{Name: args[0].String(), Shape: "box", Position: Vector3{v[0], v[1], v[2]}, Scale: Vector3{v[3], v[4], v[5]}, Material: optionalString(args, 7, BUILDER_DEFAULT_MAT)}
+	if yaw, ok := draftNumbers(args, 8, 1); ok {
+		obj.Rotation.Y = yaw[0]
+	}
+	d.Objects = append(d.Objects, obj)
+}
+
+// addSphere(name, x, y, z, radius[, material]) adds a sphere centered at (x, y, z).
+func (d *SceneDraft) addSphere(args []js.Value) {
+	v, ok := draftNumbers(args, 1, 4)
+	if !ok || args[0].Type() != js.TypeString {
+		d.fail("addSphere expects (name, x, y, z, radius[, material])")
+		return
+	}
+	d.Objects = append(d.Objects, DraftObject{Name: args[0].String(), Shape: "sphere", Position: Vector3{v[0], v[1], v[2]}, Scale: Vector3{v[3], v[3], v[3]}, Material: optionalString(args, 5, BUILDER_DEFAULT_MAT)})
+}
+
+// setMaterial(name, absorption[, r, g, b]) defines a material objects can refer to by name.
+func (d *SceneDraft) setMaterial(args []js.Value) {
+	v, ok := draftNumbers(args, 1, 1)
+	if !ok || args[0].Type() != js.TypeString {
+		d.fail("setMaterial expects (name, absorption[, r, g, b])")
+		return
+	}
+	mat := NewSceneObject("", "box").Material
+	mat.Name, mat.Absorption = args[0].String(), v[0]
+	if rgb, ok := draftNumbers(args, 2, 3); ok {
+		mat.Color = [4]float32{float32(rgb[0]), float32(rgb[1]), float32(rgb[2]), 1}
+	}
+	d.Materials[mat.Name] = mat
+}
+
+// placePoint reads (x, y, z) for placeSource and placeListener.
+func (d *SceneDraft) placePoint(method string, args []js.Value) *Vector3 {
+	v, ok := draftNumbers(args, 0, 3)
+	if !ok {
+		d.fail("%s expects (x, y, z)", method)
+		return nil
+	}
+	return &Vector3{v[0], v[1], v[2]}
+}
+
+// insideRoom reports whether p lies within a room of the given size centered on the floor.
+func insideRoom(p, room Vector3) bool {
+	return math.Abs(p.X) <= room.X/2 && p.Y >= 0 && p.Y <= room.Y && math.Abs(p.Z) <= room.Z/2
+}
+
+// validate lists every reason the draft cannot become the live scene.
+func (d *SceneDraft) validate() []string {
+	problems := append([]string(nil), d.Errors...)
+	if d.Room == nil {
+		return append(problems, "no room: call addRoom(width, height, depth)")
+	}
+	room := *d.Room
+	for _, size := range []float64{room.X, room.Y, room.Z} {
+		if size < MIN_BUILDER_ROOM_SIZE || size > MAX_BUILDER_ROOM_SIZE {
+			problems = append(problems, fmt.Sprintf("room size %v must be within [%g, %g] m", room, MIN_BUILDER_ROOM_SIZE, MAX_BUILDER_ROOM_SIZE))
+			break
+		}
+	}
+	names := map[string]bool{}
+	for _, obj := range d.Objects {
+		switch {
+		case obj.Name == "":
+			problems = append(problems, "an object has no name")
+		case builderCreatedNames[obj.Name]:
+			problems = append(problems, fmt.Sprintf("%q is created by the builder itself", obj.Name))
+		case names[obj.Name]:
+			problems = append(problems, fmt.Sprintf("duplicate object name %q", obj.Name))
+		}
+		names[obj.Name] = true
+		if obj.Scale.X <= 0 || obj.Scale.Y <= 0 || obj.Scale.Z <= 0 {
+			problems = append(problems, fmt.Sprintf("%q: sizes must be positive", obj.Name))
+		}
+		if !insideRoom(obj.Position, room) {
+			problems = append(problems, fmt.Sprintf("%q: center %v is outside the room", obj.Name, obj.Position))
+		}
+		if _, ok := d.Materials[obj.Material]; !ok && obj.Material != BUILDER_DEFAULT_MAT {
+			problems = append(problems, fmt.Sprintf("%q: unknown material %q", obj.Name, obj.Material))
+		}
+	}
+	for _, name := range sortedMaterialNames(d.Materials) {
+		if a := d.Materials[name].Absorption; !validAbsorption(&a) {
+			problems = append(problems, fmt.Sprintf("material %q: absorption %g must be in [0, 1]", name, a))
+		}
+	}
+	for i, p := range []*Vector3{d.SourcePos, d.ListenerPos} {
+		if p != nil && !insideRoom(*p, room) {
+			problems = append(problems, fmt.Sprintf("%s position %v is outside the room", []string{"source", "listener"}[i], *p))
+		}
+	}
+	return problems
+}
+
+// sortedMaterialNames lists a draft's materials in a stable order for messages.
+func sortedMaterialNames(materials map[string]MaterialProperties) []string {
+	names := make([]string, 0, len(materials))
+	for name := range materials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// commit replaces the live scene with the validated draft, holding off passes meanwhile.
+func (d *SceneDraft) commit() {
+	passMu.Lock()
+	defer passMu.Unlock()
+	allSceneObjects = make([]*SceneObject, 0, len(d.Objects)+8)
+	sceneObjectsByName = map[string]*SceneObject{}
+	staticSceneObjects = make([]*SceneObject, 0)
+	wallCeilingMeshes = make([]*SceneObject, 0)
+	roomWidth, roomHeight, roomDepth = d.Room.X, d.Room.Y, d.Room.Z
+	createEnvironment()
+	for _, obj := range d.Objects {
+		mat, ok := d.Materials[obj.Material]
+		if !ok {
+			mat = NewSceneObject("", obj.Shape).Material
+		}
+		createObject(obj.Name, obj.Shape, obj.Position, obj.Rotation, obj.Scale, mat, false, true)
+	}
+	createSoundSourceAndListener()
+	soundSource.Position = Vector3{0, BUILDER_EAR_HEIGHT, roomDepth / 4}
+	listener.Position = Vector3{0, BUILDER_EAR_HEIGHT, -roomDepth / 4}
+	if d.SourcePos != nil {
+		soundSource.Position = *d.SourcePos
+	}
+	if d.ListenerPos != nil {
+		listener.Position = *d.ListenerPos
+	}
+	rebuildSceneIndexes()
+	initOccupancyCloud()
+	occupancyCloud.ClearForbiddenZones()
+}
+
+// sceneBuilderCall wraps a builder method: it finds the builder's draft from this, runs fn and
+// returns the builder for chaining.
+func sceneBuilderCall(fn func(d *SceneDraft, args []js.Value)) func(this js.Value, args []js.Value) interface{} {
+	return func(this js.Value, args []js.Value) interface{} {
+		defer recoverFromPanic("sceneBuilder")
+		if d, ok := sceneDrafts[this.Get("id").Int()]; ok {
+			fn(d, args)
+		} else {
+			log.Println("Error: this scene builder was already committed or discarded")
+		}
+		return this
+	}
+}
+
+// builderCommit validates and applies the draft and returns {ok, errors}. The builder is spent
+// either way.
+func builderCommit(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("sceneBuilder.commit")
+	id := this.Get("id").Int()
+	d, ok := sceneDrafts[id]
+	if !ok {
+		return js.ValueOf(map[string]interface{}{"ok": false, "errors": []interface{}{"this scene builder was already committed or discarded"}})
+	}
+	delete(sceneDrafts, id)
+	if problems := d.validate(); len(problems) > 0 {
+		errors := make([]interface{}, len(problems))
+		for i, p := range problems {
+			errors[i] = p
+		}
+		log.Printf("Scene builder: %d problem(s), live scene unchanged", len(problems))
+		return js.ValueOf(map[string]interface{}{"ok": false, "errors": errors})
+	}
+	d.commit()
+	logEvent(LogInterop, LogNotice, "Scene builder committed a %.1f×%.1f×%.1f m room with %d objects", roomWidth, roomHeight, roomDepth, len(d.Objects))
+	visualizeSoundPropagation()
+	return js.ValueOf(map[string]interface{}{"ok": true, "errors": []interface{}{}})
+}
+
+// builderDiscard drops the draft without touching the live scene.
+func builderDiscard(this js.Value, args []js.Value) interface{} {
+	delete(sceneDrafts, this.Get("id").Int())
+	return nil
+}
+
+// initSceneBuilderMethods creates the builder methods once and keeps them with the exported
+// functions so goShutdown releases them.
+func initSceneBuilderMethods() {
+	if len(sceneBuilderMethod) > 0 {
+		return
+	}
+	methods := map[string]func(this js.Value, args []js.Value) interface{}{
+		"addRoom":       sceneBuilderCall((*SceneDraft).addRoom),
+		"addBox":        sceneBuilderCall((*SceneDraft).addBox),
+		"addSphere":     sceneBuilderCall((*SceneDraft).addSphere),
+		"setMaterial":   sceneBuilderCall((*SceneDraft).setMaterial),
+		"placeSource":   sceneBuilderCall(func(d *SceneDraft, args []js.Value) { d.SourcePos = d.placePoint("placeSource", args) }),
+		"placeListener": sceneBuilderCall(func(d *SceneDraft, args []js.Value) { d.ListenerPos = d.placePoint("placeListener", args) }),
+		"commit":        builderCommit,
+		"discard":       builderDiscard,
+	}
+	for name, fn := range methods {
+		f := js.FuncOf(fn)
+		sceneBuilderMethod[name] = f
+		exportedJSFuncs["sceneBuilder."+name] = f
+	}
+}
+
+// goBeginScene() starts a scene draft and returns its builder (see the top of this file).
+func goBeginScene(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goBeginScene")
+	initSceneBuilderMethods()
+	id := nextSceneDraftID
+	nextSceneDraftID++
+	sceneDrafts[id] = &SceneDraft{Materials: map[string]MaterialProperties{}}
+	builder := jsGlobal.Get("Object").New()
+	builder.Set("id", id)
+	for name, f := range sceneBuilderMethod {
+		builder.Set(name, f)
+	}
+	return builder
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// --- Scene Content Hash ---
+
+// computeSceneHash fingerprints everything a record's score depends on besides the recorded
+// positions themselves: room dimensions, air conditions, every object's shape, size and material,
+// and where the static objects are. Movable objects contribute everything but their position, so moving the
+// source or listener does not change the hash while editing the room does.
+func computeSceneHash() uint64 {
+	h := fnv.New64a()
+	writeFloat := func(v float64) {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		h.Write(buf[:])
+	}
+	writeVector := func(v Vector3) {
+		writeFloat(v.X)
+		writeFloat(v.Y)
+		writeFloat(v.Z)
+	}
+
+	writeVector(Vector3{roomWidth, roomHeight, roomDepth})
+	writeFloat(wallThickness)
+	writeFloat(environment.TemperatureC)
+	writeFloat(environment.HumidityPct)
+	writeFloat(environment.SpeedOfSound)
+
+	objects := make([]*SceneObject, len(allSceneObjects))
+	copy(objects, allSceneObjects)
+	sort.SliceStable(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	for _, obj := range objects {
+		fmt.Fprintf(h, "%s|%s|%t|%t|%s|", obj.Name, obj.ShapeType, obj.IsStatic, obj.Visible, obj.Material.Name)
+		writeFloat(obj.Material.Absorption)
+		for _, a := range obj.Material.BandAbsorption {
+			writeFloat(a)
+		}
+		writeFloat(obj.Material.Impedance)
+		writeFloat(obj.Material.Scattering)
+		writeFloat(obj.Material.TerminationProbability)
+		fmt.Fprintf(h, "%d|", obj.Material.BounceOrderCap)
+		writeVector(obj.Scale)
+		if obj.Mesh != nil {
+			for _, v := range obj.Mesh.Vertices {
+				writeVector(v)
+			}
+			for _, tri := range obj.Mesh.Triangles {
+				fmt.Fprintf(h, "%d,%d,%d|", tri[0], tri[1], tri[2])
+			}
+		}
+		if obj.IsStatic {
+			writeVector(obj.Position)
+			writeVector(obj.Rotation)
+		}
+	}
+	return h.Sum64()
+}
+
+// formatSceneHash renders a hash for JS, where a uint64 does not fit in a number.
+func formatSceneHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
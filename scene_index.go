@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"syscall/js"
+)
+
+// --- Scene Object Name Index ---
+//
+// Object names are unique. sceneObjectsByName mirrors allSceneObjects so lookups by name do not
+// scan the object list; every add, remove and rename goes through the helpers below.
+
+var sceneObjectsByName = map[string]*SceneObject{}
+
+// reservedObjectNames are names the scene code identifies objects by (see rebuildSceneIndexes and
+// isRoomBoundary), so those objects cannot be renamed.
+var reservedObjectNames = map[string]bool{"SoundSource": true, "Listener": true, "Ground": true}
+
+// findSceneObject returns the object with the given name, or nil.
+func findSceneObject(name string) *SceneObject {
+	return sceneObjectsByName[name]
+}
+
+// uniqueObjectName returns name if it is free, otherwise name-2, name-3, ... whichever is free first.
+func uniqueObjectName(name string) string {
+	if _, taken := sceneObjectsByName[name]; !taken {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if _, taken := sceneObjectsByName[candidate]; !taken {
+			return candidate
+		}
+	}
+}
+
+// addSceneObject appends obj to the scene, renaming it if its name is already taken.
+func addSceneObject(obj *SceneObject) {
+	if name := uniqueObjectName(obj.Name); name != obj.Name {
+		log.Printf("Warning: an object named %q already exists; adding it as %q", obj.Name, name)
+		obj.Name = name
+	}
+	allSceneObjects = append(allSceneObjects, obj)
+	sceneObjectsByName[obj.Name] = obj
+	invalidateCollidableSets()
+}
+
+// removeSceneObject deletes obj from the scene. Derived lists (sources, statics) are the caller's concern.
+func removeSceneObject(obj *SceneObject) bool {
+	for i, existing := range allSceneObjects {
+		if existing == obj {
+			allSceneObjects = append(allSceneObjects[:i], allSceneObjects[i+1:]...)
+			delete(sceneObjectsByName, obj.Name)
+			invalidateCollidableSets()
+			return true
+		}
+	}
+	return false
+}
+
+// rebuildNameIndex recomputes the index from allSceneObjects after the whole list was replaced.
+// Duplicate names (e.g. from an old snapshot) are renamed so the index stays one-to-one.
+func rebuildNameIndex() {
+	sceneObjectsByName = make(map[string]*SceneObject, len(allSceneObjects))
+	for _, obj := range allSceneObjects {
+		if name := uniqueObjectName(obj.Name); name != obj.Name {
+			log.Printf("Warning: duplicate object name %q renamed to %q", obj.Name, name)
+			obj.Name = name
+		}
+		sceneObjectsByName[obj.Name] = obj
+	}
+}
+
+// renameSceneObject gives an object a new, unused name. Per-source settings follow the rename.
+func renameSceneObject(oldName, newName string) error {
+	obj := findSceneObject(oldName)
+	switch {
+	case obj == nil:
+		return fmt.Errorf("no object named %q", oldName)
+	case newName == "":
+		return fmt.Errorf("the new name is empty")
+	case reservedObjectNames[oldName] || reservedObjectNames[newName]:
+		return fmt.Errorf("%q and %q: SoundSource, Listener and Ground cannot be renamed or reused", oldName, newName)
+	case findSceneObject(newName) != nil:
+		return fmt.Errorf("an object named %q already exists", newName)
+	}
+	delete(sceneObjectsByName, oldName)
+	obj.Name = newName
+	sceneObjectsByName[newName] = obj
+	if settings, ok := sourceSettings[oldName]; ok {
+		delete(sourceSettings, oldName)
+		sourceSettings[newName] = settings
+	}
+	return nil
+}
+
+// goRenameObject(oldName, newName) renames a scene object. Returns {ok, error}.
+func goRenameObject(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goRenameObject")
+	if len(args) != 2 {
+		log.Println("Error: goRenameObject expects 2 arguments (oldName, newName)")
+		return nil
+	}
+	if err := renameSceneObject(args[0].String(), args[1].String()); err != nil {
+		log.Printf("Rename failed: %v", err)
+		return js.ValueOf(map[string]interface{}{"ok": false, "error": err.Error()})
+	}
+	return js.ValueOf(map[string]interface{}{"ok": true, "error": ""})
+}
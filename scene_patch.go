@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"syscall/js"
+)
+
+// --- Partial Scene Patches ---
+//
+// A patch lists only what changes relative to the live scene:
+//
+//	{"objects": [{"name": "Couch-Left", "position": {"x": -6, "y": 0.5, "z": 4}},
+//	             {"name": "Rug", "op": "add", "shape": "box", "position": {...}, "scale": {...}, "material": "carpet"},
+//	             {"name": "Shell", "op": "add", "shape": "mesh", "vertices": [{...}, ...], "triangles": [[0, 1, 2], ...], ...},
+//	             {"name": "MiscBox1", "op": "remove"}],
+//	 "materials": [{"name": "plaster", "preset": "brick"}]}
+//
+// The whole patch is validated before anything changes, and the previous scene is restored if
+// applying it fails, so a patch is applied completely or not at all.
+
+// ObjectPatch changes, adds or removes one object. Nil fields are left unchanged.
+type ObjectPatch struct {
+	Name       string    `json:"name"`
+	Op         string    `json:"op"` // "update" (default), "add" or "remove"
+	Shape      string    `json:"shape"`
+	Position   *Vector3  `json:"position"`
+	Rotation   *Vector3  `json:"rotation"`
+	Scale      *Vector3  `json:"scale"`
+	Static     *bool     `json:"static"`
+	Visible    *bool     `json:"visible"`
+	Seating    *bool     `json:"seating"`
+	Material   string    `json:"material"` // Name of a materialBandPresets entry
+	Absorption *float64  `json:"absorption"`
+	Vertices   []Vector3 `json:"vertices"`  // Mesh only: local vertex positions
+	Triangles  [][3]int  `json:"triangles"` // Mesh only: vertex indices, three per triangle
+}
+
+// MaterialPatch changes every object whose material has the given name.
+type MaterialPatch struct {
+	Name           string    `json:"name"`
+	Preset         string    `json:"preset"`
+	Absorption     *float64  `json:"absorption"`
+	BandAbsorption []float64 `json:"bandAbsorption"`
+
+	Impedance              *float64 `json:"impedance"` // Pa·s/m; 0 goes back to plain absorption
+	TerminationProbability *float64 `json:"terminationProbability"`
+	BounceOrderCap         *int     `json:"bounceOrderCap"` // 0 removes the cap
+	Scattering             *float64 `json:"scattering"`
+}
+
+// ScenePatch is a partial scene description applied on top of the live scene.
+type ScenePatch struct {
+	Objects   []ObjectPatch   `json:"objects"`
+	Materials []MaterialPatch `json:"materials"`
+}
+
+func validAbsorption(a *float64) bool {
+	return a == nil || (*a >= 0 && *a <= 1)
+}
+
+// validateScenePatch checks a patch against the live scene without changing anything.
+func validateScenePatch(patch ScenePatch) error {
+	added := map[string]bool{}
+	for i, op := range patch.Objects {
+		if op.Name == "" {
+			return fmt.Errorf("objects[%d]: missing name", i)
+		}
+		existing := findSceneObject(op.Name)
+		switch op.Op {
+		case "", "update":
+			if existing == nil && !added[op.Name] {
+				return fmt.Errorf("objects[%d]: no object named %q", i, op.Name)
+			}
+		case "add":
+			if existing != nil || added[op.Name] {
+				return fmt.Errorf("objects[%d]: an object named %q already exists", i, op.Name)
+			}
+			if op.Shape != "box" && op.Shape != "sphere" && op.Shape != "mesh" && !isCurvedShape(op.Shape) {
+				return fmt.Errorf("objects[%d]: shape must be \"box\", \"sphere\", \"mesh\", \"ellipsoid\" or \"paraboloid\", got %q", i, op.Shape)
+			}
+			if op.Shape == "mesh" && op.Triangles == nil {
+				return fmt.Errorf("objects[%d]: added meshes need vertices and triangles", i)
+			}
+			if op.Position == nil || op.Scale == nil {
+				return fmt.Errorf("objects[%d]: added objects need a position and a scale", i)
+			}
+			added[op.Name] = true
+		case "remove":
+			if existing == nil {
+				return fmt.Errorf("objects[%d]: no object named %q", i, op.Name)
+			}
+			if existing == soundSource || existing == listener || existing.isWallOrCeiling || existing.Name == "Ground" {
+				return fmt.Errorf("objects[%d]: %q cannot be removed", i, op.Name)
+			}
+		default:
+			return fmt.Errorf("objects[%d]: unknown op %q", i, op.Op)
+		}
+		if op.Triangles != nil || op.Vertices != nil {
+			if op.Op == "remove" || (op.Op == "add" && op.Shape != "mesh") || (existing != nil && existing.ShapeType != "mesh") {
+				return fmt.Errorf("objects[%d]: only meshes take vertices and triangles", i)
+			}
+			if _, err := newTriangleMesh(op.Vertices, op.Triangles); err != nil {
+				return fmt.Errorf("objects[%d]: %v", i, err)
+			}
+		}
+		if op.Scale != nil && (op.Scale.X <= 0 || op.Scale.Y <= 0 || op.Scale.Z <= 0) {
+			return fmt.Errorf("objects[%d]: scale must be positive", i)
+		}
+		if op.Material != "" {
+			if _, ok := materialBandPresets[op.Material]; !ok {
+				return fmt.Errorf("objects[%d]: unknown material preset %q", i, op.Material)
+			}
+		}
+		if !validAbsorption(op.Absorption) {
+			return fmt.Errorf("objects[%d]: absorption must be in [0, 1]", i)
+		}
+	}
+	for i, mp := range patch.Materials {
+		if mp.Name == "" {
+			return fmt.Errorf("materials[%d]: missing name", i)
+		}
+		if mp.Preset != "" {
+			if _, ok := materialBandPresets[mp.Preset]; !ok {
+				return fmt.Errorf("materials[%d]: unknown material preset %q", i, mp.Preset)
+			}
+		}
+		if !validAbsorption(mp.Absorption) {
+			return fmt.Errorf("materials[%d]: absorption must be in [0, 1]", i)
+		}
+		if mp.BandAbsorption != nil && len(mp.BandAbsorption) != len(OCTAVE_BANDS_HZ) {
+			return fmt.Errorf("materials[%d]: bandAbsorption needs %d values", i, len(OCTAVE_BANDS_HZ))
+		}
+		for _, a := range mp.BandAbsorption {
+			if a < 0 || a > 1 {
+				return fmt.Errorf("materials[%d]: band absorption must be in [0, 1]", i)
+			}
+		}
+		if mp.Impedance != nil && (*mp.Impedance < 0 || *mp.Impedance > MAX_SURFACE_IMPEDANCE_RAYLS) {
+			return fmt.Errorf("materials[%d]: impedance must be in [0, %g] Pa·s/m", i, MAX_SURFACE_IMPEDANCE_RAYLS)
+		}
+		if !validAbsorption(mp.TerminationProbability) {
+			return fmt.Errorf("materials[%d]: termination probability must be in [0, 1]", i)
+		}
+		if mp.BounceOrderCap != nil && *mp.BounceOrderCap < 0 {
+			return fmt.Errorf("materials[%d]: bounce order cap must not be negative", i)
+		}
+		if !validAbsorption(mp.Scattering) {
+			return fmt.Errorf("materials[%d]: scattering must be in [0, 1]", i)
+		}
+	}
+	return nil
+}
+
+// applyObjectPatch applies one validated object operation to the live object list.
+func applyObjectPatch(op ObjectPatch) {
+	if op.Op == "remove" {
+		removeSceneObject(findSceneObject(op.Name))
+		return
+	}
+	obj := findSceneObject(op.Name)
+	if op.Op == "add" {
+		obj = NewSceneObject(op.Name, op.Shape)
+		addSceneObject(obj)
+	}
+	if op.Position != nil {
+		obj.Position = *op.Position
+	}
+	if op.Rotation != nil {
+		obj.Rotation = *op.Rotation
+	}
+	if op.Scale != nil {
+		obj.Scale = *op.Scale
+	}
+	if op.Triangles != nil {
+		obj.Mesh, _ = newTriangleMesh(op.Vertices, op.Triangles) // Validated by validateScenePatch
+	}
+	if op.Static != nil {
+		obj.IsStatic = *op.Static
+	}
+	if op.Visible != nil {
+		obj.Visible = *op.Visible
+	}
+	if op.Seating != nil {
+		obj.isSeating = *op.Seating
+	}
+	if op.Material != "" {
+		setMaterialBands(&obj.Material, materialBandPresets[op.Material])
+		obj.Material.Name = op.Material
+	}
+	if op.Absorption != nil {
+		obj.Material.Absorption = *op.Absorption
+		obj.Material.BandAbsorption = nil
+		obj.Material.Impedance = 0
+	}
+}
+
+// applyMaterialPatch applies one validated material change to every object using that material.
+func applyMaterialPatch(mp MaterialPatch) {
+	for _, obj := range allSceneObjects {
+		if obj.Material.Name != mp.Name {
+			continue
+		}
+		if mp.Preset != "" {
+			setMaterialBands(&obj.Material, materialBandPresets[mp.Preset])
+		}
+		if mp.BandAbsorption != nil {
+			setMaterialBands(&obj.Material, mp.BandAbsorption)
+		}
+		if mp.Absorption != nil {
+			obj.Material.Absorption = *mp.Absorption
+			obj.Material.BandAbsorption = nil
+			obj.Material.Impedance = 0
+		}
+		if mp.Impedance != nil {
+			setMaterialImpedance(&obj.Material, *mp.Impedance)
+		}
+		if mp.TerminationProbability != nil {
+			obj.Material.TerminationProbability = *mp.TerminationProbability
+		}
+		if mp.BounceOrderCap != nil {
+			obj.Material.BounceOrderCap = *mp.BounceOrderCap
+		}
+		if mp.Scattering != nil {
+			obj.Material.Scattering = *mp.Scattering
+		}
+	}
+}
+
+// applyScenePatch validates and applies a patch, restoring the previous scene if anything fails.
+func applyScenePatch(patch ScenePatch) (err error) {
+	if err := validateScenePatch(patch); err != nil {
+		return err
+	}
+	backup := captureSceneSlot()
+	defer func() {
+		if r := recover(); r != nil {
+			restoreSceneSlot(backup)
+			err = fmt.Errorf("patch failed and was rolled back: %v", r)
+		}
+	}()
+	for _, op := range patch.Objects {
+		applyObjectPatch(op)
+	}
+	for _, mp := range patch.Materials {
+		applyMaterialPatch(mp)
+	}
+	restoreSceneSlot(captureSceneSlot()) // Rebuild indexes and the occupancy cloud, keeping forbidden zones
+	return nil
+}
+
+// goApplyScenePatch(patchJSON) applies a partial scene patch. Returns {ok, error}.
+func goApplyScenePatch(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goApplyScenePatch")
+	if len(args) != 1 {
+		log.Println("Error: goApplyScenePatch expects 1 argument (patchJSON)")
+		return nil
+	}
+	if learningModeActive {
+		log.Println("Cannot patch the scene while learning mode is running.")
+		return js.ValueOf(map[string]interface{}{"ok": false, "error": "learning mode is running"})
+	}
+	var patch ScenePatch
+	if err := json.Unmarshal([]byte(jsonArgString(args[0])), &patch); err != nil {
+		log.Printf("Error: goApplyScenePatch could not parse patch: %v", err)
+		return js.ValueOf(map[string]interface{}{"ok": false, "error": err.Error()})
+	}
+	if err := applyScenePatch(patch); err != nil {
+		log.Printf("Scene patch rejected: %v", err)
+		return js.ValueOf(map[string]interface{}{"ok": false, "error": err.Error()})
+	}
+	log.Printf("Scene patch applied (%d object and %d material changes)", len(patch.Objects), len(patch.Materials))
+	notifyMovableObjectsChanged()
+	return js.ValueOf(map[string]interface{}{"ok": true, "error": ""})
+}
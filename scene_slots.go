@@ -0,0 +1,142 @@
+package main
+
+import (
+	"log"
+	"syscall/js"
+)
+
+// --- A/B Scene Slots ---
+
+// SceneSlot is an independent copy of everything that defines a room layout.
+type SceneSlot struct {
+	Objects        []SceneObject // Value copies, so slots never share objects with the live scene
+	RoomWidth      float64
+	RoomDepth      float64
+	RoomHeight     float64
+	ForbiddenZones []ForbiddenZone
+}
+
+var (
+	sceneSlots      = map[string]*SceneSlot{} // "A" and "B"
+	activeSceneSlot = "A"
+)
+
+// captureSceneSlot snapshots the live scene into a new slot.
+func captureSceneSlot() *SceneSlot {
+	slot := &SceneSlot{
+		Objects:    make([]SceneObject, len(allSceneObjects)),
+		RoomWidth:  roomWidth,
+		RoomDepth:  roomDepth,
+		RoomHeight: roomHeight,
+	}
+	for i, obj := range allSceneObjects {
+		slot.Objects[i] = *obj
+	}
+	if occupancyCloud != nil {
+		slot.ForbiddenZones = append([]ForbiddenZone(nil), occupancyCloud.ForbiddenZones...)
+	}
+	return slot
+}
+
+// restoreSceneSlot makes the slot's contents the live scene and rebuilds the occupancy cloud.
+func restoreSceneSlot(slot *SceneSlot) {
+	allSceneObjects = make([]*SceneObject, len(slot.Objects))
+	for i := range slot.Objects {
+		obj := slot.Objects[i]
+		allSceneObjects[i] = &obj
+	}
+	roomWidth, roomDepth, roomHeight = slot.RoomWidth, slot.RoomDepth, slot.RoomHeight
+	rebuildSceneIndexes()
+
+	initOccupancyCloud()
+	occupancyCloud.ClearForbiddenZones()
+	for _, zone := range slot.ForbiddenZones {
+		occupancyCloud.AddForbiddenZone(zone.Name, zone.Min, zone.Max)
+	}
+}
+
+// switchActiveScene stores the live scene in the active slot and loads the target slot.
+// An empty target slot starts as a copy of the current scene.
+func switchActiveScene(target string) bool {
+	if target != "A" && target != "B" {
+		log.Printf("Error: unknown scene slot %q (expected \"A\" or \"B\")", target)
+		return false
+	}
+	sceneSlots[activeSceneSlot] = captureSceneSlot()
+	if target == activeSceneSlot {
+		return true
+	}
+	slot, ok := sceneSlots[target]
+	if !ok {
+		slot = captureSceneSlot()
+		sceneSlots[target] = slot
+	}
+	restoreSceneSlot(slot)
+	activeSceneSlot = target
+	return true
+}
+
+// sceneMetricsForJS evaluates the live scene's key metrics.
+func sceneMetricsForJS() map[string]interface{} {
+	stats := computeSceneStatistics()
+	metrics := map[string]interface{}{
+		"roomVolume":       stats.RoomVolume,
+		"totalSurfaceArea": stats.TotalSurfaceArea,
+		"meanAbsorption":   stats.MeanAbsorption,
+		"meanFreePath":     stats.MeanFreePath,
+		"sabineRT60":       stats.SabineRT60,
+		"eyringRT60":       stats.EyringRT60,
+		"objectCount":      len(allSceneObjects),
+	}
+	if soundSource != nil && listener != nil {
+		metrics["listenerScore"] = calculateListenerScore(soundSource.Position, listener.Position)
+	}
+	return metrics
+}
+
+// goSwitchActiveScene(slot) switches the live scene to slot "A" or "B".
+func goSwitchActiveScene(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSwitchActiveScene")
+	if len(args) != 1 {
+		log.Println("Error: goSwitchActiveScene expects 1 argument (slot: \"A\" or \"B\")")
+		return false
+	}
+	if learningModeActive {
+		log.Println("Cannot switch scenes while learning mode is running.")
+		return false
+	}
+	if !switchActiveScene(args[0].String()) {
+		return false
+	}
+	log.Printf("Active scene slot is now %s", activeSceneSlot)
+	notifyMovableObjectsChanged()
+	return true
+}
+
+// goCompareSceneSlots returns {active, A: {...metrics}, B: {...metrics}} for side-by-side comparison.
+// Slots that were never populated are omitted.
+func goCompareSceneSlots(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goCompareSceneSlots")
+	if learningModeActive {
+		log.Println("Cannot compare scenes while learning mode is running.")
+		return nil
+	}
+	live := captureSceneSlot()
+	sceneSlots[activeSceneSlot] = live
+
+	result := map[string]interface{}{"active": activeSceneSlot}
+	for _, name := range []string{"A", "B"} {
+		slot, ok := sceneSlots[name]
+		if !ok {
+			continue
+		}
+		if name != activeSceneSlot {
+			restoreSceneSlot(slot)
+		}
+		result[name] = sceneMetricsForJS()
+		if name != activeSceneSlot {
+			restoreSceneSlot(live)
+		}
+	}
+	return js.ValueOf(result)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"syscall/js"
+)
+
+// --- Scene Statistics & Classical Acoustic Estimates ---
+
+const SABINE_CONSTANT float64 = 0.161 // s/m, for RT60 = 0.161 * V / A with V in m^3 and A in m^2 sabins
+
+// SceneStatistics holds geometric and analytical acoustic figures for the current scene.
+type SceneStatistics struct {
+	RoomVolume       float64            // Gross room volume
+	AirVolume        float64            // Room volume minus the volume of static furniture
+	TotalSurfaceArea float64            // Room boundaries plus exposed furniture surfaces
+	SurfaceAreaByMat map[string]float64 // Surface area per material name
+	AbsorptionArea   float64            // Equivalent absorption area A = sum(S_i * alpha_i)
+	MeanAbsorption   float64            // Area-weighted mean absorption coefficient
+	MeanFreePath     float64            // 4V / S
+	SabineRT60       float64
+	EyringRT60       float64
+}
+
+// isRoomBoundary reports whether the object is one of the room's enclosing surfaces.
+func isRoomBoundary(obj *SceneObject) bool {
+	return obj.isWallOrCeiling || obj.Name == "Ground"
+}
+
+// objectSurfaceAreaAndVolume returns the exposed surface area and volume of an object.
+// Room boundaries only contribute their inner face. Spheres use Scale.X as the radius,
+// consistent with performRaycast.
+func objectSurfaceAreaAndVolume(obj *SceneObject) (area, volume float64) {
+	switch obj.ShapeType {
+	case "box":
+		sx, sy, sz := obj.Scale.X, obj.Scale.Y, obj.Scale.Z
+		if isRoomBoundary(obj) {
+			// The two largest dimensions span the face that looks into the room
+			dims := []float64{sx, sy, sz}
+			sort.Float64s(dims)
+			return dims[1] * dims[2], 0
+		}
+		return 2 * (sx*sy + sy*sz + sx*sz), sx * sy * sz
+	case "sphere":
+		r := obj.Scale.X
+		return 4 * math.Pi * r * r, 4.0 / 3.0 * math.Pi * r * r * r
+	case "mesh": // Meshes may be open surfaces, so they displace no air
+		if obj.Mesh != nil {
+			return meshSurfaceArea(obj), 0
+		}
+	case "ellipsoid", "paraboloid": // Thin shells
+		return curvedSurfaceArea(obj), 0
+	}
+	return 0, 0
+}
+
+// computeSceneStatistics derives room volume, surface areas and the Sabine/Eyring RT60 estimates.
+// Only static objects are included; the source and listener are too small to matter.
+func computeSceneStatistics() SceneStatistics {
+	stats := SceneStatistics{
+		RoomVolume:       roomWidth * roomDepth * roomHeight,
+		SurfaceAreaByMat: map[string]float64{},
+	}
+	stats.AirVolume = stats.RoomVolume
+
+	for _, obj := range allSceneObjects {
+		if !obj.IsStatic {
+			continue
+		}
+		area, volume := objectSurfaceAreaAndVolume(obj)
+		stats.TotalSurfaceArea += area
+		stats.AirVolume -= volume
+		stats.SurfaceAreaByMat[obj.Material.Name] += area
+		stats.AbsorptionArea += area * obj.Material.Absorption
+	}
+
+	if stats.TotalSurfaceArea > 0 {
+		stats.MeanAbsorption = stats.AbsorptionArea / stats.TotalSurfaceArea
+		stats.MeanFreePath = 4 * stats.AirVolume / stats.TotalSurfaceArea
+	}
+	if stats.AbsorptionArea > 0 {
+		stats.SabineRT60 = SABINE_CONSTANT * stats.AirVolume / stats.AbsorptionArea
+	}
+	if stats.MeanAbsorption > 0 && stats.MeanAbsorption < 1 {
+		stats.EyringRT60 = SABINE_CONSTANT * stats.AirVolume / (-stats.TotalSurfaceArea * math.Log(1-stats.MeanAbsorption))
+	}
+	return stats
+}
+
+// goGetSceneStatistics returns room volume, surface areas, mean free path and Sabine/Eyring RT60.
+func goGetSceneStatistics(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetSceneStatistics")
+	stats := computeSceneStatistics()
+	areaByMat := map[string]interface{}{}
+	for name, area := range stats.SurfaceAreaByMat {
+		areaByMat[name] = area
+	}
+	return js.ValueOf(map[string]interface{}{
+		"roomVolume":       stats.RoomVolume,
+		"airVolume":        stats.AirVolume,
+		"totalSurfaceArea": stats.TotalSurfaceArea,
+		"surfaceAreaByMat": areaByMat,
+		"absorptionArea":   stats.AbsorptionArea,
+		"meanAbsorption":   stats.MeanAbsorption,
+		"meanFreePath":     stats.MeanFreePath,
+		"sabineRT60":       stats.SabineRT60,
+		"eyringRT60":       stats.EyringRT60,
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"syscall/js"
+)
+
+// --- Scene Layout Tools (swap, mirror) ---
+
+// swapSourceAndListener exchanges the positions of the sound source and the listener.
+func swapSourceAndListener() bool {
+	if soundSource == nil || listener == nil {
+		return false
+	}
+	soundSource.Position, listener.Position = listener.Position, soundSource.Position
+	resyncDynamicObjectsInCloud()
+	return true
+}
+
+// mirrorMovableObjects reflects every non-static object about the room's center plane
+// perpendicular to the given axis ("x" or "z"). The room is centered on the origin in X and Z,
+// so mirroring is a sign flip. Box rotations about Y are mirrored too so furniture stays consistent.
+func mirrorMovableObjects(axis string) bool {
+	axis = strings.ToLower(axis)
+	if axis != "x" && axis != "z" {
+		log.Printf("Error: cannot mirror about axis %q (expected \"x\" or \"z\")", axis)
+		return false
+	}
+	for _, obj := range allSceneObjects {
+		if obj.IsStatic {
+			continue
+		}
+		if axis == "x" {
+			obj.Position.X = -obj.Position.X
+		} else {
+			obj.Position.Z = -obj.Position.Z
+		}
+		obj.Rotation.Y = -obj.Rotation.Y
+	}
+	resyncDynamicObjectsInCloud()
+	return true
+}
+
+// notifyMovableObjectsChanged pushes new source/listener positions to the sliders and re-visualizes.
+func notifyMovableObjectsChanged() {
+	callOptionalJS("updateSliderValuesForObject", "SoundSource", soundSource.Position.X, soundSource.Position.Y, soundSource.Position.Z)
+	callOptionalJS("updateSliderValuesForObject", "Listener", listener.Position.X, listener.Position.Y, listener.Position.Z)
+	visualizeSoundPropagation()
+}
+
+// goSwapSourceListener swaps the sound source and listener positions.
+func goSwapSourceListener(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSwapSourceListener")
+	if learningModeActive {
+		log.Println("Cannot swap source and listener while learning mode is running.")
+		return false
+	}
+	if !swapSourceAndListener() {
+		return false
+	}
+	notifyMovableObjectsChanged()
+	return true
+}
+
+// goMirrorScene(axis) mirrors the sound source, listener and other movable objects about a room axis.
+func goMirrorScene(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goMirrorScene")
+	if len(args) != 1 {
+		log.Println("Error: goMirrorScene expects 1 argument (axis: \"x\" or \"z\")")
+		return false
+	}
+	if learningModeActive {
+		log.Println("Cannot mirror the scene while learning mode is running.")
+		return false
+	}
+	if soundSource == nil || listener == nil || !mirrorMovableObjects(args[0].String()) {
+		return false
+	}
+	notifyMovableObjectsChanged()
+	return true
+}
@@ -0,0 +1,156 @@
+package main
+
+import "syscall/js"
+
+// --- Per-Cell Score Memory ---
+//
+// The occupancy cloud remembers, for every cell learning has evaluated, the best objective score
+// seen with the source (or listener) standing there and how often it was tried. Cells tried
+// often whose best stays in the bottom of the range seen so far are "explored, low potential":
+// the local search skips them and random jumps don't land in them. The memory lives in the
+// cloud, so it is discarded with it when a scene is loaded.
+
+const (
+	SCORE_MEMORY_EXPLORED_VISITS        = 6    // Evaluations before a cell's best score is trusted
+	SCORE_MEMORY_LOW_POTENTIAL_FRACTION = 0.25 // Bottom share of the seen score range counted as low potential
+)
+
+var useScoreMemory = true // Toggle "scoreMemory": learning avoids explored low-potential cells
+
+// CellScoreMemory is what learning has observed in one cell for one moving object.
+type CellScoreMemory struct {
+	Best   int // Best objective score with the object centered in this cell
+	Visits int // Number of evaluations recorded
+}
+
+// ScoreRange is the lowest and highest per-cell best score seen for one moving object.
+type ScoreRange struct {
+	Min, Max int
+	Seen     bool
+}
+
+// scoreMemory returns the memory for the object marked with state (StateSoundSource or
+// StateListener), or nil for any other state.
+func (meta *CellMetadata) scoreMemory(state PointState) *CellScoreMemory {
+	switch state {
+	case StateSoundSource:
+		return &meta.SourceScores
+	case StateListener:
+		return &meta.ListenerScores
+	}
+	return nil
+}
+
+// RecordScore notes that the object marked with state scored score when centered at worldPos.
+func (oc *OccupancyCloud) RecordScore(worldPos Vector3, state PointState, score int) {
+	ix, iy, iz, inBounds := oc.worldToGridCoords(worldPos)
+	if !inBounds {
+		return
+	}
+	mem := oc.cellMetadata(ix, iy, iz, true).scoreMemory(state)
+	if mem == nil {
+		return
+	}
+	if mem.Visits == 0 || score > mem.Best {
+		mem.Best = score
+	}
+	mem.Visits++
+	if oc.ScoreRanges == nil {
+		oc.ScoreRanges = map[PointState]*ScoreRange{}
+	}
+	r := oc.ScoreRanges[state]
+	if r == nil {
+		r = &ScoreRange{}
+		oc.ScoreRanges[state] = r
+	}
+	if !r.Seen || mem.Best < r.Min {
+		r.Min = mem.Best
+	}
+	if !r.Seen || mem.Best > r.Max {
+		r.Max = mem.Best
+	}
+	r.Seen = true
+}
+
+// isLowPotential reports whether mem has been explored enough and its best score falls in the
+// bottom SCORE_MEMORY_LOW_POTENTIAL_FRACTION of the range seen for state.
+func (oc *OccupancyCloud) isLowPotential(mem *CellScoreMemory, state PointState) bool {
+	if mem == nil || mem.Visits < SCORE_MEMORY_EXPLORED_VISITS {
+		return false
+	}
+	r := oc.ScoreRanges[state]
+	if r == nil || r.Max <= r.Min {
+		return false
+	}
+	return float64(mem.Best-r.Min) < SCORE_MEMORY_LOW_POTENTIAL_FRACTION*float64(r.Max-r.Min)
+}
+
+// IsExploredLowPotential reports whether learning should steer the object marked with state
+// away from the cell containing worldPos. Always false while the memory is switched off.
+func (oc *OccupancyCloud) IsExploredLowPotential(worldPos Vector3, state PointState) bool {
+	if !useScoreMemory {
+		return false
+	}
+	meta := oc.CellMetadataAt(worldPos)
+	if meta == nil {
+		return false
+	}
+	return oc.isLowPotential(meta.scoreMemory(state), state)
+}
+
+// ClearScoreMemory forgets every recorded score, e.g. when the objective changes and old scores
+// are no longer comparable.
+func (oc *OccupancyCloud) ClearScoreMemory() {
+	for ix := 0; ix < oc.CellsX; ix++ {
+		for iy := 0; iy < oc.CellsY; iy++ {
+			for iz := 0; iz < oc.CellsZ; iz++ {
+				if meta := oc.Grid[ix][iy][iz].Meta; meta != nil {
+					meta.SourceScores = CellScoreMemory{}
+					meta.ListenerScores = CellScoreMemory{}
+				}
+			}
+		}
+	}
+	oc.ScoreRanges = nil
+}
+
+// goGetScoreMemory returns [{x, y, z, role, best, visits, lowPotential}] for every cell with a
+// recorded score; role is "source" or "listener".
+func goGetScoreMemory(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetScoreMemory")
+	if occupancyCloud == nil {
+		return nil
+	}
+	oc := occupancyCloud
+	roles := []struct {
+		name  string
+		state PointState
+	}{{"source", StateSoundSource}, {"listener", StateListener}}
+	var cells []interface{}
+	for ix := 0; ix < oc.CellsX; ix++ {
+		for iy := 0; iy < oc.CellsY; iy++ {
+			for iz := 0; iz < oc.CellsZ; iz++ {
+				meta := oc.Grid[ix][iy][iz].Meta
+				if meta == nil {
+					continue
+				}
+				for _, role := range roles {
+					mem := meta.scoreMemory(role.state)
+					if mem.Visits == 0 {
+						continue
+					}
+					cells = append(cells, map[string]interface{}{
+						"x":            oc.RoomMin.X + (float64(ix)+0.5)*oc.CellSize.X,
+						"y":            oc.RoomMin.Y + (float64(iy)+0.5)*oc.CellSize.Y,
+						"z":            oc.RoomMin.Z + (float64(iz)+0.5)*oc.CellSize.Z,
+						"role":         role.name,
+						"best":         mem.Best,
+						"visits":       mem.Visits,
+						"lowPotential": oc.isLowPotential(mem, role.state),
+					})
+				}
+			}
+		}
+	}
+	return js.ValueOf(cells)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Signed Distance Field over the static scene ---
+
+const (
+	SDF_NUDGE_MAX_STEPS = 8    // Gradient steps tried before giving up on pushing a position clear
+	SDF_GRADIENT_DELTA  = 0.05 // Finite-difference step (world units) for the SDF gradient
+)
+
+// SignedDistanceField stores the distance from each occupancy cell center to the nearest static
+// surface: positive in free space, negative inside obstacles or outside the room.
+// Values are sampled on the same lattice as the occupancy cloud and interpolated trilinearly.
+type SignedDistanceField struct {
+	Origin   Vector3 // Center of cell (0,0,0)
+	CellSize Vector3
+	NX       int
+	NY       int
+	NZ       int
+	Values   [][][]float64  // [ix][iy][iz]
+	Objects  []*SceneObject // The static, visible objects sampled, for exact queries
+}
+
+// staticObjectSDF is the exact signed distance to a static object, matching how the raycaster
+// treats it: boxes as oriented boxes, spheres with radius Scale.X. Meshes need not be closed and
+// curved reflectors are thin shells, so their distances are unsigned.
+func staticObjectSDF(p Vector3, obj *SceneObject) float64 {
+	if obj.ShapeType == "sphere" {
+		return p.DistanceTo(obj.Position) - obj.Scale.X
+	}
+	if obj.ShapeType == "mesh" && obj.Mesh != nil {
+		_, distance := meshNearestTriangle(obj, p)
+		return distance
+	}
+	if isCurvedShape(obj.ShapeType) {
+		return curvedDistance(obj, p)
+	}
+	half := obj.Scale.Scale(0.5)
+	return boxSDF(boxLocalPoint(obj, p), half.Scale(-1), half)
+}
+
+// boxSDF is the signed distance from p to the axis-aligned box [boxMin, boxMax].
+func boxSDF(p, boxMin, boxMax Vector3) float64 {
+	center := boxMin.Add(boxMax).Scale(0.5)
+	half := boxMax.Sub(boxMin).Scale(0.5)
+	qx := math.Abs(p.X-center.X) - half.X
+	qy := math.Abs(p.Y-center.Y) - half.Y
+	qz := math.Abs(p.Z-center.Z) - half.Z
+	outside := Vector3{math.Max(qx, 0), math.Max(qy, 0), math.Max(qz, 0)}.Length()
+	inside := math.Min(math.Max(qx, math.Max(qy, qz)), 0)
+	return outside + inside
+}
+
+// NewSignedDistanceField samples the SDF of the given static objects (and of the room bounds of
+// the cloud, so leaving the room counts as penetration) at every cell center of the cloud.
+func NewSignedDistanceField(oc *OccupancyCloud, staticObjects []*SceneObject) *SignedDistanceField {
+	sdf := &SignedDistanceField{
+		Origin:   oc.RoomMin.Add(oc.CellSize.Scale(0.5)),
+		CellSize: oc.CellSize,
+		NX:       oc.CellsX,
+		NY:       oc.CellsY,
+		NZ:       oc.CellsZ,
+		Values:   make([][][]float64, oc.CellsX),
+	}
+	for _, obj := range staticObjects {
+		if obj.IsStatic && obj.Visible {
+			sdf.Objects = append(sdf.Objects, obj)
+		}
+	}
+	for ix := 0; ix < sdf.NX; ix++ {
+		sdf.Values[ix] = make([][]float64, sdf.NY)
+		for iy := 0; iy < sdf.NY; iy++ {
+			sdf.Values[ix][iy] = make([]float64, sdf.NZ)
+			for iz := 0; iz < sdf.NZ; iz++ {
+				p := Vector3{
+					X: sdf.Origin.X + float64(ix)*sdf.CellSize.X,
+					Y: sdf.Origin.Y + float64(iy)*sdf.CellSize.Y,
+					Z: sdf.Origin.Z + float64(iz)*sdf.CellSize.Z,
+				}
+				d := -boxSDF(p, oc.RoomMin, oc.RoomMax) // Inside the room is free space
+				sdf.Values[ix][iy][iz] = math.Min(d, sdf.Exact(p))
+			}
+		}
+	}
+	return sdf
+}
+
+// Sample returns the trilinearly interpolated signed distance at a world position.
+// Positions beyond the lattice are clamped to it, which stays conservative because the
+// outermost samples already lie close to (or outside) the room bounds.
+func (sdf *SignedDistanceField) Sample(p Vector3) float64 {
+	fx := clampFloat((p.X-sdf.Origin.X)/sdf.CellSize.X, 0, float64(sdf.NX-1))
+	fy := clampFloat((p.Y-sdf.Origin.Y)/sdf.CellSize.Y, 0, float64(sdf.NY-1))
+	fz := clampFloat((p.Z-sdf.Origin.Z)/sdf.CellSize.Z, 0, float64(sdf.NZ-1))
+	x0, y0, z0 := int(fx), int(fy), int(fz)
+	x1, y1, z1 := minInt(x0+1, sdf.NX-1), minInt(y0+1, sdf.NY-1), minInt(z0+1, sdf.NZ-1)
+	tx, ty, tz := fx-float64(x0), fy-float64(y0), fz-float64(z0)
+
+	lerp := func(a, b, t float64) float64 { return a + (b-a)*t }
+	v := sdf.Values
+	c00 := lerp(v[x0][y0][z0], v[x1][y0][z0], tx)
+	c10 := lerp(v[x0][y1][z0], v[x1][y1][z0], tx)
+	c01 := lerp(v[x0][y0][z1], v[x1][y0][z1], tx)
+	c11 := lerp(v[x0][y1][z1], v[x1][y1][z1], tx)
+	return lerp(lerp(c00, c10, ty), lerp(c01, c11, ty), tz)
+}
+
+// Exact returns the exact signed distance from p to the sampled objects, leaving out the room
+// bounds. It costs a distance query per object, where Sample costs eight lookups.
+func (sdf *SignedDistanceField) Exact(p Vector3) float64 {
+	d := math.Inf(1)
+	for _, obj := range sdf.Objects {
+		d = math.Min(d, staticObjectSDF(p, obj))
+	}
+	return d
+}
+
+// SampleError bounds how far Sample may be from the exact distance. A distance field changes by
+// at most the distance moved, and Sample blends the lattice points of one cell, each within a
+// cell diagonal of the clamped position, itself within half a diagonal of positions beyond the
+// lattice.
+func (sdf *SignedDistanceField) SampleError() float64 {
+	return 1.5 * sdf.CellSize.Length()
+}
+
+// Gradient returns the normalized direction of increasing distance at p (away from obstacles),
+// estimated with central differences. Returns a zero vector in flat regions.
+func (sdf *SignedDistanceField) Gradient(p Vector3) Vector3 {
+	h := SDF_GRADIENT_DELTA
+	g := Vector3{
+		X: sdf.Sample(Vector3{p.X + h, p.Y, p.Z}) - sdf.Sample(Vector3{p.X - h, p.Y, p.Z}),
+		Y: sdf.Sample(Vector3{p.X, p.Y + h, p.Z}) - sdf.Sample(Vector3{p.X, p.Y - h, p.Z}),
+		Z: sdf.Sample(Vector3{p.X, p.Y, p.Z + h}) - sdf.Sample(Vector3{p.X, p.Y, p.Z - h}),
+	}
+	if g.LengthSquared() < EPSILON*EPSILON {
+		return Vector3{}
+	}
+	return g.Normalize()
+}
+
+// NudgeToClearance moves p along the SDF gradient until it is at least `clearance` away from
+// every static surface. Returns the nudged position and whether enough clearance was reached.
+func (sdf *SignedDistanceField) NudgeToClearance(p Vector3, clearance float64) (Vector3, bool) {
+	for step := 0; step < SDF_NUDGE_MAX_STEPS; step++ {
+		d := sdf.Sample(p)
+		if d >= clearance {
+			return p, true
+		}
+		g := sdf.Gradient(p)
+		if g == (Vector3{}) {
+			return p, false
+		}
+		p = p.Add(g.Scale(clearance - d + EPSILON))
+	}
+	return p, sdf.Sample(p) >= clearance
+}
+
+// clampFloat limits val to [min, max].
+func clampFloat(val, min, max float64) float64 {
+	return math.Max(min, math.Min(max, val))
+}
+
+// minInt returns the smaller of two ints.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// goQuerySDF([x, y, z]) returns {distance, gradient: [x, y, z]} from the static scene SDF.
+func goQuerySDF(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goQuerySDF")
+	if len(args) < 3 {
+		log.Println("Error: goQuerySDF expects 3 arguments (x, y, z)")
+		return nil
+	}
+	if occupancyCloud == nil || occupancyCloud.SDF == nil {
+		return nil
+	}
+	p := Vector3{args[0].Float(), args[1].Float(), args[2].Float()}
+	g := occupancyCloud.SDF.Gradient(p)
+	return js.ValueOf(map[string]interface{}{
+		"distance": occupancyCloud.SDF.Sample(p),
+		"gradient": []interface{}{g.X, g.Y, g.Z},
+	})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Seat-Aware Scoring ---
+
+const (
+	SEATED_EAR_HEIGHT = 1.2 // Ear height of a seated listener above the floor (m)
+	SEAT_WIDTH        = 0.9 // Seating objects get one listening position per SEAT_WIDTH of length
+
+	LearningObjectiveListener       = "listener"       // Score the listener object's position
+	LearningObjectiveSeatingAverage = "seatingAverage" // Score the mean over all seats; the source moves alone
+)
+
+var learningObjective = LearningObjectiveListener
+
+// SeatPosition is one listening position on a seating object.
+type SeatPosition struct {
+	Seat     *SceneObject
+	Index    int // Seat number along the object
+	Position Vector3
+}
+
+// seatListeningPositions places listening positions at ear height above every seating object,
+// spread along its longer horizontal axis (boxes are axis-aligned, as in the tracer). The ear
+// is kept at least a listener radius above the object's top.
+func seatListeningPositions() []SeatPosition {
+	radius := 0.25
+	if listener != nil {
+		radius = listener.Scale.X
+	}
+	var seats []SeatPosition
+	for _, obj := range allSceneObjects {
+		if !obj.isSeating {
+			continue
+		}
+		top := obj.Position.Y + obj.Scale.Y/2
+		ear := math.Max(SEATED_EAR_HEIGHT, top+radius)
+		alongX := obj.Scale.X >= obj.Scale.Z
+		length := math.Max(obj.Scale.X, obj.Scale.Z)
+		count := int(math.Max(1, math.Floor(length/SEAT_WIDTH)))
+		for i := 0; i < count; i++ {
+			offset := (float64(i)+0.5)*length/float64(count) - length/2
+			pos := Vector3{obj.Position.X, ear, obj.Position.Z}
+			if alongX {
+				pos.X += offset
+			} else {
+				pos.Z += offset
+			}
+			seats = append(seats, SeatPosition{Seat: obj, Index: i, Position: pos})
+		}
+	}
+	return seats
+}
+
+// seatingScores scores the source at sourcePos for every seat.
+func seatingScores(sourcePos Vector3, seats []SeatPosition) []int {
+	scores := make([]int, len(seats))
+	parallelFor(len(seats), func(i int) {
+		scores[i] = calculateListenerScore(sourcePos, seats[i].Position)
+	})
+	return scores
+}
+
+// seatingAverageScore is the mean seat score for a source position (0 without seats).
+func seatingAverageScore(sourcePos Vector3) int {
+	seats := seatListeningPositions()
+	if len(seats) == 0 {
+		return 0
+	}
+	total := 0
+	for _, s := range seatingScores(sourcePos, seats) {
+		total += s
+	}
+	return int(math.Round(float64(total) / float64(len(seats))))
+}
+
+// seatingObjectiveActive reports whether learning optimizes the seating average.
+func seatingObjectiveActive() bool {
+	return learningObjective == LearningObjectiveSeatingAverage
+}
+
+// objectiveScore is the score the learning loop maximizes for a placement. With the seating
+// objective the listener position is ignored.
+func objectiveScore(sourcePos, listenerPos Vector3) int {
+	if seatingObjectiveActive() {
+		return seatingAverageScore(sourcePos)
+	}
+	if learningObjective == LearningObjectiveC80 {
+		return c80Score(sourcePos, listenerPos)
+	}
+	return calculateListenerScore(sourcePos, listenerPos)
+}
+
+// goGetSeatScores returns [{seat, index, position: {x,y,z}, score}] for the current source
+// position, plus the seating average, as {seats, average}.
+func goGetSeatScores(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetSeatScores")
+	if soundSource == nil {
+		return nil
+	}
+	seats := seatListeningPositions()
+	scores := seatingScores(soundSource.Position, seats)
+	seatsJS := make([]interface{}, len(seats))
+	total := 0
+	for i, s := range seats {
+		seatsJS[i] = map[string]interface{}{
+			"seat":     s.Seat.Name,
+			"index":    s.Index,
+			"position": map[string]interface{}{"x": s.Position.X, "y": s.Position.Y, "z": s.Position.Z},
+			"score":    scores[i],
+		}
+		total += scores[i]
+	}
+	average := 0.0
+	if len(seats) > 0 {
+		average = float64(total) / float64(len(seats))
+	}
+	return js.ValueOf(map[string]interface{}{"seats": seatsJS, "average": average})
+}
+
+// goSetSeating(objectName, isSeating) tags or untags an object as seating.
+func goSetSeating(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetSeating")
+	if len(args) != 2 {
+		log.Println("Error: goSetSeating expects 2 arguments (objectName, isSeating)")
+		return false
+	}
+	obj := findSceneObject(args[0].String())
+	if obj == nil {
+		log.Printf("Error: goSetSeating: no object named %q", args[0].String())
+		return false
+	}
+	obj.isSeating = args[1].Bool()
+	return true
+}
+
+// goSetLearningObjective(name) selects "listener" (default), "seatingAverage" or "c80".
+func goSetLearningObjective(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetLearningObjective")
+	if len(args) != 1 {
+		log.Println("Error: goSetLearningObjective expects 1 argument (objective name)")
+		return false
+	}
+	if learningModeActive {
+		log.Println("Cannot change the learning objective while learning mode is running.")
+		return false
+	}
+	objective := args[0].String()
+	if !isLearningObjective(objective) {
+		log.Printf("Error: unknown learning objective %q", objective)
+		return false
+	}
+	learningObjective = objective
+	if occupancyCloud != nil {
+		occupancyCloud.ClearScoreMemory() // Scores of different objectives are not comparable
+	}
+	log.Printf("Learning objective set to %s", objective)
+	return true
+}
+
+// isLearningObjective reports whether name is an objective goSetLearningObjective accepts.
+func isLearningObjective(name string) bool {
+	switch name {
+	case LearningObjectiveListener, LearningObjectiveSeatingAverage, LearningObjectiveC80:
+		return true
+	}
+	return false
+}
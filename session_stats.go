@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync/atomic"
+	"syscall/js"
+	"time"
+)
+
+// --- Session Statistics (local only, never sent anywhere) ---
+
+const SESSION_SCORE_HISTORY_LIMIT = 500 // Oldest best-score points are dropped beyond this
+
+// ScorePoint is a new best score found during learning.
+type ScorePoint struct {
+	ElapsedSec float64 // Since the session started
+	Score      int
+	Iteration  int
+}
+
+// SessionStats counts the work done since the page loaded. Counters are atomic because
+// evaluations may run on the worker pool.
+type SessionStats struct {
+	Started            time.Time
+	Passes             atomic.Int64 // Visualization passes
+	DroppedPasses      atomic.Int64 // Pass requests served by a later pass (see pass_guard.go)
+	VisualRays         atomic.Int64 // Rays emitted by visualization passes
+	Evaluations        atomic.Int64 // Placement evaluations (calculateSourceScore calls)
+	EvaluationRays     atomic.Int64
+	LearningSessions   atomic.Int64
+	LearningIterations atomic.Int64
+	BestScoreHistory   []ScorePoint
+}
+
+var sessionStats = &SessionStats{Started: time.Now()}
+
+// recordBestScore appends a learning best score to the history.
+func (s *SessionStats) recordBestScore(score, iteration int) {
+	s.BestScoreHistory = append(s.BestScoreHistory, ScorePoint{
+		ElapsedSec: time.Since(s.Started).Seconds(),
+		Score:      score,
+		Iteration:  iteration,
+	})
+	if len(s.BestScoreHistory) > SESSION_SCORE_HISTORY_LIMIT {
+		s.BestScoreHistory = s.BestScoreHistory[len(s.BestScoreHistory)-SESSION_SCORE_HISTORY_LIMIT:]
+	}
+}
+
+// export returns the statistics as plain values, for JS and for JSON export.
+func (s *SessionStats) export() map[string]interface{} {
+	history := make([]interface{}, len(s.BestScoreHistory))
+	for i, p := range s.BestScoreHistory {
+		history[i] = map[string]interface{}{"elapsedSec": p.ElapsedSec, "score": p.Score, "iteration": p.Iteration}
+	}
+	return map[string]interface{}{
+		"sessionSeconds":     time.Since(s.Started).Seconds(),
+		"passes":             s.Passes.Load(),
+		"visualRays":         s.VisualRays.Load(),
+		"evaluations":        s.Evaluations.Load(),
+		"evaluationRays":     s.EvaluationRays.Load(),
+		"droppedPasses":      s.DroppedPasses.Load(),
+		"raysTraced":         s.VisualRays.Load() + s.EvaluationRays.Load(),
+		"learningSessions":   s.LearningSessions.Load(),
+		"learningIterations": s.LearningIterations.Load(),
+		"bestScoreHistory":   history,
+	}
+}
+
+// goGetSessionStats([asJSON]) returns the session counters and best-score history as an object,
+// or as a JSON string (for saving alongside exported results) when asJSON is true.
+func goGetSessionStats(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetSessionStats")
+	stats := sessionStats.export()
+	if len(args) >= 1 && args[0].Truthy() {
+		data, err := json.Marshal(stats)
+		if err != nil {
+			log.Printf("Error encoding session statistics: %v", err)
+			return nil
+		}
+		return string(data)
+	}
+	return js.ValueOf(stats)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"syscall/js"
+)
+
+// --- Shutdown and State Flush ---
+//
+// Single-page hosts unload and reload the module. goShutdown stops learning and every background
+// job, hands the results worth keeping to JS as a JSON blob (goRestoreState reads it back into a
+// fresh instance), and wakes main, which deletes the exported functions, releases them and the
+// scene, and returns so the Go program exits with no goroutines left behind.
+
+var (
+	exportedJSFuncs   = map[string]js.Func{}
+	shuttingDown      bool                  // Set by goShutdown; passes and loops stop at their next check
+	shutdownRequested = make(chan struct{}) // Closed by goShutdown to let main return
+)
+
+// exportJSFunc makes fn callable from JavaScript as name and keeps it for release at shutdown.
+func exportJSFunc(name string, fn func(this js.Value, args []js.Value) interface{}) {
+	f := js.FuncOf(fn)
+	exportedJSFuncs[name] = f
+	jsGlobal.Set(name, f)
+}
+
+// ExportedState is the blob goShutdown hands to JS.
+type ExportedState struct {
+	SceneHash              string                 `json:"sceneHash"`
+	SoundSourcePos         Vector3                `json:"soundSourcePos"`
+	ListenerPos            Vector3                `json:"listenerPos"`
+	Records                []BestScoreSettings    `json:"records"`
+	OptimizerProfiles      []OptimizerProfile     `json:"optimizerProfiles"`
+	ActiveOptimizerProfile string                 `json:"activeOptimizerProfile"`
+	SessionStats           map[string]interface{} `json:"sessionStats"`
+}
+
+// exportState collects the records, profiles and placement of the running session.
+func exportState() ExportedState {
+	state := ExportedState{
+		SceneHash:              formatSceneHash(computeSceneHash()),
+		Records:                append([]BestScoreSettings(nil), recordsManager.BestRecords...),
+		ActiveOptimizerProfile: activeOptimizerProfile,
+		SessionStats:           sessionStats.export(),
+	}
+	if soundSource != nil {
+		state.SoundSourcePos = soundSource.Position
+	}
+	if listener != nil {
+		state.ListenerPos = listener.Position
+	}
+	for _, name := range optimizerProfileNames() {
+		state.OptimizerProfiles = append(state.OptimizerProfiles, optimizerProfiles[name.(string)])
+	}
+	return state
+}
+
+// releaseResources runs on main once shutdown was requested: it removes and releases the exported
+// functions, stops the worker pool and drops the scene so nothing outlives the program.
+func releaseResources() {
+	names := make([]string, 0, len(exportedJSFuncs))
+	for name := range exportedJSFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		jsGlobal.Delete(name)
+		exportedJSFuncs[name].Release()
+	}
+	exportedJSFuncs = map[string]js.Func{}
+	if evaluationPool != nil {
+		evaluationPool.stop()
+		evaluationPool = nil
+	}
+	allSceneObjects, staticSceneObjects, wallCeilingMeshes, soundSources = nil, nil, nil, nil
+	sceneObjectsByName = map[string]*SceneObject{}
+	soundSource, listener, occupancyCloud = nil, nil, nil
+	tracedSegments, impactPoints, arrivalPoints = nil, nil, nil
+	invalidateCollidableSets()
+}
+
+// goShutdown() stops learning, record animations, progressive heatmaps and pending passes, and
+// returns the session's records and profiles as a JSON string (see ExportedState), also passed to
+// an optional JS onGoShutdown(blob). The module's functions are gone once it returns and the
+// program has exited. A second call returns null.
+func goShutdown(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goShutdown")
+	if shuttingDown {
+		log.Println("goShutdown: already shut down.")
+		return nil
+	}
+	shuttingDown = true
+	learningModeActive = false
+	cancelRecordAnimation()
+	cancelProgressiveHeatmap()
+	if debounceTimer != nil {
+		debounceTimer.Stop()
+	}
+
+	data, err := json.Marshal(exportState())
+	blob := string(data)
+	if err != nil {
+		log.Printf("Error: goShutdown could not encode the session state: %v", err)
+		blob = ""
+	}
+	callOptionalJS("onGoShutdown", blob)
+	log.Printf("Shutting down: %d records flushed (%d bytes).", len(recordsManager.BestRecords), len(blob))
+	close(shutdownRequested)
+	return blob
+}
+
+// goRestoreState(blob) reads records and optimizer profiles from a goShutdown blob (object or
+// JSON string) into this instance. Returns the number of records restored, or -1 on error.
+func goRestoreState(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goRestoreState")
+	if len(args) != 1 {
+		log.Println("Error: goRestoreState expects 1 argument (state blob)")
+		return -1
+	}
+	var state ExportedState
+	if err := json.Unmarshal([]byte(jsonArgString(args[0])), &state); err != nil {
+		log.Printf("Error: goRestoreState could not parse the state: %v", err)
+		return -1
+	}
+	for _, profile := range state.OptimizerProfiles {
+		if err := profile.validate(); err != nil {
+			log.Printf("Skipping optimizer profile %q: %v", profile.Name, err)
+			continue
+		}
+		optimizerProfiles[profile.Name] = profile
+	}
+	if _, ok := optimizerProfiles[state.ActiveOptimizerProfile]; ok {
+		activeOptimizerProfile = state.ActiveOptimizerProfile
+	}
+	records := state.Records
+	if len(records) > recordsManager.MaxRecords {
+		records = records[:recordsManager.MaxRecords]
+	}
+	recordsManager.BestRecords = append(recordsManager.BestRecords[:0], records...)
+	if state.SceneHash != formatSceneHash(computeSceneHash()) {
+		log.Printf("Restored state comes from scene %s; its records apply only with force.", state.SceneHash)
+	}
+	callOptionalJS("updateRecordsDisplay", recordsManager.prepareRecordsForJS())
+	return len(records)
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Sound Sources (primary source plus additional fills) ---
+
+// SourceSettings are the per-source level controls. The zero value is an unmuted source at 0 dB.
+type SourceSettings struct {
+	GainDB float64 // Level offset in dB applied to the emitted energy
+	Muted  bool    // Muted sources emit no rays and contribute nothing to the score
+
+	HideRays bool // Visualization only: the source is still traced and scored
+
+	Extent Vector3 // Size of the emitting region (see extended_source.go); zero is a point source
+}
+
+// SOURCE_HUE_STEP is the hue rotation (degrees) between successive sources' ray color families.
+// The golden angle keeps neighbouring families far apart however many sources are added.
+const SOURCE_HUE_STEP = 137.5
+
+var (
+	soundSources   []*SceneObject                 // Every emitting object; soundSource is always the first entry
+	sourceSettings = map[string]*SourceSettings{} // Keyed by object name, survives scene slot switches
+)
+
+// sourceSettingsFor returns the settings for a source, creating defaults on first use.
+func sourceSettingsFor(source *SceneObject) *SourceSettings {
+	settings, ok := sourceSettings[source.Name]
+	if !ok {
+		settings = &SourceSettings{}
+		sourceSettings[source.Name] = settings
+	}
+	return settings
+}
+
+// sourceEnergyGain converts a source's dB gain to a linear energy factor (0 when muted).
+// A nil source (no registry) emits at unit gain.
+func sourceEnergyGain(source *SceneObject) float64 {
+	if source == nil {
+		return 1.0
+	}
+	settings, ok := sourceSettings[source.Name]
+	if !ok {
+		return 1.0
+	}
+	if settings.Muted {
+		return 0
+	}
+	return math.Pow(10, settings.GainDB/10)
+}
+
+// sourceID returns the object ID stamped on rays emitted by source ("" when unknown).
+func sourceID(source *SceneObject) string {
+	if source == nil {
+		return ""
+	}
+	return source.ID
+}
+
+// sourceRayColor maps a base ray color into the color family of source. The primary source keeps
+// the base palette; every additional source gets the palette rotated in hue.
+func sourceRayColor(source *SceneObject, base uint32) uint32 {
+	for i, src := range soundSources {
+		if src == source {
+			if i == 0 {
+				return base
+			}
+			return rotateHue(base, float64(i)*SOURCE_HUE_STEP)
+		}
+	}
+	return base
+}
+
+// rotateHue shifts a 0xRRGGBB color around the HSV hue circle, keeping saturation and value.
+func rotateHue(color uint32, degrees float64) uint32 {
+	r := float64((color>>16)&0xff) / 255
+	g := float64((color>>8)&0xff) / 255
+	b := float64(color&0xff) / 255
+	maxC := math.Max(r, math.Max(g, b))
+	minC := math.Min(r, math.Min(g, b))
+	delta := maxC - minC
+	if delta == 0 { // Grey has no hue to rotate
+		return color
+	}
+	var hue float64
+	switch maxC {
+	case r:
+		hue = 60 * math.Mod((g-b)/delta, 6)
+	case g:
+		hue = 60 * ((b-r)/delta + 2)
+	default:
+		hue = 60 * ((r-g)/delta + 4)
+	}
+	hue = math.Mod(hue+degrees+360, 360)
+	saturation := delta / maxC
+
+	c := maxC * saturation
+	x := c * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	m := maxC - c
+	var r1, g1, b1 float64
+	switch {
+	case hue < 60:
+		r1, g1, b1 = c, x, 0
+	case hue < 120:
+		r1, g1, b1 = x, c, 0
+	case hue < 180:
+		r1, g1, b1 = 0, c, x
+	case hue < 240:
+		r1, g1, b1 = 0, x, c
+	case hue < 300:
+		r1, g1, b1 = x, 0, c
+	default:
+		r1, g1, b1 = c, 0, x
+	}
+	to8 := func(v float64) uint32 { return uint32(math.Round((v + m) * 255)) }
+	return to8(r1)<<16 | to8(g1)<<8 | to8(b1)
+}
+
+// findSoundSource returns the registered source with the given name, or nil.
+func findSoundSource(name string) *SceneObject {
+	obj := findSceneObject(name)
+	if obj == nil || (obj != soundSource && !obj.isSoundSource) {
+		return nil
+	}
+	return obj
+}
+
+// addSoundSource creates an additional movable source at pos, sized and colored like the primary.
+func addSoundSource(pos Vector3) *SceneObject {
+	index := len(soundSources) + 1
+	name := fmt.Sprintf("SoundSource%d", index)
+	for findSceneObject(name) != nil {
+		index++
+		name = fmt.Sprintf("SoundSource%d", index)
+	}
+	scale := Vector3{0.3, 0.3, 0.3}
+	material := MaterialProperties{Name: "source", Color: [4]float32{1, 0, 0, 1.0}}
+	if soundSource != nil {
+		scale = soundSource.Scale
+		material = soundSource.Material
+	}
+	src := createObject(name, "sphere", pos, Vector3{}, scale, material, false, false)
+	src.isSoundSource = true
+	soundSources = append(soundSources, src)
+	return src
+}
+
+// removeSoundSource deletes an additional source. The primary source cannot be removed.
+func removeSoundSource(name string) bool {
+	src := findSoundSource(name)
+	if src == nil || src == soundSource {
+		return false
+	}
+	removeSceneObject(src)
+	for i, obj := range soundSources {
+		if obj == src {
+			soundSources = append(soundSources[:i], soundSources[i+1:]...)
+			break
+		}
+	}
+	delete(sourceSettings, name)
+	return true
+}
+
+// goAddSoundSource([x, y, z]) adds a source and returns its name.
+func goAddSoundSource(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goAddSoundSource")
+	if len(args) < 3 {
+		log.Println("Error: goAddSoundSource expects 3 arguments (x, y, z)")
+		return nil
+	}
+	src := addSoundSource(Vector3{args[0].Float(), args[1].Float(), args[2].Float()})
+	visualizeSoundPropagation()
+	return js.ValueOf(src.Name)
+}
+
+// goRemoveSoundSource([name]) removes an additional source. Returns true on success.
+func goRemoveSoundSource(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goRemoveSoundSource")
+	if len(args) < 1 {
+		log.Println("Error: goRemoveSoundSource expects 1 argument (name)")
+		return js.ValueOf(false)
+	}
+	if !removeSoundSource(args[0].String()) {
+		log.Printf("Error: cannot remove sound source %q", args[0].String())
+		return js.ValueOf(false)
+	}
+	visualizeSoundPropagation()
+	return js.ValueOf(true)
+}
+
+// goSetSourceGain([name, gainDB]) sets a source's level offset in dB.
+func goSetSourceGain(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetSourceGain")
+	if len(args) < 2 {
+		log.Println("Error: goSetSourceGain expects 2 arguments (name, gainDB)")
+		return js.ValueOf(false)
+	}
+	src := findSoundSource(args[0].String())
+	if src == nil {
+		log.Printf("Error: unknown sound source %q", args[0].String())
+		return js.ValueOf(false)
+	}
+	gainDB := args[1].Float()
+	if math.IsNaN(gainDB) || math.IsInf(gainDB, 0) {
+		log.Printf("Error: invalid gain for %s", src.Name)
+		return js.ValueOf(false)
+	}
+	sourceSettingsFor(src).GainDB = gainDB
+	visualizeSoundPropagation()
+	return js.ValueOf(true)
+}
+
+// goSetSourceMuted([name, muted]) mutes or unmutes a source.
+func goSetSourceMuted(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetSourceMuted")
+	if len(args) < 2 {
+		log.Println("Error: goSetSourceMuted expects 2 arguments (name, muted)")
+		return js.ValueOf(false)
+	}
+	src := findSoundSource(args[0].String())
+	if src == nil {
+		log.Printf("Error: unknown sound source %q", args[0].String())
+		return js.ValueOf(false)
+	}
+	sourceSettingsFor(src).Muted = args[1].Bool()
+	visualizeSoundPropagation()
+	return js.ValueOf(true)
+}
+
+// goSetSourceRaysVisible([name, visible]) shows or hides one source's rays without re-tracing.
+func goSetSourceRaysVisible(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetSourceRaysVisible")
+	if len(args) < 2 {
+		log.Println("Error: goSetSourceRaysVisible expects 2 arguments (name, visible)")
+		return js.ValueOf(false)
+	}
+	src := findSoundSource(args[0].String())
+	if src == nil {
+		log.Printf("Error: unknown sound source %q", args[0].String())
+		return js.ValueOf(false)
+	}
+	sourceSettingsFor(src).HideRays = !args[1].Bool()
+	refreshRayVisualsFromCache()
+	return js.ValueOf(true)
+}
+
+// goGetSoundSources() returns [{name, id, position: [x, y, z], gainDb, muted, raysVisible, rayColor, primary, extent: [x, y, z]}].
+func goGetSoundSources(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetSoundSources")
+	result := make([]interface{}, 0, len(soundSources))
+	for _, src := range soundSources {
+		settings := SourceSettings{}
+		if s, ok := sourceSettings[src.Name]; ok {
+			settings = *s
+		}
+		result = append(result, map[string]interface{}{
+			"name":        src.Name,
+			"id":          src.ID,
+			"position":    []interface{}{src.Position.X, src.Position.Y, src.Position.Z},
+			"gainDb":      settings.GainDB,
+			"muted":       settings.Muted,
+			"raysVisible": !settings.HideRays,
+			"rayColor":    float64(sourceRayColor(src, listenerRayColor)),
+			"primary":     src == soundSource,
+			"extent":      []interface{}{settings.Extent.X, settings.Extent.Y, settings.Extent.Z},
+		})
+	}
+	return js.ValueOf(result)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"strconv"
+	"syscall/js"
+)
+
+// --- Open-Plan Office Mode: Speech Privacy ---
+//
+// Screening metrics between talker/receiver pairs, e.g. neighbouring desks. Each pair is traced
+// with the primary source as the talker and the listener as the receiver, moved to the pair's
+// positions; their bodies at their current positions are left out of the geometry.
+
+const (
+	PRIVACY_RAYS             = 1000  // Rays traced per pair
+	PRIVACY_DIRECT_WINDOW_MS = 2.0   // Arrivals this soon after the direct-path time count as direct sound
+	PRIVACY_EARLY_WINDOW_MS  = 50.0  // Early energy window after the direct-path time
+	PRIVACY_MAX_PAIRS        = 64    // Pairs accepted by goSetPrivacyPairs
+	PRIVACY_MIN_SEPARATION   = 0.1   // Meters; talker and receiver closer than this are rejected
+	PRIVACY_SHIELD_SAMPLES   = 24    // Points on the receiver's disc tested for shielding
+	PRIVACY_RATIO_FLOOR_DB   = -40.0 // Ratios are clipped to this when an energy is zero
+)
+
+// PrivacyPair is one talker position and the receiver position it should not be overheard at.
+type PrivacyPair struct {
+	Name     string  `json:"name"`
+	Talker   Vector3 `json:"talker"`
+	Receiver Vector3 `json:"receiver"`
+}
+
+// PrivacyMetrics are the speech-privacy screening results of one pair.
+type PrivacyMetrics struct {
+	Pair             PrivacyPair
+	Distance         float64
+	DirectRatioDB    float64  // Direct sound relative to everything else at the receiver
+	EarlyRatioDB     float64  // Energy within PRIVACY_EARLY_WINDOW_MS of the direct time, relative to the total
+	STI              float64  // Speech Transmission Index; lower is more private
+	ShieldedFraction float64  // Share of the receiver hidden from the talker, 0 (exposed) to 1 (fully shielded)
+	Partitions       []string // Objects crossing the direct line, nearest to the talker first
+}
+
+var privacyPairs []PrivacyPair // Empty means the current source/listener pair
+
+// activePrivacyPairs returns the configured pairs, or the current source and listener as one pair.
+func activePrivacyPairs() []PrivacyPair {
+	if len(privacyPairs) > 0 || soundSource == nil || listener == nil {
+		return privacyPairs
+	}
+	return []PrivacyPair{{Name: soundSource.Name + "→" + listener.Name, Talker: soundSource.Position, Receiver: listener.Position}}
+}
+
+// directLinePartitions names the objects the straight talker-receiver line passes through, in
+// order along the line. The source and listener bodies are skipped.
+func directLinePartitions(from, to Vector3) []string {
+	delta := to.Sub(from)
+	distance := delta.Length()
+	direction := delta.Scale(1 / distance)
+	type crossing struct {
+		name string
+		at   float64
+	}
+	var crossings []crossing
+	for _, obj := range allSceneObjects {
+		if obj == listener || obj.isSoundSource {
+			continue
+		}
+		if hit := performRaycast(from, direction, distance, []*SceneObject{obj}, nil); hit.Hit {
+			crossings = append(crossings, crossing{obj.Name, hit.Distance})
+		}
+	}
+	for i := 1; i < len(crossings); i++ { // Few crossings per line; insertion sort keeps it simple
+		for j := i; j > 0 && crossings[j].at < crossings[j-1].at; j-- {
+			crossings[j], crossings[j-1] = crossings[j-1], crossings[j]
+		}
+	}
+	names := make([]string, len(crossings))
+	for i, c := range crossings {
+		names[i] = c.name
+	}
+	return names
+}
+
+// windowRatioDB is 10·log10(part/total), clipped to PRIVACY_RATIO_FLOOR_DB.
+func windowRatioDB(part, total float64) float64 {
+	if part <= 0 || total <= 0 {
+		return PRIVACY_RATIO_FLOOR_DB
+	}
+	return math.Max(PRIVACY_RATIO_FLOOR_DB, 10*math.Log10(part/total))
+}
+
+// computePrivacyMetrics traces one pair and derives its metrics.
+func computePrivacyMetrics(pair PrivacyPair) PrivacyMetrics {
+	m := PrivacyMetrics{Pair: pair, Distance: pair.Talker.DistanceTo(pair.Receiver), Partitions: directLinePartitions(pair.Talker, pair.Receiver)}
+
+	visible := 0
+	for _, p := range listenerDiscSamples(pair.Talker, pair.Receiver, listener.Scale.X, PRIVACY_SHIELD_SAMPLES) {
+		if !isSegmentOccluded(pair.Talker, p, soundSource, listener) {
+			visible++
+		}
+	}
+	m.ShieldedFraction = 1 - float64(visible)/PRIVACY_SHIELD_SAMPLES
+
+	eg := traceBandEchogramAt(soundSource, listener, pair.Talker, pair.Receiver, PRIVACY_RAYS, ENERGY_TRACE_DEFAULT_BIN, ENERGY_TRACE_MAX_TIME)
+	broadband, _ := bandColumns(eg)
+	directSec := m.Distance / environment.SpeedOfSound
+	var direct, early, total float64
+	for i, e := range broadband {
+		t := (float64(i) + 0.5) * eg.BinSec
+		total += e
+		if t <= directSec+PRIVACY_DIRECT_WINDOW_MS/1000 {
+			direct += e
+		}
+		if t <= directSec+PRIVACY_EARLY_WINDOW_MS/1000 {
+			early += e
+		}
+	}
+	m.DirectRatioDB = windowRatioDB(direct, total-direct)
+	m.EarlyRatioDB = windowRatioDB(early, total)
+	m.STI = speechTransmissionIndex(eg)
+	return m
+}
+
+// goSetPrivacyPairs(pairsJSON) sets the talker/receiver pairs, given as
+// [{name, talker: {x,y,z}, receiver: {x,y,z}}]. An empty list goes back to the current
+// source/listener pair. Returns false if the list is rejected.
+func goSetPrivacyPairs(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetPrivacyPairs")
+	if len(args) != 1 {
+		log.Println("Error: goSetPrivacyPairs expects 1 argument (pairsJSON)")
+		return false
+	}
+	var pairs []PrivacyPair
+	if err := json.Unmarshal([]byte(jsonArgString(args[0])), &pairs); err != nil {
+		log.Printf("Error: goSetPrivacyPairs could not parse pairs: %v", err)
+		return false
+	}
+	if len(pairs) > PRIVACY_MAX_PAIRS {
+		log.Printf("Error: at most %d privacy pairs are supported, got %d", PRIVACY_MAX_PAIRS, len(pairs))
+		return false
+	}
+	for i := range pairs {
+		if pairs[i].Talker.DistanceTo(pairs[i].Receiver) < PRIVACY_MIN_SEPARATION {
+			log.Printf("Error: privacy pair %d has its talker and receiver at the same position", i)
+			return false
+		}
+		if pairs[i].Name == "" {
+			pairs[i].Name = strconv.Itoa(i) // Unnamed pairs are named by their index
+		}
+	}
+	privacyPairs = pairs
+	logEvent(LogInterop, LogNotice, "Speech privacy: %d talker/receiver pairs", len(pairs))
+	return true
+}
+
+// goGetSpeechPrivacy returns [{name, distance, directRatioDb, earlyRatioDb, sti, shielded,
+// partitions}] for every active pair.
+func goGetSpeechPrivacy(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetSpeechPrivacy")
+	if soundSource == nil || listener == nil {
+		return nil
+	}
+	pairs := activePrivacyPairs()
+	metrics := make([]PrivacyMetrics, len(pairs))
+	parallelFor(len(pairs), func(i int) {
+		metrics[i] = computePrivacyMetrics(pairs[i])
+	})
+	result := make([]interface{}, len(metrics))
+	for i, m := range metrics {
+		partitions := make([]interface{}, len(m.Partitions))
+		for j, name := range m.Partitions {
+			partitions[j] = name
+		}
+		result[i] = map[string]interface{}{
+			"name":          m.Pair.Name,
+			"distance":      m.Distance,
+			"directRatioDb": m.DirectRatioDB,
+			"earlyRatioDb":  m.EarlyRatioDB,
+			"sti":           m.STI,
+			"shielded":      m.ShieldedFraction,
+			"partitions":    partitions,
+		}
+	}
+	return js.ValueOf(result)
+}
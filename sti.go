@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"syscall/js"
+)
+
+// --- Speech Transmission Index (approximation) ---
+//
+// The modulation transfer function of each octave band follows from the energy impulse response
+// (Schroeder): m(F) = |Σ e(t)·exp(-j2πFt)| / Σ e(t). Each m(F) becomes an apparent SNR clipped to
+// ±15 dB and a transmission index; their mean per band is weighted into the STI. Masking is not
+// modelled, background noise only in the venue estimate (see venue_intelligibility.go), and bands
+// above the traced 4 kHz are left out with the weights renormalized, so this is an STI-style
+// estimate of reverberation-limited intelligibility.
+
+const STI_RAYS = 1000 // Rays traced for the echogram behind an STI estimate
+
+var (
+	// STI_MODULATION_HZ are the 14 one-third-octave modulation frequencies from 0.63 to 12.5 Hz.
+	STI_MODULATION_HZ = []float64{0.63, 0.8, 1, 1.25, 1.6, 2, 2.5, 3.15, 4, 5, 6.3, 8, 10, 12.5}
+	// STI_BAND_WEIGHTS are the male-speech octave weights by band center (Hz).
+	STI_BAND_WEIGHTS = map[float64]float64{125: 0.13, 250: 0.14, 500: 0.11, 1000: 0.12, 2000: 0.19, 4000: 0.17, 8000: 0.14}
+)
+
+// modulationTransfer is m(F) of an energy histogram with bins binSec wide.
+func modulationTransfer(energy []float64, binSec, modHz float64) float64 {
+	var re, im, total float64
+	for i, e := range energy {
+		t := (float64(i) + 0.5) * binSec
+		re += e * math.Cos(2*math.Pi*modHz*t)
+		im += e * math.Sin(2*math.Pi*modHz*t)
+		total += e
+	}
+	if total <= 0 {
+		return 0
+	}
+	return math.Hypot(re, im) / total
+}
+
+// transmissionIndex maps a modulation index to [0, 1] via the apparent SNR clipped to ±15 dB.
+func transmissionIndex(m float64) float64 {
+	if m >= 1 {
+		return 1
+	}
+	if m <= 0 {
+		return 0
+	}
+	snr := math.Max(-15, math.Min(15, 10*math.Log10(m/(1-m))))
+	return (snr + 15) / 30
+}
+
+// speechTransmissionIndex estimates the STI from a band echogram. Bands without energy transmit
+// nothing, so the result is 0 if no energy arrived.
+func speechTransmissionIndex(eg *BandEchogram) float64 {
+	return speechTransmissionIndexWithNoise(eg, nil)
+}
+
+// speechTransmissionIndexWithNoise is speechTransmissionIndex with each band's modulation reduced
+// by background noise, given as the speech-to-noise ratio in dB per band (nil for no noise).
+func speechTransmissionIndexWithNoise(eg *BandEchogram, snrDB []float64) float64 {
+	_, bands := bandColumns(eg)
+	sti, weights := 0.0, 0.0
+	for b, column := range bands {
+		weight := STI_BAND_WEIGHTS[eg.BandsHz[b]]
+		if weight == 0 {
+			continue
+		}
+		noise := 1.0
+		if snrDB != nil {
+			noise = 1 / (1 + math.Pow(10, -snrDB[b]/10))
+		}
+		mti := 0.0
+		for _, f := range STI_MODULATION_HZ {
+			mti += transmissionIndex(modulationTransfer(column, eg.BinSec, f) * noise)
+		}
+		sti += weight * mti / float64(len(STI_MODULATION_HZ))
+		weights += weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return sti / weights
+}
+
+// estimateSTI traces the current source and listener placement and returns its STI estimate.
+func estimateSTI() float64 {
+	if soundSource == nil || listener == nil {
+		return 0
+	}
+	eg := roomBandEchogram(STI_RAYS, ENERGY_TRACE_DEFAULT_BIN, ENERGY_TRACE_MAX_TIME)
+	if eg == nil {
+		return 0
+	}
+	return speechTransmissionIndex(eg)
+}
+
+// estimateSTIAt is estimateSTI with the source and listener placed at sourcePos and listenerPos,
+// e.g. for a recorded placement. Only the primary source is traced.
+func estimateSTIAt(sourcePos, listenerPos Vector3) float64 {
+	if soundSource == nil || listener == nil {
+		return 0
+	}
+	eg := traceBandEchogramAt(soundSource, listener, sourcePos, listenerPos, STI_RAYS, ENERGY_TRACE_DEFAULT_BIN, ENERGY_TRACE_MAX_TIME)
+	return speechTransmissionIndex(eg)
+}
+
+// goGetSTI returns the STI estimate (0-1) for the current placement.
+func goGetSTI(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetSTI")
+	return estimateSTI()
+}
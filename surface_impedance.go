@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Surface Impedance (Angle-Dependent Reflection) ---
+//
+// A material with an Impedance reflects like a locally reacting surface: the plane-wave
+// reflection factor R = (ζ·cosθ − 1)/(ζ·cosθ + 1), with ζ the impedance over the air's ρc, so
+// absorption peaks near grazing incidence and vanishes at it instead of being the same at every
+// angle. The Ground uses this model by default; its Absorption is kept at the random-incidence
+// value (Paris' formula) so statistics such as the RT60 estimates stay consistent.
+
+const (
+	DEFAULT_FLOOR_IMPEDANCE     = 29000.0 // Pa·s/m; about 70 ρc, a random-incidence absorption of 0.1
+	RANDOM_INCIDENCE_SAMPLES    = 90      // Angles integrated by randomIncidenceAbsorption
+	MAX_SURFACE_IMPEDANCE_RAYLS = 1e8     // Bound accepted by goSetMaterialImpedance
+)
+
+// incidenceCos is |cos θ| between a ray direction and a surface normal.
+func incidenceCos(direction, normal Vector3) float64 {
+	return math.Abs(direction.Normalize().Dot(normal.Normalize()))
+}
+
+// impedanceReflection is the energy reflection coefficient |R|² of a surface of impedance z
+// (Pa·s/m) for sound arriving at cosIncidence from the normal.
+func impedanceReflection(z, cosIncidence float64) float64 {
+	zc := z / airImpedance() * cosIncidence
+	r := (zc - 1) / (zc + 1)
+	return r * r
+}
+
+// randomIncidenceAbsorption integrates the absorption of impedance z over a diffuse field:
+// α = ∫ α(θ)·sin 2θ dθ for θ in [0, π/2], by the midpoint rule.
+func randomIncidenceAbsorption(z float64) float64 {
+	step := math.Pi / 2 / RANDOM_INCIDENCE_SAMPLES
+	sum := 0.0
+	for i := 0; i < RANDOM_INCIDENCE_SAMPLES; i++ {
+		theta := (float64(i) + 0.5) * step
+		sum += (1 - impedanceReflection(z, math.Cos(theta))) * math.Sin(2*theta)
+	}
+	return sum * step
+}
+
+// surfaceAbsorption is mat's broadband absorption for sound arriving at cosIncidence: from its
+// impedance when it has one, its Absorption otherwise.
+func surfaceAbsorption(mat MaterialProperties, cosIncidence float64) float64 {
+	if mat.Impedance > 0 {
+		return 1 - impedanceReflection(mat.Impedance, cosIncidence)
+	}
+	return mat.Absorption
+}
+
+// surfaceBandAbsorption is surfaceAbsorption for one octave band. The impedance model is the
+// same in every band.
+func surfaceBandAbsorption(mat MaterialProperties, band int, cosIncidence float64) float64 {
+	if mat.Impedance > 0 {
+		return 1 - impedanceReflection(mat.Impedance, cosIncidence)
+	}
+	return materialBandAbsorption(mat, band)
+}
+
+// setMaterialImpedance switches mat to the impedance model (z > 0) or back to its plain
+// absorption (z = 0), keeping Absorption at the random-incidence value.
+func setMaterialImpedance(mat *MaterialProperties, z float64) {
+	mat.Impedance = z
+	if z > 0 {
+		mat.Absorption = randomIncidenceAbsorption(z)
+		mat.BandAbsorption = nil
+	}
+}
+
+// goSetMaterialImpedance(name, impedance) gives an object, or every object with a material of
+// that name, an angle-dependent reflection from its surface impedance in Pa·s/m; 0 goes back to
+// the angle-independent absorption. Returns the number of objects changed.
+func goSetMaterialImpedance(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetMaterialImpedance")
+	if len(args) != 2 {
+		log.Println("Error: goSetMaterialImpedance expects 2 arguments (objectOrMaterialName, impedance)")
+		return 0
+	}
+	name, z := args[0].String(), args[1].Float()
+	if math.IsNaN(z) || z < 0 || z > MAX_SURFACE_IMPEDANCE_RAYLS {
+		log.Printf("Error: surface impedance must be in [0, %g] Pa·s/m, got %v", MAX_SURFACE_IMPEDANCE_RAYLS, z)
+		return 0
+	}
+	changed := 0
+	for _, obj := range allSceneObjects {
+		if obj.Name != name && obj.Material.Name != name {
+			continue
+		}
+		setMaterialImpedance(&obj.Material, z)
+		changed++
+	}
+	if changed > 0 {
+		debouncedVisualizeFunc()
+	}
+	return changed
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"math"
+	"syscall/js"
+)
+
+// --- Sweet Spot Detection (from the coverage heatmap) ---
+
+// SWEET_SPOT_THRESHOLD is the fraction of the best coverage score a cell needs to be part of the sweet spot.
+const SWEET_SPOT_THRESHOLD = 0.9
+
+// SweetSpot is the connected region around the best-scoring heatmap cell whose scores are within
+// SWEET_SPOT_THRESHOLD of it.
+type SweetSpot struct {
+	Centroid   Vector3
+	Min, Max   Vector3 // Extent on the XZ plane (Y is the heatmap height)
+	Area       float64 // Square meters
+	Cells      int
+	MaxScore   float64
+	ScoreFloor float64 // Lowest score a member cell may have
+}
+
+var sweetSpot *SweetSpot // Result of the last coverage heatmap run; drawn as a scene marker
+
+// findSweetSpot flood-fills (4-connected) from the maximum cell over cells scoring at least
+// SWEET_SPOT_THRESHOLD of the maximum. Returns nil if no cell scores above zero.
+func findSweetSpot(hm *Heatmap) *SweetSpot {
+	if hm == nil || len(hm.Values) == 0 {
+		return nil
+	}
+	resX, resZ := len(hm.Values), len(hm.Values[0])
+	bestX, bestZ, best := -1, -1, 0.0
+	for ix := 0; ix < resX; ix++ {
+		for iz := 0; iz < resZ; iz++ {
+			if v := hm.Values[ix][iz]; !math.IsNaN(v) && v > best {
+				bestX, bestZ, best = ix, iz, v
+			}
+		}
+	}
+	if bestX < 0 {
+		return nil
+	}
+
+	floor := best * SWEET_SPOT_THRESHOLD
+	visited := make([][]bool, resX)
+	for i := range visited {
+		visited[i] = make([]bool, resZ)
+	}
+	spot := &SweetSpot{MaxScore: best, ScoreFloor: floor, Min: Vector3{math.Inf(1), hm.Height, math.Inf(1)}, Max: Vector3{math.Inf(-1), hm.Height, math.Inf(-1)}}
+	var sum Vector3
+	stack := [][2]int{{bestX, bestZ}}
+	visited[bestX][bestZ] = true
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		center := hm.CellCenter(cell[0], cell[1])
+		sum = sum.Add(center)
+		spot.Cells++
+		spot.Min.X = math.Min(spot.Min.X, center.X-hm.CellW/2)
+		spot.Min.Z = math.Min(spot.Min.Z, center.Z-hm.CellD/2)
+		spot.Max.X = math.Max(spot.Max.X, center.X+hm.CellW/2)
+		spot.Max.Z = math.Max(spot.Max.Z, center.Z+hm.CellD/2)
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			nx, nz := cell[0]+d[0], cell[1]+d[1]
+			if nx < 0 || nz < 0 || nx >= resX || nz >= resZ || visited[nx][nz] {
+				continue
+			}
+			if v := hm.Values[nx][nz]; math.IsNaN(v) || v < floor {
+				continue
+			}
+			visited[nx][nz] = true
+			stack = append(stack, [2]int{nx, nz})
+		}
+	}
+	spot.Centroid = sum.Scale(1 / float64(spot.Cells))
+	spot.Area = float64(spot.Cells) * hm.CellW * hm.CellD
+	return spot
+}
+
+// sweetSpotToJS converts a sweet spot to {centroid, min, max, area, cells, maxScore, scoreFloor}.
+func sweetSpotToJS(spot *SweetSpot) interface{} {
+	if spot == nil {
+		return nil
+	}
+	vec := func(v Vector3) map[string]interface{} { return map[string]interface{}{"x": v.X, "y": v.Y, "z": v.Z} }
+	return map[string]interface{}{
+		"centroid":   vec(spot.Centroid),
+		"min":        vec(spot.Min),
+		"max":        vec(spot.Max),
+		"area":       spot.Area,
+		"cells":      spot.Cells,
+		"maxScore":   spot.MaxScore,
+		"scoreFloor": spot.ScoreFloor,
+	}
+}
+
+// sweetSpotMarkerJS is the scene entry JS draws as the labelled "sweet spot" marker: a flat box
+// covering the region's extent at the heatmap height.
+func sweetSpotMarkerJS(spot *SweetSpot) map[string]interface{} {
+	return map[string]interface{}{
+		"name": "SweetSpot", "type": "marker", "label": "Sweet spot",
+		"position": map[string]interface{}{"x": spot.Centroid.X, "y": spot.Centroid.Y, "z": spot.Centroid.Z},
+		"scale":    map[string]interface{}{"x": spot.Max.X - spot.Min.X, "y": 0.02, "z": spot.Max.Z - spot.Min.Z},
+		"rotation": map[string]interface{}{"x": 0, "y": 0, "z": 0},
+		"color":    map[string]interface{}{"r": 1.0, "g": 0.84, "b": 0.0, "a": 0.5},
+	}
+}
+
+// goGetSweetSpot returns the sweet spot of the last coverage heatmap run, or null.
+func goGetSweetSpot(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetSweetSpot")
+	return js.ValueOf(sweetSpotToJS(sweetSpot))
+}
+
+// goClearSweetSpot removes the sweet spot marker from the scene.
+func goClearSweetSpot(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goClearSweetSpot")
+	sweetSpot = nil
+	refreshRayVisualsFromCache()
+	return nil
+}
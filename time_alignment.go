@@ -0,0 +1,116 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Delay / Time Alignment ---
+
+// SourceAlignment is the direct-path timing of one source at the listener and the delay that
+// lines its arrival up with the reference (latest-arriving, unmuted) source.
+type SourceAlignment struct {
+	Name                 string
+	Distance             float64 // Direct-path length in meters
+	ArrivalMs            float64 // Direct-path travel time
+	RecommendedDelayMs   float64 // Delay to insert on this source's feed
+	DirectPathOccluded   bool    // Geometry blocks the straight line; arrival is only a lower bound
+	Muted                bool    // Muted sources are reported but never chosen as the reference
+	IsAlignmentReference bool
+}
+
+// computeSourceAlignment reports per-source direct delays at the listener. Every unmuted source is
+// delayed to arrive precedenceMs after the reference, except the reference itself, so the reference
+// stays first and keeps localization (precedence/Haas effect). Use 0 for pure time alignment.
+func computeSourceAlignment(precedenceMs float64) []SourceAlignment {
+	if listener == nil {
+		return nil
+	}
+	alignments := make([]SourceAlignment, 0, len(soundSources))
+	referenceIdx := -1
+	for _, src := range soundSources {
+		distance := src.Position.DistanceTo(listener.Position)
+		a := SourceAlignment{
+			Name:               src.Name,
+			Distance:           distance,
+			ArrivalMs:          distance / environment.SpeedOfSound * 1000,
+			DirectPathOccluded: isDirectPathOccluded(src, listener),
+			Muted:              sourceEnergyGain(src) == 0,
+		}
+		if !a.Muted && (referenceIdx < 0 || a.ArrivalMs > alignments[referenceIdx].ArrivalMs) {
+			referenceIdx = len(alignments)
+		}
+		alignments = append(alignments, a)
+	}
+	if referenceIdx < 0 {
+		return alignments
+	}
+	reference := alignments[referenceIdx].ArrivalMs
+	alignments[referenceIdx].IsAlignmentReference = true
+	for i := range alignments {
+		if i == referenceIdx || alignments[i].Muted {
+			continue
+		}
+		alignments[i].RecommendedDelayMs = reference - alignments[i].ArrivalMs + precedenceMs
+	}
+	return alignments
+}
+
+// isDirectPathOccluded casts the straight segment from source to target against the scene.
+func isDirectPathOccluded(source, target *SceneObject) bool {
+	return isSegmentOccluded(source.Position, target.Position, source, target)
+}
+
+// isSegmentOccluded reports whether any visible scene object other than exclude blocks the
+// segment from..to. Hits within a millimetre of `to` do not count, so a segment may end on a surface.
+func isSegmentOccluded(from, to Vector3, exclude ...*SceneObject) bool {
+	delta := to.Sub(from)
+	distance := delta.Length()
+	if distance < EPSILON {
+		return false
+	}
+	var occluders []*SceneObject
+	for _, obj := range allSceneObjects {
+		excluded := false
+		for _, ex := range exclude {
+			if obj == ex {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			occluders = append(occluders, obj)
+		}
+	}
+	hit := performRaycast(from, delta.Scale(1/distance), distance, occluders, nil)
+	return hit.Hit && hit.Distance < distance-1e-3
+}
+
+// goComputeSourceDelays([precedenceMs]) returns
+// [{name, distance, arrivalMs, delayMs, occluded, muted, reference}] for every source.
+func goComputeSourceDelays(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goComputeSourceDelays")
+	precedenceMs := 0.0
+	if len(args) >= 1 {
+		precedenceMs = args[0].Float()
+	}
+	if math.IsNaN(precedenceMs) || precedenceMs < 0 {
+		log.Printf("Error: precedence offset must be a non-negative number of milliseconds, got %v", precedenceMs)
+		return nil
+	}
+	alignments := computeSourceAlignment(precedenceMs)
+	result := make([]interface{}, len(alignments))
+	for i, a := range alignments {
+		result[i] = map[string]interface{}{
+			"name":      a.Name,
+			"distance":  a.Distance,
+			"arrivalMs": a.ArrivalMs,
+			"delayMs":   a.RecommendedDelayMs,
+			"occluded":  a.DirectPathOccluded,
+			"muted":     a.Muted,
+			"reference": a.IsAlignmentReference,
+		}
+	}
+	return js.ValueOf(result)
+}
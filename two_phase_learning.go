@@ -0,0 +1,137 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// --- Two-Phase Learning (global random search, then local descent) ---
+//
+// The "twoPhase" strategy spends the first twoPhaseExploreFraction of the learning budget
+// (iterations, or wall-clock time with a time budget) scoring uniformly random valid placements,
+// keeping the best few. The rest of the budget runs coordinate descent without random jumps,
+// starting from the best sample and moving on to the next one whenever descent stalls.
+
+const (
+	DEFAULT_TWO_PHASE_EXPLORE_FRACTION = 0.3
+	TWO_PHASE_TOP_K                    = 3  // Samples kept as descent starting points
+	TWO_PHASE_SAMPLES_PER_ITERATION    = 8  // Random placements scored per exploration iteration
+	TWO_PHASE_SAMPLE_ATTEMPTS          = 50 // Tries to find one valid random placement
+	TWO_PHASE_STALL_ITERATIONS         = 6  // Descent iterations without improvement before the next seed
+)
+
+// PlacementSample is a scored source/listener placement.
+type PlacementSample struct {
+	Source, Listener Vector3
+	Score            int
+}
+
+// TwoPhaseState is the progress of a two-phase learning run.
+type TwoPhaseState struct {
+	Samples    []PlacementSample // Best exploration samples, best first
+	Descending bool
+	SeedIndex  int // Sample the current descent started from
+	SeedBest   int // Best score seen since that descent started
+	Stalled    int // Descent iterations since SeedBest improved
+}
+
+var (
+	twoPhaseExploreFraction = DEFAULT_TWO_PHASE_EXPLORE_FRACTION // Slider "twoPhaseExploreFraction"
+	twoPhase                *TwoPhaseState                       // Reset at the start of each learning run
+)
+
+// twoPhaseExploring reports whether the run is still within its exploration share of the budget.
+func twoPhaseExploring(learningStart time.Time) bool {
+	if learningTimeBudget > 0 {
+		return time.Since(learningStart) < time.Duration(twoPhaseExploreFraction*float64(learningTimeBudget))
+	}
+	return float64(currentLearningIteration) <= twoPhaseExploreFraction*float64(maxLearningIterations)
+}
+
+// randomPlacementWithin samples a coordinate uniformly in [lower, upper] per axis.
+func randomPlacementWithin(lower, upper Vector3) Vector3 {
+	return Vector3{
+		lower.X + rand.Float64()*(upper.X-lower.X),
+		lower.Y + rand.Float64()*(upper.Y-lower.Y),
+		lower.Z + rand.Float64()*(upper.Z-lower.Z),
+	}
+}
+
+// randomValidPlacement draws a uniformly random feasible placement; locked objects stay put.
+func randomValidPlacement() (PlacementSample, bool) {
+	sLower, sUpper := placementBounds(soundSource.Scale)
+	lLower, lUpper := placementBounds(listener.Scale)
+	for attempt := 0; attempt < TWO_PHASE_SAMPLE_ATTEMPTS; attempt++ {
+		sourcePos, listenerPos := lockedPlacement(randomPlacementWithin(sLower, sUpper), randomPlacementWithin(lLower, lUpper))
+		if placementFeasible(sourcePos, listenerPos) {
+			return PlacementSample{Source: sourcePos, Listener: listenerPos}, true
+		}
+	}
+	return PlacementSample{}, false
+}
+
+// runTwoPhaseExploreStep scores a batch of random placements, keeps the top TWO_PHASE_TOP_K
+// and shows the best one.
+func runTwoPhaseExploreStep() {
+	for i := 0; i < TWO_PHASE_SAMPLES_PER_ITERATION; i++ {
+		sample, ok := randomValidPlacement()
+		if !ok {
+			continue
+		}
+		sample.Score = objectiveScore(sample.Source, sample.Listener)
+		twoPhase.Samples = append(twoPhase.Samples, sample)
+	}
+	sort.SliceStable(twoPhase.Samples, func(i, j int) bool { return twoPhase.Samples[i].Score > twoPhase.Samples[j].Score })
+	if len(twoPhase.Samples) > TWO_PHASE_TOP_K {
+		twoPhase.Samples = twoPhase.Samples[:TWO_PHASE_TOP_K]
+	}
+	if len(twoPhase.Samples) > 0 {
+		applyPlacementSample(twoPhase.Samples[0])
+	}
+}
+
+// applyPlacementSample moves the source and listener to a sample.
+func applyPlacementSample(sample PlacementSample) {
+	soundSource.Position, listener.Position = sample.Source, sample.Listener
+	resyncDynamicObjectsInCloud()
+}
+
+// startTwoPhaseDescent seeds the descent from sample index, or reports false if there is none.
+func startTwoPhaseDescent(index int) bool {
+	if index >= len(twoPhase.Samples) {
+		return false
+	}
+	twoPhase.SeedIndex, twoPhase.SeedBest, twoPhase.Stalled = index, twoPhase.Samples[index].Score, 0
+	applyPlacementSample(twoPhase.Samples[index])
+	logEvent(LogOptimizer, LogNotice, "Two-phase learning: descending from sample %d (score %d)", index, twoPhase.SeedBest)
+	return true
+}
+
+// runTwoPhaseLearningStep runs one iteration of the two-phase strategy.
+func runTwoPhaseLearningStep(learningStart time.Time, movingObject, fixedObject *SceneObject) {
+	if twoPhase == nil {
+		twoPhase = &TwoPhaseState{}
+	}
+	if !twoPhase.Descending {
+		if twoPhaseExploring(learningStart) {
+			runTwoPhaseExploreStep()
+			return
+		}
+		twoPhase.Descending = true
+		startTwoPhaseDescent(0)
+	}
+	if positionLocked(movingObject) {
+		return
+	}
+	findAndApplyBestMoveForLearning(movingObject, fixedObject, "maximize")
+	if score := objectiveScore(soundSource.Position, listener.Position); score > twoPhase.SeedBest {
+		twoPhase.SeedBest, twoPhase.Stalled = score, 0
+		return
+	}
+	twoPhase.Stalled++
+	if twoPhase.Stalled >= TWO_PHASE_STALL_ITERATIONS {
+		startTwoPhaseDescent(twoPhase.SeedIndex + 1) // The last seed keeps descending once the others are used up
+		twoPhase.Stalled = 0
+	}
+}
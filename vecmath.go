@@ -39,6 +39,11 @@ func (v Vector3) Normalize() Vector3 {
 	return Vector3{X: v.X / l, Y: v.Y / l, Z: v.Z / l}
 }
 
+// Cross returns the cross product v × other.
+func (v Vector3) Cross(other Vector3) Vector3 {
+	return Vector3{X: v.Y*other.Z - v.Z*other.Y, Y: v.Z*other.X - v.X*other.Z, Z: v.X*other.Y - v.Y*other.X}
+}
+
 func (v Vector3) Reflect(normal Vector3) Vector3 {
 	// Assumes normal is a unit vector
 	// R = V - 2 * dot(V, N) * N
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Classroom / Venue Mode: Speech Intelligibility ---
+//
+// The STI estimate of every listening position, with the modulation of each band reduced by
+// background noise. Speech and noise are flat across the octave bands. The speech level at a
+// position comes from the traced echogram: an omnidirectional source delivers a fraction r²/4d²
+// of its rays to a receiver sphere of radius r at distance d, so energy is referenced to d = 1 m.
+
+const (
+	DEFAULT_SPEECH_LEVEL_DB     = 60.0 // Talker level at 1 m (normal voice)
+	DEFAULT_BACKGROUND_NOISE_DB = 35.0 // Classroom background noise
+)
+
+var (
+	speechLevelDB     = DEFAULT_SPEECH_LEVEL_DB
+	backgroundNoiseDB = DEFAULT_BACKGROUND_NOISE_DB
+)
+
+// STI_RATINGS are the IEC 60268-16 qualification bands, by their lower STI bound.
+var STI_RATINGS = []struct {
+	Min    float64
+	Rating string
+}{{0.75, "excellent"}, {0.60, "good"}, {0.45, "fair"}, {0.30, "poor"}, {0, "bad"}}
+
+// stiRating is the qualitative rating of an STI value.
+func stiRating(sti float64) string {
+	for _, r := range STI_RATINGS {
+		if sti >= r.Min {
+			return r.Rating
+		}
+	}
+	return STI_RATINGS[len(STI_RATINGS)-1].Rating
+}
+
+// bandSpeechToNoise is the speech-to-noise ratio in dB per band at a receiver of radius
+// receiverRadius, from the energy the echogram collected there.
+func bandSpeechToNoise(eg *BandEchogram, receiverRadius float64) []float64 {
+	_, bands := bandColumns(eg)
+	reference := receiverRadius * receiverRadius / 4 // Energy collected at 1 m
+	snr := make([]float64, len(bands))
+	for b, column := range bands {
+		total := 0.0
+		for _, e := range column {
+			total += e
+		}
+		if total <= 0 {
+			snr[b] = -math.Inf(1)
+			continue
+		}
+		snr[b] = speechLevelDB + 10*math.Log10(total/reference) - backgroundNoiseDB
+	}
+	return snr
+}
+
+// VenuePositionSTI is the intelligibility estimate at one listening position.
+type VenuePositionSTI struct {
+	Label    string
+	Position Vector3
+	STI      float64
+	SNRDB    float64 // Broadband (band mean) speech-to-noise ratio
+	Rating   string
+}
+
+// venueIntelligibility estimates the STI at every seat, or at the listener if the scene has no
+// seating, with the primary source as the talker.
+func venueIntelligibility() []VenuePositionSTI {
+	var labels []string
+	var positions []Vector3
+	for _, s := range seatListeningPositions() {
+		labels = append(labels, s.Seat.Name)
+		positions = append(positions, s.Position)
+	}
+	if len(positions) == 0 {
+		labels, positions = []string{listener.Name}, []Vector3{listener.Position}
+	}
+	results := make([]VenuePositionSTI, len(positions))
+	parallelFor(len(positions), func(i int) {
+		eg := traceBandEchogramAt(soundSource, listener, soundSource.Position, positions[i], STI_RAYS, ENERGY_TRACE_DEFAULT_BIN, ENERGY_TRACE_MAX_TIME)
+		snr := bandSpeechToNoise(eg, listener.Scale.X)
+		sti := speechTransmissionIndexWithNoise(eg, snr)
+		results[i] = VenuePositionSTI{Label: labels[i], Position: positions[i], STI: sti, SNRDB: meanOf(snr), Rating: stiRating(sti)}
+	})
+	return results
+}
+
+// goGetVenueIntelligibility([speechLevelDb, backgroundNoiseDb]) returns
+// [{label, position: {x,y,z}, sti, snrDb, rating}] per listening position. Given levels are kept
+// for later calls.
+func goGetVenueIntelligibility(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetVenueIntelligibility")
+	if soundSource == nil || listener == nil {
+		return nil
+	}
+	if len(args) >= 2 {
+		speech, noise := args[0].Float(), args[1].Float()
+		if math.IsNaN(speech) || math.IsNaN(noise) {
+			log.Println("Error: goGetVenueIntelligibility expects numeric speech and noise levels (dB)")
+			return nil
+		}
+		speechLevelDB, backgroundNoiseDB = speech, noise
+	}
+	positions := venueIntelligibility()
+	result := make([]interface{}, len(positions))
+	for i, p := range positions {
+		result[i] = map[string]interface{}{
+			"label":    p.Label,
+			"position": map[string]interface{}{"x": p.Position.X, "y": p.Position.Y, "z": p.Position.Z},
+			"sti":      p.STI,
+			"snrDb":    p.SNRDB,
+			"rating":   p.Rating,
+		}
+	}
+	return js.ValueOf(result)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+	"syscall/js"
+)
+
+// --- Ray Visualization Quality Modes ---
+
+// VisualQualityMode selects how cached ray segments are turned into drawn lines.
+type VisualQualityMode string
+
+const (
+	VisualQualityStandard     VisualQualityMode = "standard"     // Every segment, as traced
+	VisualQualityInteraction  VisualQualityMode = "interaction"  // Thinned paths, capped opacity
+	VisualQualityPresentation VisualQualityMode = "presentation" // Every path, jittered and depth sorted
+)
+
+const (
+	VIS_INTERACTION_MAX_SEGMENTS = 4000 // Whole paths are dropped until at most this many segments remain
+	VIS_INTERACTION_MAX_OPACITY  = 0.6  // Caps blending so dense bundles don't saturate
+	VIS_PRESENTATION_JITTER      = 0.04 // Fractional opacity dither that breaks up banding in dense bundles
+)
+
+var visualQualityMode = VisualQualityStandard
+
+// applyVisualQuality turns the filtered segments into drawn lines for the current mode.
+func applyVisualQuality(segments []*TracedSegment) []*RayLine {
+	switch visualQualityMode {
+	case VisualQualityInteraction:
+		return interactionRayLines(segments)
+	case VisualQualityPresentation:
+		return presentationRayLines(segments)
+	}
+	lines := make([]*RayLine, len(segments))
+	for i, seg := range segments {
+		line := seg.Line
+		lines[i] = &line
+	}
+	return lines
+}
+
+// interactionRayLines keeps every k-th path so the segment count stays under
+// VIS_INTERACTION_MAX_SEGMENTS; paths are kept whole so no ray is drawn cut short.
+func interactionRayLines(segments []*TracedSegment) []*RayLine {
+	stride := 1
+	if len(segments) > VIS_INTERACTION_MAX_SEGMENTS {
+		stride = int(math.Ceil(float64(len(segments)) / VIS_INTERACTION_MAX_SEGMENTS))
+	}
+	lines := make([]*RayLine, 0, len(segments)/stride+1)
+	for _, seg := range segments {
+		if seg.PathID%stride != 0 {
+			continue
+		}
+		line := seg.Line
+		line.Opacity = math.Min(line.Opacity, VIS_INTERACTION_MAX_OPACITY)
+		lines = append(lines, &line)
+	}
+	return lines
+}
+
+// presentationRayLines draws every path back to front from the camera (by mean segment depth),
+// with a small deterministic opacity jitter per segment so layered rays don't band.
+func presentationRayLines(segments []*TracedSegment) []*RayLine {
+	depthSum := map[int]float64{}
+	count := map[int]int{}
+	for _, seg := range segments {
+		mid := Vector3{(seg.Line.Start.X + seg.Line.End.X) / 2, (seg.Line.Start.Y + seg.Line.End.Y) / 2, (seg.Line.Start.Z + seg.Line.End.Z) / 2}
+		depthSum[seg.PathID] += mid.Sub(mainCamera.Position).Length()
+		count[seg.PathID]++
+	}
+	ordered := make([]*TracedSegment, len(segments))
+	copy(ordered, segments)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i].PathID, ordered[j].PathID
+		if a == b {
+			return false
+		}
+		return depthSum[a]/float64(count[a]) > depthSum[b]/float64(count[b])
+	})
+	lines := make([]*RayLine, len(ordered))
+	for i, seg := range ordered {
+		line := seg.Line
+		line.Opacity = math.Max(0, math.Min(1, line.Opacity*(1+VIS_PRESENTATION_JITTER*ditherOffset(seg.PathID, i))))
+		lines[i] = &line
+	}
+	return lines
+}
+
+// ditherOffset returns a stable pseudo-random value in [-1, 1] for a path and segment, so the
+// jitter does not flicker between re-renders of the same pass.
+func ditherOffset(pathID, segment int) float64 {
+	h := uint32(pathID)*2654435761 ^ uint32(segment)*40503
+	h ^= h >> 15
+	h *= 2246822519
+	h ^= h >> 13
+	return float64(h%2001)/1000 - 1
+}
+
+// goSetVisualQualityMode(mode) switches between "standard", "interaction" and "presentation"
+// and re-derives the drawn rays from the last pass. Returns false for an unknown mode.
+func goSetVisualQualityMode(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetVisualQualityMode")
+	if len(args) != 1 {
+		log.Println("Error: goSetVisualQualityMode expects 1 argument (mode)")
+		return false
+	}
+	mode := VisualQualityMode(args[0].String())
+	switch mode {
+	case VisualQualityStandard, VisualQualityInteraction, VisualQualityPresentation:
+	default:
+		log.Printf("Error: unknown visual quality mode %q", mode)
+		return false
+	}
+	visualQualityMode = mode
+	refreshRayVisualsFromCache()
+	return true
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"log"
+	"math"
+	"syscall/js"
+)
+
+// --- Waterfall (cumulative spectral decay) ---
+
+const (
+	WATERFALL_DEFAULT_SLICES   int     = 30
+	WATERFALL_DEFAULT_SLICE_MS float64 = 10
+	WATERFALL_DEFAULT_RAYS     int     = 2000
+	WATERFALL_FLOOR_DB         float64 = -90 // Levels are clipped here so empty cells stay plottable
+)
+
+// Waterfall is a time x frequency matrix of remaining energy: LevelsDB[s][b] is the energy that
+// still arrives in band b after slice start time TimesMs[s], relative to the strongest band at t=0.
+type Waterfall struct {
+	TimesMs  []float64
+	BandsHz  []float64
+	LevelsDB [][]float64 // [slice][band]
+}
+
+// computeWaterfall turns a band echogram into cumulative decay slices by backward integration
+// (Schroeder) per band, the energy-domain counterpart of a cumulative spectral decay plot.
+func computeWaterfall(eg *BandEchogram, slices int, sliceMs float64) *Waterfall {
+	bands := len(eg.BandsHz)
+	remaining := make([][]float64, len(eg.Energy)+1) // remaining[i] = energy arriving at bin i or later
+	remaining[len(eg.Energy)] = make([]float64, bands)
+	for i := len(eg.Energy) - 1; i >= 0; i-- {
+		remaining[i] = make([]float64, bands)
+		for b := 0; b < bands; b++ {
+			remaining[i][b] = remaining[i+1][b] + eg.Energy[i][b]
+		}
+	}
+	reference := 0.0
+	for b := 0; b < bands; b++ {
+		reference = math.Max(reference, remaining[0][b])
+	}
+
+	wf := &Waterfall{BandsHz: eg.BandsHz}
+	for s := 0; s < slices; s++ {
+		startMs := float64(s) * sliceMs
+		bin := int(startMs / 1000 / eg.BinSec)
+		if bin > len(eg.Energy) {
+			bin = len(eg.Energy)
+		}
+		levels := make([]float64, bands)
+		for b := 0; b < bands; b++ {
+			levels[b] = WATERFALL_FLOOR_DB
+			if reference > 0 && remaining[bin][b] > 0 {
+				levels[b] = math.Max(WATERFALL_FLOOR_DB, 10*math.Log10(remaining[bin][b]/reference))
+			}
+		}
+		wf.TimesMs = append(wf.TimesMs, startMs)
+		wf.LevelsDB = append(wf.LevelsDB, levels)
+	}
+	return wf
+}
+
+// goGetWaterfall([slices, sliceMs, rays]) returns {timesMs, bandsHz, levelsDb: [[band levels] per slice]}
+// for the primary source at the listener.
+func goGetWaterfall(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goGetWaterfall")
+	slices, sliceMs, rays := WATERFALL_DEFAULT_SLICES, WATERFALL_DEFAULT_SLICE_MS, WATERFALL_DEFAULT_RAYS
+	if len(args) >= 1 {
+		slices = args[0].Int()
+	}
+	if len(args) >= 2 {
+		sliceMs = args[1].Float()
+	}
+	if len(args) >= 3 {
+		rays = args[2].Int()
+	}
+	if slices < 1 || sliceMs <= 0 || rays < 1 {
+		log.Printf("Error: goGetWaterfall needs positive slices, slice length and ray count (got %d, %.2f, %d)", slices, sliceMs, rays)
+		return nil
+	}
+	maxTime := math.Max(ENERGY_TRACE_MAX_TIME, float64(slices)*sliceMs/1000)
+	eg := traceBandEchogram(soundSource, listener, rays, ENERGY_TRACE_DEFAULT_BIN, maxTime)
+	wf := computeWaterfall(eg, slices, sliceMs)
+
+	times := make([]interface{}, len(wf.TimesMs))
+	for i, t := range wf.TimesMs {
+		times[i] = t
+	}
+	bandsHz := make([]interface{}, len(wf.BandsHz))
+	for i, f := range wf.BandsHz {
+		bandsHz[i] = f
+	}
+	levels := make([]interface{}, len(wf.LevelsDB))
+	for s, row := range wf.LevelsDB {
+		rowJS := make([]interface{}, len(row))
+		for b, v := range row {
+			rowJS[b] = v
+		}
+		levels[s] = rowJS
+	}
+	return js.ValueOf(map[string]interface{}{
+		"timesMs":  times,
+		"bandsHz":  bandsHz,
+		"levelsDb": levels,
+	})
+}
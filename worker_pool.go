@@ -0,0 +1,138 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"syscall/js"
+)
+
+// --- Worker Pool (sized from navigator.hardwareConcurrency) ---
+//
+// Independent evaluations (candidate batches, heatmap cells, visualization rays) are spread over a
+// persistent pool of goroutines. Today's js/wasm runtime schedules goroutines on a single thread,
+// so the pool mainly keeps long batches interleaved with UI callbacks; the same count is what a
+// page should use for helper WASM workers, and the pool scales as soon as the runtime gains threads.
+
+const (
+	MAX_WORKER_COUNT     = 64
+	DEFAULT_WORKER_COUNT = 1 // Used when navigator.hardwareConcurrency is unavailable
+)
+
+type workerPool struct {
+	jobs chan func()
+	size int
+}
+
+var evaluationPool *workerPool
+
+// newWorkerPool starts size goroutines that run jobs until the pool is stopped.
+func newWorkerPool(size int) *workerPool {
+	p := &workerPool{jobs: make(chan func()), size: size}
+	for i := 0; i < size; i++ {
+		go func() {
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// stop lets the pool's goroutines exit once their current job is done.
+func (p *workerPool) stop() {
+	close(p.jobs)
+}
+
+// detectHardwareConcurrency returns navigator.hardwareConcurrency, or DEFAULT_WORKER_COUNT.
+func detectHardwareConcurrency() int {
+	navigator := jsGlobal.Get("navigator")
+	if navigator.Type() != js.TypeObject {
+		return DEFAULT_WORKER_COUNT
+	}
+	cores := navigator.Get("hardwareConcurrency")
+	if cores.Type() != js.TypeNumber || cores.Int() < 1 {
+		return DEFAULT_WORKER_COUNT
+	}
+	return cores.Int()
+}
+
+// setWorkerCount replaces the evaluation pool with one of n workers (clamped to [1, MAX_WORKER_COUNT]).
+func setWorkerCount(n int) int {
+	if n < 1 {
+		n = 1
+	}
+	if n > MAX_WORKER_COUNT {
+		n = MAX_WORKER_COUNT
+	}
+	if evaluationPool != nil {
+		if evaluationPool.size == n {
+			return n
+		}
+		evaluationPool.stop()
+	}
+	evaluationPool = newWorkerPool(n)
+	return n
+}
+
+// initWorkerPool sizes the pool from the device's hardware concurrency at startup.
+func initWorkerPool() {
+	n := setWorkerCount(detectHardwareConcurrency())
+	log.Printf("Worker pool started with %d workers.", n)
+}
+
+// warmEvaluationCaches fills the lazily built caches calculateListenerScore reads, so pooled
+// evaluations only read shared state.
+func warmEvaluationCaches() {
+	for _, src := range soundSources {
+		collidableSetsFor(src)
+	}
+	if soundSource != nil {
+		collidableSetsFor(soundSource)
+	}
+	evaluationDirectionsFor(evaluationRayCount())
+}
+
+// parallelFor runs fn(i) for every i in [0, n) on the evaluation pool and waits for all of them.
+// fn must only write to its own index. Without a pool (or for a single item) it runs inline.
+// Items no idle worker takes are run by the caller, so fn may call parallelFor itself: a worker
+// waiting on nested items would otherwise hold the pool until every worker waits on the others.
+func parallelFor(n int, fn func(i int)) {
+	if evaluationPool == nil || evaluationPool.size <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	warmEvaluationCaches()
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		i := i
+		job := func() {
+			defer wg.Done()
+			defer recoverFromPanic("parallelFor job")
+			fn(i)
+		}
+		select {
+		case evaluationPool.jobs <- job:
+		default:
+			job()
+		}
+	}
+	wg.Wait()
+}
+
+// goSetWorkerCount(count) overrides the worker count; 0 restores the detected hardware
+// concurrency. Returns the count in use.
+func goSetWorkerCount(this js.Value, args []js.Value) interface{} {
+	defer recoverFromPanic("goSetWorkerCount")
+	if len(args) != 1 {
+		log.Println("Error: goSetWorkerCount expects 1 argument (count, 0 for automatic)")
+		return nil
+	}
+	n := args[0].Int()
+	if n <= 0 {
+		n = detectHardwareConcurrency()
+	}
+	return setWorkerCount(n)
+}